@@ -0,0 +1,88 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dustin/go-humanize"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/internal/corpus"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/fields"
+	"github.com/spf13/cobra"
+)
+
+func EstimateCmd() *cobra.Command {
+	estimateCmd := &cobra.Command{
+		Use:   "estimate template-path fields-definition-path",
+		Short: "Estimate a corpus",
+		Long:  "Estimate the number of events and their average size of a corpus given a template path and a fields definition path, without generating it",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("you must pass the template path and the fields definition path")
+			}
+
+			if totSize == "" {
+				return errors.New("you must provide a not empty --tot-size flag value")
+			}
+
+			if templateType != "placeholder" && templateType != "gotext" {
+				return corpus.ErrNotValidTemplate
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			totSizeInBytes, err := humanize.ParseBytes(totSize)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.LoadConfig(configFile)
+			if err != nil {
+				return err
+			}
+
+			cfg.Seed = seed
+			cfg.TemplateDir = filepath.Dir(args[0])
+
+			template, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			flds, err := fields.LoadFieldsDefinition(context.Background(), args[1])
+			if err != nil {
+				return err
+			}
+
+			estimate := genlib.EstimateTotEvents
+			if templateType == "gotext" {
+				estimate = genlib.EstimateTotEventsWithTextTemplate
+			}
+
+			events, avgEventBytes, err := estimate(template, cfg, flds, totSizeInBytes)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), "Events:", events)
+			fmt.Fprintln(cmd.OutOrStdout(), "Average event size:", avgEventBytes, "bytes")
+
+			return nil
+		},
+	}
+
+	estimateCmd.Flags().StringVarP(&configFile, "config-file", "c", "", "path to config file for generator settings")
+	estimateCmd.Flags().StringVarP(&templateType, "template-type", "y", "placeholder", "either 'placeholder' or 'gotext'")
+	estimateCmd.Flags().StringVarP(&totSize, "tot-size", "t", "", "total size of the corpus to estimate")
+	estimateCmd.Flags().Int64VarP(&seed, "seed", "s", 0, "seed for a reproducible estimation (0 means no seed)")
+	return estimateCmd
+}
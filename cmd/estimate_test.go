@@ -0,0 +1,32 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateCmd(t *testing.T) {
+	templatePath, fieldsDefinitionPath := writeTemplateFiles(t, `{"alpha":"{{.alpha}}","beta":{{.beta}}}`)
+
+	cmd := cmd.EstimateCmd()
+
+	b := new(bytes.Buffer)
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{templatePath, fieldsDefinitionPath, "--tot-size", "1MB", "--seed", "1"})
+
+	err := cmd.Execute()
+	require.Nil(t, err)
+
+	var events, avgEventBytes uint64
+	_, err = fmt.Sscanf(b.String(), "Events: %d\nAverage event size: %d bytes\n", &events, &avgEventBytes)
+	require.NoError(t, err)
+	require.InDelta(t, 1000*1000, events*avgEventBytes, 0.05*1000*1000)
+}
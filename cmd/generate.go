@@ -7,6 +7,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"github.com/dustin/go-humanize"
 	"github.com/elastic/elastic-integration-corpus-generator-tool/internal/corpus"
 	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
 	"github.com/spf13/afero"
@@ -53,6 +54,18 @@ func GenerateCmd() *cobra.Command {
 				errs = append(errs, errors.New("you must provide a not empty package version argument"))
 			}
 
+			if outputFormat == string(config.OutputFormatParquet) && compression != string(config.CompressionNone) {
+				errs = append(errs, errors.New("you must not provide a --compression flag value with --output-format parquet"))
+			}
+
+			if (maxFileSize != "" || eventsPerFile > 0 || roundRobinFiles > 0) && outputFormat != string(config.OutputFormatJSON) {
+				errs = append(errs, errors.New("you must not provide a --max-file-size, --events-per-file or --round-robin-files flag value with --output-format parquet or csv"))
+			}
+
+			if roundRobinFiles > 0 && (maxFileSize != "" || eventsPerFile > 0) {
+				errs = append(errs, errors.New("you must not provide a --max-file-size or --events-per-file flag value with --round-robin-files"))
+			}
+
 			if len(errs) > 0 {
 				return multierr.Combine(errs...)
 			}
@@ -67,17 +80,36 @@ func GenerateCmd() *cobra.Command {
 				return err
 			}
 
+			cfg.Seed = seed
+			cfg.Compression = config.Compression(compression)
+			cfg.OutputFormat = config.OutputFormat(outputFormat)
+			if maxFileSize != "" {
+				cfg.MaxFileSize, err = humanize.ParseBytes(maxFileSize)
+				if err != nil {
+					return err
+				}
+			}
+			cfg.EventsPerFile = eventsPerFile
+			cfg.RoundRobinFiles = roundRobinFiles
+			cfg.Manifest = manifest
+			if progressInterval > 0 {
+				cfg.ProgressInterval = progressInterval
+				cfg.ProgressFunc = progressPrinter(cmd.ErrOrStderr())
+			}
+
 			fc, err := corpus.NewGenerator(cfg, afero.NewOsFs(), location)
 			if err != nil {
 				return err
 			}
 
-			payloadFilename, err := fc.Generate(packageRegistryBaseURL, integrationPackage, dataStream, packageVersion, totSize)
+			payloadFilenames, err := fc.Generate(packageRegistryBaseURL, integrationPackage, dataStream, packageVersion, totSize)
 			if err != nil {
 				return err
 			}
 
-			fmt.Println("File generated:", payloadFilename)
+			for _, payloadFilename := range payloadFilenames {
+				fmt.Println("File generated:", payloadFilename)
+			}
 
 			return nil
 		},
@@ -86,5 +118,13 @@ func GenerateCmd() *cobra.Command {
 	generateCmd.Flags().StringVarP(&packageRegistryBaseURL, "package-registry-base-url", "r", "https://epr.elastic.co/", "base url of the package registry with schema")
 	generateCmd.Flags().StringVarP(&configFile, "config-file", "c", "", "path to config file for generator settings")
 	generateCmd.Flags().StringVarP(&totSize, "tot-size", "t", "", "total size of the corpus to generate")
+	generateCmd.Flags().Int64VarP(&seed, "seed", "s", 0, "seed for generating a reproducible corpus (0 means no seed)")
+	generateCmd.Flags().StringVarP(&compression, "compression", "z", "none", "either 'none' or 'gzip'")
+	generateCmd.Flags().StringVarP(&outputFormat, "output-format", "o", "json", "either 'json', 'parquet' or 'csv'")
+	generateCmd.Flags().StringVarP(&maxFileSize, "max-file-size", "m", "", "maximum size of each corpus file, rotating to the next file when exceeded (not set means a single file)")
+	generateCmd.Flags().Uint64VarP(&eventsPerFile, "events-per-file", "e", 0, "maximum number of events of each corpus file, rotating to the next file when reached (0 means a single file)")
+	generateCmd.Flags().Uint64Var(&roundRobinFiles, "round-robin-files", 0, "number of corpus files the events are spread across round-robin, for parallel ingest (0 means a single file)")
+	generateCmd.Flags().BoolVar(&manifest, "manifest", false, "write a JSON manifest describing the generated corpus alongside it")
+	generateCmd.Flags().Uint64Var(&progressInterval, "progress-interval", 0, "print the progress of the generation to stderr every that many events (0 means no progress)")
 	return generateCmd
 }
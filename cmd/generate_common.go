@@ -1,5 +1,32 @@
 package cmd
 
+import (
+	"fmt"
+	"io"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
 var packageRegistryBaseURL string
 var configFile string
 var totSize string
+var seed int64
+var compression string
+var outputFormat string
+var maxFileSize string
+var eventsPerFile uint64
+var roundRobinFiles uint64
+var manifest bool
+var progressInterval uint64
+
+// progressPrinter returns the function printing the progress of the generation to w
+func progressPrinter(w io.Writer) func(progress config.Progress) {
+	return func(progress config.Progress) {
+		if progress.EstimatedEvents > 0 {
+			fmt.Fprintf(w, "Generated %d of %d estimated events, %d bytes\n", progress.Events, progress.EstimatedEvents, progress.Bytes)
+			return
+		}
+
+		fmt.Fprintf(w, "Generated %d events, %d bytes\n", progress.Events, progress.Bytes)
+	}
+}
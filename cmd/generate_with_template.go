@@ -7,15 +7,35 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"github.com/dustin/go-humanize"
 	"github.com/elastic/elastic-integration-corpus-generator-tool/internal/corpus"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib"
 	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/multierr"
+	"os"
+	"time"
 )
 
 var templateType string
+var bulkFormat bool
+var bulkIndex string
+var ndjson bool
+var prettyJSON bool
+var validateJSON bool
+
+var elasticsearchURL string
+var elasticsearchIndex string
+var elasticsearchAPIKey string
+var bulkBatchSize int
+var elasticsearchMaxRetries int
+var kafkaBrokers []string
+var kafkaTopic string
+var kafkaKeyField string
+var kafkaBatchSize int
+var kafkaLinger time.Duration
 
 var templatePath string
 var fieldsDefinitionPath string
@@ -45,6 +65,46 @@ func GenerateWithTemplateCmd() *cobra.Command {
 				errs = append(errs, errors.New("you must provide a not empty fields definition path argument"))
 			}
 
+			if bulkFormat && bulkIndex == "" {
+				errs = append(errs, errors.New("you must provide a not empty --bulk-index flag value with --bulk-format"))
+			}
+
+			if outputFormat == string(config.OutputFormatParquet) && compression != string(config.CompressionNone) {
+				errs = append(errs, errors.New("you must not provide a --compression flag value with --output-format parquet"))
+			}
+
+			if (outputFormat == string(config.OutputFormatParquet) || outputFormat == string(config.OutputFormatCSV) || outputFormat == string(config.OutputFormatJSONArray)) && bulkFormat {
+				errs = append(errs, errors.New("you must not provide the --bulk-format flag with --output-format parquet, csv or json_array"))
+			}
+
+			if (maxFileSize != "" || eventsPerFile > 0 || roundRobinFiles > 0) && outputFormat != string(config.OutputFormatJSON) {
+				errs = append(errs, errors.New("you must not provide a --max-file-size, --events-per-file or --round-robin-files flag value with --output-format parquet, csv or json_array"))
+			}
+
+			if roundRobinFiles > 0 && (maxFileSize != "" || eventsPerFile > 0) {
+				errs = append(errs, errors.New("you must not provide a --max-file-size or --events-per-file flag value with --round-robin-files"))
+			}
+
+			if elasticsearchURL != "" {
+				if elasticsearchIndex == "" {
+					errs = append(errs, errors.New("you must provide a not empty --elasticsearch-index flag value with --elasticsearch-url"))
+				}
+
+				if outputFormat != string(config.OutputFormatJSON) || compression != string(config.CompressionNone) || bulkFormat || maxFileSize != "" || eventsPerFile > 0 || roundRobinFiles > 0 {
+					errs = append(errs, errors.New("you must not provide any --output-format, --compression, --bulk-format, --max-file-size, --events-per-file or --round-robin-files flag value with --elasticsearch-url"))
+				}
+			}
+
+			if len(kafkaBrokers) > 0 {
+				if kafkaTopic == "" {
+					errs = append(errs, errors.New("you must provide a not empty --kafka-topic flag value with --kafka-brokers"))
+				}
+
+				if elasticsearchURL != "" || outputFormat != string(config.OutputFormatJSON) || compression != string(config.CompressionNone) || bulkFormat || maxFileSize != "" || eventsPerFile > 0 || roundRobinFiles > 0 {
+					errs = append(errs, errors.New("you must not provide any --elasticsearch-url, --output-format, --compression, --bulk-format, --max-file-size, --events-per-file or --round-robin-files flag value with --kafka-brokers"))
+				}
+			}
+
 			if len(errs) > 0 {
 				return multierr.Combine(errs...)
 			}
@@ -59,17 +119,80 @@ func GenerateWithTemplateCmd() *cobra.Command {
 				return err
 			}
 
+			cfg.Seed = seed
+			cfg.Compression = config.Compression(compression)
+			cfg.OutputFormat = config.OutputFormat(outputFormat)
+			if maxFileSize != "" {
+				cfg.MaxFileSize, err = humanize.ParseBytes(maxFileSize)
+				if err != nil {
+					return err
+				}
+			}
+			cfg.EventsPerFile = eventsPerFile
+			cfg.RoundRobinFiles = roundRobinFiles
+			cfg.Manifest = manifest
+			if progressInterval > 0 {
+				cfg.ProgressInterval = progressInterval
+				cfg.ProgressFunc = progressPrinter(cmd.ErrOrStderr())
+			}
+			cfg.BulkFormat = bulkFormat
+			cfg.BulkIndex = bulkIndex
+			cfg.NDJSON = ndjson
+			cfg.PrettyJSON = prettyJSON
+			cfg.ValidateJSON = validateJSON
+
 			fc, err := corpus.NewGeneratorWithTemplate(cfg, afero.NewOsFs(), location, templateType)
 			if err != nil {
 				return err
 			}
 
-			payloadFilename, err := fc.GenerateWithTemplate(templatePath, fieldsDefinitionPath, totSize)
+			if len(kafkaBrokers) > 0 {
+				err := fc.ProduceWithTemplate(templatePath, fieldsDefinitionPath, totSize, genlib.KafkaOptions{
+					Brokers:   kafkaBrokers,
+					Topic:     kafkaTopic,
+					KeyField:  kafkaKeyField,
+					BatchSize: kafkaBatchSize,
+					Linger:    kafkaLinger,
+				})
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Corpus produced to:", kafkaTopic)
+
+				return nil
+			}
+
+			if elasticsearchURL != "" {
+				apiKey := elasticsearchAPIKey
+				if apiKey == "" {
+					apiKey = os.Getenv("ELASTICSEARCH_API_KEY")
+				}
+
+				err := fc.IngestWithTemplate(templatePath, fieldsDefinitionPath, totSize, genlib.ElasticsearchBulkOptions{
+					URL:        elasticsearchURL,
+					Index:      elasticsearchIndex,
+					APIKey:     apiKey,
+					BatchSize:  bulkBatchSize,
+					MaxRetries: elasticsearchMaxRetries,
+				})
+				if err != nil {
+					return err
+				}
+
+				fmt.Println("Corpus ingested to:", elasticsearchIndex)
+
+				return nil
+			}
+
+			payloadFilenames, err := fc.GenerateWithTemplate(templatePath, fieldsDefinitionPath, totSize)
 			if err != nil {
 				return err
 			}
 
-			fmt.Println("File generated:", payloadFilename)
+			for _, payloadFilename := range payloadFilenames {
+				fmt.Println("File generated:", payloadFilename)
+			}
 
 			return nil
 		},
@@ -78,5 +201,28 @@ func GenerateWithTemplateCmd() *cobra.Command {
 	generateWithTemplateCmd.Flags().StringVarP(&configFile, "config-file", "c", "", "path to config file for generator settings")
 	generateWithTemplateCmd.Flags().StringVarP(&templateType, "template-type", "y", "placeholder", "either 'placeholder' or 'gotext'")
 	generateWithTemplateCmd.Flags().StringVarP(&totSize, "tot-size", "t", "", "total size of the corpus to generate")
+	generateWithTemplateCmd.Flags().Int64VarP(&seed, "seed", "s", 0, "seed for generating a reproducible corpus (0 means no seed)")
+	generateWithTemplateCmd.Flags().StringVarP(&compression, "compression", "z", "none", "either 'none' or 'gzip'")
+	generateWithTemplateCmd.Flags().StringVarP(&outputFormat, "output-format", "o", "json", "either 'json', 'json_array', 'parquet' or 'csv'")
+	generateWithTemplateCmd.Flags().StringVarP(&maxFileSize, "max-file-size", "m", "", "maximum size of each corpus file, rotating to the next file when exceeded (not set means a single file)")
+	generateWithTemplateCmd.Flags().Uint64VarP(&eventsPerFile, "events-per-file", "e", 0, "maximum number of events of each corpus file, rotating to the next file when reached (0 means a single file)")
+	generateWithTemplateCmd.Flags().Uint64Var(&roundRobinFiles, "round-robin-files", 0, "number of corpus files the events are spread across round-robin, for parallel ingest (0 means a single file)")
+	generateWithTemplateCmd.Flags().BoolVar(&manifest, "manifest", false, "write a JSON manifest describing the generated corpus alongside it")
+	generateWithTemplateCmd.Flags().Uint64Var(&progressInterval, "progress-interval", 0, "print the progress of the generation to stderr every that many events (0 means no progress)")
+	generateWithTemplateCmd.Flags().BoolVarP(&bulkFormat, "bulk-format", "b", false, "precede each event with an Elasticsearch _bulk create action line")
+	generateWithTemplateCmd.Flags().StringVarP(&bulkIndex, "bulk-index", "i", "", "index name in the _bulk action lines, supporting go text/template and sprig functions")
+	generateWithTemplateCmd.Flags().BoolVarP(&ndjson, "ndjson", "n", false, "strip the trailing whitespaces of each event and terminate it with exactly one new line")
+	generateWithTemplateCmd.Flags().BoolVar(&prettyJSON, "pretty-json", false, "indent each event being valid JSON, for debugging")
+	generateWithTemplateCmd.Flags().BoolVar(&validateJSON, "validate-json", false, "fail on the first event not being valid JSON")
+	generateWithTemplateCmd.Flags().StringVar(&elasticsearchURL, "elasticsearch-url", "", "url of an Elasticsearch cluster to send the corpus to in _bulk requests, instead of writing it to file")
+	generateWithTemplateCmd.Flags().StringVar(&elasticsearchIndex, "elasticsearch-index", "", "index to create the corpus documents in with --elasticsearch-url")
+	generateWithTemplateCmd.Flags().StringVar(&elasticsearchAPIKey, "elasticsearch-api-key", "", "encoded API key authenticating the _bulk requests, read from the ELASTICSEARCH_API_KEY environment variable when not set")
+	generateWithTemplateCmd.Flags().IntVar(&bulkBatchSize, "bulk-batch-size", 500, "number of documents of each _bulk request with --elasticsearch-url")
+	generateWithTemplateCmd.Flags().IntVar(&elasticsearchMaxRetries, "elasticsearch-max-retries", 0, "number of times the documents throttled by the cluster are sent again with --elasticsearch-url (0 means no retries)")
+	generateWithTemplateCmd.Flags().StringSliceVar(&kafkaBrokers, "kafka-brokers", nil, "comma separated addresses of Kafka brokers to produce the corpus to, instead of writing it to file")
+	generateWithTemplateCmd.Flags().StringVar(&kafkaTopic, "kafka-topic", "", "topic to produce the corpus messages to with --kafka-brokers")
+	generateWithTemplateCmd.Flags().StringVar(&kafkaKeyField, "kafka-key-field", "", "field whose value is the key of each message with --kafka-brokers (not set means no key)")
+	generateWithTemplateCmd.Flags().IntVar(&kafkaBatchSize, "kafka-batch-size", 100, "number of messages of each produced batch with --kafka-brokers")
+	generateWithTemplateCmd.Flags().DurationVar(&kafkaLinger, "kafka-linger", 0, "maximum time a message waits for its batch to fill with --kafka-brokers (0 means waiting for the batch to be full)")
 	return generateWithTemplateCmd
 }
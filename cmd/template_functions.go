@@ -0,0 +1,30 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib"
+	"github.com/spf13/cobra"
+)
+
+func TemplateFunctionsCmd() *cobra.Command {
+	templateFunctionsCmd := &cobra.Command{
+		Use:   "template-functions",
+		Short: "List the gotext template functions",
+		Long:  "List the functions available in a gotext template, the sprig ones included, sorted by name",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range genlib.TemplateFuncNames() {
+				fmt.Fprintln(cmd.OutOrStdout(), name)
+			}
+
+			return nil
+		},
+	}
+
+	return templateFunctionsCmd
+}
@@ -0,0 +1,29 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateFunctionsCmd(t *testing.T) {
+	cmd := cmd.TemplateFunctionsCmd()
+
+	b := new(bytes.Buffer)
+	cmd.SetOut(b)
+	cmd.SetArgs(nil)
+
+	err := cmd.Execute()
+	require.Nil(t, err)
+
+	names := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	require.Contains(t, names, "generate")
+	require.Contains(t, names, "awsAZFromRegion")
+}
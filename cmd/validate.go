@@ -0,0 +1,69 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/fields"
+	"github.com/spf13/cobra"
+)
+
+func ValidateCmd() *cobra.Command {
+	validateCmd := &cobra.Command{
+		Use:   "validate template-path fields-definition-path",
+		Short: "Validate a template",
+		Long:  "Validate a placeholder template given a fields definition path, reporting every field referenced by the template and not defined",
+		// the usage doesn't help with an invalid template
+		SilenceUsage: true,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("you must pass the template path and the fields definition path")
+			}
+
+			if args[0] == "" || args[1] == "" {
+				return errors.New("you must provide a not empty template path and fields definition path argument")
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			template, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			template, err = genlib.ExpandTemplateIncludes(template, filepath.Dir(args[0]))
+			if err != nil {
+				return err
+			}
+
+			flds, err := fields.LoadFieldsDefinition(context.Background(), args[1])
+			if err != nil {
+				return err
+			}
+
+			errs := genlib.ValidateCustomTemplate(template, flds)
+			for _, err := range errs {
+				fmt.Fprintln(cmd.OutOrStdout(), err)
+			}
+
+			if len(errs) > 0 {
+				return fmt.Errorf("the template references %d fields not defined", len(errs))
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), "Template valid:", args[0])
+
+			return nil
+		},
+	}
+
+	return validateCmd
+}
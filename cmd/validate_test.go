@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplateFiles(t *testing.T, template string) (string, string) {
+	dir := t.TempDir()
+
+	templatePath := filepath.Join(dir, "template.tpl")
+	require.NoError(t, os.WriteFile(templatePath, []byte(template), 0600))
+
+	fieldsDefinitionPath := filepath.Join(dir, "fields.yml")
+	require.NoError(t, os.WriteFile(fieldsDefinitionPath, []byte("- name: alpha\n  type: keyword\n- name: beta\n  type: long\n"), 0600))
+
+	return templatePath, fieldsDefinitionPath
+}
+
+func TestValidateCmd_valid(t *testing.T) {
+	templatePath, fieldsDefinitionPath := writeTemplateFiles(t, `{"alpha":"{{.alpha}}","beta":{{.beta}}}`)
+
+	cmd := cmd.ValidateCmd()
+
+	b := new(bytes.Buffer)
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{templatePath, fieldsDefinitionPath})
+
+	err := cmd.Execute()
+	require.Nil(t, err)
+
+	require.Equal(t, "Template valid: "+templatePath+"\n", b.String())
+}
+
+func TestValidateCmd_eventIndex(t *testing.T) {
+	templatePath, fieldsDefinitionPath := writeTemplateFiles(t, `{"alpha":"{{.alpha}}","index":{{.@eventIndex}}}`)
+
+	cmd := cmd.ValidateCmd()
+
+	b := new(bytes.Buffer)
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{templatePath, fieldsDefinitionPath})
+
+	err := cmd.Execute()
+	require.Nil(t, err)
+
+	require.Equal(t, "Template valid: "+templatePath+"\n", b.String())
+}
+
+func TestValidateCmd_missingFields(t *testing.T) {
+	templatePath, fieldsDefinitionPath := writeTemplateFiles(t, `{"alpha":"{{.alpha}}","gamma":{{.gamma}},"delta":{{.delta}}}`)
+
+	cmd := cmd.ValidateCmd()
+
+	b := new(bytes.Buffer)
+	cmd.SetOut(b)
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{templatePath, fieldsDefinitionPath})
+
+	err := cmd.Execute()
+	require.EqualError(t, err, "the template references 2 fields not defined")
+
+	const expected = "custom template references a field not present in fields yaml definition: \"gamma\"\n" +
+		"custom template references a field not present in fields yaml definition: \"delta\"\n"
+	require.Equal(t, expected, b.String())
+}
@@ -5,7 +5,6 @@
 package corpus
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -13,6 +12,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -28,6 +28,11 @@ const (
 )
 
 var ErrNotValidTemplate = errors.New("please, pass --template-type as one of 'placeholder' or 'gotext'")
+var ErrNotValidOutputFormat = errors.New("please, pass --output-format as one of 'json', 'json_array', 'parquet' or 'csv'")
+var ErrNotValidMaxFileSize = errors.New("please, pass --max-file-size, --events-per-file and --round-robin-files only with --output-format 'json'")
+var ErrNotValidRoundRobin = errors.New("please, pass --round-robin-files without --max-file-size and --events-per-file")
+var ErrNotValidJSONArray = errors.New("please, pass --output-format 'json_array' only with a template and without --bulk-format")
+var ErrNotValidOutputDecoration = errors.New("the output header, footer and event separator can be set only with the 'json' output format and a single corpus file")
 
 type Config = config.Config
 type Fields = fields.Fields
@@ -90,7 +95,7 @@ func (gc GeneratorCorpus) Location() string {
 // To provide unique names the provided slug is prepended with current timestamp.
 func (gc GeneratorCorpus) bulkPayloadFilename(integrationPackage, dataStream, packageVersion string) string {
 	slug := integrationPackage + "-" + dataStream + "-" + packageVersion
-	filename := fmt.Sprintf("%d-%s.ndjson", gc.timestamp(), sanitizeFilename(slug))
+	filename := fmt.Sprintf("%d-%s%s", gc.timestamp(), sanitizeFilename(slug), gc.filenameExtension(".ndjson"))
 	return filename
 }
 
@@ -100,145 +105,522 @@ func (gc GeneratorCorpus) bulkPayloadFilenameWithTemplate(templatePath string) s
 	slug := path.Base(templatePath)
 	ext := path.Ext(templatePath)
 	slug = slug[0 : len(slug)-len(ext)]
-	filename := fmt.Sprintf("%d-%s%s", gc.timestamp(), sanitizeFilename(slug), sanitizeFilename(ext))
+	filename := fmt.Sprintf("%d-%s%s", gc.timestamp(), sanitizeFilename(slug), gc.filenameExtension(sanitizeFilename(ext)))
 	return filename
 }
 
+// filenameExtension is the extension of the corpus file given the extension of its events format
+func (gc GeneratorCorpus) filenameExtension(ext string) string {
+	switch gc.config.OutputFormat {
+	case config.OutputFormatParquet:
+		return genlib.ParquetExtension
+	case config.OutputFormatCSV:
+		ext = genlib.CSVExtension
+	case config.OutputFormatJSONArray:
+		ext = jsonArrayExtension
+	}
+
+	return ext + genlib.CompressionExtension(gc.config.Compression)
+}
+
+// jsonArrayExtension is the extension of the corpus files with OutputFormatJSONArray
+const jsonArrayExtension = ".json"
+
 var corpusLocPerm = os.FileMode(0770)
 var corpusPerm = os.FileMode(0660)
 
-func (gc GeneratorCorpus) eventsPayloadFromFields(template []byte, fields Fields, totSize uint64, createPayload []byte, f afero.File) error {
+// payloadOutput is where the corpus is written to: a single file, a RotatingWriter when the corpus has a maximum file size or number of events per file,
+// or a RoundRobinWriter when the corpus is spread across round-robin files
+type payloadOutput interface {
+	io.WriteCloser
+	// Paths returns the paths of the written files
+	Paths() []string
+}
+
+type payloadFile struct {
+	afero.File
+}
+
+func (pf payloadFile) Paths() []string {
+	return []string{pf.Name()}
+}
+
+// openPayload opens the output of the corpus, with payloadFilename as the name of its only file,
+// or as the base of the names of its files when rotating them by MaxFileSize or EventsPerFile, or spreading the events across RoundRobinFiles
+func (gc GeneratorCorpus) openPayload(payloadFilename string) (payloadOutput, error) {
+	if gc.config.MaxFileSize == 0 && gc.config.EventsPerFile == 0 && gc.config.RoundRobinFiles == 0 {
+		f, err := gc.fs.OpenFile(payloadFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, corpusPerm)
+		if err != nil {
+			return nil, err
+		}
+
+		return payloadFile{File: f}, nil
+	}
+
+	switch gc.config.OutputFormat {
+	case "", config.OutputFormatJSON:
+	default:
+		return nil, ErrNotValidMaxFileSize
+	}
+
+	// the index of the file goes before the extensions, the compression one included, zero-padded so that the names sort in order
+	base := path.Base(payloadFilename)
+	ext := ""
+	if i := strings.Index(base, "."); i > -1 {
+		base, ext = base[:i], base[i:]
+	}
+
+	filenameTemplate := path.Join(path.Dir(payloadFilename), base+"-{{.Sequence}}"+ext)
+
+	if gc.config.RoundRobinFiles > 0 {
+		if gc.config.MaxFileSize > 0 || gc.config.EventsPerFile > 0 {
+			return nil, ErrNotValidRoundRobin
+		}
+
+		return NewRoundRobinWriter(gc.fs, RoundRobinWriterOptions{
+			FilenameTemplate: filenameTemplate,
+			Files:            gc.config.RoundRobinFiles,
+			Compression:      gc.config.Compression,
+		})
+	}
+
+	return NewRotatingWriter(gc.fs, RotatingWriterOptions{
+		FilenameTemplate: filenameTemplate,
+		MaxBytes:         gc.config.MaxFileSize,
+		EventsPerFile:    gc.config.EventsPerFile,
+		Compression:      gc.config.Compression,
+	})
+}
+
+// compressionWriter is the writer compressing the events written to f, unless f is a RotatingWriter or a RoundRobinWriter compressing each of its files on its own
+func (gc GeneratorCorpus) compressionWriter(f io.Writer) (io.WriteCloser, error) {
+	switch w := f.(type) {
+	case *RotatingWriter:
+		return w, nil
+	case *RoundRobinWriter:
+		return w, nil
+	}
 
-	var evgen genlib.Generator
-	var err error
+	return genlib.NewCompressionWriter(gc.config.Compression, f)
+}
+
+// eventsGenerator returns the generator of the corpus events, from the fields with the custom template engine when template is empty
+func (gc GeneratorCorpus) eventsGenerator(template []byte, fields Fields, totSize uint64) (genlib.Generator, error) {
 	if len(template) == 0 {
-		evgen, err = genlib.NewGenerator(gc.config, fields, totSize)
-	} else {
-		if gc.templateType == templateTypeCustom {
-			evgen, err = genlib.NewGeneratorWithCustomTemplate(template, gc.config, fields, totSize)
-		} else if gc.templateType == templateTypeGoText {
-			evgen, err = genlib.NewGeneratorWithTextTemplate(template, gc.config, fields, totSize)
-		} else {
-			return ErrNotValidTemplate
+		return genlib.NewGenerator(genlib.GeneratorKindCustomTemplate, nil, gc.config, fields, totSize)
+	}
+
+	if gc.templateType == templateTypeCustom {
+		return genlib.NewGenerator(genlib.GeneratorKindCustomTemplate, template, gc.config, fields, totSize)
+	} else if gc.templateType == templateTypeGoText {
+		return genlib.NewGenerator(genlib.GeneratorKindTextTemplate, template, gc.config, fields, totSize)
+	}
+
+	return nil, ErrNotValidTemplate
+}
+
+// eventsPayloadFromFields writes the corpus events to f, returning the number of events written and their size
+func (gc GeneratorCorpus) eventsPayloadFromFields(template []byte, fields Fields, totSize uint64, createPayload []byte, f io.Writer) (corpusStats, error) {
+	switch gc.config.OutputFormat {
+	case "", config.OutputFormatJSON, config.OutputFormatJSONArray, config.OutputFormatParquet, config.OutputFormatCSV:
+	default:
+		return corpusStats{}, ErrNotValidOutputFormat
+	}
+
+	// the events of a JSON array must be JSON objects, not preceded by bulk action lines
+	if gc.config.OutputFormat == config.OutputFormatJSONArray && (len(template) == 0 || gc.config.BulkFormat) {
+		return corpusStats{}, ErrNotValidJSONArray
+	}
+
+	if err := gc.validateOutputDecoration(); err != nil {
+		return corpusStats{}, err
+	}
+
+	evgen, err := gc.eventsGenerator(template, fields, totSize)
+	if err != nil {
+		return corpusStats{}, err
+	}
+
+	if gc.config.OutputFormat == config.OutputFormatParquet {
+		pw, err := genlib.NewParquetWriter(gc.config, fields, f)
+		if err != nil {
+			return corpusStats{}, err
 		}
 
+		return rowsPayload(evgen, pw)
 	}
 
+	w, err := gc.compressionWriter(f)
 	if err != nil {
-		return err
+		return corpusStats{}, err
 	}
 
-	state := genlib.NewGenState()
+	if gc.config.OutputFormat == config.OutputFormatCSV {
+		// the columns follow the placeholders of a custom template
+		var customTemplate []byte
+		if gc.templateType == templateTypeCustom {
+			customTemplate = template
+		}
 
-	var buf *bytes.Buffer
-	if len(template) == 0 {
-		buf = bytes.NewBuffer(createPayload)
-	} else {
-		buf = bytes.NewBufferString("")
+		cw, err := genlib.NewCSVWriter(genlib.CSVFieldNames(customTemplate, fields), w)
+		if err != nil {
+			return corpusStats{}, err
+		}
+
+		stats, err := rowsPayload(evgen, cw)
+		if err != nil {
+			return stats, err
+		}
+
+		return stats, w.Close()
+	}
+
+	// the corpus generated from fields already has its own create payload
+	bulkFormat := gc.config.BulkFormat && len(createPayload) == 0
+	if bulkFormat {
+		evgen, err = genlib.NewGeneratorWithBulkFormat(evgen, gc.config.BulkIndex)
+		if err != nil {
+			return corpusStats{}, err
+		}
+	}
+
+	if len(template) > 0 {
+		createPayload = nil
 	}
 
+	header, separator, footer := gc.outputDecoration()
+
+	return eventsPayload(evgen, w, eventsPayloadOptions{
+		createPayload: createPayload,
+		newLine:       !bulkFormat && !gc.config.NDJSON,
+		header:        header,
+		separator:     separator,
+		footer:        footer,
+	})
+}
+
+// eventsPayloadOptions are how eventsPayload writes the events
+type eventsPayloadOptions struct {
+	// createPayload precedes each event
+	createPayload []byte
+	// newLine terminates each event
+	newLine bool
+	// header and footer are written once before the first event and once after the last one, and separator between each two events
+	header    []byte
+	separator []byte
+	footer    []byte
+}
+
+// eventsPayload writes the events of evgen to w as they are, closing it at the end, returning the number of events written and their size
+func eventsPayload(evgen genlib.Generator, w io.WriteCloser, options eventsPayloadOptions) (corpusStats, error) {
 	defer func() {
 		_ = evgen.Close()
 	}()
 
+	state := genlib.NewGenState()
+
+	buf := genlib.GetBuffer()
+	defer genlib.PutBuffer(buf)
+
+	buf.Write(options.createPayload)
+
+	if len(options.header) > 0 {
+		if _, err := w.Write(options.header); err != nil {
+			return corpusStats{}, err
+		}
+	}
+
+	var stats corpusStats
 	for {
-		buf.Truncate(len(createPayload))
+		buf.Truncate(len(options.createPayload))
 		err := evgen.Emit(state, buf)
 		if err == nil {
-			buf.WriteByte('\n')
+			if options.newLine {
+				buf.WriteByte('\n')
+			}
+
+			if stats.events > 0 && len(options.separator) > 0 {
+				if _, err = w.Write(options.separator); err != nil {
+					return stats, err
+				}
+			}
 
-			if _, err = f.Write(buf.Bytes()); err != nil {
-				return err
+			if _, err = w.Write(buf.Bytes()); err != nil {
+				return stats, err
 			}
+
+			stats.add(buf.Bytes())
 		}
 
 		if err == io.EOF {
-			return nil
+			if len(options.footer) > 0 {
+				if _, err := w.Write(options.footer); err != nil {
+					return stats, err
+				}
+			}
+
+			return stats, w.Close()
 		}
 
 		if err != nil {
-			return err
+			return stats, err
 		}
 	}
 }
 
-// Generate generates a bulk request corpus and persist it to file.
-func (gc GeneratorCorpus) Generate(packageRegistryBaseURL, integrationPackage, dataStream, packageVersion, totSize string) (string, error) {
+// outputDecoration returns the header, separator and footer of the events: the ones of a JSON array with OutputFormatJSONArray,
+// the configured ones otherwise
+func (gc GeneratorCorpus) outputDecoration() (header, separator, footer []byte) {
+	if gc.config.OutputFormat == config.OutputFormatJSONArray {
+		return []byte("["), []byte(","), []byte("]")
+	}
+
+	return gc.config.OutputHeader, gc.config.EventSeparator, gc.config.OutputFooter
+}
+
+// validateOutputDecoration checks that the output header, footer and event separator are set only when the events are written
+// as they are to a single corpus file, since the rotating and round-robin files expect a write for each event
+func (gc GeneratorCorpus) validateOutputDecoration() error {
+	if len(gc.config.OutputHeader) == 0 && len(gc.config.OutputFooter) == 0 && len(gc.config.EventSeparator) == 0 {
+		return nil
+	}
+
+	switch gc.config.OutputFormat {
+	case "", config.OutputFormatJSON:
+	default:
+		return ErrNotValidOutputDecoration
+	}
+
+	if gc.config.MaxFileSize > 0 || gc.config.EventsPerFile > 0 || gc.config.RoundRobinFiles > 0 {
+		return ErrNotValidOutputDecoration
+	}
+
+	return nil
+}
+
+// rowsWriter writes each JSON event as a row, as genlib.ParquetWriter and genlib.CSVWriter, or as a document or message, as genlib.ElasticsearchBulkWriter and genlib.KafkaWriter
+type rowsWriter interface {
+	WriteEvent(event []byte) error
+	Close() error
+}
+
+// rowsPayload writes the events of evgen as rows through rw, closing it at the end, returning the number of events written and their size
+func rowsPayload(evgen genlib.Generator, rw rowsWriter) (corpusStats, error) {
+	defer func() {
+		_ = evgen.Close()
+	}()
+
+	state := genlib.NewGenState()
+
+	buf := genlib.GetBuffer()
+	defer genlib.PutBuffer(buf)
+
+	var stats corpusStats
+	for {
+		buf.Reset()
+		err := evgen.Emit(state, buf)
+		if err == io.EOF {
+			return stats, rw.Close()
+		}
+
+		if err != nil {
+			return stats, err
+		}
+
+		if err := rw.WriteEvent(buf.Bytes()); err != nil {
+			return stats, err
+		}
+
+		stats.add(buf.Bytes())
+	}
+}
+
+// Generate generates a bulk request corpus and persist it to file, or to files when rotating them by MaxFileSize or EventsPerFile or spreading the events across RoundRobinFiles.
+// It returns the paths of the written files.
+func (gc GeneratorCorpus) Generate(packageRegistryBaseURL, integrationPackage, dataStream, packageVersion, totSize string) ([]string, error) {
 	totSizeInBytes, err := humanize.ParseBytes(totSize)
 	if err != nil {
-		return "", fmt.Errorf("cannot generate corpus location folder: %v", err)
+		return nil, fmt.Errorf("cannot generate corpus location folder: %v", err)
 	}
 	if err := gc.fs.MkdirAll(gc.location, corpusLocPerm); err != nil {
-		return "", fmt.Errorf("cannot generate corpus location folder: %v", err)
+		return nil, fmt.Errorf("cannot generate corpus location folder: %v", err)
 	}
 
 	payloadFilename := path.Join(gc.location, gc.bulkPayloadFilename(integrationPackage, dataStream, packageVersion))
-	f, err := gc.fs.OpenFile(payloadFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, corpusPerm)
+	f, err := gc.openPayload(payloadFilename)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
+	startTime := time.Now()
+
 	ctx := context.Background()
 	flds, err := fields.LoadFields(ctx, packageRegistryBaseURL, integrationPackage, dataStream, packageVersion)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	createPayload := []byte(`{ "create" : { "_index": "metrics-` + integrationPackage + `.` + dataStream + `-default" } }` + "\n")
 
-	err = gc.eventsPayloadFromFields(nil, flds, totSizeInBytes, createPayload, f)
+	stats, err := gc.eventsPayloadFromFields(nil, flds, totSizeInBytes, createPayload, f)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if err := f.Close(); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return payloadFilename, err
+	return gc.withManifest(payloadFilename, Manifest{StartTime: startTime}, stats, f.Paths())
 }
 
-// GenerateWithTemplate generates a template based corpus and persist it to file.
-func (gc GeneratorCorpus) GenerateWithTemplate(templatePath, fieldsDefinitionPath, totSize string) (string, error) {
+// GenerateWithTemplate generates a template based corpus and persist it to file, or to files when rotating them by MaxFileSize or EventsPerFile or spreading the events across RoundRobinFiles.
+// It returns the paths of the written files.
+func (gc GeneratorCorpus) GenerateWithTemplate(templatePath, fieldsDefinitionPath, totSize string) ([]string, error) {
 	totSizeInBytes, err := humanize.ParseBytes(totSize)
 	if err != nil {
-		return "", fmt.Errorf("cannot generate corpus location folder: %v", err)
+		return nil, fmt.Errorf("cannot generate corpus location folder: %v", err)
 	}
 	if err := gc.fs.MkdirAll(gc.location, corpusLocPerm); err != nil {
-		return "", fmt.Errorf("cannot generate corpus location folder: %v", err)
+		return nil, fmt.Errorf("cannot generate corpus location folder: %v", err)
 	}
 
 	payloadFilename := path.Join(gc.location, gc.bulkPayloadFilenameWithTemplate(templatePath))
-	f, err := gc.fs.OpenFile(payloadFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, corpusPerm)
+	f, err := gc.openPayload(payloadFilename)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
+	startTime := time.Now()
+
 	template, err := os.ReadFile(templatePath)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if len(template) == 0 {
-		return "", errors.New("you must provide a non empty template content")
+		return nil, errors.New("you must provide a non empty template content")
+	}
+
+	// the includes are relative to the template, and expanded before the CSV columns are taken from its placeholders
+	template, err = genlib.ExpandTemplateIncludes(template, filepath.Dir(templatePath))
+	if err != nil {
+		return nil, err
 	}
 
 	ctx := context.Background()
-	flds, err := fields.LoadFieldsWithTemplate(ctx, fieldsDefinitionPath)
+	flds, err := fields.LoadFieldsDefinition(ctx, fieldsDefinitionPath)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	err = gc.eventsPayloadFromFields(template, flds, totSizeInBytes, nil, f)
+	stats, err := gc.eventsPayloadFromFields(template, flds, totSizeInBytes, nil, f)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if err := f.Close(); err != nil {
-		return "", err
+		return nil, err
+	}
+
+	manifest := Manifest{StartTime: startTime, TemplateSHA256: sha256Hex(template)}
+	if gc.config.Manifest {
+		fieldsDefinition, err := os.ReadFile(fieldsDefinitionPath)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.FieldsSHA256 = sha256Hex(fieldsDefinition)
+	}
+
+	return gc.withManifest(payloadFilename, manifest, stats, f.Paths())
+}
+
+// withManifest writes the manifest of the corpus files at paths alongside them when enabled, completing it with the config and stats of the generation.
+// It returns the paths of the written files, the manifest included.
+func (gc GeneratorCorpus) withManifest(payloadFilename string, manifest Manifest, stats corpusStats, paths []string) ([]string, error) {
+	if !gc.config.Manifest {
+		return paths, nil
+	}
+
+	manifest.Seed = gc.config.Seed
+	manifest.Config = gc.config
+	manifest.FieldsConfig = gc.config.Fields()
+	manifest.TotalEvents = stats.events
+	manifest.TotalBytes = stats.bytes
+	manifest.EndTime = time.Now()
+	manifest.Files = paths
+
+	filename := manifestFilename(payloadFilename)
+	if err := WriteManifest(gc.fs, filename, manifest); err != nil {
+		return nil, err
+	}
+
+	return append(paths, filename), nil
+}
+
+// templateEventsGenerator returns the generator of a template based corpus
+func (gc GeneratorCorpus) templateEventsGenerator(ctx context.Context, templatePath, fieldsDefinitionPath, totSize string) (genlib.Generator, error) {
+	totSizeInBytes, err := humanize.ParseBytes(totSize)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse corpus tot size: %v", err)
+	}
+
+	template, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(template) == 0 {
+		return nil, errors.New("you must provide a non empty template content")
+	}
+
+	// the includes are relative to the template
+	template, err = genlib.ExpandTemplateIncludes(template, filepath.Dir(templatePath))
+	if err != nil {
+		return nil, err
+	}
+
+	flds, err := fields.LoadFieldsDefinition(ctx, fieldsDefinitionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return gc.eventsGenerator(template, flds, totSizeInBytes)
+}
+
+// IngestWithTemplate generates a template based corpus and sends it to Elasticsearch in _bulk requests, rather than persisting it to file.
+func (gc GeneratorCorpus) IngestWithTemplate(templatePath, fieldsDefinitionPath, totSize string, options genlib.ElasticsearchBulkOptions) error {
+	ctx := context.Background()
+	evgen, err := gc.templateEventsGenerator(ctx, templatePath, fieldsDefinitionPath, totSize)
+	if err != nil {
+		return err
+	}
+
+	ew, err := genlib.NewElasticsearchBulkWriter(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	_, err = rowsPayload(evgen, ew)
+	return err
+}
+
+// ProduceWithTemplate generates a template based corpus and produces it as the messages of a Kafka topic, rather than persisting it to file.
+func (gc GeneratorCorpus) ProduceWithTemplate(templatePath, fieldsDefinitionPath, totSize string, options genlib.KafkaOptions) error {
+	ctx := context.Background()
+	evgen, err := gc.templateEventsGenerator(ctx, templatePath, fieldsDefinitionPath, totSize)
+	if err != nil {
+		return err
+	}
+
+	kw, err := genlib.NewKafkaWriter(ctx, options)
+	if err != nil {
+		return err
 	}
 
-	return payloadFilename, err
+	_, err = rowsPayload(evgen, kw)
+	return err
 }
 
 // sanitizeFilename takes care of removing dangerous elements from a string so it can be safely
@@ -5,9 +5,18 @@
 package corpus
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,3 +49,284 @@ func TestSanitizeFilename(t *testing.T) {
 		}
 	}
 }
+
+func TestGzipCompression(t *testing.T) {
+	fc := TestNewGenerator()
+	fc.config.Compression = config.CompressionGzip
+
+	expected := "1647345675-integration-data_stream-0.0.1.ndjson.gz"
+	got := fc.bulkPayloadFilename("integration", "data_stream", "0.0.1")
+	assert.Equal(t, expected, got)
+
+	f, err := fc.fs.Create(got)
+	assert.NoError(t, err)
+
+	flds := Fields{
+		{Name: "alpha", Type: "keyword"},
+		{Name: "beta", Type: "long"},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+	_, err = fc.eventsPayloadFromFields(template, flds, 10*1024, nil, f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	f, err = fc.fs.Open(got)
+	assert.NoError(t, err)
+
+	r, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+
+	content, err := io.ReadAll(r)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	assert.Greater(t, len(lines), 1)
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), "not valid json line: %s", line)
+	}
+}
+
+func TestParquetOutputFormat(t *testing.T) {
+	fc := TestNewGenerator()
+	fc.config.OutputFormat = config.OutputFormatParquet
+
+	expected := "1647345675-integration-data_stream-0.0.1.parquet"
+	got := fc.bulkPayloadFilename("integration", "data_stream", "0.0.1")
+	assert.Equal(t, expected, got)
+
+	f, err := fc.fs.Create(got)
+	assert.NoError(t, err)
+
+	flds := Fields{
+		{Name: "alpha", Type: "keyword"},
+		{Name: "beta", Type: "long"},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+	_, err = fc.eventsPayloadFromFields(template, flds, 10*1024, nil, f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	content, err := afero.ReadFile(fc.fs, got)
+	assert.NoError(t, err)
+
+	// a Parquet file starts and ends with its magic number
+	assert.True(t, strings.HasPrefix(string(content), "PAR1"))
+	assert.True(t, strings.HasSuffix(string(content), "PAR1"))
+
+	fc.config.OutputFormat = "xml"
+	_, err = fc.eventsPayloadFromFields(template, flds, 10*1024, nil, f)
+	assert.ErrorIs(t, err, ErrNotValidOutputFormat)
+}
+
+func TestCSVOutputFormat(t *testing.T) {
+	fc := TestNewGenerator()
+	fc.config.OutputFormat = config.OutputFormatCSV
+
+	expected := "1647345675-integration-data_stream-0.0.1.csv"
+	got := fc.bulkPayloadFilename("integration", "data_stream", "0.0.1")
+	assert.Equal(t, expected, got)
+
+	f, err := fc.fs.Create(got)
+	assert.NoError(t, err)
+
+	flds := Fields{
+		{Name: "alpha", Type: "keyword"},
+		{Name: "beta", Type: "long"},
+	}
+
+	template := []byte(`{"beta":{{.beta}}, "alpha":"{{.alpha}}"}`)
+	_, err = fc.eventsPayloadFromFields(template, flds, 10*1024, nil, f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	content, err := afero.ReadFile(fc.fs, got)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	assert.Greater(t, len(lines), 2)
+	assert.Equal(t, "beta,alpha", lines[0])
+}
+
+func TestMaxFileSize(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: "keyword"},
+		{Name: "beta", Type: "long"},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+
+	fc := TestNewGenerator()
+	fc.config.Seed = 1
+
+	payloadFilename := fc.bulkPayloadFilenameWithTemplate("template.tpl")
+	single, err := fc.openPayload(payloadFilename)
+	assert.NoError(t, err)
+	_, err = fc.eventsPayloadFromFields(template, flds, 10*1024, nil, single)
+	assert.NoError(t, err)
+	assert.NoError(t, single.Close())
+
+	expected, err := afero.ReadFile(fc.fs, payloadFilename)
+	assert.NoError(t, err)
+
+	const maxFileSize = 1024
+	fc.config.MaxFileSize = maxFileSize
+
+	rotating, err := fc.openPayload(payloadFilename)
+	assert.NoError(t, err)
+	_, err = fc.eventsPayloadFromFields(template, flds, 10*1024, nil, rotating)
+	assert.NoError(t, err)
+	assert.NoError(t, rotating.Close())
+
+	paths := rotating.Paths()
+	assert.Greater(t, len(paths), 1)
+	for i, path := range paths {
+		assert.Equal(t, fmt.Sprintf("1647345675-template-%06d.tpl", i), path)
+	}
+
+	var concatenated []byte
+	for _, path := range paths {
+		content, err := afero.ReadFile(fc.fs, path)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(content), maxFileSize)
+		// no event is split across files
+		assert.True(t, strings.HasSuffix(string(content), "}\n"))
+
+		concatenated = append(concatenated, content...)
+	}
+
+	assert.Equal(t, string(expected), string(concatenated))
+}
+
+func TestMaxFileSizeNotValid(t *testing.T) {
+	fc := TestNewGenerator()
+	fc.config.MaxFileSize = 1024
+	fc.config.OutputFormat = config.OutputFormatParquet
+
+	_, err := fc.openPayload("corpus.parquet")
+	assert.ErrorIs(t, err, ErrNotValidMaxFileSize)
+
+	_, err = NewRotatingWriter(fc.fs, RotatingWriterOptions{FilenameTemplate: "corpus-{{.Date.Year}}.ndjson"})
+	assert.ErrorIs(t, err, ErrNotValidFilenameTemplate)
+}
+
+func TestOutputHeaderFooterAndEventSeparator(t *testing.T) {
+	fc := TestNewGenerator()
+	fc.config.OutputHeader = []byte("[")
+	fc.config.EventSeparator = []byte(",")
+	fc.config.OutputFooter = []byte("]")
+
+	f, err := fc.fs.Create("corpus.json")
+	assert.NoError(t, err)
+
+	flds := Fields{
+		{Name: "alpha", Type: "keyword"},
+		{Name: "beta", Type: "long"},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+	stats, err := fc.eventsPayloadFromFields(template, flds, 10*1024, nil, f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	content, err := afero.ReadFile(fc.fs, "corpus.json")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "["))
+	assert.True(t, strings.HasSuffix(string(content), "]"))
+
+	var events []map[string]any
+	assert.NoError(t, json.Unmarshal(content, &events))
+	assert.Greater(t, len(events), 1)
+	assert.Equal(t, stats.events, uint64(len(events)))
+}
+
+func TestOutputHeaderNotValid(t *testing.T) {
+	fc := TestNewGenerator()
+	fc.config.OutputHeader = []byte("[")
+	fc.config.RoundRobinFiles = 3
+
+	flds := Fields{
+		{Name: "alpha", Type: "keyword"},
+	}
+
+	_, err := fc.eventsPayloadFromFields([]byte(`{"alpha":"{{.alpha}}"}`), flds, 1024, nil, io.Discard)
+	assert.ErrorIs(t, err, ErrNotValidOutputDecoration)
+
+	fc.config.RoundRobinFiles = 0
+	fc.config.OutputFormat = config.OutputFormatCSV
+	_, err = fc.eventsPayloadFromFields([]byte(`{"alpha":"{{.alpha}}"}`), flds, 1024, nil, io.Discard)
+	assert.ErrorIs(t, err, ErrNotValidOutputDecoration)
+}
+
+func TestJSONArrayOutputFormat(t *testing.T) {
+	fc := TestNewGenerator()
+	fc.config.OutputFormat = config.OutputFormatJSONArray
+
+	got := fc.bulkPayloadFilenameWithTemplate("template.tpl")
+	assert.Equal(t, "1647345675-template.json", got)
+
+	f, err := fc.fs.Create(got)
+	assert.NoError(t, err)
+
+	flds := Fields{
+		{Name: "alpha", Type: "keyword"},
+		{Name: "beta", Type: "long"},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+	stats, err := fc.eventsPayloadFromFields(template, flds, 10*1024, nil, f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	content, err := afero.ReadFile(fc.fs, got)
+	assert.NoError(t, err)
+
+	var events []map[string]any
+	assert.NoError(t, json.Unmarshal(content, &events))
+	assert.Greater(t, len(events), 1)
+	assert.Equal(t, stats.events, uint64(len(events)))
+}
+
+// noEventsGenerator is a genlib.Generator emitting no events
+type noEventsGenerator struct{}
+
+func (noEventsGenerator) Emit(*genlib.GenState, *bytes.Buffer) error { return io.EOF }
+func (noEventsGenerator) EmitTo(*genlib.GenState, io.Writer) error   { return io.EOF }
+func (noEventsGenerator) Close() error                               { return nil }
+
+// bufferCloser is a bytes.Buffer with a no-op Close
+type bufferCloser struct {
+	bytes.Buffer
+}
+
+func (*bufferCloser) Close() error { return nil }
+
+func TestJSONArrayOutputFormatWithoutEvents(t *testing.T) {
+	fc := TestNewGenerator()
+	fc.config.OutputFormat = config.OutputFormatJSONArray
+
+	header, separator, footer := fc.outputDecoration()
+
+	var w bufferCloser
+	stats, err := eventsPayload(noEventsGenerator{}, &w, eventsPayloadOptions{newLine: true, header: header, separator: separator, footer: footer})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), stats.events)
+	assert.Equal(t, "[]", w.String())
+}
+
+func TestJSONArrayOutputFormatNotValid(t *testing.T) {
+	fc := TestNewGenerator()
+	fc.config.OutputFormat = config.OutputFormatJSONArray
+
+	flds := Fields{
+		{Name: "alpha", Type: "keyword"},
+	}
+
+	_, err := fc.eventsPayloadFromFields(nil, flds, 1024, []byte(`{ "create" : {} }`+"\n"), io.Discard)
+	assert.ErrorIs(t, err, ErrNotValidJSONArray)
+
+	fc.config.BulkFormat = true
+	_, err = fc.eventsPayloadFromFields([]byte(`{"alpha":"{{.alpha}}"}`), flds, 1024, nil, io.Discard)
+	assert.ErrorIs(t, err, ErrNotValidJSONArray)
+}
@@ -0,0 +1,74 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package corpus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+	"github.com/spf13/afero"
+)
+
+// Manifest describes a generated corpus, for reproducing it and for bookkeeping
+type Manifest struct {
+	// Seed of the generation, zero when the corpus is not reproducible
+	Seed int64 `json:"seed"`
+	// TemplateSHA256 is the hex encoded SHA-256 of the template, not set for a corpus generated from the fields only
+	TemplateSHA256 string `json:"template_sha256,omitempty"`
+	// FieldsSHA256 is the hex encoded SHA-256 of the fields definition file, not set for the fields of a package
+	FieldsSHA256 string `json:"fields_sha256,omitempty"`
+	// Config of the generation, and the configs of its fields
+	Config       config.Config        `json:"config"`
+	FieldsConfig []config.ConfigField `json:"fields_config,omitempty"`
+	// TotalEvents is the number of events actually written
+	TotalEvents uint64 `json:"total_events"`
+	// TotalBytes is the size of the written events, before compression
+	TotalBytes uint64    `json:"total_bytes"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	// Files are the paths of the corpus files, in order
+	Files []string `json:"files"`
+}
+
+// corpusStats are the number of events written to a corpus, and their size
+type corpusStats struct {
+	events uint64
+	bytes  uint64
+}
+
+func (cs *corpusStats) add(event []byte) {
+	cs.events++
+	cs.bytes += uint64(len(event))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// manifestFilename is the path of the manifest of the corpus at payloadFilename, with its extensions replaced
+func manifestFilename(payloadFilename string) string {
+	dir, base := path.Split(payloadFilename)
+	if i := strings.Index(base, "."); i > -1 {
+		base = base[:i]
+	}
+
+	return dir + base + ".manifest.json"
+}
+
+// WriteManifest writes the manifest to the file at path, as indented JSON
+func WriteManifest(fs afero.Fs, path string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, path, append(data, '\n'), corpusPerm)
+}
@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package corpus
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+	templatePath := filepath.Join(dir, "template.tpl")
+	require.NoError(t, os.WriteFile(templatePath, template, 0644))
+
+	fieldsDefinition := []byte("- name: alpha\n  type: keyword\n- name: beta\n  type: long\n")
+	fieldsDefinitionPath := filepath.Join(dir, "fields.yml")
+	require.NoError(t, os.WriteFile(fieldsDefinitionPath, fieldsDefinition, 0644))
+
+	fc := TestNewGenerator()
+	fc.config.Seed = 1
+	fc.config.Manifest = true
+	fc.config.EventsPerFile = 100
+
+	paths, err := fc.GenerateWithTemplate(templatePath, fieldsDefinitionPath, "20KB")
+	require.NoError(t, err)
+	require.Greater(t, len(paths), 2)
+
+	manifestPath := paths[len(paths)-1]
+	assert.Equal(t, "testdata/1647345675-template.manifest.json", manifestPath)
+
+	data, err := afero.ReadFile(fc.fs, manifestPath)
+	require.NoError(t, err)
+
+	var manifest Manifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+
+	var events, size int
+	for _, path := range paths[:len(paths)-1] {
+		content, err := afero.ReadFile(fc.fs, path)
+		require.NoError(t, err)
+
+		events += strings.Count(string(content), "\n")
+		size += len(content)
+	}
+
+	assert.Equal(t, uint64(events), manifest.TotalEvents)
+	assert.Equal(t, uint64(size), manifest.TotalBytes)
+	assert.Equal(t, paths[:len(paths)-1], manifest.Files)
+	assert.Equal(t, int64(1), manifest.Seed)
+	assert.Equal(t, sha256Hex(template), manifest.TemplateSHA256)
+	assert.Equal(t, sha256Hex(fieldsDefinition), manifest.FieldsSHA256)
+	assert.False(t, manifest.EndTime.Before(manifest.StartTime))
+}
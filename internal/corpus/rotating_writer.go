@@ -0,0 +1,199 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package corpus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+	"github.com/spf13/afero"
+)
+
+var ErrNotValidFilenameTemplate = errors.New("the filename template must reference the file {{.Index}} or {{.Sequence}}, so that each file has its own name")
+
+// RotatingWriterOptions are the options of a RotatingWriter
+type RotatingWriterOptions struct {
+	// FilenameTemplate is the path of the files, as a go text/template with sprig functions: {{.Index}} is the index of the file, starting from 0,
+	// {{.Sequence}} the same index zero-padded to 6 digits, and {{.Date}} the time the file is opened at
+	FilenameTemplate string
+	// MaxBytes is the maximum number of bytes written to a file, before compression; a single event bigger than it is written to a file on its own
+	MaxBytes uint64
+	// EventsPerFile is the maximum number of events written to a file, the last file holding the remainder
+	EventsPerFile uint64
+	// Compression of each file
+	Compression config.Compression
+}
+
+// filenameTemplateData is the data of the filename template
+type filenameTemplateData struct {
+	Index    int
+	Sequence string
+	Date     time.Time
+}
+
+func newFilenameTemplateData(index int, date time.Time) filenameTemplateData {
+	return filenameTemplateData{Index: index, Sequence: fmt.Sprintf("%06d", index), Date: date}
+}
+
+// RotatingWriter writes events to a sequence of files, opening the next one when writing an event to the current one would exceed
+// either the maximum size or the maximum number of events.
+// Each call to Write must be a whole event, since an event is never split across files.
+type RotatingWriter struct {
+	fs               afero.Fs
+	filenameTemplate *template.Template
+	maxBytes         uint64
+	eventsPerFile    uint64
+	compression      config.Compression
+	// now allows overriding the date of the files in tests
+	now func() time.Time
+
+	file    afero.File
+	w       io.WriteCloser
+	written uint64
+	events  uint64
+	paths   []string
+}
+
+func executeFilenameTemplate(tpl *template.Template, data filenameTemplateData) (string, error) {
+	var filename strings.Builder
+	if err := tpl.Execute(&filename, data); err != nil {
+		return "", err
+	}
+
+	return filename.String(), nil
+}
+
+// parseFilenameTemplate parses the template of the names of a sequence of files, checking that each file has its own name
+func parseFilenameTemplate(filenameTemplate string) (*template.Template, error) {
+	tpl, err := template.New("filename").Funcs(sprig.TxtFuncMap()).Parse(filenameTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// the files would overwrite each other if their names didn't depend on their index
+	now := time.Now()
+	first, err := executeFilenameTemplate(tpl, newFilenameTemplateData(0, now))
+	if err != nil {
+		return nil, err
+	}
+
+	second, err := executeFilenameTemplate(tpl, newFilenameTemplateData(1, now))
+	if err != nil {
+		return nil, err
+	}
+
+	if first == second {
+		return nil, ErrNotValidFilenameTemplate
+	}
+
+	return tpl, nil
+}
+
+// NewRotatingWriter returns a RotatingWriter creating its files in fs, the first one at the first Write
+func NewRotatingWriter(fs afero.Fs, options RotatingWriterOptions) (*RotatingWriter, error) {
+	tpl, err := parseFilenameTemplate(options.FilenameTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := genlib.NewCompressionWriter(options.Compression, io.Discard); err != nil {
+		return nil, err
+	}
+
+	return &RotatingWriter{
+		fs:               fs,
+		filenameTemplate: tpl,
+		maxBytes:         options.MaxBytes,
+		eventsPerFile:    options.EventsPerFile,
+		compression:      options.Compression,
+		now:              time.Now,
+	}, nil
+}
+
+// Write writes the event p to the current file, opening the next file first when p would make the current one exceed the maximum size,
+// or when the current one already holds the maximum number of events
+func (rw *RotatingWriter) Write(p []byte) (int, error) {
+	if rw.w == nil || rw.exceeds(uint64(len(p))) {
+		if err := rw.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rw.w.Write(p)
+	rw.written += uint64(n)
+	rw.events++
+
+	return n, err
+}
+
+// exceeds tells whether writing an event of size bytes to the current file would exceed its limits
+func (rw *RotatingWriter) exceeds(size uint64) bool {
+	if rw.eventsPerFile > 0 && rw.events >= rw.eventsPerFile {
+		return true
+	}
+
+	return rw.maxBytes > 0 && rw.written > 0 && rw.written+size > rw.maxBytes
+}
+
+func (rw *RotatingWriter) rotate() error {
+	if err := rw.closeFile(); err != nil {
+		return err
+	}
+
+	filename, err := executeFilenameTemplate(rw.filenameTemplate, newFilenameTemplateData(len(rw.paths), rw.now()))
+	if err != nil {
+		return err
+	}
+
+	f, err := rw.fs.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, corpusPerm)
+	if err != nil {
+		return err
+	}
+
+	// the compression is valid, as checked when creating the writer
+	w, _ := genlib.NewCompressionWriter(rw.compression, f)
+
+	rw.file = f
+	rw.w = w
+	rw.written = 0
+	rw.events = 0
+	rw.paths = append(rw.paths, filename)
+
+	return nil
+}
+
+func (rw *RotatingWriter) closeFile() error {
+	if rw.file == nil {
+		return nil
+	}
+
+	if err := rw.w.Close(); err != nil {
+		return err
+	}
+
+	err := rw.file.Close()
+	rw.file = nil
+	rw.w = nil
+
+	return err
+}
+
+// Close closes the current file, it can be called more than once
+func (rw *RotatingWriter) Close() error {
+	return rw.closeFile()
+}
+
+// Paths returns the paths of the written files, in order
+func (rw *RotatingWriter) Paths() []string {
+	return rw.paths
+}
@@ -0,0 +1,35 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package corpus
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingWriterEventsPerFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	rw, err := NewRotatingWriter(fs, RotatingWriterOptions{FilenameTemplate: "corpus-{{.Sequence}}.ndjson", EventsPerFile: 100})
+	assert.NoError(t, err)
+
+	for i := 0; i < 250; i++ {
+		_, err := fmt.Fprintf(rw, "{\"event\":%d}\n", i)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, rw.Close())
+	assert.Equal(t, []string{"corpus-000000.ndjson", "corpus-000001.ndjson", "corpus-000002.ndjson"}, rw.Paths())
+
+	for i, expectedEvents := range []int{100, 100, 50} {
+		content, err := afero.ReadFile(fs, rw.Paths()[i])
+		assert.NoError(t, err)
+		assert.Equal(t, expectedEvents, strings.Count(string(content), "\n"))
+		assert.True(t, strings.HasPrefix(string(content), fmt.Sprintf("{\"event\":%d}\n", i*100)))
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package corpus
+
+import (
+	"errors"
+	"io"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+	"github.com/spf13/afero"
+)
+
+var ErrNotValidRoundRobinFiles = errors.New("the number of round-robin files must be greater than 0")
+
+// RoundRobinWriterOptions are the options of a RoundRobinWriter
+type RoundRobinWriterOptions struct {
+	// FilenameTemplate is the path of the files, with the same data and functions of the RotatingWriterOptions one
+	FilenameTemplate string
+	// Files is the number of files the events are spread across
+	Files uint64
+	// Compression of each file
+	Compression config.Compression
+}
+
+// RoundRobinWriter spreads events across a fixed number of files, writing each event to the file following the one of the previous event,
+// so that the event i goes to the file i%Files.
+// Each call to Write must be a whole event, since an event is never split across files.
+type RoundRobinWriter struct {
+	fs               afero.Fs
+	filenameTemplate *template.Template
+	compression      config.Compression
+	// now allows overriding the date of the files in tests
+	now func() time.Time
+
+	files []afero.File
+	ws    []io.WriteCloser
+	next  int
+	paths []string
+}
+
+// NewRoundRobinWriter returns a RoundRobinWriter creating its files in fs, each one at its first Write
+func NewRoundRobinWriter(fs afero.Fs, options RoundRobinWriterOptions) (*RoundRobinWriter, error) {
+	if options.Files == 0 {
+		return nil, ErrNotValidRoundRobinFiles
+	}
+
+	tpl, err := parseFilenameTemplate(options.FilenameTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := genlib.NewCompressionWriter(options.Compression, io.Discard); err != nil {
+		return nil, err
+	}
+
+	return &RoundRobinWriter{
+		fs:               fs,
+		filenameTemplate: tpl,
+		compression:      options.Compression,
+		now:              time.Now,
+		files:            make([]afero.File, options.Files),
+		ws:               make([]io.WriteCloser, options.Files),
+	}, nil
+}
+
+// Write writes the event p to the next file in rotation, opening it first at its first event
+func (rw *RoundRobinWriter) Write(p []byte) (int, error) {
+	i := rw.next
+	if rw.ws[i] == nil {
+		if err := rw.open(i); err != nil {
+			return 0, err
+		}
+	}
+
+	rw.next = (i + 1) % len(rw.ws)
+
+	return rw.ws[i].Write(p)
+}
+
+func (rw *RoundRobinWriter) open(i int) error {
+	filename, err := executeFilenameTemplate(rw.filenameTemplate, newFilenameTemplateData(i, rw.now()))
+	if err != nil {
+		return err
+	}
+
+	f, err := rw.fs.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, corpusPerm)
+	if err != nil {
+		return err
+	}
+
+	// the compression is valid, as checked when creating the writer
+	w, _ := genlib.NewCompressionWriter(rw.compression, f)
+
+	rw.files[i] = f
+	rw.ws[i] = w
+	// the files are opened in order, at the first event of each one
+	rw.paths = append(rw.paths, filename)
+
+	return nil
+}
+
+// Close closes all the files, returning the first error; it can be called more than once
+func (rw *RoundRobinWriter) Close() error {
+	var firstErr error
+	for i, f := range rw.files {
+		if f == nil {
+			continue
+		}
+
+		err := rw.ws[i].Close()
+		if fileErr := f.Close(); err == nil {
+			err = fileErr
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+
+		rw.files[i] = nil
+		rw.ws[i] = nil
+	}
+
+	return firstErr
+}
+
+// Paths returns the paths of the written files, in order
+func (rw *RoundRobinWriter) Paths() []string {
+	return rw.paths
+}
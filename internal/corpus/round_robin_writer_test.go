@@ -0,0 +1,57 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package corpus
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundRobinWriter(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	rw, err := NewRoundRobinWriter(fs, RoundRobinWriterOptions{FilenameTemplate: "corpus-{{.Sequence}}.ndjson", Files: 3})
+	assert.NoError(t, err)
+
+	for i := 0; i < 30; i++ {
+		_, err := fmt.Fprintf(rw, "{\"event\":%d}\n", i)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, rw.Close())
+	assert.Equal(t, []string{"corpus-000000.ndjson", "corpus-000001.ndjson", "corpus-000002.ndjson"}, rw.Paths())
+
+	for i, path := range rw.Paths() {
+		content, err := afero.ReadFile(fs, path)
+		assert.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		assert.Len(t, lines, 10)
+		// the event j goes to the file j%3, and each file ends with a whole event
+		for j, line := range lines {
+			assert.Equal(t, fmt.Sprintf("{\"event\":%d}", j*3+i), line)
+		}
+		assert.True(t, strings.HasSuffix(string(content), "}\n"))
+	}
+}
+
+func TestRoundRobinFilesNotValid(t *testing.T) {
+	fc := TestNewGenerator()
+
+	_, err := NewRoundRobinWriter(fc.fs, RoundRobinWriterOptions{FilenameTemplate: "corpus-{{.Index}}.ndjson"})
+	assert.ErrorIs(t, err, ErrNotValidRoundRobinFiles)
+
+	_, err = NewRoundRobinWriter(fc.fs, RoundRobinWriterOptions{FilenameTemplate: "corpus.ndjson", Files: 3})
+	assert.ErrorIs(t, err, ErrNotValidFilenameTemplate)
+
+	fc.config.RoundRobinFiles = 3
+	fc.config.EventsPerFile = 100
+
+	_, err = fc.openPayload("corpus.ndjson")
+	assert.ErrorIs(t, err, ErrNotValidRoundRobin)
+}
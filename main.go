@@ -17,6 +17,9 @@ func main() {
 	rootCmd := cmd.RootCmd()
 	rootCmd.AddCommand(cmd.GenerateCmd())
 	rootCmd.AddCommand(cmd.GenerateWithTemplateCmd())
+	rootCmd.AddCommand(cmd.ValidateCmd())
+	rootCmd.AddCommand(cmd.EstimateCmd())
+	rootCmd.AddCommand(cmd.TemplateFunctionsCmd())
 	rootCmd.AddCommand(cmd.VersionCmd())
 
 	err := rootCmd.Execute()
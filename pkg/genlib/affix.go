@@ -0,0 +1,74 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+// hasAffixes tells if the values of the field are wrapped by a prefix or a suffix, for string-like fields only
+func hasAffixes(fieldCfg ConfigField, field Field) bool {
+	if len(fieldCfg.Prefix) == 0 && len(fieldCfg.Suffix) == 0 {
+		return false
+	}
+
+	switch field.Type {
+	case FieldTypeKeyword, FieldTypeConstantKeyword, FieldTypeText, FieldTypeWildcard:
+		return true
+	default:
+		return false
+	}
+}
+
+// bindAffixes wraps the emit function of the field, writing the prefix and the suffix around its values.
+// It's called by bindByType, so that cardinality and cardinality pools deduplicate the decorated values.
+func bindAffixes(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if !hasAffixes(fieldCfg, field) {
+		return nil
+	}
+
+	bindF, ok := fieldMap[field.Name].(emitFNotReturn)
+	if !ok {
+		return nil
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		if _, err := buf.WriteString(fieldCfg.Prefix); err != nil {
+			return err
+		}
+
+		if err := bindF(state, buf); err != nil {
+			return err
+		}
+
+		_, err := buf.WriteString(fieldCfg.Suffix)
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindAffixesWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if !hasAffixes(fieldCfg, field) {
+		return nil
+	}
+
+	bindF, ok := fieldMap[field.Name].(EmitF)
+	if !ok {
+		return nil
+	}
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		v := bindF(state)
+		value, ok := v.(string)
+		if !ok {
+			return v
+		}
+
+		return fieldCfg.Prefix + value + fieldCfg.Suffix
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
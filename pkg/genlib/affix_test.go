@@ -0,0 +1,85 @@
+package genlib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func Test_FieldAffixesWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  prefix: order-\n  charset: digits\n  length:\n    min: 6\n    max: 6\n- name: beta\n  suffix: .local\n  cardinality:\n    numerator: 1\n    denominator: 10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}","beta":"{{.beta}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	betaValues := make(map[string]struct{})
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if alpha := m["alpha"]; len(alpha) != 12 || !strings.HasPrefix(alpha, "order-") || strings.Trim(alpha[6:], "0123456789") != "" {
+			t.Fatalf("expected value starting with the prefix, got %s", alpha)
+		}
+
+		beta := m["beta"]
+		if !strings.HasSuffix(beta, ".local") || beta == ".local" {
+			t.Fatalf("expected value ending with the suffix, got %s", beta)
+		}
+
+		betaValues[beta] = struct{}{}
+	}
+
+	if len(betaValues) != 10 {
+		t.Errorf("expected 10 decorated values with cardinality, got %d", len(betaValues))
+	}
+}
+
+func Test_FieldAffixesWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  prefix: order-\n  suffix: .local\n  enum: ['a', 'b']\n- name: beta\n  prefix: order-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}","beta":{{generate "beta"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+		if alpha := m["alpha"]; alpha != "order-a.local" && alpha != "order-b.local" {
+			t.Fatalf("expected value wrapped by the affixes, got %v", alpha)
+		}
+
+		if _, ok := m["beta"].(float64); !ok {
+			t.Fatalf("expected numeric value not decorated, got %v", m["beta"])
+		}
+	}
+}
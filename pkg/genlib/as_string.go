@@ -0,0 +1,72 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import "fmt"
+
+// quotedAsString tells whether the values of the field are emitted as JSON strings, as configured by as_string,
+// for numeric, boolean and ip fields only: hardcoded values are emitted as they are
+func quotedAsString(fieldCfg ConfigField, field Field) bool {
+	if !fieldCfg.AsString || fieldCfg.Value != nil || len(field.Value) > 0 {
+		return false
+	}
+
+	switch field.Type {
+	case FieldTypeInteger, FieldTypeLong, FieldTypeUnsignedLong, FieldTypeHTTPStatus,
+		FieldTypeDouble, FieldTypeFloat, FieldTypeHalfFloat, FieldTypeScaledFloat,
+		FieldTypeBool, FieldTypeIP, FieldTypeIPv6:
+		return true
+	default:
+		return false
+	}
+}
+
+// bindAsString wraps the emit function of the field, writing the quotes around its values.
+// It's called by bindField, so that counters and cardinalities are quoted as well.
+func bindAsString(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if !quotedAsString(fieldCfg, field) {
+		return nil
+	}
+
+	bindF, ok := fieldMap[field.Name].(emitFNotReturn)
+	if !ok {
+		return nil
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		if err := buf.WriteByte('"'); err != nil {
+			return err
+		}
+
+		if err := bindF(state, buf); err != nil {
+			return err
+		}
+
+		return buf.WriteByte('"')
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindAsStringWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if !quotedAsString(fieldCfg, field) {
+		return nil
+	}
+
+	bindF, ok := fieldMap[field.Name].(EmitF)
+	if !ok {
+		return nil
+	}
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return fmt.Sprint(bindF(state))
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
@@ -0,0 +1,125 @@
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func Test_FieldAsStringWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeLong},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeBool},
+		{Name: "delta", Type: FieldTypeIP},
+		{Name: "epsilon", Type: FieldTypeLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  as_string: true\n  range:\n    min: 123\n    max: 124\n- name: beta\n  range:\n    min: 123\n    max: 124\n- name: gamma\n  as_string: true\n- name: delta\n  as_string: true\n- name: epsilon\n  as_string: true\n  counter:\n    start: 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{.alpha}},"beta":{{.beta}},"gamma":{{.gamma}},"delta":{{.delta}},"epsilon":{{.epsilon}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 10; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[json.RawMessage](t, buf.Bytes())
+		if alpha := string(m["alpha"]); alpha != `"123"` {
+			t.Errorf("expected quoted numeric value, got %s", alpha)
+		}
+
+		if beta := string(m["beta"]); beta != `123` {
+			t.Errorf("expected unquoted numeric value, got %s", beta)
+		}
+
+		if gamma := string(m["gamma"]); gamma != `"true"` && gamma != `"false"` {
+			t.Errorf("expected quoted boolean value, got %s", gamma)
+		}
+
+		var delta string
+		if err := json.Unmarshal(m["delta"], &delta); err != nil || net.ParseIP(delta) == nil {
+			t.Errorf("expected quoted ip value, got %s", m["delta"])
+		}
+
+		if epsilon := string(m["epsilon"]); epsilon != strconv.Quote(strconv.Itoa(i+1)) {
+			t.Errorf("expected quoted counter value %d, got %s", i+1, epsilon)
+		}
+	}
+}
+
+func Test_FieldAsStringWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeLong},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  as_string: true\n  range:\n    min: 123\n    max: 124\n- name: beta\n  range:\n    min: 123\n    max: 124"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}","beta":{{generate "beta"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != `{"alpha":"123","beta":123}` {
+		t.Errorf("expected numeric value as string only for alpha, got %s", got)
+	}
+}
+
+func Test_FieldAsStringWithGeneratedTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeDouble},
+		{Name: "beta", Type: FieldTypeIP},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  as_string: true\n- name: beta\n  as_string: true\n  array_length:\n    min: 2\n    max: 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, _ := generateCustomTemplateFromField(cfg, flds)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var m struct {
+		Alpha string   `json:"alpha"`
+		Beta  []string `json:"beta"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("expected values as strings, got %s: %s", buf.String(), err)
+	}
+
+	if _, err := strconv.ParseFloat(m.Alpha, 64); err != nil {
+		t.Errorf("expected double as string, got %s", m.Alpha)
+	}
+
+	if len(m.Beta) != 2 || net.ParseIP(m.Beta[0]) == nil || net.ParseIP(m.Beta[1]) == nil {
+		t.Errorf("expected array of ips, got %v", m.Beta)
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"encoding/base64"
+	"math/rand"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// defaultBinaryLength is the number of random bytes of binary values when length is not configured
+var defaultBinaryLength = config.Length{Min: 16, Max: 64}
+
+// makeBinaryFunc returns a function generating the standard base64 encoding, with padding, of a random number of random bytes
+func makeBinaryFunc(length config.Length) func(r *rand.Rand) string {
+	lengthFunc := makeLengthFunc(length)
+
+	return func(r *rand.Rand) string {
+		payload := make([]byte, lengthFunc(r))
+		readRand(r, payload)
+
+		return base64.StdEncoding.EncodeToString(payload)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// decodedLength returns the number of bytes value is the standard base64 encoding of
+func decodedLength(t *testing.T, value string) int {
+	t.Helper()
+
+	payload, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatalf("expected base64 value, got %s: %s", value, err)
+	}
+
+	return len(payload)
+}
+
+func Test_FieldBinaryWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeBinary},
+		{Name: "beta", Type: FieldTypeBinary},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  length:\n    min: 32\n    max: 32\n- name: beta\n  length:\n    min: 1\n    max: 10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}","beta":"{{.beta}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if n := decodedLength(t, m["alpha"]); n != 32 {
+			t.Fatalf("expected 32 bytes, got %d", n)
+		}
+
+		if n := decodedLength(t, m["beta"]); n < 1 || n > 10 {
+			t.Fatalf("expected between 1 and 10 bytes, got %d", n)
+		}
+	}
+}
+
+func Test_FieldBinaryWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeBinary},
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}","beta":"{{randomBase64 20}}","gamma":"{{randomBase64 5 8}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, config.Config{}, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if n := decodedLength(t, m["alpha"]); n < defaultBinaryLength.Min || n > defaultBinaryLength.Max {
+			t.Fatalf("expected between %d and %d bytes, got %d", defaultBinaryLength.Min, defaultBinaryLength.Max, n)
+		}
+
+		if n := decodedLength(t, m["beta"]); n != 20 {
+			t.Fatalf("expected 20 bytes, got %d", n)
+		}
+
+		if n := decodedLength(t, m["gamma"]); n < 5 || n > 8 {
+			t.Fatalf("expected between 5 and 8 bytes, got %d", n)
+		}
+	}
+}
+
+func Test_RandomBase64NotValidLengthWithTextTemplate(t *testing.T) {
+	template := []byte(`{{randomBase64 8 4}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, config.Config{}, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); !errors.Is(err, notValidBase64Length) {
+		t.Fatalf("expected error %v, got %v", notValidBase64Length, err)
+	}
+}
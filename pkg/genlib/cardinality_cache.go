@@ -0,0 +1,84 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"container/list"
+)
+
+// cardinalityCache is the pool of the values of a field with cardinality: the value at each index of the pool is generated once
+// and then reused, avoiding duplicated values in the pool as far as possible.
+// With a window the pool keeps at most that many values, evicting the least recently used one when full:
+// the values are then deduplicated within the window only, and a cardinality greater than the window is not honored.
+type cardinalityCache struct {
+	window int
+	// entries of the pool, the most recently used first
+	order   *list.List
+	entries map[int]*list.Element
+	// number of entries of each value, for the dedup check
+	values map[any]int
+}
+
+type cardinalityEntry struct {
+	index int
+	value any
+}
+
+// newCardinalityCache returns an empty cardinalityCache, with no maximum number of values when window is zero
+func newCardinalityCache(window int) *cardinalityCache {
+	return &cardinalityCache{
+		window:  window,
+		order:   list.New(),
+		entries: make(map[int]*list.Element),
+		values:  make(map[any]int),
+	}
+}
+
+// get returns the value at index of the pool, if any, marking it as the most recently used
+func (cc *cardinalityCache) get(index int) (any, bool) {
+	element, ok := cc.entries[index]
+	if !ok {
+		return nil, false
+	}
+
+	cc.order.MoveToFront(element)
+
+	return element.Value.(*cardinalityEntry).value, true
+}
+
+// contains tells whether value is in the pool
+func (cc *cardinalityCache) contains(value any) bool {
+	_, ok := cc.values[value]
+	return ok
+}
+
+// add sets value at index of the pool, evicting the least recently used value when the window is full
+func (cc *cardinalityCache) add(index int, value any) {
+	if element, ok := cc.entries[index]; ok {
+		cc.remove(element)
+	}
+
+	if cc.window > 0 && cc.order.Len() >= cc.window {
+		cc.remove(cc.order.Back())
+	}
+
+	cc.entries[index] = cc.order.PushFront(&cardinalityEntry{index: index, value: value})
+	cc.values[value]++
+}
+
+func (cc *cardinalityCache) remove(element *list.Element) {
+	entry := cc.order.Remove(element).(*cardinalityEntry)
+	delete(cc.entries, entry.index)
+
+	cc.values[entry.value]--
+	if cc.values[entry.value] == 0 {
+		delete(cc.values, entry.value)
+	}
+}
+
+// len returns the number of values in the pool
+func (cc *cardinalityCache) len() int {
+	return cc.order.Len()
+}
@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func TestCardinalityWindow(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the generation of 10M events in short mode")
+	}
+
+	const events = 10_000_000
+	const window = 1000
+
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  cardinality:\n    numerator: 1\n    denominator: 10000000\n  range:\n    min: 0\n    max: 1000000000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.CardinalityWindow = window
+
+	template := []byte(`{{.alpha}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	// the values of the last window events, for checking that they are not duplicated
+	recent := make([]string, window)
+	recentCount := make(map[string]int, window)
+
+	var buf bytes.Buffer
+	for i := 0; i < events; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if i >= window {
+			oldest := recent[i%window]
+			recentCount[oldest]--
+			if recentCount[oldest] == 0 {
+				delete(recentCount, oldest)
+			}
+		}
+
+		value := buf.String()
+		if recentCount[value] > 0 {
+			t.Fatalf("expected no duplicated value within the window, got %s at event %d", value, i)
+		}
+
+		recent[i%window] = value
+		recentCount[value]++
+	}
+
+	if l := state.prevCacheCardinality["alpha"].len(); l > window {
+		t.Fatalf("expected at most %d cached values, got %d", window, l)
+	}
+
+	var after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	// the unbounded pool would hold 10M values, hundreds of MB
+	if after.HeapAlloc > before.HeapAlloc && after.HeapAlloc-before.HeapAlloc > 16<<20 {
+		t.Fatalf("expected the heap to grow by less than 16MB, grown by %d bytes", after.HeapAlloc-before.HeapAlloc)
+	}
+}
+
+func TestCardinalityCacheEviction(t *testing.T) {
+	cache := newCardinalityCache(2)
+	cache.add(0, "a")
+	cache.add(1, "b")
+
+	// index 0 is now the most recently used, index 1 is evicted next
+	if value, ok := cache.get(0); !ok || value != "a" {
+		t.Fatalf("expected value a at index 0, got %v", value)
+	}
+
+	cache.add(2, "c")
+
+	if _, ok := cache.get(1); ok {
+		t.Fatal("expected index 1 to be evicted")
+	}
+
+	if cache.contains("b") {
+		t.Fatal("expected value b to be evicted")
+	}
+
+	if cache.len() != 2 || !cache.contains("a") || !cache.contains("c") {
+		t.Fatalf("expected values a and c in the pool, got %d values", cache.len())
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkpointVersion is the version of the checkpoint format, to be increased on any incompatible change
+const checkpointVersion = 3
+
+var notValidCheckpoint = errors.New("not valid checkpoint")
+
+func init() {
+	// the concrete types of the cached values, besides the basic ones gob already knows about
+	gob.Register(time.Time{})
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+	gob.Register([]map[string]any{})
+}
+
+// replayableSource is a source of randomness counting its draws, so that its position can be restored
+// by drawing the same number of values from a source with the same seed
+type replayableSource struct {
+	seed  int64
+	src   rand.Source64
+	draws uint64
+}
+
+func newReplayableSource(seed int64) *replayableSource {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &replayableSource{seed: seed, src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (rs *replayableSource) Int63() int64 {
+	rs.draws++
+	return rs.src.Int63()
+}
+
+func (rs *replayableSource) Uint64() uint64 {
+	rs.draws++
+	return rs.src.Uint64()
+}
+
+func (rs *replayableSource) Seed(seed int64) {
+	rs.seed = seed
+	rs.draws = 0
+	rs.src.Seed(seed)
+}
+
+// replay draws values until the source is at the given position
+func (rs *replayableSource) replay(draws uint64) {
+	for rs.draws < draws {
+		rs.Uint64()
+	}
+}
+
+// readRand fills p with random bytes: unlike rand.Rand.Read it keeps no bytes between calls,
+// so that the position of the randomness of a state is fully captured by its checkpoint
+func readRand(r *rand.Rand, p []byte) {
+	var v uint64
+	for i := range p {
+		if i%8 == 0 {
+			v = r.Uint64()
+		}
+
+		p[i] = byte(v)
+		v >>= 8
+	}
+}
+
+// seedRand makes the randomness of the state derive from seed, the current time being used when zero
+func (s *GenState) seedRand(seed int64) {
+	s.source = newReplayableSource(seed)
+	s.rand = rand.New(s.source)
+	// the zipf distributions draw from the previous rand, they are built again on first use
+	s.zipfs = make(map[string]*rand.Zipf)
+}
+
+// checkpoint is what is encoded of a GenState: the values cached while generating an event only are left out,
+// since they don't outlive it
+type checkpoint struct {
+	Version              int
+	Seed                 int64
+	Draws                uint64
+	Counter              uint64
+	PrevCache            map[string]any
+	PrevCacheCardinality map[string]checkpointCardinalityCache
+	CardinalityPools     map[string][]any
+	Counters             map[string]int64
+}
+
+// checkpointCardinalityCache is the pool of values of a field with cardinality, the least recently used entry first
+type checkpointCardinalityCache struct {
+	Window  int
+	Indexes []int
+	Values  []any
+}
+
+// WriteCheckpoint writes a snapshot of the state to w, between the emission of two events:
+// the events emitted with the state restored by ReadCheckpoint continue from exactly where the state left off.
+// NOTE: the random functions of sprig rely on a global source that is not part of the state.
+func (s *GenState) WriteCheckpoint(w io.Writer) error {
+	cp := checkpoint{
+		Version:              checkpointVersion,
+		Counter:              s.counter,
+		PrevCache:            s.prevCache,
+		PrevCacheCardinality: make(map[string]checkpointCardinalityCache, len(s.prevCacheCardinality)),
+		CardinalityPools:     make(map[string][]any, len(s.cardinalityPools)),
+		Counters:             s.counters,
+	}
+
+	if s.source != nil {
+		cp.Seed = s.source.seed
+		cp.Draws = s.source.draws
+	}
+
+	for name, cache := range s.prevCacheCardinality {
+		cpCache := checkpointCardinalityCache{
+			Window:  cache.window,
+			Indexes: make([]int, 0, cache.len()),
+			Values:  make([]any, 0, cache.len()),
+		}
+
+		for element := cache.order.Back(); element != nil; element = element.Prev() {
+			entry := element.Value.(*cardinalityEntry)
+			cpCache.Indexes = append(cpCache.Indexes, entry.index)
+			cpCache.Values = append(cpCache.Values, entry.value)
+		}
+
+		cp.PrevCacheCardinality[name] = cpCache
+	}
+
+	for name, pool := range s.cardinalityPools {
+		cp.CardinalityPools[name] = pool.values
+	}
+
+	return gob.NewEncoder(w).Encode(cp)
+}
+
+// ReadCheckpoint returns the state whose snapshot was written to r by WriteCheckpoint
+func ReadCheckpoint(r io.Reader) (*GenState, error) {
+	var cp checkpoint
+	if err := gob.NewDecoder(r).Decode(&cp); err != nil {
+		return nil, fmt.Errorf("%w: %v", notValidCheckpoint, err)
+	}
+
+	if cp.Version != checkpointVersion {
+		return nil, fmt.Errorf("%w: version %d, expected %d", notValidCheckpoint, cp.Version, checkpointVersion)
+	}
+
+	state := NewGenState()
+	state.counter = cp.Counter
+
+	// a state never used for emitting is seeded by the generator it is first used with
+	if cp.Seed != 0 {
+		state.seedRand(cp.Seed)
+		state.source.replay(cp.Draws)
+	}
+
+	for name, value := range cp.PrevCache {
+		state.prevCache[name] = value
+	}
+
+	for name, cpCache := range cp.PrevCacheCardinality {
+		if len(cpCache.Indexes) != len(cpCache.Values) {
+			return nil, fmt.Errorf("%w: %d cardinality indexes for %d values of field %q", notValidCheckpoint, len(cpCache.Indexes), len(cpCache.Values), name)
+		}
+
+		cache := newCardinalityCache(cpCache.Window)
+		for i, index := range cpCache.Indexes {
+			cache.add(index, cpCache.Values[i])
+		}
+
+		state.prevCacheCardinality[name] = cache
+	}
+
+	for name, values := range cp.CardinalityPools {
+		// the zipf sampling is bound to the randomness of the restored state on first use
+		state.cardinalityPools[name] = &cardinalityPool{values: values}
+	}
+
+	for name, value := range cp.Counters {
+		state.counters[name] = value
+	}
+
+	return state, nil
+}
+
+// SaveCheckpoint writes a snapshot of the state to the file at path, as WriteCheckpoint does.
+// The file is replaced atomically, so that a run killed while saving leaves the previous checkpoint in place.
+func (s *GenState) SaveCheckpoint(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if err := s.WriteCheckpoint(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// LoadCheckpoint returns the state whose snapshot was saved to the file at path by SaveCheckpoint
+func LoadCheckpoint(path string) (*GenState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return ReadCheckpoint(f)
+}
@@ -0,0 +1,114 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func TestCheckpoint(t *testing.T) {
+	flds := Fields{
+		{Name: "id", Type: FieldTypeLong},
+		{Name: "alpha", Type: FieldTypeLong},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeDouble},
+		{Name: "delta", Type: FieldTypeIP},
+		{Name: "epsilon", Type: FieldTypeMAC},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(`- name: id
+  counter:
+    start: 1
+    step: 1
+- name: beta
+  cardinality:
+    numerator: 1
+    denominator: 20
+  range:
+    min: 0
+    max: 1000000
+- name: gamma
+  fuzziness: 0.1
+  range:
+    min: 0
+    max: 100
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Seed = 1
+
+	template := []byte(`{"id":{{.id}},"alpha":{{.alpha}},"beta":{{.beta}},"gamma":{{.gamma}},"delta":"{{.delta}}","epsilon":"{{.epsilon}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	emit := func(g Generator, state *GenState, n int) []string {
+		events := make([]string, 0, n)
+		var buf bytes.Buffer
+		for i := 0; i < n; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			events = append(events, buf.String())
+		}
+
+		return events
+	}
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+	events := emit(g, state, 500)
+
+	checkpointPath := filepath.Join(t.TempDir(), "state.checkpoint")
+	if err := state.SaveCheckpoint(checkpointPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new generator, as when continuing an interrupted run
+	g, _ = makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+	restored, err := LoadCheckpoint(checkpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events = append(events, emit(g, restored, 500)...)
+
+	if len(events) != 1000 {
+		t.Fatalf("expected 1000 events, got %d", len(events))
+	}
+
+	for i, event := range events {
+		m := unmarshalJSONT[any](t, []byte(event))
+		if id := m["id"].(float64); id != float64(i+1) {
+			t.Fatalf("expected id %d for event %d, got %v", i+1, i, id)
+		}
+	}
+
+	// the restored state continues exactly as the one never interrupted
+	g, state = makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+	uninterrupted := emit(g, state, 1000)
+	for i := range uninterrupted {
+		if events[i] != uninterrupted[i] {
+			t.Fatalf("expected event %d to be %s, got %s", i, uninterrupted[i], events[i])
+		}
+	}
+}
+
+func TestCheckpointNotValid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewGenState().WriteCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadCheckpoint(bytes.NewReader(buf.Bytes()[:buf.Len()/2])); !errors.Is(err, notValidCheckpoint) {
+		t.Fatalf("expected error %v, got %v", notValidCheckpoint, err)
+	}
+}
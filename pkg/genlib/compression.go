@@ -0,0 +1,53 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+var notValidCompression = errors.New("compression must be one of 'none' or 'gzip'")
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// gzipWriter is a gzip compression writer, terminated by the generators it was emitted to through EmitTo when closed
+type gzipWriter struct {
+	*gzip.Writer
+}
+
+func (*gzipWriter) closedByGenerators() {}
+
+// NewCompressionWriter returns a writer compressing to w what is emitted to it through EmitTo.
+// Closing the returned writer, or the generators it was emitted to through EmitTo, flushes and terminates the compressed
+// stream, without closing w.
+func NewCompressionWriter(compression config.Compression, w io.Writer) (io.WriteCloser, error) {
+	switch compression {
+	case "", config.CompressionNone:
+		return nopWriteCloser{w}, nil
+	case config.CompressionGzip:
+		return &gzipWriter{gzip.NewWriter(w)}, nil
+	default:
+		return nil, notValidCompression
+	}
+}
+
+// CompressionExtension returns the file extension for the given compression
+func CompressionExtension(compression config.Compression) string {
+	if compression == config.CompressionGzip {
+		return ".gz"
+	}
+
+	return ""
+}
@@ -0,0 +1,79 @@
+package genlib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func Test_GzipCompressionWithEmitTo(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, err := NewGeneratorWithCustomTemplateN(template, Config{}, flds, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var compressed bytes.Buffer
+	w, err := NewCompressionWriter(config.CompressionGzip, &compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+	for {
+		err := g.EmitTo(state, w)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte("\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := gzip.NewReader(&compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events int
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		_ = unmarshalJSONT[any](t, scanner.Bytes())
+		events += 1
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if events != 100 {
+		t.Errorf("expected 100 events, got %d", events)
+	}
+}
+
+func Test_NotValidCompression(t *testing.T) {
+	_, err := NewCompressionWriter(config.Compression("zstd"), io.Discard)
+	if err != notValidCompression {
+		t.Errorf("expected error %v, got %v", notValidCompression, err)
+	}
+}
@@ -6,11 +6,24 @@ import (
 	"io/ioutil"
 	"math"
 	"os"
+	"sort"
+	"time"
+	"unicode"
 
 	"github.com/elastic/go-ucfg/yaml"
 )
 
 var rangeBoundNotSet = errors.New("range bound not set")
+var notValidDistribution = errors.New("distribution type must be one of 'uniform', 'normal', 'exponential' or 'zipf'")
+var notValidNormalDistribution = errors.New("normal distribution stddev must be greater than or equal to 0")
+var notValidExponentialDistribution = errors.New("exponential distribution lambda must be greater than 0")
+var notValidZipfDistribution = errors.New("zipf distribution s must be greater than 1 and v greater than or equal to 1")
+var notValidGeoPointFormat = errors.New("geo_point format must be one of 'string' or 'object'")
+var notValidGeoPointRegion = errors.New("geo_point must have either a bounding_box, a center with a radius greater than 0 or a country")
+var notValidBoundingBox = errors.New("bounding_box latitudes must be between -90 and 90, longitudes between -180 and 180, with min lower than or equal to max")
+var notValidCenter = errors.New("center latitude must be between -90 and 90, longitude between -180 and 180")
+var notValidDynamicKeysAlphabet = errors.New("dynamic_keys alphabet must not contain quotes, backslashes or control characters")
+var notValidLength = errors.New("length min and max must be greater than or equal to 0, with min lower than or equal to max")
 
 type Ratio struct {
 	Numerator   int `config:"numerator"`
@@ -18,23 +31,282 @@ type Ratio struct {
 }
 
 type Range struct {
-  // NOTE: we want to distinguish when Min/Max are explicitly set to zero value or are not set at all. We use a pointer, such that when not set will be `nil`.
+	// NOTE: we want to distinguish when Min/Max are explicitly set to zero value or are not set at all. We use a pointer, such that when not set will be `nil`.
 	Min *float64 `config:"min"`
 	Max *float64 `config:"max"`
 }
 
+// Compression is the compression applied to the generated corpus
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+)
+
+// OutputFormat is the format of the generated corpus
+type OutputFormat string
+
+const (
+	OutputFormatJSON      OutputFormat = "json"
+	OutputFormatJSONArray OutputFormat = "json_array"
+	OutputFormatParquet   OutputFormat = "parquet"
+	OutputFormatCSV       OutputFormat = "csv"
+)
+
+// Distribution types of generated numeric values
+const (
+	DistributionUniform     = "uniform"
+	DistributionNormal      = "normal"
+	DistributionExponential = "exponential"
+	DistributionZipf        = "zipf"
+)
+
+type Distribution struct {
+	Type string `config:"type"`
+	// Mean and StdDev of the normal distribution
+	Mean   float64 `config:"mean"`
+	StdDev float64 `config:"stddev"`
+	// Lambda is the rate of the exponential distribution
+	Lambda float64 `config:"lambda"`
+	// S and V are the parameters of the zipf distribution, as in math/rand
+	S float64 `config:"s"`
+	V float64 `config:"v"`
+}
+
+// Formats of generated geo_point values
+const (
+	GeoPointFormatString = "string"
+	GeoPointFormatObject = "object"
+)
+
+type GeoPoint struct {
+	// NOTE: we want to distinguish when the region is set or not. We use a pointer, such that when not set will be `nil`.
+	BoundingBox *BoundingBox `config:"bounding_box"`
+	Center      *LatLon      `config:"center"`
+	// Radius around Center, in kilometers
+	Radius float64 `config:"radius"`
+	// Country is the ISO 3166-1 alpha-2 code of the country whose rough outline the points are generated inside
+	Country string `config:"country"`
+	// Format is either "lat,lon" string (the default) or {"lat":..,"lon":..} object
+	Format string `config:"format"`
+}
+
+type BoundingBox struct {
+	MinLat float64 `config:"min_lat"`
+	MaxLat float64 `config:"max_lat"`
+	MinLon float64 `config:"min_lon"`
+	MaxLon float64 `config:"max_lon"`
+}
+
+type LatLon struct {
+	Lat float64 `config:"lat"`
+	Lon float64 `config:"lon"`
+}
+
+// Values of the country_region of keyword fields
+const (
+	CountryRegionCountryISOCode = "country_iso_code"
+	CountryRegionRegionName     = "region_name"
+)
+
+// Formats of generated date values
+const (
+	DateFormatRFC3339     = "rfc3339"
+	DateFormatRFC3339Nano = "rfc3339nano"
+	DateFormatEpochMillis = "epoch_millis"
+	DateFormatEpochSecond = "epoch_second"
+)
+
+// Timestamp is the time window of the generated events
+type Timestamp struct {
+	// Start of the time window, one hour before End when not set
+	Start time.Time
+	// End of the time window, the time the generator is created at when not set
+	End time.Time
+	// Rate is the number of events per second: the timestamp of each event follows the previous one by 1/Rate seconds, starting from Start.
+	// When not set the timestamps are uniformly random within the time window.
+	Rate float64
+	// BusinessHours biases the random timestamps toward the working hours, it cannot be used together with Rate
+	BusinessHours *BusinessHours
+	// Timezone the timestamps are formatted in, as a location name of the IANA Time Zone database; the local one when not set
+	Timezone string
+}
+
+// BusinessHours are the working hours of the week, any other time gets fewer events
+type BusinessHours struct {
+	// Location of the working hours, the Timestamp Timezone when not set, or UTC
+	Location *time.Location
+	// StartHour and EndHour of the working days, from 9 to 17 when not set
+	StartHour int
+	EndHour   int
+	// Weekdays are the working days, from Monday to Friday when not set
+	Weekdays []time.Weekday
+	// OffHoursWeight is the rate of the events out of the working hours relative to the one within, between 0 and 1, defaults to 0.1 when not set
+	OffHoursWeight float64
+}
+
+// Progress of the generation of a corpus
+type Progress struct {
+	// Events generated so far
+	Events uint64
+	// Bytes of the events generated so far
+	Bytes uint64
+	// EstimatedEvents is the total number of events, as estimated from the total size, zero when there's no limit
+	EstimatedEvents uint64
+}
+
+// Counter values start from Start and increase by Step, 1 when not set, for each generated value
+type Counter struct {
+	Start int64 `config:"start"`
+	Step  int64 `config:"step" validate:"min=0"`
+}
+
+// Length is the number of generated elements, between Min and Max included
+type Length struct {
+	Min int `config:"min"`
+	Max int `config:"max"`
+}
+
+// DynamicKeys are the random keys generated for an object field
+type DynamicKeys struct {
+	// Keys is the number of keys generated in each object
+	Keys Length `config:"keys"`
+	// Alphabet and KeyLength of the random key names
+	Alphabet  string `config:"alphabet"`
+	KeyLength Length `config:"key_length"`
+}
+
+// Semver are the bounds of the generated semantic versions
+type Semver struct {
+	// NOTE: we want to distinguish when a bound is set or not, since zero is a valid major, minor and patch version. We use pointers, such that when not set will be `nil`.
+	Major *Length `config:"major"`
+	Minor *Length `config:"minor"`
+	Patch *Length `config:"patch"`
+	// PrereleaseProbability and BuildProbability are the probabilities, between 0.0 and 1.0, of a version having a prerelease and a build metadata
+	PrereleaseProbability float64 `config:"prerelease_probability" validate:"min=0, max=1"`
+	BuildProbability      float64 `config:"build_probability" validate:"min=0, max=1"`
+}
+
 type Config struct {
-	m map[string]ConfigField
+	// Seed makes the generated corpus reproducible when set to a value different from zero
+	Seed int64
+	// EstimationSamples is the number of events generated for estimating the total number of events from the total size, defaults to 16 when not set
+	EstimationSamples int
+	// Compression of the generated corpus, no compression is applied when not set
+	Compression Compression
+	// BulkFormat precedes each generated event with an Elasticsearch _bulk create action line for BulkIndex
+	BulkFormat bool
+	// BulkIndex is the index name in the _bulk action lines, supporting go text/template and sprig functions
+	BulkIndex string
+	// NDJSON strips the trailing whitespaces of each generated event and terminates it with exactly one new line
+	NDJSON bool
+	// PrettyJSON indents each generated event being valid JSON, the others being left untouched, for debugging; it can't be set together with NDJSON
+	PrettyJSON bool
+	// ValidateJSON checks that each generated event is valid JSON, failing on the first one that is not; it's off by default for performance
+	ValidateJSON bool
+	// OutputFormat of the generated corpus, the events as they are generated when not set.
+	// With OutputFormatParquet and OutputFormatCSV the events must be JSON objects, written as the rows of a Parquet or CSV file with a column for each field.
+	// With OutputFormatJSONArray the events, JSON objects generated from a template, are written as the elements of a single JSON array.
+	OutputFormat OutputFormat
+	// MaxFileSize is the maximum size in bytes of each corpus file, before compression, the corpus being written to a single file when not set
+	MaxFileSize uint64
+	// EventsPerFile is the maximum number of events of each corpus file, the last file holding the remainder, the corpus being written to a single file when not set
+	EventsPerFile uint64
+	// RoundRobinFiles is the number of corpus files the events are spread across round-robin, the event i being written to the file i%RoundRobinFiles;
+	// it can't be set together with MaxFileSize or EventsPerFile
+	RoundRobinFiles uint64
+	// OutputHeader and OutputFooter are written once before the first event and once after the last one, and EventSeparator between each
+	// two events, e.g. "[", "]" and "," for a JSON array; they can be set with the json OutputFormat and a single corpus file only
+	OutputHeader   []byte
+	OutputFooter   []byte
+	EventSeparator []byte
+	// TemplateDir is the directory the @include directives of the templates are resolved relative to, the working directory when not set
+	TemplateDir string
+	// CardinalityWindow is the maximum number of values kept for each field with cardinality, bounding the memory of high cardinality fields:
+	// the values are then deduplicated within the window only, and a cardinality greater than the window is not honored.
+	// There's no maximum when not set.
+	CardinalityWindow int
+	// Manifest writes a JSON file describing the generated corpus alongside it
+	Manifest bool
+	// Timestamp of the generated events, emitted for the @timestamp field when set
+	Timestamp *Timestamp
+	// ECSVersion of the generated events, a semantic version emitted for the ecs.version field when set
+	ECSVersion string
+	// ProgressInterval is the number of events between the calls to ProgressFunc, with the progress of the generation;
+	// there's no progress reported when not set
+	ProgressInterval uint64
+	ProgressFunc     func(progress Progress) `json:"-"`
+	m                map[string]ConfigField
 }
 
 type ConfigField struct {
-	Name        string   `config:"name"`
-	Fuzziness   float64  `config:"fuzziness"`
-	Range       Range    `config:"range"`
-	Cardinality Ratio    `config:"cardinality"`
-	Enum        []string `config:"enum"`
-	ObjectKeys  []string `config:"object_keys"`
-	Value       any      `config:"value"`
+	Name            string   `config:"name"`
+	Fuzziness       float64  `config:"fuzziness"`
+	Range           Range    `config:"range"`
+	Cardinality     Ratio    `config:"cardinality"`
+	Enum            []string `config:"enum"`
+	ObjectKeys      []string `config:"object_keys"`
+	Value           any      `config:"value"`
+	NullProbability float64  `config:"null_probability" validate:"min=0, max=1"`
+	// WeightedEnum values are chosen proportionally to their weight, or uniformly when no weight is set
+	WeightedEnum []WeightedValue `config:"weighted_enum"`
+	Distribution Distribution    `config:"distribution"`
+	GeoPoint     GeoPoint        `config:"geo_point"`
+	// CIDR of the network, either IPv4 or IPv6, generated ip values belong to
+	CIDR string `config:"cidr"`
+	// ScalingFactor of scaled_float values, generated values are quantized to its inverse
+	ScalingFactor float64 `config:"scaling_factor" validate:"min=0"`
+	// Documents is the number of sub-documents generated for nested fields
+	Documents Length `config:"documents"`
+	// NOTE: we want to distinguish when ArrayLength is set or not, since an empty array is a valid value. We use a pointer, such that when not set will be `nil`.
+	ArrayLength *Length `config:"array_length"`
+	// Format of generated date values, either one of the DateFormat ones or a Go time layout
+	Format      string       `config:"format"`
+	DynamicKeys *DynamicKeys `config:"dynamic_keys"`
+	Counter     *Counter     `config:"counter"`
+	// ObjectProbability is the probability of each value of a flattened field being itself an object of random keys with scalar values
+	ObjectProbability float64 `config:"object_probability" validate:"min=0, max=1"`
+	// Timezone date values are formatted in, as a location name of the IANA Time Zone database; the local one when not set
+	Timezone string `config:"timezone"`
+	// SameAs is the name of the field whose value, within the same event, is emitted for the field
+	SameAs string `config:"same_as"`
+	// Pattern is the regular expression generated keyword values match
+	Pattern string `config:"pattern"`
+	// ValuesFile is the path of a file with the keyword values to choose from, one per line
+	ValuesFile string `config:"values_file"`
+	// Length of generated keyword values, or number of random bytes of binary values, with Min equal to Max for a fixed one
+	Length *Length `config:"length"`
+	// Charset is the characters generated keyword values are made of, either the name of a preset or the characters themselves
+	Charset string `config:"charset"`
+	// WordCount and SentenceCount of generated text values
+	WordCount     *Length `config:"word_count"`
+	SentenceCount *Length `config:"sentence_count"`
+	Semver        Semver  `config:"semver"`
+	// Complexity is the number of paths, arguments and key=value pairs generated wildcard values are made of
+	Complexity *Length `config:"complexity"`
+	// AsString emits numeric, boolean and ip values as JSON strings, for pipelines expecting them as strings
+	AsString bool `config:"as_string"`
+	// NOTE: we want to distinguish when TrueProbability is explicitly set to zero value or is not set at all. We use a pointer, such that when not set will be `nil`.
+	TrueProbability *float64 `config:"true_probability" validate:"min=0, max=1"`
+	// OUI is the first three octets of generated mac values, colon separated
+	OUI string `config:"oui"`
+	// LocallyAdministered marks generated mac values without OUI as locally administered
+	LocallyAdministered bool `config:"locally_administered"`
+	// CardinalityPool is the number of distinct values generated once for the field, and then sampled from
+	CardinalityPool int `config:"cardinality_pool" validate:"min=0"`
+	// CardinalityReusePattern is how often each value of the cardinality pool recurs, either DistributionUniform or DistributionZipf; uniform when not set
+	CardinalityReusePattern string `config:"cardinality_reuse_pattern"`
+	// CountryRegion is either the country code or the region name of a country and region pair, drawn once per event for the fields of the same object
+	CountryRegion string `config:"country_region"`
+	// Prefix and Suffix wrap the generated values of string-like fields, before they are deduplicated by any cardinality
+	Prefix string `config:"prefix"`
+	Suffix string `config:"suffix"`
+}
+
+type WeightedValue struct {
+	Value  string  `config:"value"`
+	Weight float64 `config:"weight" validate:"min=0"`
 }
 
 func (r Range) MinAsInt64() (int64, error) {
@@ -42,7 +314,7 @@ func (r Range) MinAsInt64() (int64, error) {
 		return 0, rangeBoundNotSet
 	}
 
-	return int64(*r.Min), nil
+	return float64ToInt64(*r.Min), nil
 }
 
 func (r Range) MaxAsInt64() (int64, error) {
@@ -50,7 +322,51 @@ func (r Range) MaxAsInt64() (int64, error) {
 		return math.MaxInt64, rangeBoundNotSet
 	}
 
-	return int64(*r.Max), nil
+	return float64ToInt64(*r.Max), nil
+}
+
+// MinAsUint64 returns the Min bound as an unsigned integer, saturated to the uint64 range
+func (r Range) MinAsUint64() (uint64, error) {
+	if r.Min == nil {
+		return 0, rangeBoundNotSet
+	}
+
+	return float64ToUint64(*r.Min), nil
+}
+
+// MaxAsUint64 returns the Max bound as an unsigned integer, saturated to the uint64 range
+func (r Range) MaxAsUint64() (uint64, error) {
+	if r.Max == nil {
+		return math.MaxUint64, rangeBoundNotSet
+	}
+
+	return float64ToUint64(*r.Max), nil
+}
+
+// float64ToInt64 converts v to an integer, saturating instead of overflowing:
+// math.MaxInt64 itself is parsed as 2^63, that doesn't fit in an int64
+func float64ToInt64(v float64) int64 {
+	switch {
+	case v <= math.MinInt64:
+		return math.MinInt64
+	case v >= math.MaxInt64:
+		return math.MaxInt64
+	default:
+		return int64(v)
+	}
+}
+
+// float64ToUint64 converts v to an unsigned integer, saturating instead of overflowing:
+// math.MaxUint64 itself is parsed as 2^64, that doesn't fit in an uint64
+func float64ToUint64(v float64) uint64 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= math.MaxUint64:
+		return math.MaxUint64
+	default:
+		return uint64(v)
+	}
 }
 
 func (r Range) MinAsFloat64() (float64, error) {
@@ -69,6 +385,84 @@ func (r Range) MaxAsFloat64() (float64, error) {
 	return *r.Max, nil
 }
 
+func (d Distribution) Validate() error {
+	switch d.Type {
+	case "", DistributionUniform:
+	case DistributionNormal:
+		if d.StdDev < 0 {
+			return notValidNormalDistribution
+		}
+	case DistributionExponential:
+		if d.Lambda <= 0 {
+			return notValidExponentialDistribution
+		}
+	case DistributionZipf:
+		if d.S <= 1 || d.V < 1 {
+			return notValidZipfDistribution
+		}
+	default:
+		return notValidDistribution
+	}
+
+	return nil
+}
+
+func (g GeoPoint) Validate() error {
+	switch g.Format {
+	case "", GeoPointFormatString, GeoPointFormatObject:
+	default:
+		return notValidGeoPointFormat
+	}
+
+	if g.BoundingBox != nil && g.Center != nil {
+		return notValidGeoPointRegion
+	}
+
+	if len(g.Country) > 0 && (g.BoundingBox != nil || g.Center != nil) {
+		return notValidGeoPointRegion
+	}
+
+	if g.Center != nil && g.Radius <= 0 {
+		return notValidGeoPointRegion
+	}
+
+	return nil
+}
+
+func (b BoundingBox) Validate() error {
+	if b.MinLat < -90 || b.MaxLat > 90 || b.MinLon < -180 || b.MaxLon > 180 || b.MinLat > b.MaxLat || b.MinLon > b.MaxLon {
+		return notValidBoundingBox
+	}
+
+	return nil
+}
+
+func (l Length) Validate() error {
+	if l.Min < 0 || l.Max < 0 || l.Min > l.Max {
+		return notValidLength
+	}
+
+	return nil
+}
+
+func (d DynamicKeys) Validate() error {
+	for _, c := range d.Alphabet {
+		if c == '"' || c == '\\' || unicode.IsControl(c) {
+			return notValidDynamicKeysAlphabet
+		}
+	}
+
+	return nil
+}
+
+func (l LatLon) Validate() error {
+	if l.Lat < -90 || l.Lat > 90 || l.Lon < -180 || l.Lon > 180 {
+		return notValidCenter
+	}
+
+	return nil
+}
+
 func LoadConfig(configFile string) (Config, error) {
 	if len(configFile) == 0 {
 		return Config{}, nil
@@ -115,3 +509,17 @@ func (c Config) GetField(fieldName string) (ConfigField, bool) {
 	v, ok := c.m[fieldName]
 	return v, ok
 }
+
+// Fields returns the configs of all the fields, sorted by name
+func (c Config) Fields() []ConfigField {
+	fields := make([]ConfigField, 0, len(c.m))
+	for _, field := range c.m {
+		fields = append(fields, field)
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+
+	return fields
+}
@@ -233,3 +233,175 @@ func TestRange_MinAsInt64(t *testing.T) {
 		})
 	}
 }
+
+func TestDistribution_Validate(t *testing.T) {
+	testCases := []struct {
+		scenario         string
+		distributionYaml string
+		hasError         bool
+	}{
+		{
+			scenario:         "not set",
+			distributionYaml: "mean: 10",
+		},
+		{
+			scenario:         "uniform",
+			distributionYaml: "type: uniform",
+		},
+		{
+			scenario:         "normal",
+			distributionYaml: "type: normal\nmean: 10\nstddev: 2",
+		},
+		{
+			scenario:         "normal with negative stddev",
+			distributionYaml: "type: normal\nmean: 10\nstddev: -2",
+			hasError:         true,
+		},
+		{
+			scenario:         "exponential",
+			distributionYaml: "type: exponential\nlambda: 0.5",
+		},
+		{
+			scenario:         "exponential without lambda",
+			distributionYaml: "type: exponential",
+			hasError:         true,
+		},
+		{
+			scenario:         "zipf",
+			distributionYaml: "type: zipf\ns: 1.5\nv: 1",
+		},
+		{
+			scenario:         "zipf with s not greater than 1",
+			distributionYaml: "type: zipf\ns: 1\nv: 1",
+			hasError:         true,
+		},
+		{
+			scenario:         "unknown",
+			distributionYaml: "type: pareto",
+			hasError:         true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.distributionYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var distribution Distribution
+			err = cfg.Unpack(&distribution)
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %s", err)
+			}
+		})
+	}
+}
+
+func TestGeoPoint_Validate(t *testing.T) {
+	testCases := []struct {
+		scenario     string
+		geoPointYaml string
+		hasError     bool
+	}{
+		{
+			scenario:     "bounding box",
+			geoPointYaml: "bounding_box:\n  min_lat: 10\n  max_lat: 20\n  min_lon: -10\n  max_lon: 10",
+		},
+		{
+			scenario:     "bounding box with min greater than max",
+			geoPointYaml: "bounding_box:\n  min_lat: 20\n  max_lat: 10\n  min_lon: -10\n  max_lon: 10",
+			hasError:     true,
+		},
+		{
+			scenario:     "bounding box out of bounds",
+			geoPointYaml: "bounding_box:\n  min_lat: -100\n  max_lat: 10\n  min_lon: -10\n  max_lon: 10",
+			hasError:     true,
+		},
+		{
+			scenario:     "center with radius",
+			geoPointYaml: "center:\n  lat: 10\n  lon: 10\nradius: 5",
+		},
+		{
+			scenario:     "center without radius",
+			geoPointYaml: "center:\n  lat: 10\n  lon: 10",
+			hasError:     true,
+		},
+		{
+			scenario:     "both bounding box and center",
+			geoPointYaml: "bounding_box:\n  min_lat: 10\n  max_lat: 20\n  min_lon: -10\n  max_lon: 10\ncenter:\n  lat: 10\n  lon: 10\nradius: 5",
+			hasError:     true,
+		},
+		{
+			scenario:     "unknown format",
+			geoPointYaml: "format: wkt",
+			hasError:     true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.geoPointYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var geoPoint GeoPoint
+			err = cfg.Unpack(&geoPoint)
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %s", err)
+			}
+		})
+	}
+}
+
+func TestLength_Validate(t *testing.T) {
+	testCases := []struct {
+		scenario   string
+		lengthYaml string
+		hasError   bool
+	}{
+		{
+			scenario:   "min and max",
+			lengthYaml: "min: 1\nmax: 3",
+		},
+		{
+			scenario:   "fixed",
+			lengthYaml: "min: 2\nmax: 2",
+		},
+		{
+			scenario:   "min greater than max",
+			lengthYaml: "min: 3\nmax: 1",
+			hasError:   true,
+		},
+		{
+			scenario:   "negative min",
+			lengthYaml: "min: -1\nmax: 1",
+			hasError:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.lengthYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var length Length
+			err = cfg.Unpack(&length)
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+var notValidCountryRegion = errors.New("country_region must be one of 'country_iso_code' or 'region_name'")
+
+// countryRegions are the names of the main regions of some countries, keyed by their ISO 3166-1 alpha-2 code
+var countryRegions = map[string][]string{
+	"AT": {"Burgenland", "Carinthia", "Lower Austria", "Salzburg", "Styria", "Tyrol", "Upper Austria", "Vienna", "Vorarlberg"},
+	"AU": {"Australian Capital Territory", "New South Wales", "Northern Territory", "Queensland", "South Australia", "Tasmania", "Victoria", "Western Australia"},
+	"BE": {"Brussels Capital", "Flanders", "Wallonia"},
+	"BR": {"Bahia", "Minas Gerais", "Parana", "Pernambuco", "Rio de Janeiro", "Rio Grande do Sul", "Sao Paulo"},
+	"CA": {"Alberta", "British Columbia", "Manitoba", "Nova Scotia", "Ontario", "Quebec", "Saskatchewan"},
+	"CH": {"Bern", "Geneva", "Ticino", "Vaud", "Zurich"},
+	"DE": {"Baden-Wurttemberg", "Bavaria", "Berlin", "Hamburg", "Hesse", "Lower Saxony", "North Rhine-Westphalia", "Saxony"},
+	"ES": {"Andalusia", "Basque Country", "Catalonia", "Galicia", "Madrid", "Valencia"},
+	"FR": {"Auvergne-Rhone-Alpes", "Brittany", "Grand Est", "Hauts-de-France", "Ile-de-France", "Normandy", "Nouvelle-Aquitaine", "Occitanie", "Provence-Alpes-Cote d'Azur"},
+	"GB": {"England", "Northern Ireland", "Scotland", "Wales"},
+	"IN": {"Delhi", "Gujarat", "Karnataka", "Kerala", "Maharashtra", "Tamil Nadu", "Telangana", "West Bengal"},
+	"IT": {"Campania", "Emilia-Romagna", "Lazio", "Lombardy", "Piedmont", "Sicily", "Tuscany", "Veneto"},
+	"JP": {"Aichi", "Fukuoka", "Hokkaido", "Kanagawa", "Kyoto", "Osaka", "Tokyo"},
+	"MX": {"Jalisco", "Mexico City", "Nuevo Leon", "Puebla", "Quintana Roo", "Yucatan"},
+	"NL": {"Gelderland", "North Brabant", "North Holland", "South Holland", "Utrecht"},
+	"PL": {"Lesser Poland", "Lower Silesia", "Masovia", "Pomerania", "Silesia"},
+	"PT": {"Algarve", "Centro", "Lisbon", "Norte"},
+	"US": {"California", "Florida", "Illinois", "New York", "Pennsylvania", "Texas", "Virginia", "Washington"},
+	"ZA": {"Eastern Cape", "Gauteng", "KwaZulu-Natal", "Western Cape"},
+}
+
+// countryRegionCodes are the sorted codes of the countries in countryRegions, the countries being drawn by their index
+var countryRegionCodes = func() []string {
+	codes := make([]string, 0, len(countryRegions))
+	for code := range countryRegions {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+	return codes
+}()
+
+// countryRegion is a country code and the name of one of its regions
+type countryRegion struct {
+	countryISOCode string
+	regionName     string
+}
+
+// countryRegionGroup is the name the fields with country_region draw the same pair by, within an event: their parent object,
+// as "source.geo" for "source.geo.country_iso_code" and "source.geo.region_name"
+func countryRegionGroup(fieldName string) string {
+	if i := strings.LastIndex(fieldName, "."); i > -1 {
+		return fieldName[:i]
+	}
+
+	return ""
+}
+
+// drawCountryRegion returns the pair of the group in the current event, drawing it the first time a field of the group is generated.
+// The pair is kept in the same cache of the same_as values, under a key no field can have.
+func drawCountryRegion(state *GenState, group string) countryRegion {
+	key := "country_region " + group
+	if cached, ok := state.sameAsCache[key]; ok && cached.event == state.counter {
+		return cached.value.(countryRegion)
+	}
+
+	code := countryRegionCodes[state.rand.Intn(len(countryRegionCodes))]
+	regions := countryRegions[code]
+	pair := countryRegion{countryISOCode: code, regionName: regions[state.rand.Intn(len(regions))]}
+	state.sameAsCache[key] = sameAsValue{event: state.counter, value: pair}
+
+	return pair
+}
+
+// makeCountryRegionFunc returns a function returning either the country code or the region name of the pair of the field group in the event
+func makeCountryRegionFunc(fieldCfg ConfigField, field Field) (func(state *GenState) string, error) {
+	group := countryRegionGroup(field.Name)
+	switch fieldCfg.CountryRegion {
+	case config.CountryRegionCountryISOCode:
+		return func(state *GenState) string {
+			return drawCountryRegion(state, group).countryISOCode
+		}, nil
+	case config.CountryRegionRegionName:
+		return func(state *GenState) string {
+			return drawCountryRegion(state, group).regionName
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", notValidCountryRegion, fieldCfg.CountryRegion)
+	}
+}
+
+func bindCountryRegion(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	countryRegionFunc, err := makeCountryRegionFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		_, err := buf.WriteString(countryRegionFunc(state))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindCountryRegionWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	countryRegionFunc, err := makeCountryRegionFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return countryRegionFunc(state)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
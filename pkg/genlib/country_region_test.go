@@ -0,0 +1,119 @@
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// hasRegion tells if region is one of the regions of country
+func hasRegion(country, region string) bool {
+	for _, r := range countryRegions[country] {
+		if r == region {
+			return true
+		}
+	}
+
+	return false
+}
+
+func Test_FieldCountryRegionWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "source.geo.country_iso_code", Type: FieldTypeKeyword},
+		{Name: "source.geo.region_name", Type: FieldTypeKeyword},
+		{Name: "destination.geo.country_iso_code", Type: FieldTypeKeyword},
+		{Name: "destination.geo.region_name", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: source.geo.country_iso_code\n  country_region: country_iso_code\n- name: source.geo.region_name\n  country_region: region_name\n" +
+		"- name: destination.geo.country_iso_code\n  country_region: country_iso_code\n- name: destination.geo.region_name\n  country_region: region_name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"source":"{{.source.geo.country_iso_code}}/{{.source.geo.region_name}}","destination":"{{.destination.geo.country_iso_code}}/{{.destination.geo.region_name}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	countries := make(map[string]struct{})
+	var differentCountries bool
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		var eventCountries []string
+		for _, key := range []string{"source", "destination"} {
+			pair := strings.SplitN(m[key], "/", 2)
+			country, region := pair[0], pair[1]
+			if !hasRegion(country, region) {
+				t.Fatalf("expected region of %s, got %s", country, region)
+			}
+
+			countries[country] = struct{}{}
+			eventCountries = append(eventCountries, country)
+		}
+
+		differentCountries = differentCountries || eventCountries[0] != eventCountries[1]
+	}
+
+	if len(countries) != len(countryRegions) {
+		t.Errorf("expected all the %d countries, got %d", len(countryRegions), len(countries))
+	}
+
+	if !differentCountries {
+		t.Error("expected pairs of different objects drawn independently")
+	}
+}
+
+func Test_FieldCountryRegionWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "source.geo.country_iso_code", Type: FieldTypeKeyword},
+		{Name: "source.geo.region_name", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: source.geo.country_iso_code\n  country_region: country_iso_code\n- name: source.geo.region_name\n  country_region: region_name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"region":"{{generate "source.geo.region_name"}}","country":"{{generate "source.geo.country_iso_code"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if !hasRegion(m["country"], m["region"]) {
+			t.Fatalf("expected region of %s, got %s", m["country"], m["region"])
+		}
+	}
+}
+
+func Test_FieldCountryRegionNotValid(t *testing.T) {
+	flds := Fields{
+		{Name: "source.geo.country_name", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: source.geo.country_name\n  country_region: country_name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{"alpha":"{{.source.geo.country_name}}"}`), cfg, flds, 0); !errors.Is(err, notValidCountryRegion) {
+		t.Fatalf("expected not valid country_region error, got %v", err)
+	}
+}
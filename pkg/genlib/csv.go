@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// CSVExtension is the extension of the corpus files written by a CSVWriter
+const CSVExtension = ".csv"
+
+// CSVWriter writes JSON events as CSV rows, with a column for each field name, preceded by a header row with the field names
+type CSVWriter struct {
+	fieldNames []string
+	writer     *csv.Writer
+	record     []string
+}
+
+// CSVFieldNames returns the field names of the CSV columns: the ones of the placeholders of customTemplate, in order of first appearance,
+// or the ones of fields, in order, when customTemplate is empty
+func CSVFieldNames(customTemplate []byte, fields Fields) []string {
+	if len(customTemplate) == 0 {
+		fieldNames := make([]string, 0, len(fields))
+		for _, field := range fields {
+			fieldNames = append(fieldNames, field.Name)
+		}
+
+		return fieldNames
+	}
+
+	orderedFields, _, _ := parseCustomTemplate(customTemplate)
+
+	seen := make(map[string]struct{}, len(orderedFields))
+	fieldNames := make([]string, 0, len(orderedFields))
+	for _, fieldName := range orderedFields {
+		if _, ok := seen[fieldName]; ok {
+			continue
+		}
+
+		seen[fieldName] = struct{}{}
+		fieldNames = append(fieldNames, fieldName)
+	}
+
+	return fieldNames
+}
+
+// NewCSVWriter returns a CSVWriter writing to w, having already written the header row
+func NewCSVWriter(fieldNames []string, w io.Writer) (*CSVWriter, error) {
+	csvWriter := &CSVWriter{
+		fieldNames: fieldNames,
+		writer:     csv.NewWriter(w),
+		record:     make([]string, len(fieldNames)),
+	}
+
+	if err := csvWriter.writer.Write(fieldNames); err != nil {
+		return nil, err
+	}
+
+	return csvWriter, nil
+}
+
+// WriteEvent writes the JSON event as a row, with the value of each field looked up either by its dotted name or by its path in nested objects.
+// Missing and null values are empty cells, while objects and arrays are JSON encoded in a single cell.
+func (cw *CSVWriter) WriteEvent(event []byte) error {
+	doc, err := decodeJSONEvent(event)
+	if err != nil {
+		return err
+	}
+
+	for i, fieldName := range cw.fieldNames {
+		value, _ := lookupEventValue(doc, fieldName)
+
+		cell, err := eventValueString(value)
+		if err != nil {
+			return err
+		}
+
+		cw.record[i] = cell
+	}
+
+	return cw.writer.Write(cw.record)
+}
+
+// Close flushes the buffered rows, it doesn't close the underlying writer
+func (cw *CSVWriter) Close() error {
+	cw.writer.Flush()
+
+	return cw.writer.Error()
+}
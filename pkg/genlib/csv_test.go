@@ -0,0 +1,139 @@
+package genlib
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func Test_CSVWriter(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeIP},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  enum: [\"with, comma\", \"without comma\"]\n- name: gamma\n  array_length:\n    min: 2\n    max: 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the placeholders order, rather than the fields one, defines the columns
+	template := []byte(`{"gamma":{{.gamma}},"beta":{{.beta}},"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	const totEvents = 50
+
+	g, err := NewGeneratorWithCustomTemplateN(template, cfg, flds, totEvents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cw, err := NewCSVWriter(CSVFieldNames(template, flds), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := cw.WriteEvent(buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Count(out.String(), "\n"); lines != totEvents+1 {
+		t.Fatalf("expected %d lines, got %d", totEvents+1, lines)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header := strings.Join(records[0], ","); header != "gamma,beta,alpha" {
+		t.Fatalf("expected header gamma,beta,alpha, got %s", header)
+	}
+
+	for _, record := range records[1:] {
+		if ips := unmarshalJSONT[any](t, []byte(`{"gamma":`+record[0]+`}`))["gamma"].([]any); len(ips) != 2 {
+			t.Errorf("expected JSON array of 2 ips, got %s", record[0])
+		}
+
+		if record[2] != "with, comma" && record[2] != "without comma" {
+			t.Errorf("expected enum value, got %s", record[2])
+		}
+	}
+}
+
+func Test_CSVFieldNames(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	if fieldNames := strings.Join(CSVFieldNames(nil, flds), ","); fieldNames != "alpha,beta" {
+		t.Fatalf("expected fields order, got %s", fieldNames)
+	}
+
+	if fieldNames := strings.Join(CSVFieldNames([]byte(`{{.beta}} {{.alpha}} {{.beta}}`), flds), ","); fieldNames != "beta,alpha" {
+		t.Fatalf("expected placeholders order, got %s", fieldNames)
+	}
+}
+
+func Test_CSVWriterNotValid(t *testing.T) {
+	cw, err := NewCSVWriter([]string{"alpha"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cw.WriteEvent([]byte(`not json`)); !errors.Is(err, notValidJSONEvent) {
+		t.Fatalf("expected event error, got %v", err)
+	}
+}
+
+func Test_CSVWriterEscaping(t *testing.T) {
+	var out bytes.Buffer
+	cw, err := NewCSVWriter([]string{"message", "host.name", "labels", "missing"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cw.WriteEvent([]byte(`{"message":"with \"quotes\",\nand a new line","host":{"name":"alpha"},"labels":{"k":"v"}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"with \"quotes\",\nand a new line", "alpha", `{"k":"v"}`, ""}
+	if strings.Join(records[1], "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected %q, got %q", expected, records[1])
+	}
+}
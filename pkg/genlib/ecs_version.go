@@ -0,0 +1,76 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ECSVersionFieldName is the field the ECS version of the events is emitted for
+const ECSVersionFieldName = "ecs.version"
+
+var notValidECSVersion = errors.New("ECS version must be a semantic version, as 8.11.0")
+
+// ecsVersionRegex matches the semantic versions, with optional prerelease and build metadata, as of https://semver.org
+var ecsVersionRegex = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// bindECSVersion binds the ecs.version field to the configured ECS version, overriding its definition in the fields if any
+func bindECSVersion(cfg Config, fieldMap map[string]any, withReturn bool) error {
+	if len(cfg.ECSVersion) == 0 {
+		return nil
+	}
+
+	if !ecsVersionRegex.MatchString(cfg.ECSVersion) {
+		return fmt.Errorf("%w: %q", notValidECSVersion, cfg.ECSVersion)
+	}
+
+	if withReturn {
+		var emitF EmitF
+		emitF = func(state *GenState) any {
+			return cfg.ECSVersion
+		}
+
+		fieldMap[ECSVersionFieldName] = emitF
+
+		return nil
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		_, err := buf.WriteString(cfg.ECSVersion)
+		return err
+	}
+
+	fieldMap[ECSVersionFieldName] = emitFNotReturn
+
+	return nil
+}
+
+// withECSVersionField returns the fields for the templates generated from them when the ECS version is configured: the
+// ecs.version field is appended when not defined, and replaced by a plain keyword one otherwise, since it's overridden
+func withECSVersionField(cfg Config, fields Fields) Fields {
+	if len(cfg.ECSVersion) == 0 {
+		return fields
+	}
+
+	ecsVersionField := Field{Name: ECSVersionFieldName, Type: FieldTypeKeyword}
+	withECSVersion := make(Fields, 0, len(fields)+1)
+	var defined bool
+	for _, field := range fields {
+		if field.Name == ECSVersionFieldName {
+			field, defined = ecsVersionField, true
+		}
+
+		withECSVersion = append(withECSVersion, field)
+	}
+
+	if !defined {
+		withECSVersion = append(withECSVersion, ecsVersionField)
+	}
+
+	return withECSVersion
+}
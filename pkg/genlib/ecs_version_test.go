@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func Test_ECSVersion(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	cfg := Config{ECSVersion: "8.11.0"}
+
+	generators := map[string]func() (Generator, error){
+		"custom template": func() (Generator, error) {
+			return NewGeneratorWithCustomTemplate([]byte(`{"alpha":"{{.alpha}}","ecs.version":"{{.ecs.version}}"}`), cfg, flds, 0)
+		},
+		"text template": func() (Generator, error) {
+			return NewGeneratorWithTextTemplate([]byte(`{"alpha":"{{generate "alpha"}}","ecs.version":"{{ecsVersion}}"}`), cfg, flds, 0)
+		},
+		"custom template from fields": func() (Generator, error) {
+			return NewGenerator(GeneratorKindCustomTemplate, nil, cfg, flds, 0)
+		},
+		"text template from fields": func() (Generator, error) {
+			return NewGenerator(GeneratorKindTextTemplate, nil, cfg, flds, 0)
+		},
+	}
+
+	for name, newGenerator := range generators {
+		g, err := newGenerator()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		state := NewGenState()
+
+		var buf bytes.Buffer
+		for i := 0; i < 100; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			if ecsVersion := unmarshalJSONT[any](t, buf.Bytes())[ECSVersionFieldName]; ecsVersion != cfg.ECSVersion {
+				t.Fatalf("expected ecs.version %s with %s, got %v in %s", cfg.ECSVersion, name, ecsVersion, buf.String())
+			}
+		}
+	}
+}
+
+func Test_ECSVersionOverridesField(t *testing.T) {
+	flds := Fields{
+		{Name: "ecs.version", Type: FieldTypeKeyword, Value: "1.0.0"},
+	}
+
+	g, err := NewGenerator(GeneratorKindCustomTemplate, nil, Config{ECSVersion: "8.11.0"}, flds, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := g.Emit(NewGenState(), &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := unmarshalJSONT[string](t, buf.Bytes()); len(m) != 1 || m[ECSVersionFieldName] != "8.11.0" {
+		t.Fatalf("expected configured ecs.version only, got %s", buf.String())
+	}
+}
+
+func Test_ECSVersionNotValid(t *testing.T) {
+	for _, ecsVersion := range []string{"8", "8.11", "v8.11.0", "08.11.0", "8.11.0-", "latest"} {
+		cfg := Config{ECSVersion: ecsVersion}
+
+		if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.ecs.version}}`), cfg, Fields{}, 0); !errors.Is(err, notValidECSVersion) {
+			t.Fatalf("expected error on not valid ECS version %q with custom template, got %v", ecsVersion, err)
+		}
+
+		if _, err := NewGeneratorWithTextTemplate([]byte(`{{ecsVersion}}`), cfg, Fields{}, 0); !errors.Is(err, notValidECSVersion) {
+			t.Fatalf("expected error on not valid ECS version %q with text template, got %v", ecsVersion, err)
+		}
+	}
+
+	for _, ecsVersion := range []string{"8.11.0", "1.12.2", "9.0.0-rc.1", "8.11.0+build.1"} {
+		if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.ecs.version}}`), Config{ECSVersion: ecsVersion}, Fields{}, 0); err != nil {
+			t.Fatalf("expected valid ECS version %q, got %v", ecsVersion, err)
+		}
+	}
+}
+
+func Test_ECSVersionNotConfiguredWithTextTemplate(t *testing.T) {
+	template := []byte(`{{ecsVersion}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); !errors.Is(err, notConfiguredECSVersion) {
+		t.Fatalf("expected error on ecsVersion without configured ECS version, got %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultBulkBatchSize is the number of events of each _bulk request when not set
+const defaultBulkBatchSize = 500
+
+// defaultBulkRetryBackoff and defaultBulkMaxRetryBackoff are the first and the maximum wait before retrying throttled events when not set
+const (
+	defaultBulkRetryBackoff    = 100 * time.Millisecond
+	defaultBulkMaxRetryBackoff = 10 * time.Second
+)
+
+var notValidBulkOptions = errors.New("not valid elasticsearch bulk options")
+var failedBulkRequest = errors.New("failed elasticsearch bulk request")
+var failedBulkItems = errors.New("failed elasticsearch bulk items")
+
+// ElasticsearchBulkOptions are the options of an ElasticsearchBulkWriter
+type ElasticsearchBulkOptions struct {
+	// URL of the Elasticsearch cluster, the requests being sent to its _bulk endpoint
+	URL string
+	// Index the events are created in
+	Index string
+	// APIKey is the encoded API key authenticating the requests, no authentication is sent when not set
+	APIKey string
+	// BatchSize is the number of events of each _bulk request, defaults to 500 when not set
+	BatchSize int
+	// Client sending the requests, defaults to http.DefaultClient when not set
+	Client *http.Client
+	// MaxRetries is the number of times the events throttled with a 429 status are sent again, none when not set:
+	// only the throttled items of a _bulk response are retried, or the whole batch when the request itself is throttled
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubling at each one up to MaxRetryBackoff, each wait being randomly
+	// shortened by up to a half; they default to 100ms and 10s when not set
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// ElasticsearchBulkWriter writes JSON events to an Elasticsearch cluster, batching them in _bulk requests.
+// Each batch is sent as soon as it is full, waiting for its response before accepting the next event,
+// so that the generation never outpaces the cluster.
+type ElasticsearchBulkWriter struct {
+	ctx        context.Context
+	client     *http.Client
+	bulkURL    string
+	apiKey     string
+	actionLine []byte
+	batchSize  int
+
+	maxRetries      int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+	rand            *rand.Rand
+	// sleep waits before a retry, replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+
+	batch bytes.Buffer
+	// itemEnds are the offsets in batch where the action and event lines of each item end
+	itemEnds []int
+	events   int
+}
+
+// bulkResponse is the part of the _bulk response needed for surfacing the failed items
+type bulkResponse struct {
+	Errors bool                                 `json:"errors"`
+	Items  []map[string]bulkResponseItemOutcome `json:"items"`
+}
+
+type bulkResponseItemOutcome struct {
+	Status int `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error,omitempty"`
+}
+
+// NewElasticsearchBulkWriter returns an ElasticsearchBulkWriter sending its requests with ctx
+func NewElasticsearchBulkWriter(ctx context.Context, options ElasticsearchBulkOptions) (*ElasticsearchBulkWriter, error) {
+	if options.URL == "" {
+		return nil, fmt.Errorf("%w: missing url", notValidBulkOptions)
+	}
+
+	if options.Index == "" {
+		return nil, fmt.Errorf("%w: missing index", notValidBulkOptions)
+	}
+
+	if options.BatchSize < 0 {
+		return nil, fmt.Errorf("%w: negative batch size %d", notValidBulkOptions, options.BatchSize)
+	}
+
+	batchSize := options.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultBulkBatchSize
+	}
+
+	if options.MaxRetries < 0 || options.RetryBackoff < 0 || options.MaxRetryBackoff < 0 {
+		return nil, fmt.Errorf("%w: negative retries or backoff", notValidBulkOptions)
+	}
+
+	retryBackoff := options.RetryBackoff
+	if retryBackoff == 0 {
+		retryBackoff = defaultBulkRetryBackoff
+	}
+
+	maxRetryBackoff := options.MaxRetryBackoff
+	if maxRetryBackoff == 0 {
+		maxRetryBackoff = defaultBulkMaxRetryBackoff
+	}
+
+	client := options.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &ElasticsearchBulkWriter{
+		ctx:        ctx,
+		client:     client,
+		bulkURL:    strings.TrimSuffix(options.URL, "/") + "/_bulk",
+		apiKey:     options.APIKey,
+		actionLine: bulkActionLine(options.Index),
+		batchSize:  batchSize,
+
+		maxRetries:      options.MaxRetries,
+		retryBackoff:    retryBackoff,
+		maxRetryBackoff: maxRetryBackoff,
+		rand:            rand.New(rand.NewSource(time.Now().UnixNano())),
+		sleep:           sleepContext,
+	}, nil
+}
+
+// sleepContext waits for d, returning earlier with the error of ctx when done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// WriteEvent adds the JSON event to the current batch, compacted to a single line, sending the batch when full
+func (ew *ElasticsearchBulkWriter) WriteEvent(event []byte) error {
+	batchLen := ew.batch.Len()
+	ew.batch.Write(ew.actionLine)
+	if err := json.Compact(&ew.batch, event); err != nil {
+		ew.batch.Truncate(batchLen)
+		return fmt.Errorf("%w: %v", notValidJSONEvent, err)
+	}
+
+	ew.batch.WriteByte('\n')
+	ew.itemEnds = append(ew.itemEnds, ew.batch.Len())
+	ew.events++
+
+	if ew.events < ew.batchSize {
+		return nil
+	}
+
+	return ew.flush()
+}
+
+// Close sends the last batch, if any
+func (ew *ElasticsearchBulkWriter) Close() error {
+	if ew.events == 0 {
+		return nil
+	}
+
+	return ew.flush()
+}
+
+// flush sends the batch, sending again the throttled events with an exponential backoff up to the maximum number of retries
+func (ew *ElasticsearchBulkWriter) flush() error {
+	defer func() {
+		ew.batch.Reset()
+		ew.itemEnds = ew.itemEnds[:0]
+		ew.events = 0
+	}()
+
+	batch := ew.batch.Bytes()
+	items := make([][]byte, len(ew.itemEnds))
+	var start int
+	for i, end := range ew.itemEnds {
+		items[i] = batch[start:end]
+		start = end
+	}
+
+	payload := batch
+	backoff := ew.retryBackoff
+	for retry := 0; ; retry++ {
+		throttled, err := ew.send(payload, items)
+		if len(throttled) == 0 || retry >= ew.maxRetries {
+			return err
+		}
+
+		if err := ew.sleep(ew.ctx, ew.jitter(backoff)); err != nil {
+			return err
+		}
+
+		backoff *= 2
+		if backoff > ew.maxRetryBackoff {
+			backoff = ew.maxRetryBackoff
+		}
+
+		items = throttled
+		payload = bytes.Join(items, nil)
+	}
+}
+
+// jitter randomly shortens the backoff by up to a half, so that many writers throttled together do not retry together
+func (ew *ElasticsearchBulkWriter) jitter(backoff time.Duration) time.Duration {
+	half := int64(backoff / 2)
+	if half == 0 {
+		return backoff
+	}
+
+	return backoff - time.Duration(ew.rand.Int63n(half+1))
+}
+
+// send sends the _bulk request with payload, made of items, returning the items throttled with a 429 status together with the error reporting them.
+// No item is returned when the request or any item failed otherwise, since sending them again would fail the same.
+func (ew *ElasticsearchBulkWriter) send(payload []byte, items [][]byte) ([][]byte, error) {
+	req, err := http.NewRequestWithContext(ew.ctx, http.MethodPost, ew.bulkURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if ew.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+ew.apiKey)
+	}
+
+	resp, err := ew.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return items, fmt.Errorf("%w: status %d: %s", failedBulkRequest, resp.StatusCode, body)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%w: status %d: %s", failedBulkRequest, resp.StatusCode, body)
+	}
+
+	var bulkResp bulkResponse
+	if err := json.Unmarshal(body, &bulkResp); err != nil {
+		return nil, fmt.Errorf("%w: %v", failedBulkRequest, err)
+	}
+
+	if !bulkResp.Errors {
+		return nil, nil
+	}
+
+	// the items of the response are in the same order of the ones of the request
+	var throttled [][]byte
+	for i, item := range bulkResp.Items {
+		for _, outcome := range item {
+			if outcome.Error == nil {
+				continue
+			}
+
+			if outcome.Status != http.StatusTooManyRequests || i >= len(items) {
+				return nil, bulkItemsError(bulkResp)
+			}
+
+			throttled = append(throttled, items[i])
+		}
+	}
+
+	return throttled, bulkItemsError(bulkResp)
+}
+
+// bulkItemsError reports the number of failed items, together with the first failure
+func bulkItemsError(bulkResp bulkResponse) error {
+	var failed int
+	var first string
+	for _, item := range bulkResp.Items {
+		for _, outcome := range item {
+			if outcome.Error == nil {
+				continue
+			}
+
+			if failed == 0 {
+				first = fmt.Sprintf("status %d: %s: %s", outcome.Status, outcome.Error.Type, outcome.Error.Reason)
+			}
+
+			failed++
+		}
+	}
+
+	return fmt.Errorf("%w: %d of %d items failed, the first one with %s", failedBulkItems, failed, len(bulkResp.Items), first)
+}
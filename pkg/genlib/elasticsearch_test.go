@@ -0,0 +1,262 @@
+package genlib
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_ElasticsearchBulkWriter(t *testing.T) {
+	const totEvents = 250
+	const batchSize = 100
+
+	var mu sync.Mutex
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/_bulk" {
+			t.Errorf("expected POST /_bulk, got %s %s", r.Method, r.URL.Path)
+		}
+
+		if contentType := r.Header.Get("Content-Type"); contentType != "application/x-ndjson" {
+			t.Errorf("expected ndjson content type, got %s", contentType)
+		}
+
+		if authorization := r.Header.Get("Authorization"); authorization != "ApiKey secret" {
+			t.Errorf("expected api key authorization, got %s", authorization)
+		}
+
+		scanner := bufio.NewScanner(r.Body)
+		var lines int
+		for scanner.Scan() {
+			var line map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Errorf("expected JSON line, got %s", scanner.Text())
+			}
+
+			if lines%2 == 0 {
+				if index := line["create"].(map[string]any)["_index"]; index != "logs-test-default" {
+					t.Errorf("expected create action line, got %s", scanner.Text())
+				}
+			} else if _, ok := line["alpha"]; !ok {
+				t.Errorf("expected event line, got %s", scanner.Text())
+			}
+
+			lines++
+		}
+
+		mu.Lock()
+		batches = append(batches, lines/2)
+		mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"took":1,"errors":false,"items":[]}`))
+	}))
+	defer server.Close()
+
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	// a multi-line event is compacted to a single line
+	template := []byte("{\n  \"alpha\": \"{{.alpha}}\",\n  \"beta\": {{.beta}}\n}\n")
+	t.Logf("with template: %s", string(template))
+
+	g, err := NewGeneratorWithCustomTemplateN(template, Config{}, flds, totEvents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ew, err := NewElasticsearchBulkWriter(context.Background(), ElasticsearchBulkOptions{
+		URL:       server.URL + "/",
+		Index:     "logs-test-default",
+		APIKey:    "secret",
+		BatchSize: batchSize,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ew.WriteEvent(buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ew.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batches) != 3 || batches[0] != 100 || batches[1] != 100 || batches[2] != 50 {
+		t.Fatalf("expected bulk requests of 100, 100 and 50 events, got %v", batches)
+	}
+}
+
+func Test_ElasticsearchBulkWriterItemsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"took":1,"errors":true,"items":[{"create":{"status":201}},{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [beta]"}}}]}`))
+	}))
+	defer server.Close()
+
+	ew, err := NewElasticsearchBulkWriter(context.Background(), ElasticsearchBulkOptions{URL: server.URL, Index: "logs-test-default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ew.WriteEvent([]byte(`{"beta":1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ew.WriteEvent([]byte(`{"beta":"b"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ew.Close()
+	if !errors.Is(err, failedBulkItems) {
+		t.Fatalf("expected items error, got %v", err)
+	}
+
+	t.Log(err)
+}
+
+func Test_ElasticsearchBulkWriterRequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	ew, err := NewElasticsearchBulkWriter(context.Background(), ElasticsearchBulkOptions{URL: server.URL, Index: "logs-test-default", BatchSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ew.WriteEvent([]byte(`not json`)); !errors.Is(err, notValidJSONEvent) {
+		t.Fatalf("expected event error, got %v", err)
+	}
+
+	if err := ew.WriteEvent([]byte(`{"beta":1}`)); !errors.Is(err, failedBulkRequest) {
+		t.Fatalf("expected request error, got %v", err)
+	}
+
+	if _, err := NewElasticsearchBulkWriter(context.Background(), ElasticsearchBulkOptions{URL: server.URL}); !errors.Is(err, notValidBulkOptions) {
+		t.Fatalf("expected options error, got %v", err)
+	}
+}
+
+func Test_ElasticsearchBulkWriterRetries(t *testing.T) {
+	var mu sync.Mutex
+	var requests [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []string
+		scanner := bufio.NewScanner(r.Body)
+		for i := 0; scanner.Scan(); i++ {
+			if i%2 == 1 {
+				events = append(events, scanner.Text())
+			}
+		}
+
+		mu.Lock()
+		requests = append(requests, events)
+		n := len(requests)
+		mu.Unlock()
+
+		switch n {
+		case 1:
+			// the whole request is throttled
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			// only the second item is throttled
+			_, _ = w.Write([]byte(`{"took":1,"errors":true,"items":[{"create":{"status":201}},{"create":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"rejected execution"}}},{"create":{"status":201}}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"took":1,"errors":false,"items":[{"create":{"status":201}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	const retryBackoff = 100 * time.Millisecond
+	ew, err := NewElasticsearchBulkWriter(context.Background(), ElasticsearchBulkOptions{URL: server.URL, Index: "logs-test-default", MaxRetries: 3, RetryBackoff: retryBackoff})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var waits []time.Duration
+	ew.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	for _, event := range []string{`{"alpha":1}`, `{"alpha":2}`, `{"alpha":3}`} {
+		if err := ew.WriteEvent([]byte(event)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ew.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(requests) != 3 || len(requests[0]) != 3 || len(requests[1]) != 3 {
+		t.Fatalf("expected the whole batch sent twice before the retry of the throttled item, got %v", requests)
+	}
+
+	if len(requests[2]) != 1 || requests[2][0] != `{"alpha":2}` {
+		t.Fatalf("expected only the throttled item retried, got %v", requests[2])
+	}
+
+	if len(waits) != 2 {
+		t.Fatalf("expected 2 backoffs, got %v", waits)
+	}
+
+	if waits[0] < retryBackoff/2 || waits[0] > retryBackoff || waits[1] < retryBackoff || waits[1] > 2*retryBackoff {
+		t.Fatalf("expected exponential backoffs with jitter, got %v", waits)
+	}
+}
+
+func Test_ElasticsearchBulkWriterRetriesExhausted(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ew, err := NewElasticsearchBulkWriter(context.Background(), ElasticsearchBulkOptions{URL: server.URL, Index: "logs-test-default", BatchSize: 1, MaxRetries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ew.sleep = func(context.Context, time.Duration) error {
+		return nil
+	}
+
+	if err := ew.WriteEvent([]byte(`{"alpha":1}`)); !errors.Is(err, failedBulkRequest) {
+		t.Fatalf("expected request error, got %v", err)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("expected the request and 2 retries, got %d requests", n)
+	}
+}
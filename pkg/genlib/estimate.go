@@ -0,0 +1,41 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+// EstimateTotEvents returns the number of events a custom template generator emits up to totSize in bytes, together with the average size
+// of its events, as estimated by NewGeneratorWithCustomTemplate, without generating the corpus
+func EstimateTotEvents(template []byte, cfg Config, fields Fields, totSize uint64) (events uint64, avgEventBytes uint64, err error) {
+	template, err = ExpandTemplateIncludes(template, cfg.TemplateDir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	gen, err := newGeneratorWithCustomTemplate(template, cfg, fields)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer gen.Close()
+
+	return calculateTotEventsWithCustomTemplate(totSize, gen.emitters, gen.trailingTemplate, cfg.Seed, estimationSamples(cfg))
+}
+
+// EstimateTotEventsWithTextTemplate returns the number of events a text template generator emits up to totSize in bytes, together with the average size
+// of its events, as estimated by NewGeneratorWithTextTemplate, without generating the corpus
+func EstimateTotEventsWithTextTemplate(tpl []byte, cfg Config, fields Fields, totSize uint64) (events uint64, avgEventBytes uint64, err error) {
+	tpl, err = ExpandTemplateIncludes(tpl, cfg.TemplateDir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	gen, err := newGeneratorWithTextTemplate(tpl, cfg, fields)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer gen.Close()
+
+	return calculateTotEventsWithTextTemplate(totSize, gen.fieldMap, tpl, cfg.Seed, estimationSamples(cfg))
+}
@@ -0,0 +1,44 @@
+package genlib
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_EstimateTotEvents(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	const totSize = 1024 * 1024
+
+	customTemplate := []byte(`{"alpha":"{{.alpha}}","beta":{{.beta}}}`)
+	textTemplate := []byte(`{"alpha":"{{generate "alpha"}}","beta":{{generate "beta"}}}`)
+
+	for name, estimate := range map[string]func() (uint64, uint64, error){
+		"custom": func() (uint64, uint64, error) { return EstimateTotEvents(customTemplate, Config{}, flds, totSize) },
+		"text": func() (uint64, uint64, error) {
+			return EstimateTotEventsWithTextTemplate(textTemplate, Config{}, flds, totSize)
+		},
+	} {
+		events, avgEventBytes, err := estimate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if events == 0 || avgEventBytes == 0 {
+			t.Fatalf("%s: expected events and average event size, got %d and %d", name, events, avgEventBytes)
+		}
+
+		// the average size is rounded to the byte
+		if projected := float64(events * avgEventBytes); math.Abs(projected-totSize)/totSize > 0.05 {
+			t.Errorf("%s: expected %d events of %d bytes close to %d bytes, got %.0f", name, events, avgEventBytes, totSize, projected)
+		}
+	}
+
+	events, avgEventBytes, err := EstimateTotEvents(customTemplate, Config{}, flds, 0)
+	if err != nil || events != 0 || avgEventBytes != 0 {
+		t.Fatalf("expected no events for a zero size, got %d, %d and %v", events, avgEventBytes, err)
+	}
+}
@@ -1,11 +1,15 @@
 package fields
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
 )
 
+var ErrConflictingField = errors.New("field defined with different types in the fields definitions")
+
 type Fields []Field
 
 func (f Fields) Len() int           { return len(f) }
@@ -18,11 +22,13 @@ type Field struct {
 	ObjectType string
 	Example    string
 	Value      string
+	// Fields of a nested field, with names relative to it
+	Fields Fields
 }
 
 func (fields Fields) merge(fieldsToMerge ...Field) Fields {
-	merged := false
 	for _, field := range fieldsToMerge {
+		merged := false
 		for _, currentField := range fields {
 			if currentField.Name != field.Name {
 				continue
@@ -48,6 +54,33 @@ func (fields Fields) merge(fieldsToMerge ...Field) Fields {
 	return fields
 }
 
+// mergeDefinitions merges the fields of the definitions, in order, into a single set of fields. A field defined again with
+// the same type keeps its first definition, while with a different type it's a conflict, unless override is set, in which case
+// the last definition wins.
+func mergeDefinitions(definitions []Fields, override bool) (Fields, error) {
+	var merged Fields
+	positions := make(map[string]int)
+	for _, definition := range definitions {
+		for _, field := range definition {
+			i, ok := positions[field.Name]
+			if !ok {
+				positions[field.Name] = len(merged)
+				merged = append(merged, field)
+				continue
+			}
+
+			switch {
+			case override:
+				merged[i] = field
+			case merged[i].Type != field.Type:
+				return nil, fmt.Errorf("%w: %q is both %q and %q", ErrConflictingField, field.Name, merged[i].Type, field.Type)
+			}
+		}
+	}
+
+	return normaliseFields(merged)
+}
+
 func normaliseFields(fields Fields) (Fields, error) {
 	sort.Sort(fields)
 	normalisedFields := make(Fields, 0, len(fields))
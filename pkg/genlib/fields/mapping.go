@@ -0,0 +1,178 @@
+package fields
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var ErrNotValidMapping = errors.New("not valid elasticsearch mapping: expected the properties either at the top level, under mappings, or under the mappings of an index")
+
+// mappingProperty is a property of an Elasticsearch mapping, with the fields of its multi-fields being ignored,
+// since they index the same value as the property
+type mappingProperty struct {
+	Type       string                     `json:"type"`
+	Value      any                        `json:"value"`
+	Properties map[string]mappingProperty `json:"properties"`
+}
+
+type mapping struct {
+	Properties map[string]mappingProperty `json:"properties"`
+	Mappings   *mapping                   `json:"mappings"`
+}
+
+// mappingTypes maps the Elasticsearch types to the ones generated, any type missing being kept as it is
+var mappingTypes = map[string]string{
+	"byte":       "integer",
+	"short":      "integer",
+	"date_nanos": "date",
+}
+
+// LoadFieldsDefinition loads the fields definition at path, either an Elasticsearch mapping when path has a .json extension, or a fields yaml otherwise
+func LoadFieldsDefinition(ctx context.Context, path string) (Fields, error) {
+	if filepath.Ext(path) == ".json" {
+		return LoadFieldsWithMapping(ctx, path)
+	}
+
+	return LoadFieldsWithTemplate(ctx, path)
+}
+
+// LoadFieldsDefinitions loads the fields definitions at paths, as LoadFieldsDefinition does, and merges them into a single set of fields.
+// A field defined in more than one definition with different types is an error, unless override is set: then the definitions of the
+// later paths override the ones of the earlier paths.
+func LoadFieldsDefinitions(ctx context.Context, paths []string, override bool) (Fields, error) {
+	definitions := make([]Fields, 0, len(paths))
+	for _, path := range paths {
+		definition, err := LoadFieldsDefinition(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	return mergeDefinitions(definitions, override)
+}
+
+// LoadFieldsWithMapping loads the fields of the Elasticsearch mapping at mappingPath
+func LoadFieldsWithMapping(ctx context.Context, mappingPath string) (Fields, error) {
+	mappingContent, err := os.ReadFile(mappingPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadFieldsWithMappingFromString(ctx, string(mappingContent))
+}
+
+// LoadFieldsWithMappingFromString loads the fields of an Elasticsearch mapping: either the properties, the mappings wrapping them,
+// or the response of the get mapping API, whose indices mappings are merged.
+// Object properties are flattened into dotted field names, while the sub-fields of nested properties are kept with names relative to them.
+func LoadFieldsWithMappingFromString(ctx context.Context, mappingContent string) (Fields, error) {
+	if len(mappingContent) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var m mapping
+	if err := json.Unmarshal([]byte(mappingContent), &m); err != nil {
+		return nil, err
+	}
+
+	var properties []map[string]mappingProperty
+	switch {
+	case m.Properties != nil:
+		properties = append(properties, m.Properties)
+	case m.Mappings != nil && m.Mappings.Properties != nil:
+		properties = append(properties, m.Mappings.Properties)
+	default:
+		// the get mapping API response has the mappings of each index under its name
+		var indices map[string]mapping
+		if err := json.Unmarshal([]byte(mappingContent), &indices); err != nil {
+			return nil, err
+		}
+
+		for _, index := range indices {
+			if index.Mappings == nil || index.Mappings.Properties == nil {
+				return nil, ErrNotValidMapping
+			}
+
+			properties = append(properties, index.Mappings.Properties)
+		}
+	}
+
+	if len(properties) == 0 {
+		return nil, ErrNotValidMapping
+	}
+
+	// a field in the mappings of more than one index is kept once
+	var fields Fields
+	seen := make(map[string]struct{})
+	for _, indexProperties := range properties {
+		for _, field := range collectMappingFields(indexProperties, "") {
+			if _, ok := seen[field.Name]; ok {
+				continue
+			}
+
+			seen[field.Name] = struct{}{}
+			fields = append(fields, field)
+		}
+	}
+
+	return normaliseFields(fields)
+}
+
+func collectMappingFields(properties map[string]mappingProperty, namePrefix string) Fields {
+	// the properties are sorted so that the first index with a field defines it
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fields := make(Fields, 0, len(properties))
+	for _, name := range names {
+		property := properties[name]
+
+		fieldName := name
+		if len(namePrefix) > 0 {
+			fieldName = namePrefix + "." + name
+		}
+
+		switch {
+		case property.Type == "nested" && len(property.Properties) > 0:
+			// Sub-fields of a nested field are generated as a whole in each of its sub-documents
+			fields = append(fields, Field{Name: fieldName, Type: "nested", Fields: collectMappingFields(property.Properties, "")})
+		case len(property.Properties) > 0:
+			fields = append(fields, collectMappingFields(property.Properties, fieldName)...)
+		case property.Type == "alias":
+			// an alias has no value of its own
+		default:
+			fields = append(fields, mappingField(fieldName, property))
+		}
+	}
+
+	return fields
+}
+
+func mappingField(name string, property mappingProperty) Field {
+	field := Field{Name: name, Type: property.Type}
+
+	// an object without properties has dynamic keys, as an object field of fields.yaml without object_type
+	if field.Type == "" {
+		field.Type = "object"
+	}
+
+	if generatedType, ok := mappingTypes[field.Type]; ok {
+		field.Type = generatedType
+	}
+
+	// the value of a constant_keyword is the only one it accepts
+	if value, ok := property.Value.(string); ok {
+		field.Value = value
+	}
+
+	return field
+}
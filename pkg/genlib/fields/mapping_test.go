@@ -0,0 +1,211 @@
+package fields
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testMapping = `{
+  "mappings": {
+    "properties": {
+      "@timestamp": {"type": "date"},
+      "message": {"type": "match_only_text"},
+      "host": {
+        "properties": {
+          "name": {"type": "keyword", "fields": {"text": {"type": "text"}}},
+          "ip": {"type": "ip"},
+          "uptime": {"type": "long"}
+        }
+      },
+      "data_stream": {
+        "properties": {
+          "dataset": {"type": "constant_keyword", "value": "nginx.access"}
+        }
+      },
+      "event": {
+        "properties": {
+          "created": {"type": "date_nanos"},
+          "severity": {"type": "byte"},
+          "original": {"type": "wildcard"}
+        }
+      },
+      "labels": {"type": "object"},
+      "related": {
+        "type": "nested",
+        "properties": {
+          "user": {"type": "keyword"},
+          "score": {"properties": {"value": {"type": "scaled_float", "scaling_factor": 100}}}
+        }
+      },
+      "hostname": {"type": "alias", "path": "host.name"}
+    }
+  }
+}`
+
+func assertFields(t *testing.T, expected, got Fields) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d fields, got %d: %+v", len(expected), len(got), got)
+	}
+
+	for i := range expected {
+		if got[i].Name != expected[i].Name || got[i].Type != expected[i].Type || got[i].Value != expected[i].Value {
+			t.Errorf("expected field %+v, got %+v", expected[i], got[i])
+		}
+
+		assertFields(t, expected[i].Fields, got[i].Fields)
+	}
+}
+
+func TestLoadFieldsWithMappingFromString(t *testing.T) {
+	fields, err := LoadFieldsWithMappingFromString(context.Background(), testMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Fields{
+		{Name: "@timestamp", Type: "date"},
+		{Name: "data_stream.dataset", Type: "constant_keyword", Value: "nginx.access"},
+		{Name: "event.created", Type: "date"},
+		{Name: "event.original", Type: "wildcard"},
+		{Name: "event.severity", Type: "integer"},
+		{Name: "host.ip", Type: "ip"},
+		{Name: "host.name", Type: "keyword"},
+		{Name: "host.uptime", Type: "long"},
+		{Name: "labels", Type: "object"},
+		{Name: "message", Type: "match_only_text"},
+		{Name: "related", Type: "nested", Fields: Fields{
+			{Name: "score.value", Type: "scaled_float"},
+			{Name: "user", Type: "keyword"},
+		}},
+	}
+
+	assertFields(t, expected, fields)
+}
+
+func TestLoadFieldsWithMappingFromStringGeneratedTypes(t *testing.T) {
+	// the types with a generator of their own are kept as they are
+	mapping := `{"properties": {"alpha": {"type": "wildcard"}, "beta": {"type": "version"}}}`
+
+	fields, err := LoadFieldsWithMappingFromString(context.Background(), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Fields{
+		{Name: "alpha", Type: "wildcard"},
+		{Name: "beta", Type: "version"},
+	}
+
+	assertFields(t, expected, fields)
+}
+
+func TestLoadFieldsWithMappingFromStringGetMappingResponse(t *testing.T) {
+	mapping := `{
+  "logs-a": {"mappings": {"properties": {"alpha": {"type": "keyword"}, "beta": {"type": "long"}}}},
+  "logs-b": {"mappings": {"properties": {"alpha": {"type": "keyword"}, "gamma": {"type": "short"}}}}
+}`
+
+	fields, err := LoadFieldsWithMappingFromString(context.Background(), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Fields{
+		{Name: "alpha", Type: "keyword"},
+		{Name: "beta", Type: "long"},
+		{Name: "gamma", Type: "integer"},
+	}
+
+	assertFields(t, expected, fields)
+
+	if _, err := LoadFieldsWithMappingFromString(context.Background(), `{"logs-a": {"settings": {}}}`); !errors.Is(err, ErrNotValidMapping) {
+		t.Fatalf("expected mapping error, got %v", err)
+	}
+}
+
+func TestLoadFieldsDefinition(t *testing.T) {
+	mappingPath := filepath.Join(t.TempDir(), "mapping.json")
+	if err := os.WriteFile(mappingPath, []byte(`{"properties": {"alpha": {"type": "keyword"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fieldsYamlPath := filepath.Join(t.TempDir(), "fields.yml")
+	if err := os.WriteFile(fieldsYamlPath, []byte("- name: alpha\n  type: keyword\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{mappingPath, fieldsYamlPath} {
+		fields, err := LoadFieldsDefinition(context.Background(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertFields(t, Fields{{Name: "alpha", Type: "keyword"}}, fields)
+	}
+}
+
+func TestLoadFieldsDefinitions(t *testing.T) {
+	dir := t.TempDir()
+
+	// host.name is defined twice, the group sub-fields following it must not be lost
+	ecsPath := filepath.Join(dir, "ecs.yml")
+	if err := os.WriteFile(ecsPath, []byte(`- name: host.name
+  type: keyword
+- name: host
+  type: group
+  fields:
+    - name: name
+      type: keyword
+    - name: ip
+      type: ip
+- name: event.duration
+  type: long
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	integrationPath := filepath.Join(dir, "fields.yml")
+	if err := os.WriteFile(integrationPath, []byte("- name: host.name\n  type: keyword\n- name: event.duration\n  type: keyword\n- name: nginx.status\n  type: long\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFieldsDefinitions(context.Background(), []string{ecsPath, integrationPath}, false)
+	if !errors.Is(err, ErrConflictingField) {
+		t.Fatalf("expected error %v, got %v", ErrConflictingField, err)
+	}
+
+	fields, err := LoadFieldsDefinitions(context.Background(), []string{ecsPath, integrationPath}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFields(t, Fields{
+		{Name: "event.duration", Type: "keyword"},
+		{Name: "host.ip", Type: "ip"},
+		{Name: "host.name", Type: "keyword"},
+		{Name: "nginx.status", Type: "long"},
+	}, fields)
+
+	// fields defined again with the same type are no conflict, keeping the first definition
+	samePath := filepath.Join(dir, "same.yml")
+	if err := os.WriteFile(samePath, []byte("- name: host.name\n  type: keyword\n  example: web-01\n- name: nginx.status\n  type: long\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err = LoadFieldsDefinitions(context.Background(), []string{ecsPath, samePath}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFields(t, Fields{
+		{Name: "event.duration", Type: "long"},
+		{Name: "host.ip", Type: "ip"},
+		{Name: "host.name", Type: "keyword"},
+		{Name: "nginx.status", Type: "long"},
+	}, fields)
+}
@@ -50,7 +50,11 @@ func collectFields(fieldsFromYaml yamlFields, namePrefix string) Fields {
 			field.Name = namePrefix + "." + fieldFromYaml.Name
 		}
 
-		if len(fieldFromYaml.Fields) == 0 {
+		if field.Type == "nested" && len(fieldFromYaml.Fields) > 0 {
+			// Sub-fields of a nested field are generated as a whole in each of its sub-documents
+			field.Fields = collectFields(fieldFromYaml.Fields, "")
+			fields = fields.merge(field)
+		} else if len(fieldFromYaml.Fields) == 0 {
 			// There are examples of fields of type "group" with no subfields; ignore these.
 			if field.Type != "group" {
 				fields = fields.merge(field)
@@ -0,0 +1,46 @@
+package fields
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLoadFieldsWithTemplateFromStringGroups(t *testing.T) {
+	fieldsYaml := `- name: host
+  type: group
+  fields:
+    - name: name
+      type: keyword
+    - name: os
+      type: group
+      fields:
+        - name: name
+          type: keyword
+          example: Ubuntu
+        - name: version
+          type: keyword
+    - name: uptime
+      type: long
+- name: empty
+  type: group
+- name: message
+  type: text
+`
+
+	fields, err := LoadFieldsWithTemplateFromString(context.Background(), fieldsYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFields(t, Fields{
+		{Name: "host.name", Type: "keyword"},
+		{Name: "host.os.name", Type: "keyword"},
+		{Name: "host.os.version", Type: "keyword"},
+		{Name: "host.uptime", Type: "long"},
+		{Name: "message", Type: "text"},
+	}, fields)
+
+	if fields[1].Example != "Ubuntu" {
+		t.Errorf("expected example of host.os.name kept, got %q", fields[1].Example)
+	}
+}
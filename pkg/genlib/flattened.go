@@ -0,0 +1,121 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math/rand"
+	"strconv"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// flattenedEntry is a key of a flattened object, with either a scalar value or the entries of a sub-object
+type flattenedEntry struct {
+	key   string
+	value any
+}
+
+// isFlattenedObject tells whether the field is a flattened one with random keys and values of any scalar type,
+// rather than with the keys and the object type of an object field
+func isFlattenedObject(fieldCfg ConfigField, field Field) bool {
+	return field.Type == FieldTypeFlattened && len(field.Fields) == 0 && len(fieldCfg.ObjectKeys) == 0 && len(field.ObjectType) == 0
+}
+
+// makeFlattenedFunc returns a function generating the entries of a flattened object, with the keys of the configured dynamic_keys
+// and random string, number and boolean values: each value is a sub-object with scalar values according to the object probability
+func makeFlattenedFunc(fieldCfg ConfigField) (func(r *rand.Rand) []flattenedEntry, error) {
+	var dynamicKeys config.DynamicKeys
+	if fieldCfg.DynamicKeys != nil {
+		dynamicKeys = *fieldCfg.DynamicKeys
+	}
+
+	keysFunc, err := makeDynamicKeysFunc(dynamicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	scalarEntries := func(r *rand.Rand, keys []string) []flattenedEntry {
+		entries := make([]flattenedEntry, 0, len(keys))
+		for _, key := range keys {
+			entries = append(entries, flattenedEntry{key: key, value: randomScalar(r)})
+		}
+
+		return entries
+	}
+
+	return func(r *rand.Rand) []flattenedEntry {
+		keys := keysFunc(r)
+		if fieldCfg.ObjectProbability <= 0 {
+			return scalarEntries(r, keys)
+		}
+
+		entries := make([]flattenedEntry, 0, len(keys))
+		for _, key := range keys {
+			if r.Float64() < fieldCfg.ObjectProbability {
+				entries = append(entries, flattenedEntry{key: key, value: scalarEntries(r, keysFunc(r))})
+			} else {
+				entries = append(entries, flattenedEntry{key: key, value: randomScalar(r)})
+			}
+		}
+
+		return entries
+	}, nil
+}
+
+// randomScalar returns either a word, an integer or a boolean
+func randomScalar(r *rand.Rand) any {
+	switch r.Intn(3) {
+	case 0:
+		return loremWords[r.Intn(len(loremWords))]
+	case 1:
+		return r.Int63n(10000)
+	default:
+		return r.Intn(2) == 0
+	}
+}
+
+// appendFlattened appends the JSON object of the entries to dst: keys and words need no escaping
+func appendFlattened(dst []byte, entries []flattenedEntry) []byte {
+	dst = append(dst, '{')
+	for i, entry := range entries {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+
+		dst = append(dst, '"')
+		dst = append(dst, entry.key...)
+		dst = append(dst, '"', ':')
+
+		switch value := entry.value.(type) {
+		case []flattenedEntry:
+			dst = appendFlattened(dst, value)
+		case string:
+			dst = append(dst, '"')
+			dst = append(dst, value...)
+			dst = append(dst, '"')
+		case int64:
+			dst = strconv.AppendInt(dst, value, 10)
+		case bool:
+			dst = strconv.AppendBool(dst, value)
+		}
+	}
+
+	return append(dst, '}')
+}
+
+// flattenedToMap returns the object of the entries as a map
+func flattenedToMap(entries []flattenedEntry) map[string]any {
+	object := make(map[string]any, len(entries))
+	for _, entry := range entries {
+		if subEntries, ok := entry.value.([]flattenedEntry); ok {
+			object[entry.key] = flattenedToMap(subEntries)
+			continue
+		}
+
+		object[entry.key] = entry.value
+	}
+
+	return object
+}
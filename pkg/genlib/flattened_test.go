@@ -0,0 +1,133 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+const flattenedYaml = `- name: labels
+  object_probability: 0.3
+  dynamic_keys:
+    keys:
+      min: 2
+      max: 4
+`
+
+// assertFlattened checks that object has between 2 and 4 keys, whose values are scalars or, at the top level only, objects;
+// it returns the number of sub-objects
+func assertFlattened(t *testing.T, object map[string]any, topLevel bool) int {
+	t.Helper()
+
+	if len(object) < 2 || len(object) > 4 {
+		t.Fatalf("expected between 2 and 4 keys, got %d: %v", len(object), object)
+	}
+
+	var subObjects int
+	for key, value := range object {
+		switch value := value.(type) {
+		case string, float64, bool:
+		case map[string]any:
+			if !topLevel {
+				t.Fatalf("expected scalar value of %s in sub-object, got %v", key, value)
+			}
+
+			assertFlattened(t, value, false)
+			subObjects++
+		default:
+			t.Fatalf("expected scalar value or object of %s, got %v", key, value)
+		}
+	}
+
+	return subObjects
+}
+
+func Test_FieldFlattenedWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "labels", Type: FieldTypeFlattened},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(flattenedYaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"labels":{{.labels}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var subObjects int
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		subObjects += assertFlattened(t, unmarshalJSONT[map[string]any](t, buf.Bytes())["labels"], true)
+	}
+
+	if subObjects == 0 {
+		t.Fatal("expected some sub-objects")
+	}
+}
+
+func Test_FieldFlattenedWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "labels", Type: FieldTypeFlattened},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(flattenedYaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"labels":{{generate "labels" | toJson}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		assertFlattened(t, unmarshalJSONT[map[string]any](t, buf.Bytes())["labels"], true)
+	}
+}
+
+func Test_FieldFlattenedWithTemplateFromFields(t *testing.T) {
+	flds := Fields{
+		{Name: "labels", Type: FieldTypeFlattened},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(flattenedYaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewGenerator(GeneratorKindCustomTemplate, nil, cfg, flds, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		assertFlattened(t, unmarshalJSONT[map[string]any](t, buf.Bytes())["labels"], true)
+	}
+}
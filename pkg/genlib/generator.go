@@ -7,9 +7,7 @@ package genlib
 import (
 	"bytes"
 	"fmt"
-	"github.com/Pallinder/go-randomdata"
 	"github.com/lithammer/shortuuid/v3"
-	"math/rand"
 	"strings"
 )
 
@@ -18,17 +16,20 @@ const (
 	customTemplateEngine
 )
 
+// defaultEstimationSamples is the number of sample events used for estimating the total number of events
+const defaultEstimationSamples = 16
+
 func fieldValueWrapByType(field Field) string {
 	if len(field.Value) > 0 {
 		return ""
 	}
 
 	switch field.Type {
-	case FieldTypeDate, FieldTypeIP:
+	case FieldTypeDate, FieldTypeIP, FieldTypeIPv6, FieldTypeMAC:
 		return "\""
 	case FieldTypeDouble, FieldTypeFloat, FieldTypeHalfFloat, FieldTypeScaledFloat:
 		return ""
-	case FieldTypeInteger, FieldTypeLong, FieldTypeUnsignedLong:
+	case FieldTypeInteger, FieldTypeLong, FieldTypeUnsignedLong, FieldTypeHTTPStatus:
 		return ""
 	case FieldTypeConstantKeyword:
 		return "\""
@@ -37,6 +38,11 @@ func fieldValueWrapByType(field Field) string {
 	case FieldTypeBool:
 		return ""
 	case FieldTypeObject, FieldTypeNested, FieldTypeFlattened:
+		// a nested field with sub-fields is an array of sub-documents
+		if len(field.Fields) > 0 {
+			return ""
+		}
+
 		if len(field.ObjectType) > 0 {
 			field.Type = field.ObjectType
 		} else {
@@ -45,11 +51,42 @@ func fieldValueWrapByType(field Field) string {
 		return fieldValueWrapByType(field)
 	case FieldTypeGeoPoint:
 		return "\""
+	case FieldTypeIntegerRange, FieldTypeLongRange, FieldTypeFloatRange, FieldTypeDoubleRange, FieldTypeDateRange, FieldTypeIPRange:
+		return ""
 	default:
 		return "\""
 	}
 }
 
+func isRangeType(fieldType string) bool {
+	switch fieldType {
+	case FieldTypeIntegerRange, FieldTypeLongRange, FieldTypeFloatRange, FieldTypeDoubleRange, FieldTypeDateRange, FieldTypeIPRange:
+		return true
+	default:
+		return false
+	}
+}
+
+// fieldValueWrap is the wrapping of the field value in the template, according to the field type and config
+func fieldValueWrap(fieldCfg ConfigField, field Field) string {
+	// hardcoded values and arrays are emitted as they are
+	if fieldCfg.Value != nil || fieldCfg.ArrayLength != nil || fieldCfg.DynamicKeys != nil || isFlattenedObject(fieldCfg, field) {
+		return ""
+	}
+
+	// values as strings are quoted by the custom template emitters themselves
+	if quotedAsString(fieldCfg, field) {
+		return ""
+	}
+
+	// dates as epoch are numbers
+	if field.Type == FieldTypeDate && isEpochDateFormat(fieldCfg.Format) {
+		return ""
+	}
+
+	return fieldValueWrapByType(field)
+}
+
 func generateCustomTemplateFromField(cfg Config, fields Fields) ([]byte, []Field) {
 	return generateTemplateFromField(cfg, fields, customTemplateEngine)
 }
@@ -63,17 +100,21 @@ func generateTemplateFromField(cfg Config, fields Fields, templateEngine int) ([
 		return nil, nil
 	}
 
+	fields = withECSVersionField(cfg, fields)
+
+	r := newRand(cfg.Seed)
+
 	dupes := make(map[string]struct{})
 	objectKeysField := make([]Field, 0, len(fields))
 
 	templatePrefix := "{ "
 	templateBuffer := bytes.NewBufferString(templatePrefix)
 	for i, field := range fields {
-		fieldWrap := fieldValueWrapByType(field)
-		if fieldCfg, ok := cfg.GetField(field.Name); ok {
-			if fieldCfg.Value != nil {
-				fieldWrap = ""
-			}
+		fieldCfg, _ := cfg.GetField(field.Name)
+		fieldWrap := fieldValueWrap(fieldCfg, field)
+		// the text template values as strings are quoted by the template instead
+		if quotedAsString(fieldCfg, field) && templateEngine == textTemplateEngine {
+			fieldWrap = "\""
 		}
 
 		fieldTrailer := []byte(",")
@@ -81,13 +122,14 @@ func generateTemplateFromField(cfg Config, fields Fields, templateEngine int) ([
 			fieldTrailer = []byte(" }")
 		}
 
-		if strings.HasSuffix(field.Name, ".*") || field.Type == FieldTypeObject || field.Type == FieldTypeNested || field.Type == FieldTypeFlattened {
+		isObject := field.Type == FieldTypeObject || field.Type == FieldTypeNested || field.Type == FieldTypeFlattened
+		if (strings.HasSuffix(field.Name, ".*") || isObject && len(field.Fields) == 0) && fieldCfg.DynamicKeys == nil && !isFlattenedObject(fieldCfg, field) {
 			// This is a special case.  We are randomly generating keys on the fly
 			// Will set the json field name as "field.Name.N"
 			N := 5
 			for ii := 0; ii < N; ii++ {
 				// Fire or skip
-				if rand.Int()%2 == 0 {
+				if r.Int()%2 == 0 {
 					continue
 				}
 
@@ -97,10 +139,10 @@ func generateTemplateFromField(cfg Config, fields Fields, templateEngine int) ([
 
 				var try int
 				const maxTries = 10
-				rNoun := randomdata.Noun()
+				rNoun := randomNoun(r)
 				_, ok := dupes[rNoun]
 				for ; ok && try < maxTries; try++ {
-					rNoun = randomdata.Noun()
+					rNoun = randomNoun(r)
 					_, ok = dupes[rNoun]
 				}
 
@@ -116,7 +158,7 @@ func generateTemplateFromField(cfg Config, fields Fields, templateEngine int) ([
 				fieldNameRoot := replacer.Replace(field.Name)
 				fieldVariableName := fieldNormalizerRegex.ReplaceAllString(fmt.Sprintf("%s%s", fieldNameRoot, rNoun), "")
 				fieldVariableName += "Var"
-				if field.Type == FieldTypeDate {
+				if field.Type == FieldTypeDate && len(fieldCfg.Format) == 0 {
 					if templateEngine == textTemplateEngine {
 						fieldTemplate = fmt.Sprintf(`{{ $%s := generate "%s.%s" }}"%s.%s": %s{{$%s.Format "2006-01-02T15:04:05.999999Z07:00"}}%s%s`, fieldVariableName, fieldNameRoot, rNoun, fieldNameRoot, rNoun, fieldWrap, fieldVariableName, fieldWrap, fieldTrailer)
 					} else if templateEngine == customTemplateEngine {
@@ -141,17 +183,21 @@ func generateTemplateFromField(cfg Config, fields Fields, templateEngine int) ([
 			var fieldTemplate string
 			fieldVariableName := fieldNormalizerRegex.ReplaceAllString(field.Name, "")
 			fieldVariableName += "Var"
-			if field.Type == FieldTypeDate {
+			// an object with dynamic keys is emitted under its root name
+			fieldKey := replacer.Replace(field.Name)
+			if (isObject || isRangeType(field.Type) || fieldCfg.ArrayLength != nil) && templateEngine == textTemplateEngine {
+				fieldTemplate = fmt.Sprintf(`"%s": {{generate "%s" | toJson}}%s`, fieldKey, field.Name, fieldTrailer)
+			} else if field.Type == FieldTypeDate && len(fieldCfg.Format) == 0 {
 				if templateEngine == textTemplateEngine {
-					fieldTemplate = fmt.Sprintf(`{{ $%s := generate "%s" }}"%s": %s{{$%s.Format "2006-01-02T15:04:05.999999Z07:00"}}%s%s`, fieldVariableName, field.Name, field.Name, fieldWrap, fieldVariableName, fieldWrap, fieldTrailer)
+					fieldTemplate = fmt.Sprintf(`{{ $%s := generate "%s" }}"%s": %s{{$%s.Format "2006-01-02T15:04:05.999999Z07:00"}}%s%s`, fieldVariableName, field.Name, fieldKey, fieldWrap, fieldVariableName, fieldWrap, fieldTrailer)
 				} else if templateEngine == customTemplateEngine {
-					fieldTemplate = fmt.Sprintf(`"%s": %s{{.%s}}%s%s`, field.Name, fieldWrap, field.Name, fieldWrap, fieldTrailer)
+					fieldTemplate = fmt.Sprintf(`"%s": %s{{.%s}}%s%s`, fieldKey, fieldWrap, field.Name, fieldWrap, fieldTrailer)
 				}
 			} else {
 				if templateEngine == textTemplateEngine {
-					fieldTemplate = fmt.Sprintf(`"%s": %s{{generate "%s"}}%s%s`, field.Name, fieldWrap, field.Name, fieldWrap, fieldTrailer)
+					fieldTemplate = fmt.Sprintf(`"%s": %s{{generate "%s"}}%s%s`, fieldKey, fieldWrap, field.Name, fieldWrap, fieldTrailer)
 				} else if templateEngine == customTemplateEngine {
-					fieldTemplate = fmt.Sprintf(`"%s": %s{{.%s}}%s%s`, field.Name, fieldWrap, field.Name, fieldWrap, fieldTrailer)
+					fieldTemplate = fmt.Sprintf(`"%s": %s{{.%s}}%s%s`, fieldKey, fieldWrap, field.Name, fieldWrap, fieldTrailer)
 				}
 			}
 
@@ -162,9 +208,67 @@ func generateTemplateFromField(cfg Config, fields Fields, templateEngine int) ([
 	return templateBuffer.Bytes(), objectKeysField
 }
 
-func NewGenerator(cfg Config, flds Fields, totSize uint64) (Generator, error) {
-	template, objectKeysField := generateCustomTemplateFromField(cfg, flds)
-	flds = append(flds, objectKeysField...)
+func estimationSamples(cfg Config) int {
+	if cfg.EstimationSamples < 1 {
+		return defaultEstimationSamples
+	}
+
+	return cfg.EstimationSamples
+}
+
+// totEventsFromSamples calculates the total number of events fitting in totSize given the total size of the generated samples
+func totEventsFromSamples(totSize uint64, samplesSize uint64, samples int) uint64 {
+	if samples < 1 || samplesSize == 0 {
+		return 1
+	}
+
+	// totSize / (samplesSize / samples), without losing precision on the average event size
+	totEvents := totSize * uint64(samples) / samplesSize
+	if totEvents < 1 {
+		totEvents = 1
+	}
+
+	return totEvents
+}
+
+// avgEventSizeFromSamples calculates the average event size, rounded to the nearest byte, given the total size of the generated samples
+func avgEventSizeFromSamples(samplesSize uint64, samples int) uint64 {
+	if samples < 1 {
+		return 0
+	}
+
+	return (samplesSize + uint64(samples)/2) / uint64(samples)
+}
+
+// NewGenerator returns the generator of the template engine of kind, with a template generated from the fields when
+// template is empty, reporting the progress of the generation when configured
+func NewGenerator(kind GeneratorKind, template []byte, cfg Config, flds Fields, totSize uint64) (Generator, error) {
+	var gen Generator
+	var err error
+	switch kind {
+	case GeneratorKindCustomTemplate:
+		if len(template) == 0 {
+			var objectKeysField []Field
+			template, objectKeysField = generateCustomTemplateFromField(cfg, flds)
+			flds = append(flds, objectKeysField...)
+		}
+
+		gen, err = NewGeneratorWithCustomTemplate(template, cfg, flds, totSize)
+	case GeneratorKindTextTemplate:
+		if len(template) == 0 {
+			var objectKeysField []Field
+			template, objectKeysField = generateTextTemplateFromField(cfg, flds)
+			flds = append(flds, objectKeysField...)
+		}
+
+		gen, err = NewGeneratorWithTextTemplate(template, cfg, flds, totSize)
+	default:
+		return nil, fmt.Errorf("%w: %d", notValidGeneratorKind, kind)
+	}
+
+	if err != nil {
+		return nil, err
+	}
 
-	return NewGeneratorWithCustomTemplate(template, cfg, flds, totSize)
+	return withProgress(cfg, gen)
 }
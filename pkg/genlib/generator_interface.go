@@ -5,21 +5,28 @@
 package genlib
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/Pallinder/go-randomdata"
 	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
 	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/fields"
+	"hash/fnv"
+	"io"
+	"log"
 	"math"
 	"math/rand"
+	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"testing"
+	"text/template"
 	"time"
+	"unicode"
 )
 
 type (
@@ -27,14 +34,23 @@ type (
 	Field       = fields.Field
 	Config      = config.Config
 	ConfigField = config.ConfigField
+	Progress    = config.Progress
 )
 
 const (
 	FieldTypeBool            = "boolean"
 	FieldTypeKeyword         = "keyword"
 	FieldTypeConstantKeyword = "constant_keyword"
+	FieldTypeText            = "text"
+	FieldTypeWildcard        = "wildcard"
+	FieldTypeVersion         = "version"
+	FieldTypeSemver          = "semver"
+	FieldTypeBinary          = "binary"
 	FieldTypeDate            = "date"
 	FieldTypeIP              = "ip"
+	FieldTypeIPv6            = "ipv6"
+	FieldTypeMAC             = "mac"
+	FieldTypeHTTPStatus      = "http_status"
 	FieldTypeDouble          = "double"
 	FieldTypeFloat           = "float"
 	FieldTypeHalfFloat       = "half_float"
@@ -46,46 +62,107 @@ const (
 	FieldTypeNested          = "nested"
 	FieldTypeFlattened       = "flattened"
 	FieldTypeGeoPoint        = "geo_point"
+	FieldTypeIntegerRange    = "integer_range"
+	FieldTypeLongRange       = "long_range"
+	FieldTypeFloatRange      = "float_range"
+	FieldTypeDoubleRange     = "double_range"
+	FieldTypeDateRange       = "date_range"
+	FieldTypeIPRange         = "ip_range"
 
 	FieldTypeTimeRange  = 3600 // seconds
 	FieldTypeTimeLayout = "2006-01-02T15:04:05.999999Z07:00"
 )
 
+var notValidRangeBounds = errors.New("range type field range min must be lower than max")
+var notValidIPRangeCIDR = errors.New("ip_range field cidr must contain more than one address")
+var notValidDynamicKeys = errors.New("dynamic_keys alphabet and key_length allow fewer distinct key names than keys max")
+var notValidSameAsCycle = errors.New("same_as references form a cycle")
+var notValidSameAsField = errors.New("same_as references a field not present in fields yaml definition")
+var notValidIPv6CIDR = errors.New("ipv6 field cidr must be an IPv6 network")
+var notValidOUI = errors.New("mac field oui must be three colon separated hexadecimal octets")
+var notValidCardinalityReusePattern = errors.New("cardinality_reuse_pattern must be one of 'uniform' or 'zipf'")
+var notValidHTTPStatus = errors.New("http_status field weighted_enum values must be HTTP status codes between 100 and 599")
+var notValidPrettyJSON = errors.New("pretty printed JSON events span many lines, hence can't be NDJSON")
+var notValidKeywordCharset = errors.New("keyword charset must not be empty nor contain quotes, backslashes or control characters")
+var notValidConstantKeyword = errors.New("constant_keyword field must have the same value in every event, hence can't have cardinality, cardinality_pool, enum, weighted_enum, pattern, values_file, counter nor array_length")
+var notValidGeneratorKind = errors.New("generator kind must be one of GeneratorKindCustomTemplate or GeneratorKindTextTemplate")
+var notValidDateFormat = errors.New("date format must be one of 'rfc3339', 'rfc3339nano', 'epoch_millis', 'epoch_second' or a Go time layout")
+
 var (
 	replacer             = strings.NewReplacer(".*", "")
 	fieldNormalizerRegex = regexp.MustCompile("[^a-zA-Z0-9]")
 	keywordRegex         = regexp.MustCompile("(\\.|-|_|\\s){1,1}")
 )
 
+// writer is what the custom template engine streams content to, satisfied by *bytes.Buffer and *bufio.Writer
+type writer interface {
+	io.Writer
+	io.ByteWriter
+	io.StringWriter
+}
+
 // This is the emit function for the custom template engine where we stream content directly to the output buffer and no need a return value
-type emitFNotReturn func(state *GenState, buf *bytes.Buffer) error
+type emitFNotReturn func(state *GenState, buf writer) error
 
 // EmitF Typedef of the internal emit function
 type EmitF func(state *GenState) any
 
+// Generator is implemented by the generators of each template engine, returning io.EOF once all the events are emitted
 type Generator interface {
 	Emit(state *GenState, buf *bytes.Buffer) error
+	EmitTo(state *GenState, w io.Writer) error
 	Close() error
 }
 
+// GeneratorKind is the template engine of the generator returned by NewGenerator
+type GeneratorKind int
+
+const (
+	GeneratorKindCustomTemplate GeneratorKind = iota
+	GeneratorKindTextTemplate
+)
+
 type GenState struct {
 	// event counter
 	counter uint64
 	// previous value cache; necessary for fuzziness, cardinality, etc.
 	prevCache map[string]any
-	// previous value cache for dup check; necessary for cardinality
-	prevCacheForDup map[string]map[any]struct{}
-	// previous cardinality value cache; necessary for cardinality
-	prevCacheCardinality map[string][]any
+	// pool of values of each field with cardinality
+	prevCacheCardinality map[string]*cardinalityCache
+	// fixed pool of distinct values of each field with cardinality_pool
+	cardinalityPools map[string]*cardinalityPool
+	// next value of the counter fields
+	counters map[string]int64
+	// values of the fields referenced by same_as or by generateOnce, and the country_region pairs, in the event they were generated for
+	sameAsCache map[string]sameAsValue
+	// timestamp of the current event
+	timestamp timestampValue
 	// internal buffer pool to decrease load on GC
 	pool sync.Pool
+	// source of randomness for the generated values, and the replayable source it draws from
+	rand   *rand.Rand
+	source *replayableSource
+	// zipf distribution of each field, drawing from rand
+	zipfs map[string]*rand.Zipf
+	// buffered writer for emitting to writers not supporting byte and string writes
+	bufWriter *bufio.Writer
+	// text template bound to the state, and the parsed template it was cloned from
+	tpl       *template.Template
+	tplSource *template.Template
+	// errors reported by the text template functions bound to the state
+	errChan chan error
+	// prettyJSONWarned is set once warned about an event not being pretty printed
+	prettyJSONWarned bool
 }
 
+// NewGenState returns a GenState whose randomness is seeded by the generator it is first used with
 func NewGenState() *GenState {
 	return &GenState{
 		prevCache:            make(map[string]any),
-		prevCacheForDup:      make(map[string]map[any]struct{}),
-		prevCacheCardinality: make(map[string][]any, 0),
+		prevCacheCardinality: make(map[string]*cardinalityCache),
+		cardinalityPools:     make(map[string]*cardinalityPool),
+		counters:             make(map[string]int64),
+		sameAsCache:          make(map[string]sameAsValue),
 		pool: sync.Pool{
 			New: func() any {
 				return new(bytes.Buffer)
@@ -94,426 +171,1739 @@ func NewGenState() *GenState {
 	}
 }
 
-func bindField(cfg Config, field Field, fieldMap map[string]any, withReturn bool) error {
+// NewGenStateWithSeed returns a GenState whose randomness derives from seed.
+// A zero seed means no seed: the current time is used instead.
+func NewGenStateWithSeed(seed int64) *GenState {
+	state := NewGenState()
+	state.seedRand(seed)
 
-	// Check for hardcoded field value
-	if len(field.Value) > 0 {
-		if withReturn {
-			return bindStaticWithReturn(field, field.Value, fieldMap)
-		} else {
-			return bindStatic(field, field.Value, fieldMap)
-		}
+	return state
+}
+
+// lazyInit makes the state ready to be used, seeding its randomness with seed if not seeded yet
+func (s *GenState) lazyInit(seed int64) {
+	if s.rand == nil {
+		s.seedRand(seed)
 	}
 
-	// Check config override of value
-	fieldCfg, _ := cfg.GetField(field.Name)
-	if fieldCfg.Value != nil {
-		if withReturn {
-			return bindStaticWithReturn(field, fieldCfg.Value, fieldMap)
-		} else {
-			return bindStatic(field, fieldCfg.Value, fieldMap)
-		}
+	if s.prevCache == nil {
+		s.prevCache = make(map[string]any)
 	}
 
-	if fieldCfg.Cardinality.Numerator > 0 {
-		if withReturn {
-			return bindCardinalityWithReturn(cfg, field, fieldMap)
-		} else {
-			return bindCardinality(cfg, field, fieldMap)
-		}
+	if s.prevCacheCardinality == nil {
+		s.prevCacheCardinality = make(map[string]*cardinalityCache)
 	}
 
-	if withReturn {
-		return bindByTypeWithReturn(cfg, field, fieldMap)
-	} else {
-		return bindByType(cfg, field, fieldMap)
+	if s.cardinalityPools == nil {
+		s.cardinalityPools = make(map[string]*cardinalityPool)
 	}
-}
 
-// Check for dupes O(n)
-func isDupeByteSlice(va []bytes.Buffer, dst []byte) bool {
-	var dupe bool
-	for _, b := range va {
-		if bytes.Equal(dst, b.Bytes()) {
-			dupe = true
-			break
-		}
+	if s.counters == nil {
+		s.counters = make(map[string]int64)
 	}
-	return dupe
-}
 
-func isDupeAny(va map[any]struct{}, dst any) bool {
-	_, ok := va[dst]
-	return ok
-}
+	if s.sameAsCache == nil {
+		s.sameAsCache = make(map[string]sameAsValue)
+	}
 
-// Check for dupes O(n)
-func isDupeInterface(va []any, dst any) bool {
-	var dupe bool
-	for _, b := range va {
-		if b == dst {
-			dupe = true
-			break
+	if s.pool.New == nil {
+		s.pool.New = func() any {
+			return new(bytes.Buffer)
 		}
 	}
-	return dupe
 }
 
-func bindByType(cfg Config, field Field, fieldMap map[string]any) (err error) {
-
-	fieldCfg, _ := cfg.GetField(field.Name)
-
-	switch field.Type {
-	case FieldTypeDate:
-		err = bindNearTime(field, fieldMap)
-	case FieldTypeIP:
-		err = bindIP(field, fieldMap)
-	case FieldTypeDouble, FieldTypeFloat, FieldTypeHalfFloat, FieldTypeScaledFloat:
-		err = bindDouble(fieldCfg, field, fieldMap)
-	case FieldTypeInteger, FieldTypeLong, FieldTypeUnsignedLong: // TODO: generate > 63 bit values for unsigned_long
-		err = bindLong(fieldCfg, field, fieldMap)
-	case FieldTypeConstantKeyword:
-		err = bindConstantKeyword(field, fieldMap)
-	case FieldTypeKeyword:
-		err = bindKeyword(fieldCfg, field, fieldMap)
-	case FieldTypeBool:
-		err = bindBool(field, fieldMap)
-	case FieldTypeObject, FieldTypeNested, FieldTypeFlattened:
-		err = bindObject(cfg, fieldCfg, field, fieldMap)
-	case FieldTypeGeoPoint:
-		err = bindGeoPoint(field, fieldMap)
+// reset returns the state to the one of NewGenStateWithSeed, keeping the text template bound to it
+func (s *GenState) reset(seed int64) {
+	s.counter = 0
+	s.prevCache = make(map[string]any)
+	s.prevCacheCardinality = make(map[string]*cardinalityCache)
+	s.cardinalityPools = make(map[string]*cardinalityPool)
+	s.counters = make(map[string]int64)
+	s.sameAsCache = make(map[string]sameAsValue)
+	s.timestamp = timestampValue{}
+	s.prettyJSONWarned = false
+	s.seedRand(seed)
+
+	// an error reported by a text template function and not collected belongs to the previous run
+	select {
+	case <-s.errChan:
 	default:
-		err = bindWordN(field, 25, fieldMap)
 	}
-
-	return
 }
 
-func bindByTypeWithReturn(cfg Config, field Field, fieldMap map[string]any) (err error) {
-
-	fieldCfg, _ := cfg.GetField(field.Name)
-
-	switch field.Type {
-	case FieldTypeDate:
-		err = bindNearTimeWithReturn(field, fieldMap)
-	case FieldTypeIP:
-		err = bindIPWithReturn(field, fieldMap)
-	case FieldTypeDouble, FieldTypeFloat, FieldTypeHalfFloat, FieldTypeScaledFloat:
-		err = bindDoubleWithReturn(fieldCfg, field, fieldMap)
-	case FieldTypeInteger, FieldTypeLong, FieldTypeUnsignedLong: // TODO: generate > 63 bit values for unsigned_long
-		err = bindLongWithReturn(fieldCfg, field, fieldMap)
-	case FieldTypeConstantKeyword:
-		err = bindConstantKeywordWithReturn(field, fieldMap)
-	case FieldTypeKeyword:
-		err = bindKeywordWithReturn(fieldCfg, field, fieldMap)
-	case FieldTypeBool:
-		err = bindBoolWithReturn(field, fieldMap)
-	case FieldTypeObject, FieldTypeNested, FieldTypeFlattened:
-		err = bindObjectWithReturn(cfg, fieldCfg, field, fieldMap)
-	case FieldTypeGeoPoint:
-		err = bindGeoPointWithReturn(field, fieldMap)
-	default:
-		err = bindWordNWithReturn(field, 25, fieldMap)
+// cardinalityCache returns the pool of values of the field with cardinality, creating it with window on first use
+func (s *GenState) cardinalityCache(field string, window int) *cardinalityCache {
+	cache, ok := s.prevCacheCardinality[field]
+	if !ok {
+		cache = newCardinalityCache(window)
+		s.prevCacheCardinality[field] = cache
 	}
 
-	return
+	return cache
 }
 
-func makeFloatFunc(fieldCfg ConfigField, field Field) func() float64 {
-	minValue, _ := fieldCfg.Range.MinAsFloat64()
-	maxValue, err := fieldCfg.Range.MaxAsFloat64()
-	// maxValue not set, let's set it to 0 for the sake of the switch above
-	if err != nil {
-		maxValue = 0
+// emitNDJSON emits an event through emit to w, stripping its trailing whitespaces and terminating it with exactly one new line
+func emitNDJSON(state *GenState, w io.Writer, emit func(state *GenState, w io.Writer) error) error {
+	buf := state.pool.Get().(*bytes.Buffer)
+	defer state.pool.Put(buf)
+
+	buf.Reset()
+	if err := emit(state, buf); err != nil {
+		return err
 	}
 
-	var dummyFunc func() float64
+	buf.Truncate(len(bytes.TrimRightFunc(buf.Bytes(), unicode.IsSpace)))
+	buf.WriteByte('\n')
 
-	switch {
-	case maxValue > 0:
-		dummyFunc = func() float64 { return minValue + rand.Float64()*(maxValue-minValue) }
-	case len(field.Example) == 0:
-		dummyFunc = func() float64 { return rand.Float64() * 10 }
-	default:
-		totDigit := len(field.Example)
-		max := math.Pow10(totDigit)
-		dummyFunc = func() float64 {
-			return rand.Float64() * max
-		}
-	}
+	_, err := w.Write(buf.Bytes())
 
-	return dummyFunc
+	return err
 }
 
-func makeIntFunc(fieldCfg ConfigField, field Field) func() int64 {
-	minValue, _ := fieldCfg.Range.MinAsInt64()
-	maxValue, err := fieldCfg.Range.MaxAsInt64()
-	// maxValue not set, let's set it to 0 for the sake of the switch above
-	if err != nil {
-		maxValue = 0
+// emitPrettyJSON emits an event through emit to w, indented when it is valid JSON, and untouched with a warning otherwise
+func emitPrettyJSON(state *GenState, w io.Writer, emit func(state *GenState, w io.Writer) error) error {
+	buf := state.pool.Get().(*bytes.Buffer)
+	defer state.pool.Put(buf)
+
+	buf.Reset()
+	if err := emit(state, buf); err != nil {
+		return err
 	}
 
-	var dummyFunc func() int64
+	indented := state.pool.Get().(*bytes.Buffer)
+	defer state.pool.Put(indented)
 
-	switch {
-	case maxValue > 0:
-		dummyFunc = func() int64 { return rand.Int63n(maxValue-minValue) + minValue }
-	case len(field.Example) == 0:
-		dummyFunc = func() int64 { return rand.Int63n(10) }
-	default:
-		totDigit := len(field.Example)
-		max := int64(math.Pow10(totDigit))
-		dummyFunc = func() int64 {
-			return rand.Int63n(max)
+	// json.Indent keeps the order of the keys, and the trailing whitespaces are kept as they are
+	event := bytes.TrimRightFunc(buf.Bytes(), unicode.IsSpace)
+	indented.Reset()
+	if err := json.Indent(indented, event, "", "  "); err != nil {
+		if !state.prettyJSONWarned {
+			log.Printf("warning: events not being valid JSON are not pretty printed: %v", err)
+			state.prettyJSONWarned = true
 		}
-	}
-
-	return dummyFunc
-}
 
-func bindObject(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
-	if len(field.ObjectType) > 0 {
-		field.Type = field.ObjectType
-	} else {
-		field.Type = FieldTypeKeyword
+		_, err := w.Write(buf.Bytes())
+		return err
 	}
 
-	objectRootFieldName := replacer.Replace(field.Name)
+	indented.Write(buf.Bytes()[len(event):])
 
-	if len(fieldCfg.ObjectKeys) > 0 {
-		for _, objectsKey := range fieldCfg.ObjectKeys {
-			field.Name = objectRootFieldName + "." + objectsKey
+	_, err := w.Write(indented.Bytes())
 
-			if err := bindField(cfg, field, fieldMap, false); err != nil {
-				return err
-			}
-		}
+	return err
+}
 
-		return nil
+// bufferedWriter returns the state buffered writer, reset for writing to w
+func (s *GenState) bufferedWriter(w io.Writer) *bufio.Writer {
+	if s.bufWriter == nil {
+		s.bufWriter = bufio.NewWriter(w)
+	} else {
+		s.bufWriter.Reset(w)
 	}
 
-	return bindDynamicObject(cfg, field, fieldMap)
+	return s.bufWriter
 }
 
-func bindDynamicObject(cfg Config, field Field, fieldMap map[string]any) error {
+// maxPooledBufferSize is the capacity above which a buffer is not returned to the pool, so that a few huge events don't pin their memory
+const maxPooledBufferSize = 1 << 20
 
-	// Temporary fieldMap which we pass to the bind function,
-	// then extract the generated emitFunction for use in the stub.
-	dynMap := make(map[string]any)
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
 
-	if err := bindField(cfg, field, dynMap, false); err != nil {
-		return err
+// GetBuffer returns an empty buffer from a pool shared by the generators, for emitting events in a hot loop without allocating a buffer for each of them
+func GetBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// PutBuffer resets buf and returns it to the pool for reuse: buf must not be used after the call
+func PutBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
 	}
-	stub := makeDynamicStub(dynMap[field.Name])
-	fieldMap[field.Name] = stub
 
-	return nil
+	buf.Reset()
+	bufferPool.Put(buf)
 }
 
-func genNounsN(n int, buf *bytes.Buffer) {
-
-	for i := 0; i < n-1; i++ {
-		buf.WriteString(randomdata.Noun())
-		buf.WriteByte(' ')
+func newRand(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
 	}
 
-	// randomdata.Adjective() + randomdata.Noun() -> 364 * 527 (~190k) different values
-	buf.WriteString(randomdata.Adjective())
-	buf.WriteString(randomdata.Noun())
+	return rand.New(rand.NewSource(seed))
 }
 
-func genNounsNWithReturn(n int) string {
-	value := ""
-	for i := 0; i < n-1; i++ {
-		value += randomdata.Noun() + " "
+// validateConstantKeyword checks that no config of a constant_keyword field makes its value vary across events
+func validateConstantKeyword(fieldCfg ConfigField, field Field) error {
+	if field.Type != FieldTypeConstantKeyword {
+		return nil
 	}
 
-	// randomdata.Adjective() + randomdata.Noun() -> 364 * 527 (~190k) different values
-	value += randomdata.Adjective()
-	value += randomdata.Noun()
+	if fieldCfg.Cardinality.Numerator > 0 || fieldCfg.CardinalityPool > 0 || len(fieldCfg.Enum) > 0 || len(fieldCfg.WeightedEnum) > 0 ||
+		len(fieldCfg.Pattern) > 0 || len(fieldCfg.ValuesFile) > 0 || fieldCfg.Counter != nil || fieldCfg.ArrayLength != nil {
+		return fmt.Errorf("%w: %q", notValidConstantKeyword, field.Name)
+	}
 
-	return value
+	return nil
 }
 
-func randGeoPoint(buf *bytes.Buffer) error {
-	lat := rand.Intn(181) - 90
-	var latD int
-	if lat != -90 && lat != 90 {
-		latD = rand.Intn(100)
+func bindField(cfg Config, field Field, fieldMap map[string]any, withReturn bool) error {
+	fieldCfg, _ := cfg.GetField(field.Name)
+	if err := validateConstantKeyword(fieldCfg, field); err != nil {
+		return err
 	}
-	var longD int
-	long := rand.Intn(361) - 180
-	if long != -180 && long != 180 {
-		longD = rand.Intn(100)
+
+	if err := bindFieldValue(cfg, field, fieldMap, withReturn); err != nil {
+		return err
 	}
-	_, err := fmt.Fprintf(buf, "%d.%d,%d.%d", lat, latD, long, longD)
-	return err
-}
 
-func randGeoPointWithReturn() string {
-	lat := rand.Intn(181) - 90
-	var latD int
-	if lat != -90 && lat != 90 {
-		latD = rand.Intn(100)
+	var err error
+	if withReturn {
+		err = bindAsStringWithReturn(fieldCfg, field, fieldMap)
+	} else {
+		err = bindAsString(fieldCfg, field, fieldMap)
 	}
-	var longD int
-	long := rand.Intn(361) - 180
-	if long != -180 && long != 180 {
-		longD = rand.Intn(100)
+
+	if err != nil {
+		return err
 	}
 
-	return fmt.Sprintf("%d.%d,%d.%d", lat, latD, long, longD)
-}
+	if fieldCfg.ArrayLength != nil {
+		if withReturn {
+			err = bindArrayWithReturn(fieldCfg, field, fieldMap)
+		} else {
+			err = bindArray(fieldCfg, field, fieldMap)
+		}
 
-func bindConstantKeyword(field Field, fieldMap map[string]any) error {
-	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		value, ok := state.prevCache[field.Name].(string)
-		if !ok {
-			// randomdata.Adjective() + randomdata.Noun() -> 364 * 527 (~190k) different values
-			value = randomdata.Adjective() + randomdata.Noun()
-			state.prevCache[field.Name] = value
+		if err != nil {
+			return err
 		}
-		buf.WriteString(value)
-		return nil
 	}
 
-	fieldMap[field.Name] = emitFNotReturn
+	// The custom template engine handles the null probability when emitting, since the field prefix must be suppressed as well
+	if withReturn && fieldCfg.NullProbability > 0 {
+		return bindNullProbabilityWithReturn(fieldCfg, field, fieldMap)
+	}
+
 	return nil
 }
 
-func bindKeyword(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
-	if len(fieldCfg.Enum) > 0 {
-		var emitFNotReturn emitFNotReturn
-		emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-			idx := rand.Intn(len(fieldCfg.Enum))
-			buf.WriteString(fieldCfg.Enum[idx])
-			return nil
-		}
-
-		fieldMap[field.Name] = emitFNotReturn
-	} else if len(field.Example) > 0 {
+func bindFieldValue(cfg Config, field Field, fieldMap map[string]any, withReturn bool) error {
 
-		totWords := len(keywordRegex.Split(field.Example, -1))
+	// Check for hardcoded field value
+	if len(field.Value) > 0 {
+		if withReturn {
+			return bindStaticWithReturn(field, field.Value, fieldMap)
+		} else {
+			return bindStatic(field, field.Value, fieldMap)
+		}
+	}
 
-		var joiner string
-		if strings.Contains(field.Example, "\\.") {
-			joiner = "\\."
-		} else if strings.Contains(field.Example, "-") {
-			joiner = "-"
-		} else if strings.Contains(field.Example, "_") {
-			joiner = "_"
-		} else if strings.Contains(field.Example, " ") {
-			joiner = " "
+	// Check config override of value
+	fieldCfg, _ := cfg.GetField(field.Name)
+	if fieldCfg.Value != nil {
+		if withReturn {
+			return bindStaticWithReturn(field, fieldCfg.Value, fieldMap)
+		} else {
+			return bindStatic(field, fieldCfg.Value, fieldMap)
 		}
+	}
 
-		return bindJoinRand(field, totWords, joiner, fieldMap)
-	} else {
-		var emitFNotReturn emitFNotReturn
-		emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-			// randomdata.Adjective() + randomdata.Noun() -> 364 * 527 (~190k) different values
-			buf.WriteString(randomdata.Adjective() + randomdata.Noun())
-			return nil
+	if fieldCfg.Counter != nil {
+		if withReturn {
+			return bindCounterWithReturn(fieldCfg, field, fieldMap)
+		} else {
+			return bindCounter(fieldCfg, field, fieldMap)
 		}
+	}
 
-		fieldMap[field.Name] = emitFNotReturn
+	if fieldCfg.CardinalityPool > 0 {
+		if withReturn {
+			return bindCardinalityPoolWithReturn(cfg, field, fieldMap)
+		} else {
+			return bindCardinalityPool(cfg, field, fieldMap)
+		}
 	}
-	return nil
-}
 
-func bindJoinRand(field Field, N int, joiner string, fieldMap map[string]any) error {
-	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		for i := 0; i < N-1; i++ {
-			buf.WriteString(randomdata.Noun())
-			buf.WriteString(joiner)
+	if fieldCfg.Cardinality.Numerator > 0 {
+		if withReturn {
+			return bindCardinalityWithReturn(cfg, field, fieldMap)
+		} else {
+			return bindCardinality(cfg, field, fieldMap)
 		}
-		// randomdata.Adjective() + randomdata.Noun() -> 364 * 527 (~190k) different values
-		buf.WriteString(randomdata.Adjective())
-		buf.WriteString(randomdata.Noun())
-		return nil
 	}
 
+	if withReturn {
+		return bindByTypeWithReturn(cfg, field, fieldMap)
+	} else {
+		return bindByType(cfg, field, fieldMap)
+	}
+}
+
+// makeCounterFunc returns a function returning the next value of the field counter in the state,
+// starting from the counter start and increasing by its step
+func makeCounterFunc(fieldCfg ConfigField, field Field) func(state *GenState) int64 {
+	start := fieldCfg.Counter.Start
+	step := fieldCfg.Counter.Step
+	if step == 0 {
+		step = 1
+	}
+
+	return func(state *GenState) int64 {
+		value, ok := state.counters[field.Name]
+		if !ok {
+			value = start
+		}
+
+		state.counters[field.Name] = value + step
+
+		return value
+	}
+}
+
+func bindCounter(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	counterFunc := makeCounterFunc(fieldCfg, field)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		v := make([]byte, 0, 20)
+		_, err := buf.Write(strconv.AppendInt(v, counterFunc(state), 10))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindCounterWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	counterFunc := makeCounterFunc(fieldCfg, field)
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return counterFunc(state)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+// EventIndexFieldName is the placeholder of the custom templates emitting the index of the event in the state, starting from zero
+const EventIndexFieldName = "@eventIndex"
+
+// bindEventIndex binds the EventIndexFieldName placeholder to the event counter of the state, unless a field has the same name
+func bindEventIndex(fieldMap map[string]any) {
+	if _, ok := fieldMap[EventIndexFieldName]; ok {
+		return
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		v := make([]byte, 0, 20)
+		_, err := buf.Write(strconv.AppendUint(v, state.counter, 10))
+		return err
+	}
+
+	fieldMap[EventIndexFieldName] = emitFNotReturn
+}
+
+// bindArray wraps the field emit function for emitting a JSON array of independently generated values
+func bindArray(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	bindF, ok := fieldMap[field.Name].(emitFNotReturn)
+	if !ok {
+		return nil
+	}
+
+	wrap := fieldValueWrapByType(field)
+	// values as strings are quoted by the emitter itself
+	if fieldCfg.Value != nil || quotedAsString(fieldCfg, field) {
+		wrap = ""
+	}
+
+	lengthFunc := makeLengthFunc(*fieldCfg.ArrayLength)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		length := lengthFunc(state.rand)
+
+		buf.WriteByte('[')
+		for i := 0; i < length; i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			buf.WriteString(wrap)
+			if err := bindF(state, buf); err != nil {
+				return err
+			}
+			buf.WriteString(wrap)
+		}
+
+		_, err := buf.WriteString("]")
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindArrayWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	bindF, ok := fieldMap[field.Name].(EmitF)
+	if !ok {
+		return nil
+	}
+
+	lengthFunc := makeLengthFunc(*fieldCfg.ArrayLength)
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		values := make([]any, lengthFunc(state.rand))
+		for i := range values {
+			values[i] = bindF(state)
+		}
+
+		return values
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+type sameAsValue struct {
+	event uint64
+	value any
+}
+
+// sameAsRoot follows the same_as references from field, returning the field generating the value
+func sameAsRoot(cfg Config, field string) (string, error) {
+	visited := map[string]struct{}{field: {}}
+	for {
+		fieldCfg, _ := cfg.GetField(field)
+		if len(fieldCfg.SameAs) == 0 {
+			return field, nil
+		}
+
+		field = fieldCfg.SameAs
+		if _, ok := visited[field]; ok {
+			return "", fmt.Errorf("%w: %q", notValidSameAsCycle, field)
+		}
+
+		visited[field] = struct{}{}
+	}
+}
+
+// bindSameAs binds the fields with a same_as reference to the value generated, once per event, by the referenced field.
+// It must be called once all the fields are bound.
+func bindSameAs(cfg Config, fields Fields, fieldMap map[string]any, withReturn bool) error {
+	memoized := make(map[string]any)
+	for _, field := range fields {
+		fieldCfg, _ := cfg.GetField(field.Name)
+		if len(fieldCfg.SameAs) == 0 {
+			continue
+		}
+
+		root, err := sameAsRoot(cfg, field.Name)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := fieldMap[root]; !ok {
+			return fmt.Errorf("%w: %q", notValidSameAsField, root)
+		}
+
+		if _, ok := memoized[root]; !ok {
+			if withReturn {
+				memoized[root] = memoizeSameAsWithReturn(root, fieldMap[root].(EmitF))
+			} else {
+				memoized[root] = memoizeSameAs(root, fieldMap[root].(emitFNotReturn))
+			}
+
+			fieldMap[root] = memoized[root]
+		}
+
+		fieldMap[field.Name] = memoized[root]
+	}
+
+	return nil
+}
+
+// memoizeSameAs wraps the emit function of the field, such that it generates a single value per event for every field referencing it
+func memoizeSameAs(fieldName string, bindF emitFNotReturn) emitFNotReturn {
+	return func(state *GenState, buf writer) error {
+		if cached, ok := state.sameAsCache[fieldName]; ok && cached.event == state.counter {
+			_, err := buf.Write(cached.value.([]byte))
+			return err
+		}
+
+		value := state.pool.Get().(*bytes.Buffer)
+		defer state.pool.Put(value)
+
+		value.Reset()
+		if err := bindF(state, value); err != nil {
+			return err
+		}
+
+		state.sameAsCache[fieldName] = sameAsValue{event: state.counter, value: append([]byte(nil), value.Bytes()...)}
+
+		_, err := buf.Write(value.Bytes())
+		return err
+	}
+}
+
+func memoizeSameAsWithReturn(fieldName string, bindF EmitF) EmitF {
+	return func(state *GenState) any {
+		if cached, ok := state.sameAsCache[fieldName]; ok && cached.event == state.counter {
+			return cached.value
+		}
+
+		value := bindF(state)
+		state.sameAsCache[fieldName] = sameAsValue{event: state.counter, value: value}
+
+		return value
+	}
+}
+
+// isNull tells if a field value must be omitted, according to the field null probability
+func isNull(state *GenState, nullProbability float64) bool {
+	return nullProbability > 0 && state.rand.Float64() < nullProbability
+}
+
+func bindNullProbabilityWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	bindF, ok := fieldMap[field.Name].(EmitF)
+	if !ok {
+		return nil
+	}
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		if isNull(state, fieldCfg.NullProbability) {
+			return nil
+		}
+
+		return bindF(state)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+// Check for dupes O(n)
+func isDupeByteSlice(va []bytes.Buffer, dst []byte) bool {
+	var dupe bool
+	for _, b := range va {
+		if bytes.Equal(dst, b.Bytes()) {
+			dupe = true
+			break
+		}
+	}
+	return dupe
+}
+
+// Check for dupes O(n)
+func isDupeInterface(va []any, dst any) bool {
+	var dupe bool
+	for _, b := range va {
+		if b == dst {
+			dupe = true
+			break
+		}
+	}
+	return dupe
+}
+
+func bindByType(cfg Config, field Field, fieldMap map[string]any) (err error) {
+
+	fieldCfg, _ := cfg.GetField(field.Name)
+
+	switch field.Type {
+	case FieldTypeDate:
+		err = bindNearTime(fieldCfg, field, fieldMap)
+	case FieldTypeIP, FieldTypeIPv6:
+		err = bindIP(fieldCfg, field, fieldMap)
+	case FieldTypeMAC:
+		err = bindMAC(fieldCfg, field, fieldMap)
+	case FieldTypeHTTPStatus:
+		err = bindHTTPStatus(fieldCfg, field, fieldMap)
+	case FieldTypeDouble, FieldTypeFloat:
+		err = bindDouble(fieldCfg, field, fieldMap)
+	case FieldTypeHalfFloat:
+		err = bindHalfFloat(fieldCfg, field, fieldMap)
+	case FieldTypeScaledFloat:
+		err = bindScaledFloat(fieldCfg, field, fieldMap)
+	case FieldTypeInteger, FieldTypeLong:
+		err = bindLong(fieldCfg, field, fieldMap)
+	case FieldTypeUnsignedLong:
+		err = bindUnsignedLong(fieldCfg, field, fieldMap)
+	case FieldTypeConstantKeyword:
+		err = bindConstantKeyword(cfg.Seed, field, fieldMap)
+	case FieldTypeKeyword:
+		err = bindKeyword(fieldCfg, field, fieldMap)
+	case FieldTypeText:
+		err = bindText(fieldCfg, field, fieldMap)
+	case FieldTypeWildcard:
+		err = bindWildcard(fieldCfg, field, fieldMap)
+	case FieldTypeVersion, FieldTypeSemver:
+		err = bindSemver(fieldCfg, field, fieldMap)
+	case FieldTypeBinary:
+		err = bindBinary(fieldCfg, field, fieldMap)
+	case FieldTypeBool:
+		err = bindBool(fieldCfg, field, fieldMap)
+	case FieldTypeObject, FieldTypeNested, FieldTypeFlattened:
+		err = bindObject(cfg, fieldCfg, field, fieldMap)
+	case FieldTypeGeoPoint:
+		err = bindGeoPoint(fieldCfg, field, fieldMap)
+	case FieldTypeIntegerRange, FieldTypeLongRange, FieldTypeFloatRange, FieldTypeDoubleRange, FieldTypeDateRange, FieldTypeIPRange:
+		err = bindRange(fieldCfg, field, fieldMap)
+	default:
+		err = bindWordN(field, 25, fieldMap)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return bindAffixes(fieldCfg, field, fieldMap)
+}
+
+func bindByTypeWithReturn(cfg Config, field Field, fieldMap map[string]any) (err error) {
+
+	fieldCfg, _ := cfg.GetField(field.Name)
+
+	switch field.Type {
+	case FieldTypeDate:
+		err = bindNearTimeWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeIP, FieldTypeIPv6:
+		err = bindIPWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeMAC:
+		err = bindMACWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeHTTPStatus:
+		err = bindHTTPStatusWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeDouble, FieldTypeFloat:
+		err = bindDoubleWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeHalfFloat:
+		err = bindHalfFloatWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeScaledFloat:
+		err = bindScaledFloatWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeInteger, FieldTypeLong:
+		err = bindLongWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeUnsignedLong:
+		err = bindUnsignedLongWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeConstantKeyword:
+		err = bindConstantKeywordWithReturn(cfg.Seed, field, fieldMap)
+	case FieldTypeKeyword:
+		err = bindKeywordWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeText:
+		err = bindTextWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeWildcard:
+		err = bindWildcardWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeVersion, FieldTypeSemver:
+		err = bindSemverWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeBinary:
+		err = bindBinaryWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeBool:
+		err = bindBoolWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeObject, FieldTypeNested, FieldTypeFlattened:
+		err = bindObjectWithReturn(cfg, fieldCfg, field, fieldMap)
+	case FieldTypeGeoPoint:
+		err = bindGeoPointWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeIntegerRange, FieldTypeLongRange, FieldTypeFloatRange, FieldTypeDoubleRange, FieldTypeDateRange, FieldTypeIPRange:
+		err = bindRangeWithReturn(fieldCfg, field, fieldMap)
+	default:
+		err = bindWordNWithReturn(field, 25, fieldMap)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return bindAffixesWithReturn(fieldCfg, field, fieldMap)
+}
+
+// makeDistributionFunc returns a function generating values according to the configured distribution,
+// clamped to the configured range; it returns nil for the uniform distribution.
+// Values out of the range are clamped rather than rejection sampled, so that generating a value takes a bounded time
+// however narrow the range: the bounds get the probability of the tails of the distribution.
+func makeDistributionFunc(fieldCfg ConfigField) func(state *GenState) float64 {
+	minValue, minErr := fieldCfg.Range.MinAsFloat64()
+	maxValue, maxErr := fieldCfg.Range.MaxAsFloat64()
+
+	distribution := fieldCfg.Distribution
+
+	var distributionFunc func(state *GenState) float64
+	switch distribution.Type {
+	case config.DistributionNormal:
+		distributionFunc = func(state *GenState) float64 {
+			return distribution.Mean + state.rand.NormFloat64()*distribution.StdDev
+		}
+	case config.DistributionExponential:
+		distributionFunc = func(state *GenState) float64 {
+			return state.rand.ExpFloat64() / distribution.Lambda
+		}
+	case config.DistributionZipf:
+		// zipf values are in [0, imax], shifted by the range min if any
+		imax := uint64(math.MaxInt64)
+		if maxErr == nil {
+			imax = uint64(math.Max(maxValue-minValue, 0))
+		}
+
+		// the zipf distribution of the field is built once for each state, on first use
+		distributionFunc = func(state *GenState) float64 {
+			zipf, ok := state.zipfs[fieldCfg.Name]
+			if !ok {
+				zipf = rand.NewZipf(state.rand, distribution.S, distribution.V, imax)
+				state.zipfs[fieldCfg.Name] = zipf
+			}
+
+			return minValue + float64(zipf.Uint64())
+		}
+	default:
+		return nil
+	}
+
+	return func(state *GenState) float64 {
+		v := distributionFunc(state)
+		if minErr == nil {
+			v = math.Max(v, minValue)
+		}
+
+		if maxErr == nil {
+			v = math.Min(v, maxValue)
+		}
+
+		return v
+	}
+}
+
+func makeFloatFunc(fieldCfg ConfigField, field Field) func(state *GenState) float64 {
+	if distributionFunc := makeDistributionFunc(fieldCfg); distributionFunc != nil {
+		return distributionFunc
+	}
+
+	minValue, _ := fieldCfg.Range.MinAsFloat64()
+	maxValue, err := fieldCfg.Range.MaxAsFloat64()
+	// maxValue not set, let's set it to 0 for the sake of the switch above
+	if err != nil {
+		maxValue = 0
+	}
+
+	var dummyFunc func(state *GenState) float64
+
+	switch {
+	case maxValue > 0:
+		dummyFunc = func(state *GenState) float64 { return minValue + state.rand.Float64()*(maxValue-minValue) }
+	case len(field.Example) == 0:
+		dummyFunc = func(state *GenState) float64 { return state.rand.Float64() * 10 }
+	default:
+		totDigit := len(field.Example)
+		max := math.Pow10(totDigit)
+		dummyFunc = func(state *GenState) float64 {
+			return state.rand.Float64() * max
+		}
+	}
+
+	return dummyFunc
+}
+
+func makeIntFunc(fieldCfg ConfigField, field Field) func(state *GenState) int64 {
+	if distributionFunc := makeDistributionFunc(fieldCfg); distributionFunc != nil {
+		// rounding can take values past bounds that are not integers, the integers within them are the bounds then
+		minValue, minErr := fieldCfg.Range.MinAsFloat64()
+		maxValue, maxErr := fieldCfg.Range.MaxAsFloat64()
+		return func(state *GenState) int64 {
+			v := math.Round(distributionFunc(state))
+			if minErr == nil && v < minValue {
+				v = math.Ceil(minValue)
+			}
+
+			if maxErr == nil && v > maxValue {
+				v = math.Floor(maxValue)
+			}
+
+			return int64(v)
+		}
+	}
+
+	minValue, _ := fieldCfg.Range.MinAsInt64()
+	maxValue, err := fieldCfg.Range.MaxAsInt64()
+	// maxValue not set, let's set it to 0 for the sake of the switch above
+	if err != nil {
+		maxValue = 0
+	}
+
+	var dummyFunc func(state *GenState) int64
+
+	switch {
+	case maxValue > 0:
+		dummyFunc = func(state *GenState) int64 { return state.rand.Int63n(maxValue-minValue) + minValue }
+	case len(field.Example) == 0:
+		dummyFunc = func(state *GenState) int64 { return state.rand.Int63n(10) }
+	default:
+		totDigit := len(field.Example)
+		max := int64(math.Pow10(totDigit))
+		dummyFunc = func(state *GenState) int64 {
+			return state.rand.Int63n(max)
+		}
+	}
+
+	return dummyFunc
+}
+
+func bindObject(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if len(field.Fields) > 0 {
+		return bindNested(cfg, fieldCfg, field, fieldMap)
+	}
+
+	if isFlattenedObject(fieldCfg, field) {
+		return bindFlattened(fieldCfg, field, fieldMap)
+	}
+
+	if fieldCfg.DynamicKeys != nil {
+		return bindDynamicKeys(cfg, fieldCfg, field, fieldMap)
+	}
+
+	if len(field.ObjectType) > 0 {
+		field.Type = field.ObjectType
+	} else {
+		field.Type = FieldTypeKeyword
+	}
+
+	objectRootFieldName := replacer.Replace(field.Name)
+
+	if len(fieldCfg.ObjectKeys) > 0 {
+		for _, objectsKey := range fieldCfg.ObjectKeys {
+			field.Name = objectRootFieldName + "." + objectsKey
+
+			if err := bindField(cfg, field, fieldMap, false); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return bindDynamicObject(cfg, field, fieldMap)
+}
+
+func bindDynamicObject(cfg Config, field Field, fieldMap map[string]any) error {
+
+	// Temporary fieldMap which we pass to the bind function,
+	// then extract the generated emitFunction for use in the stub.
+	dynMap := make(map[string]any)
+
+	if err := bindField(cfg, field, dynMap, false); err != nil {
+		return err
+	}
+	stub := makeDynamicStub(dynMap[field.Name])
+	fieldMap[field.Name] = stub
+
+	return nil
+}
+
+// makeLengthFunc returns a function drawing a number of elements between the length min and max
+func makeLengthFunc(length config.Length) func(r *rand.Rand) int {
+	if length.Min == length.Max {
+		return func(_ *rand.Rand) int { return length.Min }
+	}
+
+	return func(r *rand.Rand) int {
+		return length.Min + r.Intn(length.Max-length.Min+1)
+	}
+}
+
+// nestedDocuments is the number of sub-documents generated for a nested field, a single one when not configured
+func nestedDocuments(fieldCfg ConfigField) config.Length {
+	if fieldCfg.Documents.Max == 0 {
+		return config.Length{Min: 1, Max: 1}
+	}
+
+	return fieldCfg.Documents
+}
+
+type nestedSubField struct {
+	emitFunc        emitFNotReturn
+	key             []byte
+	wrap            string
+	nullProbability float64
+}
+
+// bindNested emits a JSON array of sub-documents, each of them with its own value for every sub-field of the nested field
+func bindNested(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	subFieldsMap := make(map[string]any)
+	subFields := make([]nestedSubField, 0, len(field.Fields))
+	for _, subField := range field.Fields {
+		key := subField.Name
+		subField.Name = field.Name + "." + subField.Name
+
+		if err := bindField(cfg, subField, subFieldsMap, false); err != nil {
+			return err
+		}
+
+		subFieldCfg, _ := cfg.GetField(subField.Name)
+		subFields = append(subFields, nestedSubField{
+			emitFunc:        subFieldsMap[subField.Name].(emitFNotReturn),
+			key:             []byte(strconv.Quote(key) + ":"),
+			wrap:            fieldValueWrap(subFieldCfg, subField),
+			nullProbability: subFieldCfg.NullProbability,
+		})
+	}
+
+	documentsFunc := makeLengthFunc(nestedDocuments(fieldCfg))
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		documents := documentsFunc(state.rand)
+
+		buf.WriteByte('[')
+		for i := 0; i < documents; i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			buf.WriteByte('{')
+			first := true
+			for _, subField := range subFields {
+				// a null sub-field is omitted from the sub-document
+				if isNull(state, subField.nullProbability) {
+					continue
+				}
+
+				if !first {
+					buf.WriteByte(',')
+				}
+
+				first = false
+
+				buf.Write(subField.key)
+				buf.WriteString(subField.wrap)
+				if err := subField.emitFunc(state, buf); err != nil {
+					return err
+				}
+				buf.WriteString(subField.wrap)
+			}
+			buf.WriteByte('}')
+		}
+
+		_, err := buf.WriteString("]")
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+const defaultDynamicKeysAlphabet = "abcdefghijklmnopqrstuvwxyz"
+
+// keywordCharsets are the presets of the charset of keyword values
+var keywordCharsets = map[string]string{
+	"hex":    "0123456789abcdef",
+	"digits": "0123456789",
+	"lower":  "abcdefghijklmnopqrstuvwxyz",
+	"upper":  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"alpha":  "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"alnum":  "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+}
+
+// makeKeywordCharsetFunc returns a function generating keyword values of the configured length and charset,
+// alphanumeric values from 5 to 10 characters long being generated for the setting not configured
+func makeKeywordCharsetFunc(fieldCfg ConfigField) (func(r *rand.Rand) string, error) {
+	charset := fieldCfg.Charset
+	if len(charset) == 0 {
+		charset = "alnum"
+	}
+
+	if preset, ok := keywordCharsets[charset]; ok {
+		charset = preset
+	}
+
+	chars := []rune(charset)
+	for _, c := range chars {
+		if c == '"' || c == '\\' || unicode.IsControl(c) {
+			return nil, fmt.Errorf("%w: %q", notValidKeywordCharset, fieldCfg.Charset)
+		}
+	}
+
+	length := config.Length{Min: 5, Max: 10}
+	if fieldCfg.Length != nil {
+		length = *fieldCfg.Length
+	}
+
+	lengthFunc := makeLengthFunc(length)
+
+	return func(r *rand.Rand) string {
+		value := make([]rune, lengthFunc(r))
+		for i := range value {
+			value[i] = chars[r.Intn(len(chars))]
+		}
+
+		return string(value)
+	}, nil
+}
+
+// makeDynamicKeysFunc returns a function generating the unique random key names of an object, applying the defaults
+// for the settings not configured
+func makeDynamicKeysFunc(dynamicKeys config.DynamicKeys) (func(r *rand.Rand) []string, error) {
+	keys := dynamicKeys.Keys
+	if keys.Max == 0 {
+		keys = config.Length{Min: 1, Max: 5}
+	}
+
+	alphabet := []rune(dynamicKeys.Alphabet)
+	if len(alphabet) == 0 {
+		alphabet = []rune(defaultDynamicKeysAlphabet)
+	}
+
+	keyLength := dynamicKeys.KeyLength
+	if keyLength.Max == 0 {
+		keyLength = config.Length{Min: 5, Max: 10}
+	}
+
+	// there must be enough distinct key names for keys to be unique within an object
+	var keySpace float64
+	for l := keyLength.Min; l <= keyLength.Max; l++ {
+		keySpace += math.Pow(float64(len(alphabet)), float64(l))
+	}
+
+	if keySpace < float64(keys.Max) {
+		return nil, notValidDynamicKeys
+	}
+
+	keysFunc := makeLengthFunc(keys)
+	keyLengthFunc := makeLengthFunc(keyLength)
+
+	return func(r *rand.Rand) []string {
+		n := keysFunc(r)
+		names := make([]string, 0, n)
+		seen := make(map[string]struct{}, n)
+		for len(names) < n {
+			name := make([]rune, keyLengthFunc(r))
+			for i := range name {
+				name[i] = alphabet[r.Intn(len(alphabet))]
+			}
+
+			if _, ok := seen[string(name)]; ok {
+				continue
+			}
+
+			seen[string(name)] = struct{}{}
+			names = append(names, string(name))
+		}
+
+		return names
+	}, nil
+}
+
+// dynamicKeysValueField is the field the values of the dynamic keys are generated for, according to the object type
+func dynamicKeysValueField(field Field) Field {
+	if len(field.ObjectType) > 0 {
+		field.Type = field.ObjectType
+	} else {
+		field.Type = FieldTypeKeyword
+	}
+
+	return field
+}
+
+// bindDynamicKeys emits an object with random key names, whose values are generated according to the object type
+func bindDynamicKeys(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	keysFunc, err := makeDynamicKeysFunc(*fieldCfg.DynamicKeys)
+	if err != nil {
+		return err
+	}
+
+	valueField := dynamicKeysValueField(field)
+	valueMap := make(map[string]any)
+	if err := bindByType(cfg, valueField, valueMap); err != nil {
+		return err
+	}
+
+	valueF := valueMap[valueField.Name].(emitFNotReturn)
+	wrap := fieldValueWrapByType(valueField)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		buf.WriteByte('{')
+		for i, key := range keysFunc(state.rand) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			buf.WriteByte('"')
+			buf.WriteString(key)
+			buf.WriteString(`":`)
+			buf.WriteString(wrap)
+			if err := valueF(state, buf); err != nil {
+				return err
+			}
+			buf.WriteString(wrap)
+		}
+
+		_, err := buf.WriteString("}")
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+// bindFlattened emits an object with random key names and scalar values, some of them being objects themselves
+func bindFlattened(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	flattenedFunc, err := makeFlattenedFunc(fieldCfg)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		v := make([]byte, 0, 256)
+		_, err := buf.Write(appendFlattened(v, flattenedFunc(state.rand)))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func genNounsN(r *rand.Rand, n int, buf writer) {
+
+	for i := 0; i < n-1; i++ {
+		buf.WriteString(randomNoun(r))
+		buf.WriteByte(' ')
+	}
+
+	// randomAdjective(r) + randomNoun(r) -> 364 * 527 (~190k) different values
+	buf.WriteString(randomAdjective(r))
+	buf.WriteString(randomNoun(r))
+}
+
+func genNounsNWithReturn(r *rand.Rand, n int) string {
+	value := ""
+	for i := 0; i < n-1; i++ {
+		value += randomNoun(r) + " "
+	}
+
+	// randomAdjective(r) + randomNoun(r) -> 364 * 527 (~190k) different values
+	value += randomAdjective(r)
+	value += randomNoun(r)
+
+	return value
+}
+
+func randGeoPoint(r *rand.Rand, buf writer) error {
+	lat := r.Intn(181) - 90
+	var latD int
+	if lat != -90 && lat != 90 {
+		latD = r.Intn(100)
+	}
+	var longD int
+	long := r.Intn(361) - 180
+	if long != -180 && long != 180 {
+		longD = r.Intn(100)
+	}
+	_, err := fmt.Fprintf(buf, "%d.%d,%d.%d", lat, latD, long, longD)
+	return err
+}
+
+// earthRadiusKm is the mean Earth radius, used for generating geo_point values within a radius
+const earthRadiusKm = 6371.0088
+
+// makeGeoPointFunc returns a function generating latitude and longitude in the configured region,
+// or nil when neither a region nor a format is configured
+func makeGeoPointFunc(geoPointCfg config.GeoPoint) (func(r *rand.Rand) (float64, float64), error) {
+	switch {
+	case len(geoPointCfg.Country) > 0:
+		return makeCountryGeoPointFunc(geoPointCfg.Country)
+	case geoPointCfg.BoundingBox != nil:
+		bbox := *geoPointCfg.BoundingBox
+		return func(r *rand.Rand) (float64, float64) {
+			return bbox.MinLat + r.Float64()*(bbox.MaxLat-bbox.MinLat), bbox.MinLon + r.Float64()*(bbox.MaxLon-bbox.MinLon)
+		}, nil
+	case geoPointCfg.Center != nil:
+		centerLat := geoPointCfg.Center.Lat * math.Pi / 180
+		centerLon := geoPointCfg.Center.Lon * math.Pi / 180
+		angularRadius := math.Min(geoPointCfg.Radius/earthRadiusKm, math.Pi)
+		return func(r *rand.Rand) (float64, float64) {
+			// uniform on the spherical cap: the cosine of the angular distance is uniform in [cos(angularRadius), 1]
+			distance := math.Acos(1 - r.Float64()*(1-math.Cos(angularRadius)))
+			bearing := r.Float64() * 2 * math.Pi
+
+			lat := math.Asin(math.Sin(centerLat)*math.Cos(distance) + math.Cos(centerLat)*math.Sin(distance)*math.Cos(bearing))
+			lon := centerLon + math.Atan2(math.Sin(bearing)*math.Sin(distance)*math.Cos(centerLat), math.Cos(distance)-math.Sin(centerLat)*math.Sin(lat))
+
+			// normalise the longitude in [-180, 180)
+			lon = math.Mod(lon+3*math.Pi, 2*math.Pi) - math.Pi
+
+			return lat * 180 / math.Pi, lon * 180 / math.Pi
+		}, nil
+	case len(geoPointCfg.Format) > 0:
+		return func(r *rand.Rand) (float64, float64) {
+			return r.Float64()*180 - 90, r.Float64()*360 - 180
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func appendGeoPoint(dst []byte, format string, lat, lon float64) []byte {
+	if format == config.GeoPointFormatObject {
+		dst = append(dst, `{"lat":`...)
+		dst = strconv.AppendFloat(dst, lat, 'f', 6, 64)
+		dst = append(dst, `,"lon":`...)
+		dst = strconv.AppendFloat(dst, lon, 'f', 6, 64)
+		return append(dst, '}')
+	}
+
+	dst = strconv.AppendFloat(dst, lat, 'f', 6, 64)
+	dst = append(dst, ',')
+	return strconv.AppendFloat(dst, lon, 'f', 6, 64)
+}
+
+func randGeoPointWithReturn(r *rand.Rand) string {
+	lat := r.Intn(181) - 90
+	var latD int
+	if lat != -90 && lat != 90 {
+		latD = r.Intn(100)
+	}
+	var longD int
+	long := r.Intn(361) - 180
+	if long != -180 && long != 180 {
+		longD = r.Intn(100)
+	}
+
+	return fmt.Sprintf("%d.%d,%d.%d", lat, latD, long, longD)
+}
+
+// constantKeywordValue returns the value of a constant_keyword field, drawn once from a source of its own: derived from
+// the seed and the field name, so that the value is reproducible without consuming the randomness of the states
+func constantKeywordValue(seed int64, field Field) string {
+	if seed != 0 {
+		h := fnv.New64a()
+		h.Write([]byte(field.Name))
+		seed ^= int64(h.Sum64())
+		// a zero seed means no seed
+		if seed == 0 {
+			seed = 1
+		}
+	}
+
+	r := newRand(seed)
+
+	// adjective + noun -> 527 * 364 (~190k) different values
+	return randomAdjective(r) + randomNoun(r)
+}
+
+func bindConstantKeyword(seed int64, field Field, fieldMap map[string]any) error {
+	value := constantKeywordValue(seed, field)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		_, err := buf.WriteString(value)
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+// makeWeightedIndexFunc returns a function picking an index of weightedValues proportionally to their weight,
+// or uniformly when no weight is set
+func makeWeightedIndexFunc(weightedValues []config.WeightedValue) func(r *rand.Rand) int {
+	cumulativeWeights := make([]float64, len(weightedValues))
+	var totWeight float64
+	for i, weightedValue := range weightedValues {
+		totWeight += weightedValue.Weight
+		cumulativeWeights[i] = totWeight
+	}
+
+	if totWeight == 0 {
+		return func(r *rand.Rand) int {
+			return r.Intn(len(weightedValues))
+		}
+	}
+
+	return func(r *rand.Rand) int {
+		// the first cumulative weight strictly greater than the point picked in [0, totWeight)
+		point := r.Float64() * totWeight
+		return sort.Search(len(cumulativeWeights), func(i int) bool {
+			return cumulativeWeights[i] > point
+		})
+	}
+}
+
+func bindKeyword(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if len(fieldCfg.CountryRegion) > 0 {
+		return bindCountryRegion(fieldCfg, field, fieldMap)
+	}
+
+	if len(fieldCfg.Pattern) > 0 {
+		patternFunc, err := compilePattern(fieldCfg.Pattern)
+		if err != nil {
+			return err
+		}
+
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			v := make([]byte, 0, 32)
+			_, err := buf.Write(patternFunc(state.rand, v))
+			return err
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+	} else if len(fieldCfg.ValuesFile) > 0 {
+		values, err := loadValuesFile(fieldCfg.ValuesFile)
+		if err != nil {
+			return err
+		}
+
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			_, err := buf.WriteString(values[state.rand.Intn(len(values))])
+			return err
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+	} else if len(fieldCfg.WeightedEnum) > 0 {
+		weightedIndex := makeWeightedIndexFunc(fieldCfg.WeightedEnum)
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			buf.WriteString(fieldCfg.WeightedEnum[weightedIndex(state.rand)].Value)
+			return nil
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+	} else if len(fieldCfg.Enum) > 0 {
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			idx := state.rand.Intn(len(fieldCfg.Enum))
+			buf.WriteString(fieldCfg.Enum[idx])
+			return nil
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+	} else if fieldCfg.Length != nil || len(fieldCfg.Charset) > 0 {
+		charsetFunc, err := makeKeywordCharsetFunc(fieldCfg)
+		if err != nil {
+			return err
+		}
+
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			_, err := buf.WriteString(charsetFunc(state.rand))
+			return err
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+	} else if len(field.Example) > 0 {
+
+		totWords := len(keywordRegex.Split(field.Example, -1))
+
+		var joiner string
+		if strings.Contains(field.Example, "\\.") {
+			joiner = "\\."
+		} else if strings.Contains(field.Example, "-") {
+			joiner = "-"
+		} else if strings.Contains(field.Example, "_") {
+			joiner = "_"
+		} else if strings.Contains(field.Example, " ") {
+			joiner = " "
+		}
+
+		return bindJoinRand(field, totWords, joiner, fieldMap)
+	} else {
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			// adjective + noun -> 527 * 364 (~190k) different values
+			buf.WriteString(randomAdjective(state.rand) + randomNoun(state.rand))
+			return nil
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+	}
+	return nil
+}
+
+func bindText(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	textFunc := makeTextFunc(fieldCfg)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		_, err := buf.WriteString(textFunc(state.rand))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindWildcard(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if hasKeywordValuesConfig(fieldCfg) {
+		return bindKeyword(fieldCfg, field, fieldMap)
+	}
+
+	wildcardFunc := makeWildcardFunc(fieldCfg)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		_, err := buf.WriteString(wildcardFunc(state.rand))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindSemver(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	semverFunc := makeSemverFunc(fieldCfg.Semver)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		_, err := buf.WriteString(semverFunc(state.rand))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+// binaryLength is the number of random bytes of a binary field, defaultBinaryLength when not configured
+func binaryLength(fieldCfg ConfigField) config.Length {
+	if fieldCfg.Length == nil {
+		return defaultBinaryLength
+	}
+
+	return *fieldCfg.Length
+}
+
+func bindBinary(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	binaryFunc := makeBinaryFunc(binaryLength(fieldCfg))
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		_, err := buf.WriteString(binaryFunc(state.rand))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindJoinRand(field Field, N int, joiner string, fieldMap map[string]any) error {
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		for i := 0; i < N-1; i++ {
+			buf.WriteString(randomNoun(state.rand))
+			buf.WriteString(joiner)
+		}
+		// adjective + noun -> 527 * 364 (~190k) different values
+		buf.WriteString(randomAdjective(state.rand))
+		buf.WriteString(randomNoun(state.rand))
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+
+	return nil
+}
+
+func bindStatic(field Field, v any, fieldMap map[string]any) error {
+	vstr, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		buf.Write(vstr)
+		return nil
+	}
+	fieldMap[field.Name] = emitFNotReturn
+
+	return nil
+}
+
+// trueProbability is the probability of generating true for a boolean field, 0.5 when not configured
+func trueProbability(fieldCfg ConfigField) float64 {
+	if fieldCfg.TrueProbability == nil {
+		return 0.5
+	}
+
+	return *fieldCfg.TrueProbability
+}
+
+func bindBool(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	p := trueProbability(fieldCfg)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		if state.rand.Float64() < p {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindGeoPoint(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	var emitFNotReturn emitFNotReturn
+	geoPointFunc, err := makeGeoPointFunc(fieldCfg.GeoPoint)
+	if err != nil {
+		return err
+	}
+
+	if geoPointFunc != nil {
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			lat, lon := geoPointFunc(state.rand)
+			v := make([]byte, 0, 48)
+			_, err := buf.Write(appendGeoPoint(v, fieldCfg.GeoPoint.Format, lat, lon))
+			return err
+		}
+	} else {
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			return randGeoPoint(state.rand, buf)
+		}
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindWordN(field Field, n int, fieldMap map[string]any) error {
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		genNounsN(state.rand, state.rand.Intn(n), buf)
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindNearTime(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	location, err := loadTimezone(fieldCfg.Timezone)
+	if err != nil {
+		return err
+	}
+
+	if len(fieldCfg.Format) > 0 {
+		dateFormatFunc, err := makeDateFormatFunc(fieldCfg.Format)
+		if err != nil {
+			return err
+		}
+
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			offset := time.Duration(state.rand.Intn(FieldTypeTimeRange)*-1) * time.Second
+			newTime := time.Now().Add(offset).In(location)
+
+			_, err := fmt.Fprint(buf, dateFormatFunc(newTime))
+			return err
+		}
+		fieldMap[field.Name] = emitFNotReturn
+		return nil
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		offset := time.Duration(state.rand.Intn(FieldTypeTimeRange)*-1) * time.Second
+		newTime := time.Now().Add(offset).In(location)
+
+		buf.WriteString(newTime.Format(FieldTypeTimeLayout))
+		return nil
+	}
 	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+// randPublicIPv4 returns a random IPv4 address, excluding private, loopback, link local, multicast and reserved ones
+func randPublicIPv4(r *rand.Rand) net.IP {
+	for {
+		ip := net.IPv4(byte(r.Intn(255)), byte(r.Intn(255)), byte(r.Intn(255)), byte(r.Intn(255)))
+		if ip[12] != 0 && ip[12] < 224 && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() {
+			return ip
+		}
+	}
+}
+
+// globalUnicastIPv6 is the network of the global unicast IPv6 addresses
+var globalUnicastIPv6 = &net.IPNet{IP: net.ParseIP("2000::"), Mask: net.CIDRMask(3, 128)}
+
+// randIPInNet returns a random address of ipNet
+func randIPInNet(r *rand.Rand, ipNet *net.IPNet) net.IP {
+	ip := make(net.IP, len(ipNet.IP))
+	readRand(r, ip)
+	for i := range ip {
+		// network bits from the CIDR, host bits random
+		ip[i] = ipNet.IP[i] | (ip[i] &^ ipNet.Mask[i])
+	}
+
+	return ip
+}
+
+// makeIPFunc returns a function generating addresses in the configured CIDR, or public addresses when not set:
+// IPv4 ones for the ip field type, and global unicast IPv6 ones for the ipv6 field type, whose CIDR must be an IPv6 network
+func makeIPFunc(fieldCfg ConfigField, fieldType string) (func(r *rand.Rand) net.IP, error) {
+	if len(fieldCfg.CIDR) == 0 {
+		if fieldType == FieldTypeIPv6 {
+			return func(r *rand.Rand) net.IP {
+				return randIPInNet(r, globalUnicastIPv6)
+			}, nil
+		}
+
+		return randPublicIPv4, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(fieldCfg.CIDR)
+	if err != nil {
+		return nil, err
+	}
+
+	if fieldType == FieldTypeIPv6 && ipNet.IP.To4() != nil {
+		return nil, fmt.Errorf("%w: %q", notValidIPv6CIDR, fieldCfg.CIDR)
+	}
 
-	return nil
+	return func(r *rand.Rand) net.IP {
+		return randIPInNet(r, ipNet)
+	}, nil
 }
 
-func bindStatic(field Field, v any, fieldMap map[string]any) error {
-	vstr, err := json.Marshal(v)
+func bindIP(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	ipFunc, err := makeIPFunc(fieldCfg, field.Type)
 	if err != nil {
 		return err
 	}
 
 	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		buf.Write(vstr)
-		return nil
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		_, err := buf.WriteString(ipFunc(state.rand).String())
+		return err
 	}
+
 	fieldMap[field.Name] = emitFNotReturn
 
 	return nil
 }
 
-func bindBool(field Field, fieldMap map[string]any) error {
-	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		switch rand.Int() % 2 {
-		case 0:
-			buf.WriteString("false")
-		case 1:
-			buf.WriteString("true")
-		}
-		return nil
+// parseOUI parses the first three octets of a mac address, colon separated
+func parseOUI(oui string) ([]byte, error) {
+	hw, err := net.ParseMAC(oui + ":00:00:00")
+	if err != nil || len(hw) != 6 || strings.Count(oui, ":") != 2 {
+		return nil, fmt.Errorf("%w: %q", notValidOUI, oui)
 	}
 
-	fieldMap[field.Name] = emitFNotReturn
-	return nil
+	return hw[:3], nil
 }
 
-func bindGeoPoint(field Field, fieldMap map[string]any) error {
-	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		return randGeoPoint(buf)
+// makeMACFunc returns a function generating colon separated mac addresses, starting with the configured OUI when set,
+// otherwise unicast ones, either universally or locally administered
+func makeMACFunc(oui string, locallyAdministered bool) (func(r *rand.Rand) net.HardwareAddr, error) {
+	var ouiOctets []byte
+	if len(oui) > 0 {
+		var err error
+		if ouiOctets, err = parseOUI(oui); err != nil {
+			return nil, err
+		}
 	}
 
-	fieldMap[field.Name] = emitFNotReturn
-	return nil
+	return func(r *rand.Rand) net.HardwareAddr {
+		mac := make(net.HardwareAddr, 6)
+		readRand(r, mac)
+
+		if ouiOctets != nil {
+			copy(mac, ouiOctets)
+			return mac
+		}
+
+		// the least significant bit of the first octet marks multicast addresses, the one after it locally administered ones
+		mac[0] &^= 0x01
+		if locallyAdministered {
+			mac[0] |= 0x02
+		} else {
+			mac[0] &^= 0x02
+		}
+
+		return mac
+	}, nil
 }
 
-func bindWordN(field Field, n int, fieldMap map[string]any) error {
+func bindMAC(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	macFunc, err := makeMACFunc(fieldCfg.OUI, fieldCfg.LocallyAdministered)
+	if err != nil {
+		return err
+	}
+
 	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		genNounsN(rand.Intn(n), buf)
-		return nil
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		_, err := buf.WriteString(macFunc(state.rand).String())
+		return err
 	}
 
 	fieldMap[field.Name] = emitFNotReturn
+
 	return nil
 }
 
-func bindNearTime(field Field, fieldMap map[string]any) error {
-	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		offset := time.Duration(rand.Intn(FieldTypeTimeRange)*-1) * time.Second
-		newTime := time.Now().Add(offset)
+// defaultHTTPStatuses is the distribution of HTTP status codes of a typical web server:
+// mostly 2xx, some 3xx and 4xx, and rare 5xx
+var defaultHTTPStatuses = []config.WeightedValue{
+	{Value: "200", Weight: 69.8},
+	{Value: "201", Weight: 2},
+	{Value: "204", Weight: 2},
+	{Value: "206", Weight: 1},
+	{Value: "301", Weight: 2},
+	{Value: "302", Weight: 4},
+	{Value: "304", Weight: 7},
+	{Value: "400", Weight: 2},
+	{Value: "401", Weight: 2},
+	{Value: "403", Weight: 1.5},
+	{Value: "404", Weight: 5},
+	{Value: "408", Weight: 0.2},
+	{Value: "429", Weight: 0.3},
+	{Value: "500", Weight: 0.5},
+	{Value: "502", Weight: 0.3},
+	{Value: "503", Weight: 0.3},
+	{Value: "504", Weight: 0.1},
+}
 
-		buf.WriteString(newTime.Format(FieldTypeTimeLayout))
-		return nil
+// makeHTTPStatusFunc returns a function picking HTTP status codes proportionally to their weight,
+// from the default distribution when no weighted value is set
+func makeHTTPStatusFunc(weightedValues []config.WeightedValue) (func(r *rand.Rand) int64, error) {
+	if len(weightedValues) == 0 {
+		weightedValues = defaultHTTPStatuses
 	}
-	fieldMap[field.Name] = emitFNotReturn
-	return nil
+
+	codes := make([]int64, len(weightedValues))
+	for i, weightedValue := range weightedValues {
+		code, err := strconv.ParseInt(weightedValue.Value, 10, 64)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("%w: %q", notValidHTTPStatus, weightedValue.Value)
+		}
+
+		codes[i] = code
+	}
+
+	weightedIndex := makeWeightedIndexFunc(weightedValues)
+
+	return func(r *rand.Rand) int64 {
+		return codes[weightedIndex(r)]
+	}, nil
 }
 
-func bindIP(field Field, fieldMap map[string]any) error {
-	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		i0 := rand.Intn(255)
-		i1 := rand.Intn(255)
-		i2 := rand.Intn(255)
-		i3 := rand.Intn(255)
+func bindHTTPStatus(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	httpStatusFunc, err := makeHTTPStatusFunc(fieldCfg.WeightedEnum)
+	if err != nil {
+		return err
+	}
 
-		_, err := fmt.Fprintf(buf, "%d.%d.%d.%d", i0, i1, i2, i3)
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		v := make([]byte, 0, 3)
+		_, err := buf.Write(strconv.AppendInt(v, httpStatusFunc(state.rand), 10))
 		return err
 	}
 
@@ -522,12 +1912,28 @@ func bindIP(field Field, fieldMap map[string]any) error {
 	return nil
 }
 
-func fuzzyInt(previous int64, fuzziness, min, max float64) int64 {
-	lowerBound := float64(previous) * (1 - fuzziness)
-	higherBound := float64(previous) * (1 + fuzziness)
-	lowerBound = math.Max(lowerBound, min)
-	higherBound = math.Min(higherBound, max)
-	return rand.Int63n(int64(math.Ceil(higherBound-lowerBound))) + int64(lowerBound)
+// fuzzyInt returns a random integer within the fuzziness of the previous one, both bounds included, clamped to [min, max]
+func fuzzyInt(r *rand.Rand, previous int64, fuzziness, min, max float64) int64 {
+	lowerBound, higherBound := fuzzyBounds(float64(previous), fuzziness, min, max)
+	lowerBound, higherBound = math.Ceil(lowerBound), math.Floor(higherBound)
+	if lowerBound > higherBound {
+		return previous
+	}
+
+	return int64(lowerBound) + r.Int63n(int64(higherBound-lowerBound)+1)
+}
+
+// fuzzyBounds returns the bounds of the values within the fuzziness of the previous one, clamped to [min, max]:
+// the lower one is greater than the higher one when no such value exists
+func fuzzyBounds(previous, fuzziness, min, max float64) (float64, float64) {
+	lowerBound := previous * (1 - fuzziness)
+	higherBound := previous * (1 + fuzziness)
+	// the bounds of negative values are the other way around
+	if lowerBound > higherBound {
+		lowerBound, higherBound = higherBound, lowerBound
+	}
+
+	return math.Max(lowerBound, min), math.Min(higherBound, max)
 }
 
 func bindLong(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
@@ -535,9 +1941,9 @@ func bindLong(fieldCfg ConfigField, field Field, fieldMap map[string]any) error
 
 	if fieldCfg.Fuzziness <= 0 {
 		var emitFNotReturn emitFNotReturn
-		emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
+		emitFNotReturn = func(state *GenState, buf writer) error {
 			v := make([]byte, 0, 32)
-			v = strconv.AppendInt(v, dummyFunc(), 10)
+			v = strconv.AppendInt(v, dummyFunc(state), 10)
 			buf.Write(v)
 			return nil
 		}
@@ -551,10 +1957,10 @@ func bindLong(fieldCfg ConfigField, field Field, fieldMap map[string]any) error
 	max, _ := fieldCfg.Range.MaxAsFloat64()
 
 	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		dummyInt := dummyFunc()
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		dummyInt := dummyFunc(state)
 		if previousDummyInt, ok := state.prevCache[field.Name].(int64); ok {
-			dummyInt = fuzzyInt(previousDummyInt, fieldCfg.Fuzziness, min, max)
+			dummyInt = fuzzyInt(state.rand, previousDummyInt, fieldCfg.Fuzziness, min, max)
 		}
 		state.prevCache[field.Name] = dummyInt
 		v := make([]byte, 0, 32)
@@ -567,12 +1973,42 @@ func bindLong(fieldCfg ConfigField, field Field, fieldMap map[string]any) error
 	return nil
 }
 
-func fuzzyFloat(previous, fuzziness, min, max float64) float64 {
-	lowerBound := previous * (1 - fuzziness)
-	higherBound := previous * (1 + fuzziness)
-	lowerBound = math.Max(lowerBound, min)
-	higherBound = math.Min(higherBound, max)
-	return lowerBound + rand.Float64()*(higherBound-lowerBound)
+func bindUnsignedLong(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	dummyFunc, err := makeUnsignedLongFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	min, _ := fieldCfg.Range.MinAsUint64()
+	max, _ := fieldCfg.Range.MaxAsUint64()
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		dummyUint := dummyFunc(state)
+		if fieldCfg.Fuzziness > 0 {
+			if previousDummyUint, ok := state.prevCache[field.Name].(uint64); ok {
+				dummyUint = fuzzyUint64(state.rand, previousDummyUint, fieldCfg.Fuzziness, min, max)
+			}
+			state.prevCache[field.Name] = dummyUint
+		}
+
+		v := make([]byte, 0, 32)
+		_, err := buf.Write(strconv.AppendUint(v, dummyUint, 10))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+// fuzzyFloat returns a random value within the fuzziness of the previous one, clamped to [min, max]
+func fuzzyFloat(r *rand.Rand, previous, fuzziness, min, max float64) float64 {
+	lowerBound, higherBound := fuzzyBounds(previous, fuzziness, min, max)
+	if lowerBound > higherBound {
+		return previous
+	}
+
+	return lowerBound + r.Float64()*(higherBound-lowerBound)
 }
 
 func bindDouble(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
@@ -580,8 +2016,8 @@ func bindDouble(fieldCfg ConfigField, field Field, fieldMap map[string]any) erro
 
 	if fieldCfg.Fuzziness <= 0 {
 		var emitFNotReturn emitFNotReturn
-		emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-			dummyFloat := dummyFunc()
+		emitFNotReturn = func(state *GenState, buf writer) error {
+			dummyFloat := dummyFunc(state)
 			_, err := fmt.Fprintf(buf, "%f", dummyFloat)
 			return err
 		}
@@ -594,10 +2030,10 @@ func bindDouble(fieldCfg ConfigField, field Field, fieldMap map[string]any) erro
 	max, _ := fieldCfg.Range.MaxAsFloat64()
 
 	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		dummyFloat := dummyFunc()
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		dummyFloat := dummyFunc(state)
 		if previousDummyFloat, ok := state.prevCache[field.Name].(float64); ok {
-			dummyFloat = fuzzyFloat(previousDummyFloat, fieldCfg.Fuzziness, min, max)
+			dummyFloat = fuzzyFloat(state.rand, previousDummyFloat, fieldCfg.Fuzziness, min, max)
 		}
 		state.prevCache[field.Name] = dummyFloat
 		_, err := fmt.Fprintf(buf, "%f", dummyFloat)
@@ -609,10 +2045,291 @@ func bindDouble(fieldCfg ConfigField, field Field, fieldMap map[string]any) erro
 	return nil
 }
 
+// makeScaledFloatFunc returns a function generating float values quantized to the inverse of the scaling factor,
+// as they are stored by a scaled_float field
+func makeScaledFloatFunc(fieldCfg ConfigField, field Field) func(state *GenState) float64 {
+	dummyFunc := makeFloatFunc(fieldCfg, field)
+	min, minErr := fieldCfg.Range.MinAsFloat64()
+	max, maxErr := fieldCfg.Range.MaxAsFloat64()
+
+	return func(state *GenState) float64 {
+		dummyFloat := dummyFunc(state)
+		if fieldCfg.Fuzziness > 0 {
+			if previousDummyFloat, ok := state.prevCache[field.Name].(float64); ok {
+				dummyFloat = fuzzyFloat(state.rand, previousDummyFloat, fieldCfg.Fuzziness, min, max)
+			}
+			state.prevCache[field.Name] = dummyFloat
+		}
+
+		// as for half_float values, quantizing stays within the range bounds
+		scaledFloat := math.Round(dummyFloat*fieldCfg.ScalingFactor) / fieldCfg.ScalingFactor
+		switch {
+		case minErr == nil && scaledFloat < min:
+			scaledFloat = math.Ceil(dummyFloat*fieldCfg.ScalingFactor) / fieldCfg.ScalingFactor
+		case maxErr == nil && scaledFloat > max:
+			scaledFloat = math.Floor(dummyFloat*fieldCfg.ScalingFactor) / fieldCfg.ScalingFactor
+		}
+
+		return scaledFloat
+	}
+}
+
+func bindScaledFloat(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if fieldCfg.ScalingFactor <= 0 {
+		return bindDouble(fieldCfg, field, fieldMap)
+	}
+
+	scaledFloatFunc := makeScaledFloatFunc(fieldCfg, field)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		// the shortest representation of the quantized value carries no more precision than the scaling factor
+		_, err := buf.WriteString(strconv.FormatFloat(scaledFloatFunc(state), 'f', -1, 64))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindHalfFloat(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	halfFloatFunc := makeHalfFloatFunc(fieldCfg, field)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		// half precision values are dyadic fractions: the shortest representation is exact
+		_, err := buf.WriteString(strconv.FormatFloat(halfFloatFunc(state), 'f', -1, 64))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+// rangeValueFunc returns the lower and upper bounds of a range type value, with gte lower than lt
+type rangeValueFunc func(r *rand.Rand) (gte, lt any)
+
+// longRangeBounds draws gte and lt, with min <= gte < lt <= max: max must be greater than min.
+// The differences are computed as unsigned, since max - min overflows an int64 for the widest ranges.
+func longRangeBounds(r *rand.Rand, min, max int64) (int64, int64) {
+	gte := min + int64(randUint64n(r, uint64(max)-uint64(min)))
+	lt := gte + 1 + int64(randUint64n(r, uint64(max)-uint64(gte)))
+
+	return gte, lt
+}
+
+func makeLongRangeFunc(fieldCfg ConfigField) (rangeValueFunc, error) {
+	min, err := fieldCfg.Range.MinAsInt64()
+	if err != nil {
+		min = 0
+	}
+
+	max, err := fieldCfg.Range.MaxAsInt64()
+	if err != nil {
+		max = min + 1000
+		if max < min {
+			max = math.MaxInt64
+		}
+	}
+
+	if max <= min {
+		return nil, notValidRangeBounds
+	}
+
+	return func(r *rand.Rand) (any, any) {
+		return longRangeBounds(r, min, max)
+	}, nil
+}
+
+func makeDoubleRangeFunc(fieldCfg ConfigField) (rangeValueFunc, error) {
+	min, err := fieldCfg.Range.MinAsFloat64()
+	if err != nil {
+		min = 0
+	}
+
+	max, err := fieldCfg.Range.MaxAsFloat64()
+	if err != nil {
+		max = min + 1000
+	}
+
+	if max <= min {
+		return nil, notValidRangeBounds
+	}
+
+	return func(r *rand.Rand) (any, any) {
+		gte := min + r.Float64()*(max-min)
+		lt := gte + (1-r.Float64())*(max-gte)
+		if lt <= gte {
+			lt = max
+		}
+
+		return gte, lt
+	}, nil
+}
+
+// makeDateFormatFunc returns a function formatting dates according to format: epoch formats give int64 values,
+// the others string ones, with rfc3339 being the default
+func makeDateFormatFunc(format string) (func(t time.Time) any, error) {
+	switch format {
+	case "", config.DateFormatRFC3339:
+		return func(t time.Time) any { return t.Format(FieldTypeTimeLayout) }, nil
+	case config.DateFormatRFC3339Nano:
+		return func(t time.Time) any { return t.Format(time.RFC3339Nano) }, nil
+	case config.DateFormatEpochMillis:
+		return func(t time.Time) any { return t.UnixMilli() }, nil
+	case config.DateFormatEpochSecond:
+		return func(t time.Time) any { return t.Unix() }, nil
+	}
+
+	// a layout without any element formats every date the same, as a mistyped format name does
+	first := time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC)
+	second := time.Date(2017, time.November, 13, 9, 31, 48, 123456789, time.FixedZone("", 3600))
+	if first.Format(format) == second.Format(format) {
+		return nil, fmt.Errorf("%w: %q", notValidDateFormat, format)
+	}
+
+	return func(t time.Time) any { return t.Format(format) }, nil
+}
+
+// isEpochDateFormat tells whether the dates formatted according to format are numbers
+func isEpochDateFormat(format string) bool {
+	return format == config.DateFormatEpochMillis || format == config.DateFormatEpochSecond
+}
+
+// makeDateRangeFunc returns a function generating date ranges within the range min and max, as epoch milliseconds,
+// or within the same time window of date fields when not set.
+// When only one of them is set and the time window doesn't fit, the missing bound is a time window away from the set one.
+func makeDateRangeFunc(fieldCfg ConfigField) (rangeValueFunc, error) {
+	location, err := loadTimezone(fieldCfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	dateFormatFunc, err := makeDateFormatFunc(fieldCfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	formatFunc := func(ms int64) any {
+		return dateFormatFunc(time.UnixMilli(ms).In(location))
+	}
+
+	min, minErr := fieldCfg.Range.MinAsInt64()
+	max, maxErr := fieldCfg.Range.MaxAsInt64()
+	if minErr == nil && maxErr == nil && max <= min {
+		return nil, notValidRangeBounds
+	}
+
+	return func(r *rand.Rand) (any, any) {
+		const window = FieldTypeTimeRange * 1000
+		now := time.Now().UnixMilli()
+
+		lower, upper := now-window, now
+		switch {
+		case minErr == nil && maxErr == nil:
+			lower, upper = min, max
+		case minErr == nil:
+			lower = min
+			if upper <= lower {
+				upper = lower + window
+			}
+		case maxErr == nil:
+			upper = max
+			if lower >= upper {
+				lower = upper - window
+			}
+		}
+
+		gte, lt := longRangeBounds(r, lower, upper)
+
+		return formatFunc(gte), formatFunc(lt)
+	}, nil
+}
+
+func makeIPRangeFunc(fieldCfg ConfigField) (rangeValueFunc, error) {
+	if len(fieldCfg.CIDR) > 0 {
+		if _, ipNet, err := net.ParseCIDR(fieldCfg.CIDR); err == nil {
+			if ones, bits := ipNet.Mask.Size(); ones == bits {
+				return nil, notValidIPRangeCIDR
+			}
+		}
+	}
+
+	ipFunc, err := makeIPFunc(fieldCfg, FieldTypeIP)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r *rand.Rand) (any, any) {
+		gte, lt := ipFunc(r), ipFunc(r)
+		for gte.Equal(lt) {
+			lt = ipFunc(r)
+		}
+
+		if bytes.Compare(gte, lt) > 0 {
+			gte, lt = lt, gte
+		}
+
+		return gte.String(), lt.String()
+	}, nil
+}
+
+func makeRangeFunc(fieldCfg ConfigField, field Field) (rangeValueFunc, error) {
+	switch field.Type {
+	case FieldTypeIntegerRange, FieldTypeLongRange:
+		return makeLongRangeFunc(fieldCfg)
+	case FieldTypeFloatRange, FieldTypeDoubleRange:
+		return makeDoubleRangeFunc(fieldCfg)
+	case FieldTypeDateRange:
+		return makeDateRangeFunc(fieldCfg)
+	default:
+		return makeIPRangeFunc(fieldCfg)
+	}
+}
+
+// appendRangeValue appends a range bound as a JSON value
+func appendRangeValue(dst []byte, value any) []byte {
+	switch v := value.(type) {
+	case int64:
+		return strconv.AppendInt(dst, v, 10)
+	case float64:
+		return strconv.AppendFloat(dst, v, 'f', -1, 64)
+	default:
+		return strconv.AppendQuote(dst, fmt.Sprint(v))
+	}
+}
+
+// bindRange emits a range type value as a {"gte":..,"lt":..} object
+func bindRange(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	rangeFunc, err := makeRangeFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		gte, lt := rangeFunc(state.rand)
+
+		value := make([]byte, 0, 64)
+		value = append(value, `{"gte":`...)
+		value = appendRangeValue(value, gte)
+		value = append(value, `,"lt":`...)
+		value = appendRangeValue(value, lt)
+		value = append(value, '}')
+
+		_, err := buf.Write(value)
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
 func bindCardinality(cfg Config, field Field, fieldMap map[string]any) error {
 
 	fieldCfg, _ := cfg.GetField(field.Name)
 	cardinality := int(math.Ceil((float64(fieldCfg.Cardinality.Denominator) / float64(fieldCfg.Cardinality.Numerator))))
+	window := cfg.CardinalityWindow
 
 	if strings.HasSuffix(field.Name, ".*") {
 		field.Name = replacer.Replace(field.Name)
@@ -630,15 +2347,17 @@ func bindCardinality(cfg Config, field Field, fieldMap map[string]any) error {
 	}
 
 	var emitFNotReturn emitFNotReturn
-	emitFNotReturn = func(state *GenState, buf *bytes.Buffer) error {
-		// Have we rolled over once?  If not, generate a value and cache it.
-		if len(state.prevCacheCardinality[field.Name]) < cardinality {
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		idx := int(state.counter % uint64(cardinality))
+		cache := state.cardinalityCache(field.Name, window)
 
+		// Is the value at idx in the pool?  If not, generate a value and cache it.
+		value, ok := cache.get(idx)
+		if !ok {
 			// Do college try dupe detection on value;
 			// Allow dupe if no unique value in nTries.
 			nTries := 11 // "These go to 11."
 			var tmp bytes.Buffer
-			var value []byte
 			for i := 0; i < nTries; i++ {
 
 				tmp.Reset()
@@ -646,26 +2365,129 @@ func bindCardinality(cfg Config, field Field, fieldMap map[string]any) error {
 					return err
 				}
 
-				value = tmp.Bytes()
-				if !isDupeAny(state.prevCacheForDup[field.Name], string(value)) {
+				value = tmp.String()
+				if !cache.contains(value) {
 					break
 				}
 			}
 
-			state.prevCacheForDup[field.Name][string(value)] = struct{}{}
-			state.prevCacheCardinality[field.Name] = append(state.prevCacheCardinality[field.Name], value)
+			cache.add(idx, value)
 		}
 
-		idx := int(state.counter % uint64(cardinality))
+		_, err := buf.WriteString(value.(string))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+// cardinalityZipfS is the s parameter of the zipf reuse pattern of the cardinality pools, the first values recurring the most
+const cardinalityZipfS = 1.1
+
+// cardinalityPool is the fixed pool of distinct values of a field with cardinality_pool
+type cardinalityPool struct {
+	values []any
+	// zipf samples the values with the zipf reuse pattern, uniformly when nil
+	zipf *rand.Zipf
+}
+
+// makeCardinalityPoolFunc returns a function sampling a value from the pool of the field in the state,
+// generating the distinct values of the pool through generate on first use
+func makeCardinalityPoolFunc(fieldCfg ConfigField, field Field) (func(state *GenState, generate func() (any, error)) (any, error), error) {
+	size := fieldCfg.CardinalityPool
+	reusePattern := fieldCfg.CardinalityReusePattern
+	if reusePattern != "" && reusePattern != config.DistributionUniform && reusePattern != config.DistributionZipf {
+		return nil, fmt.Errorf("%w: %q", notValidCardinalityReusePattern, reusePattern)
+	}
+
+	return func(state *GenState, generate func() (any, error)) (any, error) {
+		pool, ok := state.cardinalityPools[field.Name]
+		if !ok {
+			values, err := generateDistinctValues(size, generate)
+			if err != nil {
+				return nil, err
+			}
 
-		// Safety check; should be a noop
-		if idx >= len(state.prevCacheCardinality[field.Name]) {
-			idx = len(state.prevCacheCardinality[field.Name]) - 1
+			pool = &cardinalityPool{values: values}
+			state.cardinalityPools[field.Name] = pool
 		}
 
-		choice := state.prevCacheCardinality[field.Name][idx].([]byte)
-		buf.Write(choice)
-		return nil
+		if reusePattern == config.DistributionZipf {
+			if pool.zipf == nil {
+				pool.zipf = rand.NewZipf(state.rand, cardinalityZipfS, 1, uint64(len(pool.values)-1))
+			}
+
+			return pool.values[pool.zipf.Uint64()], nil
+		}
+
+		return pool.values[state.rand.Intn(len(pool.values))], nil
+	}, nil
+}
+
+// generateDistinctValues returns size values generated through generate, distinct as far as the values they are generated from allow
+func generateDistinctValues(size int, generate func() (any, error)) ([]any, error) {
+	values := make([]any, 0, size)
+	seen := make(map[any]struct{}, size)
+
+	// Do college try dupe detection on each value;
+	// Allow dupe if no unique value in nTries.
+	nTries := 11 // "These go to 11."
+	for len(values) < size {
+		var value any
+		for i := 0; i < nTries; i++ {
+			var err error
+			if value, err = generate(); err != nil {
+				return nil, err
+			}
+
+			if _, ok := seen[value]; !ok {
+				break
+			}
+		}
+
+		seen[value] = struct{}{}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func bindCardinalityPool(cfg Config, field Field, fieldMap map[string]any) error {
+	fieldCfg, _ := cfg.GetField(field.Name)
+
+	poolFunc, err := makeCardinalityPoolFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	// Go ahead and bind the original field
+	if err := bindByType(cfg, field, fieldMap); err != nil {
+		return err
+	}
+
+	// We will wrap the function we just generated
+	boundF, ok := fieldMap[field.Name].(emitFNotReturn)
+	if !ok {
+		return errors.New("cannot bind cardinality pool")
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		value, err := poolFunc(state, func() (any, error) {
+			var tmp bytes.Buffer
+			if err := boundF(state, &tmp); err != nil {
+				return nil, err
+			}
+
+			return tmp.String(), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = buf.WriteString(value.(string))
+		return err
 	}
 
 	fieldMap[field.Name] = emitFNotReturn
@@ -673,7 +2495,7 @@ func bindCardinality(cfg Config, field Field, fieldMap map[string]any) error {
 }
 
 func makeDynamicStub(boundF any) emitFNotReturn {
-	return func(state *GenState, buf *bytes.Buffer) error {
+	return func(state *GenState, buf writer) error {
 		v := state.pool.Get()
 		tmp := v.(*bytes.Buffer)
 		tmp.Reset()
@@ -702,15 +2524,11 @@ func makeDynamicStubWithReturn(boundF any) EmitF {
 	}
 }
 
-func bindConstantKeywordWithReturn(field Field, fieldMap map[string]any) error {
+func bindConstantKeywordWithReturn(seed int64, field Field, fieldMap map[string]any) error {
+	value := constantKeywordValue(seed, field)
+
 	var emitF EmitF
 	emitF = func(state *GenState) any {
-		value, ok := state.prevCache[field.Name].(string)
-		if !ok {
-			// randomdata.Adjective() + randomdata.Noun() -> 364 * 527 (~190k) different values
-			value = randomdata.Adjective() + randomdata.Noun()
-			state.prevCache[field.Name] = value
-		}
 		return value
 	}
 
@@ -719,13 +2537,62 @@ func bindConstantKeywordWithReturn(field Field, fieldMap map[string]any) error {
 }
 
 func bindKeywordWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
-	if len(fieldCfg.Enum) > 0 {
+	if len(fieldCfg.CountryRegion) > 0 {
+		return bindCountryRegionWithReturn(fieldCfg, field, fieldMap)
+	}
+
+	if len(fieldCfg.Pattern) > 0 {
+		patternFunc, err := compilePattern(fieldCfg.Pattern)
+		if err != nil {
+			return err
+		}
+
+		var emitF EmitF
+		emitF = func(state *GenState) any {
+			v := make([]byte, 0, 32)
+			return string(patternFunc(state.rand, v))
+		}
+
+		fieldMap[field.Name] = emitF
+	} else if len(fieldCfg.ValuesFile) > 0 {
+		values, err := loadValuesFile(fieldCfg.ValuesFile)
+		if err != nil {
+			return err
+		}
+
+		var emitF EmitF
+		emitF = func(state *GenState) any {
+			return values[state.rand.Intn(len(values))]
+		}
+
+		fieldMap[field.Name] = emitF
+	} else if len(fieldCfg.WeightedEnum) > 0 {
+		weightedIndex := makeWeightedIndexFunc(fieldCfg.WeightedEnum)
+		var emitF EmitF
+		emitF = func(state *GenState) any {
+			return fieldCfg.WeightedEnum[weightedIndex(state.rand)].Value
+		}
+
+		fieldMap[field.Name] = emitF
+	} else if len(fieldCfg.Enum) > 0 {
 		var emitF EmitF
 		emitF = func(state *GenState) any {
-			idx := rand.Intn(len(fieldCfg.Enum))
+			idx := state.rand.Intn(len(fieldCfg.Enum))
 			return fieldCfg.Enum[idx]
 		}
 
+		fieldMap[field.Name] = emitF
+	} else if fieldCfg.Length != nil || len(fieldCfg.Charset) > 0 {
+		charsetFunc, err := makeKeywordCharsetFunc(fieldCfg)
+		if err != nil {
+			return err
+		}
+
+		var emitF EmitF
+		emitF = func(state *GenState) any {
+			return charsetFunc(state.rand)
+		}
+
 		fieldMap[field.Name] = emitF
 	} else if len(field.Example) > 0 {
 
@@ -746,8 +2613,8 @@ func bindKeywordWithReturn(fieldCfg ConfigField, field Field, fieldMap map[strin
 	} else {
 		var emitF EmitF
 		emitF = func(state *GenState) any {
-			// randomdata.Adjective() + randomdata.Noun() -> 364 * 527 (~190k) different values
-			return randomdata.Adjective() + randomdata.Noun()
+			// adjective + noun -> 527 * 364 (~190k) different values
+			return randomAdjective(state.rand) + randomNoun(state.rand)
 		}
 
 		fieldMap[field.Name] = emitF
@@ -755,17 +2622,69 @@ func bindKeywordWithReturn(fieldCfg ConfigField, field Field, fieldMap map[strin
 	return nil
 }
 
+func bindTextWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	textFunc := makeTextFunc(fieldCfg)
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return textFunc(state.rand)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+func bindWildcardWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if hasKeywordValuesConfig(fieldCfg) {
+		return bindKeywordWithReturn(fieldCfg, field, fieldMap)
+	}
+
+	wildcardFunc := makeWildcardFunc(fieldCfg)
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return wildcardFunc(state.rand)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+func bindSemverWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	semverFunc := makeSemverFunc(fieldCfg.Semver)
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return semverFunc(state.rand)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+func bindBinaryWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	binaryFunc := makeBinaryFunc(binaryLength(fieldCfg))
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return binaryFunc(state.rand)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
 func bindJoinRandWithReturn(field Field, N int, joiner string, fieldMap map[string]any) error {
 	var emitF EmitF
 	emitF = func(state *GenState) any {
 		value := ""
 		for i := 0; i < N-1; i++ {
-			value += randomdata.Noun() + joiner
+			value += randomNoun(state.rand) + joiner
 		}
 
-		// randomdata.Adjective() + randomdata.Noun() -> 364 * 527 (~190k) different values
-		value += randomdata.Adjective()
-		value += randomdata.Noun()
+		// adjective + noun -> 527 * 364 (~190k) different values
+		value += randomAdjective(state.rand)
+		value += randomNoun(state.rand)
 
 		return value
 	}
@@ -784,25 +2703,34 @@ func bindStaticWithReturn(field Field, v any, fieldMap map[string]any) error {
 	return nil
 }
 
-func bindBoolWithReturn(field Field, fieldMap map[string]any) error {
+func bindBoolWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	p := trueProbability(fieldCfg)
+
 	var emitF EmitF
 	emitF = func(state *GenState) any {
-		switch rand.Int() % 2 {
-		case 0:
-			return false
-		default:
-			return true
-		}
+		return state.rand.Float64() < p
 	}
 
 	fieldMap[field.Name] = emitF
 	return nil
 }
 
-func bindGeoPointWithReturn(field Field, fieldMap map[string]any) error {
+func bindGeoPointWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
 	var emitF EmitF
-	emitF = func(state *GenState) any {
-		return randGeoPointWithReturn()
+	geoPointFunc, err := makeGeoPointFunc(fieldCfg.GeoPoint)
+	if err != nil {
+		return err
+	}
+
+	if geoPointFunc != nil {
+		emitF = func(state *GenState) any {
+			lat, lon := geoPointFunc(state.rand)
+			return string(appendGeoPoint(nil, fieldCfg.GeoPoint.Format, lat, lon))
+		}
+	} else {
+		emitF = func(state *GenState) any {
+			return randGeoPointWithReturn(state.rand)
+		}
 	}
 
 	fieldMap[field.Name] = emitF
@@ -813,33 +2741,77 @@ func bindGeoPointWithReturn(field Field, fieldMap map[string]any) error {
 func bindWordNWithReturn(field Field, n int, fieldMap map[string]any) error {
 	var emitF EmitF
 	emitF = func(state *GenState) any {
-		return genNounsNWithReturn(rand.Intn(n))
+		return genNounsNWithReturn(state.rand, state.rand.Intn(n))
 	}
 	fieldMap[field.Name] = emitF
 	return nil
 }
 
-func bindNearTimeWithReturn(field Field, fieldMap map[string]any) error {
+func bindNearTimeWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	location, err := loadTimezone(fieldCfg.Timezone)
+	if err != nil {
+		return err
+	}
+
+	// without a format the dates are returned as they are, for the template to format them
+	formatFunc := func(t time.Time) any { return t }
+	if len(fieldCfg.Format) > 0 {
+		formatFunc, err = makeDateFormatFunc(fieldCfg.Format)
+		if err != nil {
+			return err
+		}
+	}
+
 	var emitF EmitF
 	emitF = func(state *GenState) any {
-		offset := time.Duration(rand.Intn(FieldTypeTimeRange)*-1) * time.Second
-		newTime := time.Now().Add(offset)
+		offset := time.Duration(state.rand.Intn(FieldTypeTimeRange)*-1) * time.Second
+		newTime := time.Now().Add(offset).In(location)
 
-		return newTime
+		return formatFunc(newTime)
 	}
 	fieldMap[field.Name] = emitF
 	return nil
 }
 
-func bindIPWithReturn(field Field, fieldMap map[string]any) error {
+func bindIPWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	ipFunc, err := makeIPFunc(fieldCfg, field.Type)
+	if err != nil {
+		return err
+	}
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return ipFunc(state.rand).String()
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+func bindMACWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	macFunc, err := makeMACFunc(fieldCfg.OUI, fieldCfg.LocallyAdministered)
+	if err != nil {
+		return err
+	}
+
 	var emitF EmitF
 	emitF = func(state *GenState) any {
-		i0 := rand.Intn(255)
-		i1 := rand.Intn(255)
-		i2 := rand.Intn(255)
-		i3 := rand.Intn(255)
+		return macFunc(state.rand).String()
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+func bindHTTPStatusWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	httpStatusFunc, err := makeHTTPStatusFunc(fieldCfg.WeightedEnum)
+	if err != nil {
+		return err
+	}
 
-		return fmt.Sprintf("%d.%d.%d.%d", i0, i1, i2, i3)
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return httpStatusFunc(state.rand)
 	}
 
 	fieldMap[field.Name] = emitF
@@ -852,7 +2824,7 @@ func bindLongWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]a
 	if fieldCfg.Fuzziness <= 0 {
 		var emitF EmitF
 		emitF = func(state *GenState) any {
-			return dummyFunc()
+			return dummyFunc(state)
 		}
 
 		fieldMap[field.Name] = emitF
@@ -864,9 +2836,9 @@ func bindLongWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]a
 
 	var emitF EmitF
 	emitF = func(state *GenState) any {
-		dummyInt := dummyFunc()
+		dummyInt := dummyFunc(state)
 		if previousDummyInt, ok := state.prevCache[field.Name].(int64); ok {
-			dummyInt = fuzzyInt(previousDummyInt, fieldCfg.Fuzziness, min, max)
+			dummyInt = fuzzyInt(state.rand, previousDummyInt, fieldCfg.Fuzziness, min, max)
 		}
 		state.prevCache[field.Name] = dummyInt
 		return dummyInt
@@ -876,13 +2848,39 @@ func bindLongWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]a
 	return nil
 }
 
+func bindUnsignedLongWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	dummyFunc, err := makeUnsignedLongFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	min, _ := fieldCfg.Range.MinAsUint64()
+	max, _ := fieldCfg.Range.MaxAsUint64()
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		dummyUint := dummyFunc(state)
+		if fieldCfg.Fuzziness > 0 {
+			if previousDummyUint, ok := state.prevCache[field.Name].(uint64); ok {
+				dummyUint = fuzzyUint64(state.rand, previousDummyUint, fieldCfg.Fuzziness, min, max)
+			}
+			state.prevCache[field.Name] = dummyUint
+		}
+
+		return dummyUint
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
 func bindDoubleWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
 	dummyFunc := makeFloatFunc(fieldCfg, field)
 
 	if fieldCfg.Fuzziness <= 0 {
 		var emitF EmitF
 		emitF = func(state *GenState) any {
-			return dummyFunc()
+			return dummyFunc(state)
 		}
 
 		fieldMap[field.Name] = emitF
@@ -895,9 +2893,9 @@ func bindDoubleWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string
 
 	var emitF EmitF
 	emitF = func(state *GenState) any {
-		dummyFloat := dummyFunc()
+		dummyFloat := dummyFunc(state)
 		if previousDummyFloat, ok := state.prevCache[field.Name].(float64); ok {
-			dummyFloat = fuzzyFloat(previousDummyFloat, fieldCfg.Fuzziness, min, max)
+			dummyFloat = fuzzyFloat(state.rand, previousDummyFloat, fieldCfg.Fuzziness, min, max)
 		}
 		state.prevCache[field.Name] = dummyFloat
 		return dummyFloat
@@ -908,10 +2906,57 @@ func bindDoubleWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string
 	return nil
 }
 
+func bindScaledFloatWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if fieldCfg.ScalingFactor <= 0 {
+		return bindDoubleWithReturn(fieldCfg, field, fieldMap)
+	}
+
+	scaledFloatFunc := makeScaledFloatFunc(fieldCfg, field)
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return scaledFloatFunc(state)
+	}
+
+	fieldMap[field.Name] = emitF
+
+	return nil
+}
+
+func bindHalfFloatWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	halfFloatFunc := makeHalfFloatFunc(fieldCfg, field)
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return halfFloatFunc(state)
+	}
+
+	fieldMap[field.Name] = emitF
+
+	return nil
+}
+
+func bindRangeWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	rangeFunc, err := makeRangeFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		gte, lt := rangeFunc(state.rand)
+		return map[string]any{"gte": gte, "lt": lt}
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
 func bindCardinalityWithReturn(cfg Config, field Field, fieldMap map[string]any) error {
 
 	fieldCfg, _ := cfg.GetField(field.Name)
 	cardinality := int(math.Ceil((float64(fieldCfg.Cardinality.Denominator) / float64(fieldCfg.Cardinality.Numerator))))
+	window := cfg.CardinalityWindow
 
 	if strings.HasSuffix(field.Name, ".*") {
 		field.Name = replacer.Replace(field.Name)
@@ -926,34 +2971,56 @@ func bindCardinalityWithReturn(cfg Config, field Field, fieldMap map[string]any)
 	boundFWithReturn := fieldMap[field.Name].(EmitF)
 	var emitF EmitF
 	emitF = func(state *GenState) any {
-		var value any
-		// Have we rolled over once?  If not, generate a value and cache it.
-		if len(state.prevCacheCardinality[field.Name]) < cardinality {
+		idx := int(state.counter % uint64(cardinality))
+		cache := state.cardinalityCache(field.Name, window)
+
+		// Is the value at idx in the pool?  If not, generate a value and cache it.
+		value, ok := cache.get(idx)
+		if !ok {
 			// Do college try dupe detection on value;
 			// Allow dupe if no unique value in nTries.
 			nTries := 11 // "These go to 11."
 			for i := 0; i < nTries; i++ {
 				value = boundFWithReturn(state)
 
-				if !isDupeAny(state.prevCacheForDup[field.Name], value) {
+				if !cache.contains(value) {
 					break
 				}
 			}
 
-			state.prevCacheForDup[field.Name][value] = struct{}{}
-			state.prevCacheCardinality[field.Name] = append(state.prevCacheCardinality[field.Name], value)
+			cache.add(idx, value)
 		}
 
-		idx := int(state.counter % uint64(cardinality))
+		return value
+	}
 
-		// Safety check; should be a noop
-		if idx >= len(state.prevCacheCardinality[field.Name]) {
-			idx = len(state.prevCacheCardinality[field.Name]) - 1
-		}
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+func bindCardinalityPoolWithReturn(cfg Config, field Field, fieldMap map[string]any) error {
+	fieldCfg, _ := cfg.GetField(field.Name)
+
+	poolFunc, err := makeCardinalityPoolFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	// Go ahead and bind the original field
+	if err := bindByTypeWithReturn(cfg, field, fieldMap); err != nil {
+		return err
+	}
 
-		choice := state.prevCacheCardinality[field.Name][idx]
+	// We will wrap the function we just generated
+	boundFWithReturn := fieldMap[field.Name].(EmitF)
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		// the generation of the values with return never fails
+		value, _ := poolFunc(state, func() (any, error) {
+			return boundFWithReturn(state), nil
+		})
 
-		return choice
+		return value
 	}
 
 	fieldMap[field.Name] = emitF
@@ -961,6 +3028,18 @@ func bindCardinalityWithReturn(cfg Config, field Field, fieldMap map[string]any)
 }
 
 func bindObjectWithReturn(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if len(field.Fields) > 0 {
+		return bindNestedWithReturn(cfg, fieldCfg, field, fieldMap)
+	}
+
+	if isFlattenedObject(fieldCfg, field) {
+		return bindFlattenedWithReturn(fieldCfg, field, fieldMap)
+	}
+
+	if fieldCfg.DynamicKeys != nil {
+		return bindDynamicKeysWithReturn(cfg, fieldCfg, field, fieldMap)
+	}
+
 	if len(field.ObjectType) > 0 {
 		field.Type = field.ObjectType
 	} else {
@@ -999,6 +3078,89 @@ func bindDynamicObjectWithReturn(cfg Config, field Field, fieldMap map[string]an
 	return nil
 }
 
+func bindNestedWithReturn(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	subFieldsMap := make(map[string]any)
+	keys := make([]string, 0, len(field.Fields))
+	emitFs := make([]EmitF, 0, len(field.Fields))
+	for _, subField := range field.Fields {
+		keys = append(keys, subField.Name)
+		subField.Name = field.Name + "." + subField.Name
+
+		if err := bindField(cfg, subField, subFieldsMap, true); err != nil {
+			return err
+		}
+
+		emitFs = append(emitFs, subFieldsMap[subField.Name].(EmitF))
+	}
+
+	documentsFunc := makeLengthFunc(nestedDocuments(fieldCfg))
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		documents := make([]map[string]any, documentsFunc(state.rand))
+		for i := range documents {
+			document := make(map[string]any, len(keys))
+			for j, emitF := range emitFs {
+				// a null sub-field is omitted from the sub-document
+				if value := emitF(state); value != nil {
+					document[keys[j]] = value
+				}
+			}
+
+			documents[i] = document
+		}
+
+		return documents
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+func bindDynamicKeysWithReturn(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	keysFunc, err := makeDynamicKeysFunc(*fieldCfg.DynamicKeys)
+	if err != nil {
+		return err
+	}
+
+	valueField := dynamicKeysValueField(field)
+	valueMap := make(map[string]any)
+	if err := bindByTypeWithReturn(cfg, valueField, valueMap); err != nil {
+		return err
+	}
+
+	valueF := valueMap[valueField.Name].(EmitF)
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		keys := keysFunc(state.rand)
+		object := make(map[string]any, len(keys))
+		for _, key := range keys {
+			object[key] = valueF(state)
+		}
+
+		return object
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+func bindFlattenedWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	flattenedFunc, err := makeFlattenedFunc(fieldCfg)
+	if err != nil {
+		return err
+	}
+
+	var emitF EmitF
+	emitF = func(state *GenState) any {
+		return flattenedToMap(flattenedFunc(state.rand))
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
 func unmarshalJSONT[T any](t *testing.T, data []byte) map[string]T {
 	m := make(map[string]T)
 	if err := json.Unmarshal(data, &m); err != nil {
@@ -3,6 +3,10 @@ package genlib
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
@@ -333,3 +337,317 @@ func Benchmark_GeneratorTextTemplateVPCFlowLogs(b *testing.B) {
 		buf.Reset()
 	}
 }
+
+func benchmarkEmitVsEmitToFields() Fields {
+	return Fields{
+		{
+			Name: "SrcAddr",
+			Type: FieldTypeIP,
+		},
+		{
+			Name: "SrcPort",
+			Type: FieldTypeLong,
+		},
+		{
+			Name:    "InterfaceID",
+			Type:    FieldTypeKeyword,
+			Example: "eni-1235b8ca123456789",
+		},
+		{
+			Name: "End",
+			Type: FieldTypeDate,
+		},
+	}
+}
+
+func benchmarkEmit(b *testing.B, g Generator) {
+	var buf bytes.Buffer
+
+	state := NewGenState()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := g.Emit(state, &buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, &buf)
+		buf.Reset()
+	}
+}
+
+func benchmarkEmitTo(b *testing.B, g Generator) {
+	state := NewGenState()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := g.EmitTo(state, io.Discard)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_GeneratorCustomTemplateEmit(b *testing.B) {
+	template := []byte(`{{.SrcAddr}}:{{.SrcPort}} {{.InterfaceID}} {{.End}}`)
+	g, err := NewGeneratorWithCustomTemplate(template, Config{}, benchmarkEmitVsEmitToFields(), uint64(len(template)*b.N*1024))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer func() {
+		_ = g.Close()
+	}()
+
+	benchmarkEmit(b, g)
+}
+
+func Benchmark_GeneratorCustomTemplateEmitTo(b *testing.B) {
+	template := []byte(`{{.SrcAddr}}:{{.SrcPort}} {{.InterfaceID}} {{.End}}`)
+	g, err := NewGeneratorWithCustomTemplate(template, Config{}, benchmarkEmitVsEmitToFields(), uint64(len(template)*b.N*1024))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer func() {
+		_ = g.Close()
+	}()
+
+	benchmarkEmitTo(b, g)
+}
+
+func Benchmark_GeneratorTextTemplateEmit(b *testing.B) {
+	template := []byte(`{{generate "SrcAddr"}}:{{generate "SrcPort"}} {{generate "InterfaceID"}} {{generate "End"}}`)
+	g, err := NewGeneratorWithTextTemplate(template, Config{}, benchmarkEmitVsEmitToFields(), uint64(len(template)*b.N*1024))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer func() {
+		_ = g.Close()
+	}()
+
+	benchmarkEmit(b, g)
+}
+
+func Benchmark_GeneratorTextTemplateEmitTo(b *testing.B) {
+	template := []byte(`{{generate "SrcAddr"}}:{{generate "SrcPort"}} {{generate "InterfaceID"}} {{generate "End"}}`)
+	g, err := NewGeneratorWithTextTemplate(template, Config{}, benchmarkEmitVsEmitToFields(), uint64(len(template)*b.N*1024))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer func() {
+		_ = g.Close()
+	}()
+
+	benchmarkEmitTo(b, g)
+}
+
+func Benchmark_GeneratorCustomTemplateEmitWithNewBuffer(b *testing.B) {
+	template := []byte(`{{.SrcAddr}}:{{.SrcPort}} {{.InterfaceID}} {{.End}}`)
+	g, err := NewGeneratorWithCustomTemplate(template, Config{}, benchmarkEmitVsEmitToFields(), uint64(len(template)*b.N*1024))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer func() {
+		_ = g.Close()
+	}()
+
+	state := NewGenState()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf := new(bytes.Buffer)
+		err := g.Emit(state, buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, buf)
+	}
+}
+
+func Benchmark_GeneratorCustomTemplateEmitWithPooledBuffer(b *testing.B) {
+	template := []byte(`{{.SrcAddr}}:{{.SrcPort}} {{.InterfaceID}} {{.End}}`)
+	g, err := NewGeneratorWithCustomTemplate(template, Config{}, benchmarkEmitVsEmitToFields(), uint64(len(template)*b.N*1024))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer func() {
+		_ = g.Close()
+	}()
+
+	state := NewGenState()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf := GetBuffer()
+		err := g.Emit(state, buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, buf)
+		PutBuffer(buf)
+	}
+}
+
+// countingWriter counts the writes of the emitted events
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes += 1
+	return w.Buffer.Write(p)
+}
+
+func (w *countingWriter) WriteByte(c byte) error {
+	w.writes += 1
+	return w.Buffer.WriteByte(c)
+}
+
+func (w *countingWriter) WriteString(s string) (int, error) {
+	w.writes += 1
+	return w.Buffer.WriteString(s)
+}
+
+func Benchmark_GeneratorCustomTemplateStaticPrefixes(b *testing.B) {
+	// 50 fields, every other one with a static value
+	var flds Fields
+	var configYaml, template strings.Builder
+	template.WriteString("{")
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("field%d", i)
+		flds = append(flds, Field{Name: name, Type: FieldTypeLong})
+		if i%2 == 0 {
+			fmt.Fprintf(&configYaml, "- name: %s\n  value: %d\n", name, i)
+		}
+
+		if i > 0 {
+			template.WriteString(",")
+		}
+
+		fmt.Fprintf(&template, `"%s":{{.%s}}`, name, name)
+	}
+	template.WriteString("}")
+
+	cfg, err := config.LoadConfigFromYaml([]byte(configYaml.String()))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	emitters, trailingTemplate, err := bindCustomTemplateEmitters([]byte(template.String()), cfg, flds)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	coalesced, err := NewGeneratorWithCustomTemplateN([]byte(template.String()), cfg, flds, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bc := range []struct {
+		name string
+		gen  *GeneratorWithCustomTemplate
+	}{
+		{name: "uncoalesced", gen: &GeneratorWithCustomTemplate{emitters: emitters, trailingTemplate: trailingTemplate}},
+		{name: "coalesced", gen: coalesced},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var w countingWriter
+
+			state := NewGenState()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := bc.gen.EmitTo(state, &w); err != nil {
+					b.Fatal(err)
+				}
+				w.Reset()
+			}
+
+			b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+		})
+	}
+}
+
+func Benchmark_ParseCustomTemplate(b *testing.B) {
+	for _, template := range [][]byte{
+		[]byte(`{"static":"event"}`),
+		[]byte(`{{.SrcAddr}}:{{.SrcPort}} {{.InterfaceID}} {{.End}}`),
+	} {
+		b.Run(string(template), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				parseCustomTemplate(template)
+			}
+		})
+	}
+}
+
+func Test_NewGenerator(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  enum: [\"a\"]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templates := map[GeneratorKind][]byte{
+		GeneratorKindCustomTemplate: []byte(`{"alpha":"{{.alpha}}"}`),
+		GeneratorKindTextTemplate:   []byte(`{"alpha":"{{generate "alpha"}}"}`),
+	}
+
+	for kind, template := range templates {
+		for _, tpl := range [][]byte{template, nil} {
+			g, err := NewGenerator(kind, tpl, cfg, flds, 10*uint64(len(`{"alpha":"a"}`)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			state := NewGenState()
+
+			var events int
+			var buf bytes.Buffer
+			for {
+				buf.Reset()
+				err := g.Emit(state, &buf)
+				if err == io.EOF {
+					break
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if m := unmarshalJSONT[string](t, buf.Bytes()); m["alpha"] != "a" {
+					t.Fatalf("expected alpha to be a, got %s", buf.String())
+				}
+
+				events++
+				if events > 100 {
+					t.Fatalf("expected generator of kind %d with template %q to reach io.EOF", kind, tpl)
+				}
+			}
+
+			if events == 0 {
+				t.Fatalf("expected generator of kind %d with template %q to emit events before io.EOF", kind, tpl)
+			}
+
+			if err := g.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func Test_NewGeneratorKindNotValid(t *testing.T) {
+	if _, err := NewGenerator(GeneratorKind(-1), nil, Config{}, Fields{}, 0); !errors.Is(err, notValidGeneratorKind) {
+		t.Fatalf("expected error on not valid generator kind, got %v", err)
+	}
+}
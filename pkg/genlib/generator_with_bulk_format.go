@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// GeneratorWithBulkFormat wraps a Generator emitting each event preceded by an Elasticsearch _bulk create action line
+type GeneratorWithBulkFormat struct {
+	gen Generator
+	// indexTpl is nil when the index name is static
+	indexTpl    *template.Template
+	staticIndex []byte
+	outputs     *outputs
+}
+
+// NewGeneratorWithBulkFormat returns a GeneratorWithBulkFormat wrapping gen.
+// The index name is a go text/template supporting the sprig functions, for example `logs-{{ now | date "2006.01.02" }}`.
+func NewGeneratorWithBulkFormat(gen Generator, index string) (*GeneratorWithBulkFormat, error) {
+	bulkGen := &GeneratorWithBulkFormat{
+		gen:     gen,
+		outputs: newOutputs(),
+	}
+
+	if !strings.Contains(index, "{{") {
+		bulkGen.staticIndex = bulkActionLine(index)
+		return bulkGen, nil
+	}
+
+	indexTpl, err := template.New("bulk_index").Funcs(sprig.TxtFuncMap()).Parse(index)
+	if err != nil {
+		return nil, err
+	}
+
+	bulkGen.indexTpl = indexTpl
+
+	return bulkGen, nil
+}
+
+func bulkActionLine(index string) []byte {
+	// json encoding a string never fails
+	quotedIndex, _ := json.Marshal(index)
+
+	actionLine := make([]byte, 0, len(quotedIndex)+24)
+	actionLine = append(actionLine, `{"create":{"_index":`...)
+	actionLine = append(actionLine, quotedIndex...)
+	actionLine = append(actionLine, "}}\n"...)
+
+	return actionLine
+}
+
+// Close closes the wrapped generator and finalizes the writers the events were streamed to with EmitTo, returning the first error
+func (gen GeneratorWithBulkFormat) Close() error {
+	err := gen.gen.Close()
+	if outputsErr := gen.outputs.close(); err == nil {
+		err = outputsErr
+	}
+
+	return err
+}
+
+// Emit generates the action line and the event in buf, each followed by a new line
+func (gen GeneratorWithBulkFormat) Emit(state *GenState, buf *bytes.Buffer) error {
+	return gen.EmitTo(state, buf)
+}
+
+// EmitTo generates the action line and the event streaming them to w, each followed by a new line
+func (gen GeneratorWithBulkFormat) EmitTo(state *GenState, w io.Writer) error {
+	// The event is generated first so that nothing is written to w on io.EOF
+	eventBuf := GetBuffer()
+	defer PutBuffer(eventBuf)
+
+	if err := gen.gen.EmitTo(state, eventBuf); err != nil {
+		return err
+	}
+
+	actionLine := gen.staticIndex
+	if gen.indexTpl != nil {
+		var index strings.Builder
+		if err := gen.indexTpl.Execute(&index, nil); err != nil {
+			return err
+		}
+
+		actionLine = bulkActionLine(index.String())
+	}
+
+	if _, err := w.Write(actionLine); err != nil {
+		return err
+	}
+
+	// events already terminated by a new line, like in NDJSON, are left untouched
+	if !bytes.HasSuffix(eventBuf.Bytes(), []byte{'\n'}) {
+		eventBuf.WriteByte('\n')
+	}
+
+	if _, err := w.Write(eventBuf.Bytes()); err != nil {
+		return err
+	}
+
+	gen.outputs.add(w)
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_BulkFormat(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+	t.Logf("with template: %s", string(template))
+
+	testCases := []struct {
+		index         string
+		expectedIndex string
+	}{
+		{
+			index:         "logs-generic-default",
+			expectedIndex: "logs-generic-default",
+		},
+		{
+			index:         `logs-{{ now | date "2006.01.02" }}`,
+			expectedIndex: "logs-" + time.Now().Format("2006.01.02"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		g, err := NewGeneratorWithCustomTemplateN(template, Config{}, flds, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bulkGen, err := NewGeneratorWithBulkFormat(g, testCase.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		state := NewGenState()
+		for {
+			err := bulkGen.Emit(state, &buf)
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		content := buf.String()
+		if !strings.HasSuffix(content, "}\n") {
+			t.Errorf("expected a trailing new line on the final line, got %q", content)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+		if len(lines) != 20 {
+			t.Fatalf("expected 20 lines, got %d", len(lines))
+		}
+
+		for i := 0; i < len(lines); i += 2 {
+			var action map[string]map[string]string
+			if err := json.Unmarshal([]byte(lines[i]), &action); err != nil {
+				t.Fatalf("expected action line, got %q: %s", lines[i], err)
+			}
+
+			if action["create"]["_index"] != testCase.expectedIndex {
+				t.Errorf("expected index %s, got %s", testCase.expectedIndex, action["create"]["_index"])
+			}
+
+			doc := unmarshalJSONT[any](t, []byte(lines[i+1]))
+			if _, ok := doc["alpha"]; !ok {
+				t.Errorf("expected document line, got %q", lines[i+1])
+			}
+		}
+	}
+}
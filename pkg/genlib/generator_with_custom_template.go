@@ -6,15 +6,22 @@ package genlib
 
 import (
 	"bytes"
+	"fmt"
 	"io"
-	"regexp"
 )
 
 type emitter struct {
-	fieldName string
-	fieldType string
-	emitFunc  emitFNotReturn
-	prefix    []byte
+	fieldName       string
+	fieldType       string
+	emitFunc        emitFNotReturn
+	prefix          []byte
+	nullProbability float64
+	// nullPrefixLen is the length of the prefix emitted with a null value, up to its last bracket out of JSON strings or
+	// to the quote closing the preceding value, and quoted tells whether the value is within a JSON string
+	nullPrefixLen int
+	quoted        bool
+	// static emitters emit the same value in every event
+	static bool
 }
 
 // GeneratorWithCustomTemplate is resolved at construction to a slice of emit functions
@@ -22,160 +29,464 @@ type GeneratorWithCustomTemplate struct {
 	totEvents        uint64
 	emitters         []emitter
 	trailingTemplate []byte
+	seed             int64
+	ndjson           bool
+	prettyJSON       bool
+	validateJSON     bool
 	state            *GenState
+	outputs          *outputs
 }
 
-func parseCustomTemplate(template []byte) ([]string, map[string][]byte, []byte) {
+var (
+	placeholderOpen  = []byte("{{.")
+	placeholderClose = []byte("}}")
+)
+
+// parseCustomTemplate splits the template in the ordered placeholders field names, the literal prefix preceding each of them
+// and the literal trailing template. Any brace not part of a `{{.field}}` placeholder is preserved verbatim.
+// Prefixes are positional, so that a field repeated in the template keeps the prefix of each of its placeholders,
+// and the trailing template is nil when the template ends with a placeholder.
+func parseCustomTemplate(template []byte) ([]string, [][]byte, []byte) {
 	if len(template) == 0 {
 		return nil, nil, nil
 	}
 
-	tokenizer := regexp.MustCompile(`([^{]*)({{\.[^}]+}})*`)
-	allIndexes := tokenizer.FindAllSubmatchIndex(template, -1)
+	// a template without placeholders is all trailing template, with nothing else to allocate
+	if !bytes.Contains(template, placeholderOpen) {
+		return nil, nil, template
+	}
 
-	orderedFields := make([]string, 0, len(allIndexes))
-	templateFieldsMap := make(map[string][]byte, len(allIndexes))
+	orderedFields := make([]string, 0)
+	prefixes := make([][]byte, 0)
 
-	var fieldPrefixBuffer []byte
-	var fieldPrefixPreviousN int
-	var trimTrailingTemplateN int
+	var prefixStart, pos int
+	for {
+		placeholderStart := bytes.Index(template[pos:], placeholderOpen)
+		if placeholderStart < 0 {
+			break
+		}
 
-	for i, loc := range allIndexes {
-		var fieldName []byte
-		var fieldPrefix []byte
+		placeholderStart += pos
+		fieldNameStart := placeholderStart + len(placeholderOpen)
 
-		if loc[4] > -1 && loc[5] > -1 {
-			fieldName = template[loc[4]+3 : loc[5]-2]
+		fieldNameEnd := bytes.Index(template[fieldNameStart:], placeholderClose)
+		if fieldNameEnd < 0 {
+			break
 		}
 
-		if loc[2] > -1 && loc[3] > -1 {
-			fieldPrefix = template[loc[2]:loc[3]]
-		}
+		fieldNameEnd += fieldNameStart
 
-		if len(fieldName) == 0 {
-			if template[fieldPrefixPreviousN] == byte(123) {
-				fieldPrefixBuffer = append(fieldPrefixBuffer, byte(123))
-			} else {
-				if i == len(allIndexes)-1 {
-					fieldPrefixBuffer = template[trimTrailingTemplateN:]
-				} else {
-					fieldPrefixBuffer = append(fieldPrefixBuffer, fieldPrefix...)
-					fieldPrefixBufferIdx := bytes.Index(template[trimTrailingTemplateN:], fieldPrefixBuffer)
-					if fieldPrefixBufferIdx > 0 {
-						trimTrailingTemplateN += fieldPrefixBufferIdx
-					}
+		fieldName := template[fieldNameStart:fieldNameEnd]
+		if len(fieldName) == 0 || bytes.ContainsAny(fieldName, "{}") {
+			// Not a placeholder: the opening brace is part of the literal text
+			pos = placeholderStart + 1
+			continue
+		}
 
-				}
-			}
-		} else {
-			fieldPrefixBuffer = append(fieldPrefixBuffer, fieldPrefix...)
-			trimTrailingTemplateN = loc[5]
-			templateFieldsMap[string(fieldName)] = fieldPrefixBuffer
-			orderedFields = append(orderedFields, string(fieldName))
-			fieldPrefixBuffer = nil
+		var fieldPrefix []byte
+		if placeholderStart > prefixStart {
+			fieldPrefix = template[prefixStart:placeholderStart]
 		}
 
-		fieldPrefixPreviousN = loc[2]
+		prefixes = append(prefixes, fieldPrefix)
+		orderedFields = append(orderedFields, string(fieldName))
+
+		prefixStart = fieldNameEnd + len(placeholderClose)
+		pos = prefixStart
 	}
 
-	return orderedFields, templateFieldsMap, fieldPrefixBuffer
+	var trailingTemplate []byte
+	if prefixStart < len(template) {
+		trailingTemplate = template[prefixStart:]
+	}
 
+	return orderedFields, prefixes, trailingTemplate
 }
 
-func calculateTotEventsWithCustomTemplate(totSize uint64, emitters []emitter, trailingTemplate []byte) (uint64, error) {
+func calculateTotEventsWithCustomTemplate(totSize uint64, emitters []emitter, trailingTemplate []byte, seed int64, samples int) (uint64, uint64, error) {
 	if totSize == 0 {
-		return 0, nil
+		return 0, 0, nil
 	}
 
-	// Generate a single event to calculate the total number of events based on its size
+	// Generate a few sample events to calculate the total number of events based on their average size
+	state := NewGenStateWithSeed(seed)
 	buf := bytes.NewBufferString("")
-	for _, e := range emitters {
-		buf.Write(e.prefix)
-		state := NewGenState()
-		state.prevCacheForDup[e.fieldName] = make(map[any]struct{})
-		state.prevCacheCardinality[e.fieldName] = make([]any, 0)
-		if err := e.emitFunc(state, buf); err != nil {
-			return 0, err
+	for i := 0; i < samples; i++ {
+		if err := emitEmitters(state, buf, emitters, trailingTemplate); err != nil {
+			return 0, 0, err
 		}
+
+		state.counter += 1
 	}
 
-	buf.Write(trailingTemplate)
+	return totEventsFromSamples(totSize, uint64(buf.Len()), samples), avgEventSizeFromSamples(uint64(buf.Len()), samples), nil
+}
+
+// NewGeneratorWithCustomTemplate returns a generator emitting events up to an estimated totSize in bytes
+func NewGeneratorWithCustomTemplate(template []byte, cfg Config, fields Fields, totSize uint64) (*GeneratorWithCustomTemplate, error) {
+	template, err := ExpandTemplateIncludes(template, cfg.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
 
-	singleEventSize := uint64(buf.Len())
-	if singleEventSize == 0 {
-		return 1, nil
+	gen, err := newGeneratorWithCustomTemplate(template, cfg, fields)
+	if err != nil {
+		return nil, err
 	}
 
-	totEvents := totSize / singleEventSize
-	if totEvents < 1 {
-		totEvents = 1
+	totEvents, _, err := calculateTotEventsWithCustomTemplate(totSize, gen.emitters, gen.trailingTemplate, cfg.Seed, estimationSamples(cfg))
+	if err != nil {
+		return nil, err
 	}
 
-	return totEvents, nil
+	gen.totEvents = totEvents
+
+	return gen, nil
 }
 
-func NewGeneratorWithCustomTemplate(template []byte, cfg Config, fields Fields, totSize uint64) (*GeneratorWithCustomTemplate, error) {
+// NewGeneratorWithCustomTemplateN returns a generator emitting exactly totEvents events, with no limit when totEvents is zero
+func NewGeneratorWithCustomTemplateN(template []byte, cfg Config, fields Fields, totEvents uint64) (*GeneratorWithCustomTemplate, error) {
+	template, err := ExpandTemplateIncludes(template, cfg.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	gen, err := newGeneratorWithCustomTemplate(template, cfg, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	gen.totEvents = totEvents
+
+	return gen, nil
+}
+
+func newGeneratorWithCustomTemplate(template []byte, cfg Config, fields Fields) (*GeneratorWithCustomTemplate, error) {
+	if cfg.NDJSON && cfg.PrettyJSON {
+		return nil, notValidPrettyJSON
+	}
+
+	emitters, trailingTemplate, err := bindCustomTemplateEmitters(template, cfg, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	emitters, trailingTemplate, err = coalesceStaticEmitters(emitters, trailingTemplate, cfg.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	layoutNullPrefixes(emitters)
+
+	state := NewGenStateWithSeed(cfg.Seed)
+
+	return &GeneratorWithCustomTemplate{emitters: emitters, trailingTemplate: trailingTemplate, seed: cfg.Seed, ndjson: cfg.NDJSON, prettyJSON: cfg.PrettyJSON, validateJSON: cfg.ValidateJSON, state: state, outputs: newOutputs()}, nil
+}
+
+// bindCustomTemplateEmitters returns the emitters of the template placeholders, in order, and the trailing template
+func bindCustomTemplateEmitters(template []byte, cfg Config, fields Fields) ([]emitter, []byte, error) {
 	// Parse the template and extract relevant information
-	orderedFields, templateFieldsMap, trailingTemplate := parseCustomTemplate(template)
+	orderedFields, prefixes, trailingTemplate := parseCustomTemplate(template)
 
 	// Preprocess the fields, generating appropriate emit functions
-	state := NewGenState()
 	fieldMap := make(map[string]any)
 	fieldTypes := make(map[string]string)
+	staticFields := make(map[string]bool)
 	for _, field := range fields {
 		if err := bindField(cfg, field, fieldMap, false); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		fieldTypes[field.Name] = field.Type
-		state.prevCacheForDup[field.Name] = make(map[any]struct{})
-		state.prevCacheCardinality[field.Name] = make([]any, 0)
+		staticFields[field.Name] = isStaticField(cfg, field)
+	}
+
+	if err := bindTimestamp(cfg, fieldMap, false); err != nil {
+		return nil, nil, err
+	}
+
+	if err := bindECSVersion(cfg, fieldMap, false); err != nil {
+		return nil, nil, err
+	}
+
+	if len(cfg.ECSVersion) > 0 {
+		staticFields[ECSVersionFieldName] = true
+	}
+
+	bindEventIndex(fieldMap)
+
+	if err := bindSameAs(cfg, fields, fieldMap, false); err != nil {
+		return nil, nil, err
 	}
 
 	// Roll into slice of emit functions
 	emitters := make([]emitter, 0, len(fieldMap))
-	for _, fieldName := range orderedFields {
+	for i, fieldName := range orderedFields {
+		emitFunc, ok := fieldMap[fieldName].(emitFNotReturn)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %q", customTemplateFieldNotInFieldsYaml, fieldName)
+		}
+
+		fieldCfg, _ := cfg.GetField(fieldName)
 		emitters = append(emitters, emitter{
-			fieldName: fieldName,
-			emitFunc:  fieldMap[fieldName].(emitFNotReturn),
-			fieldType: fieldTypes[fieldName],
-			prefix:    templateFieldsMap[fieldName],
+			fieldName:       fieldName,
+			emitFunc:        emitFunc,
+			fieldType:       fieldTypes[fieldName],
+			prefix:          prefixes[i],
+			nullProbability: fieldCfg.NullProbability,
+			static:          staticFields[fieldName],
 		})
 	}
 
-	totEvents, err := calculateTotEventsWithCustomTemplate(totSize, emitters, trailingTemplate)
-	if err != nil {
-		return nil, err
+	return emitters, trailingTemplate, nil
+}
+
+// layoutNullPrefixes sets the part of the prefixes of the emitters to emit with a null value: the text preceding a null value
+// is omitted, but for the brackets it opens or closes and the quote closing the preceding value, that the JSON structure of
+// the event needs anyway
+func layoutNullPrefixes(emitters []emitter) {
+	var inString bool
+	for i := range emitters {
+		emitters[i].nullPrefixLen = 0
+
+		// a prefix starting within a JSON string closes the preceding value first
+		closing := inString
+		prefix := emitters[i].prefix
+		for j := 0; j < len(prefix); j++ {
+			switch c := prefix[j]; {
+			case inString && c == '\\':
+				j++
+			case c == '"':
+				if closing {
+					emitters[i].nullPrefixLen = j + 1
+					closing = false
+				}
+
+				inString = !inString
+			case !inString && (c == '{' || c == '[' || c == '}' || c == ']'):
+				emitters[i].nullPrefixLen = j + 1
+			}
+		}
+
+		emitters[i].quoted = inString
 	}
+}
 
-	return &GeneratorWithCustomTemplate{emitters: emitters, trailingTemplate: trailingTemplate, totEvents: totEvents, state: state}, nil
+// nullSkip is what the text following a null value must skip: the quote closing the value, when quoted, and the separator
+// from the next value when the null one is the first of an object or array
+type nullSkip struct {
+	quote     bool
+	separator bool
 }
 
-func (gen GeneratorWithCustomTemplate) Close() error {
+// start returns the offset text must be emitted from
+func (skip nullSkip) start(text []byte) int {
+	var start int
+	if skip.quote && len(text) > 0 && text[0] == '"' {
+		start = 1
+	}
+
+	if !skip.separator {
+		return start
+	}
+
+	var inString bool
+	for j := start; j < len(text); j++ {
+		switch c := text[j]; {
+		case inString && c == '\\':
+			j++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == ',':
+			return j + 1
+		case c == '}' || c == ']':
+			// the object or array is left empty
+			return j
+		case c == '{' || c == '[':
+			return start
+		}
+	}
+
+	return start
+}
+
+// emitEmitters emits the prefixes and the values of emitters followed by trailingTemplate. A null value is omitted
+// together with its prefix, but for the brackets in it and the quote closing the preceding value, and so are the quote
+// closing it and, when it's the first value of an object or array, the separator following it.
+func emitEmitters(state *GenState, buf writer, emitters []emitter, trailingTemplate []byte) error {
+	var skip nullSkip
+	for _, e := range emitters {
+		if isNull(state, e.nullProbability) {
+			if e.nullPrefixLen > 0 {
+				if start := skip.start(e.prefix); start < e.nullPrefixLen {
+					if _, err := buf.Write(e.prefix[start:e.nullPrefixLen]); err != nil {
+						return err
+					}
+
+					last := e.prefix[e.nullPrefixLen-1]
+					skip.separator = last == '{' || last == '['
+				}
+			}
+
+			skip.quote = e.quoted
+
+			continue
+		}
+
+		if _, err := buf.Write(e.prefix[skip.start(e.prefix):]); err != nil {
+			return err
+		}
+
+		skip = nullSkip{}
+		if err := e.emitFunc(state, buf); err != nil {
+			return err
+		}
+	}
+
+	_, err := buf.Write(trailingTemplate[skip.start(trailingTemplate):])
+
+	return err
+}
+
+// isStaticField tells whether the field emits the same value in every event
+func isStaticField(cfg Config, field Field) bool {
+	if cfg.Timestamp != nil && field.Name == TimestampFieldName {
+		return false
+	}
+
+	fieldCfg, _ := cfg.GetField(field.Name)
+	if fieldCfg.ArrayLength != nil || fieldCfg.NullProbability > 0 || len(fieldCfg.SameAs) > 0 {
+		return false
+	}
+
+	return len(field.Value) > 0 || fieldCfg.Value != nil
+}
+
+// emitNothing is the emit function of an emitter writing its prefix only
+func emitNothing(_ *GenState, _ writer) error {
 	return nil
 }
 
+// coalesceStaticEmitters renders once the values of the static emitters, joining them with the surrounding prefixes in a single
+// prefix, so that fewer writes are needed for each event. The emitted bytes are the same.
+func coalesceStaticEmitters(emitters []emitter, trailingTemplate []byte, seed int64) ([]emitter, []byte, error) {
+	state := NewGenStateWithSeed(seed)
+
+	var pending bytes.Buffer
+	coalesced := make([]emitter, 0, len(emitters))
+	for _, e := range emitters {
+		if e.static {
+			pending.Write(e.prefix)
+			if err := e.emitFunc(state, &pending); err != nil {
+				return nil, nil, err
+			}
+
+			continue
+		}
+
+		if pending.Len() > 0 {
+			// the prefix of a null field value is omitted, then the static text preceding it must be emitted on its own
+			if e.nullProbability > 0 {
+				coalesced = append(coalesced, emitter{prefix: append([]byte(nil), pending.Bytes()...), emitFunc: emitNothing})
+			} else {
+				pending.Write(e.prefix)
+				e.prefix = append([]byte(nil), pending.Bytes()...)
+			}
+
+			pending.Reset()
+		}
+
+		coalesced = append(coalesced, e)
+	}
+
+	if pending.Len() > 0 {
+		pending.Write(trailingTemplate)
+		trailingTemplate = pending.Bytes()
+	}
+
+	return coalesced, trailingTemplate, nil
+}
+
+// Reset returns the generator to its state after construction, without parsing the template again, so that it emits
+// the events again from the first one: the same ones when a seed is set. Only the generator internal state is reset,
+// the independent states passed to Emit are not.
+func (gen GeneratorWithCustomTemplate) Reset() {
+	gen.state.reset(gen.seed)
+}
+
+// generatorSeed returns the seed of the generator, zero when not set
+func (gen GeneratorWithCustomTemplate) generatorSeed() int64 {
+	return gen.seed
+}
+
+// Close finalizes the writers the events were streamed to with EmitTo, flushing the buffering ones and closing the
+// compression ones returned by NewCompressionWriter, returning the first error. Closing any other writer is up to the caller.
+func (gen GeneratorWithCustomTemplate) Close() error {
+	return gen.outputs.close()
+}
+
+// Emit generates an event in buf, using state for keeping track of what was generated.
+// Independent states can be used for independent emission streams with the same generator;
+// when state is nil the generator internal state is used.
 func (gen GeneratorWithCustomTemplate) Emit(state *GenState, buf *bytes.Buffer) error {
-	state = gen.state
-	if err := gen.emit(state, buf); err != nil {
+	return gen.EmitTo(state, buf)
+}
+
+// EmitTo generates an event streaming it to w, with the same semantic of Emit.
+// Writers not supporting byte and string writes are wrapped in a buffered writer flushed at the end of the event.
+// Writers to be flushed or closed are finalized by Close.
+func (gen GeneratorWithCustomTemplate) EmitTo(state *GenState, w io.Writer) error {
+	if state == nil {
+		state = gen.state
+	}
+
+	state.lazyInit(gen.seed)
+
+	emit := gen.emit
+	if gen.validateJSON {
+		emit = gen.emitValidJSON
+	}
+
+	var err error
+	switch {
+	case gen.ndjson:
+		err = emitNDJSON(state, w, emit)
+	case gen.prettyJSON:
+		err = emitPrettyJSON(state, w, emit)
+	default:
+		err = emit(state, w)
+	}
+
+	if err != nil {
 		return err
 	}
 
+	gen.outputs.add(w)
 	state.counter += 1
 
 	return nil
 }
 
-func (gen GeneratorWithCustomTemplate) emit(state *GenState, buf *bytes.Buffer) error {
+// emitValidJSON emits an event failing when it is not valid JSON, as configured by ValidateJSON
+func (gen GeneratorWithCustomTemplate) emitValidJSON(state *GenState, w io.Writer) error {
+	return emitValidJSON(state, w, gen.emit)
+}
+
+func (gen GeneratorWithCustomTemplate) emit(state *GenState, w io.Writer) error {
 	if gen.totEvents == 0 || state.counter < gen.totEvents {
-		for _, e := range gen.emitters {
-			buf.Write(e.prefix)
-			if err := e.emitFunc(state, buf); err != nil {
-				return err
-			}
+		buf, ok := w.(writer)
+		if !ok {
+			buf = state.bufferedWriter(w)
+		}
+
+		if err := emitEmitters(state, buf, gen.emitters, gen.trailingTemplate); err != nil {
+			return err
 		}
 
-		buf.Write(gen.trailingTemplate)
+		if !ok {
+			return state.bufWriter.Flush()
+		}
 	} else {
 		return io.EOF
 	}
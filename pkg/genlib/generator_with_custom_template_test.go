@@ -2,12 +2,19 @@ package genlib
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+	"io"
+	"math"
 	"math/rand"
 	"net"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -40,7 +47,7 @@ func Test_ParseTemplate(t *testing.T) {
 		{
 			template:                  []byte("with prefix {{.aField}} {{.anotherField}}"),
 			expectedOrderFields:       []string{"aField", "anotherField"},
-			expectedTemplateFieldsMap: map[string][]byte{"aField": []byte("with prefix"), "anotherField": []byte(" ")},
+			expectedTemplateFieldsMap: map[string][]byte{"aField": []byte("with prefix "), "anotherField": []byte(" ")},
 			expectedTrailingTemplate:  nil,
 		},
 		{
@@ -52,7 +59,7 @@ func Test_ParseTemplate(t *testing.T) {
 		{
 			template:                  []byte("with prefix {{.aField}} {{.anotherField}} and trailing"),
 			expectedOrderFields:       []string{"aField", "anotherField"},
-			expectedTemplateFieldsMap: map[string][]byte{"aField": []byte("with prefix"), "anotherField": []byte(" ")},
+			expectedTemplateFieldsMap: map[string][]byte{"aField": []byte("with prefix "), "anotherField": []byte(" ")},
 			expectedTrailingTemplate:  []byte(" and trailing"),
 		},
 		{
@@ -148,34 +155,265 @@ func Test_ParseTemplate(t *testing.T) {
 	}
 	for _, testCase := range testCases {
 		t.Run(fmt.Sprintf("with template: %s", string(testCase.template)), func(t *testing.T) {
-			orderedFields, templateFieldsMap, trailingTemplate := parseCustomTemplate(testCase.template)
+			orderedFields, prefixes, trailingTemplate := parseCustomTemplate(testCase.template)
 			if len(orderedFields) != len(testCase.expectedOrderFields) {
 				t.Errorf("Expected equal orderedFields")
 			}
 
+			if len(prefixes) != len(orderedFields) {
+				t.Errorf("Expected a prefix for each ordered field")
+			}
+
 			for i := range orderedFields {
 				if orderedFields[i] != testCase.expectedOrderFields[i] {
 					t.Errorf("Expected ordered field at position %d is wrong (expected: `%s`, given: `%s`", i, testCase.expectedOrderFields[i], orderedFields[i])
 				}
+
+				expectedPrefix, ok := testCase.expectedTemplateFieldsMap[orderedFields[i]]
+				if !ok {
+					t.Errorf("Missing expected field `%s` in templateFieldsMap", orderedFields[i])
+				}
+
+				if string(prefixes[i]) != string(expectedPrefix) {
+					t.Errorf("Expected prefix for field `%s` is wrong (expected: `%s`, given: `%s`", orderedFields[i], expectedPrefix, prefixes[i])
+				}
+			}
+
+			if string(trailingTemplate) != string(testCase.expectedTrailingTemplate) {
+				t.Errorf("Expected trailing template is wrong (expected: `%s`, given: `%s`", testCase.expectedTrailingTemplate, trailingTemplate)
 			}
+		})
+	}
+}
+
+func Test_ParseTemplateWithBraces(t *testing.T) {
+	testCases := []struct {
+		template                 []byte
+		expectedOrderFields      []string
+		expectedPrefixes         []string
+		expectedTrailingTemplate string
+	}{
+		{
+			template:                 []byte(`{"nested":{"k":"v"}}`),
+			expectedOrderFields:      []string{},
+			expectedPrefixes:         []string{},
+			expectedTrailingTemplate: `{"nested":{"k":"v"}}`,
+		},
+		{
+			template:                 []byte(`{"nested":{"k":"{{.aField}}"}}`),
+			expectedOrderFields:      []string{"aField"},
+			expectedPrefixes:         []string{`{"nested":{"k":"`},
+			expectedTrailingTemplate: `"}}`,
+		},
+		{
+			template:                 []byte(`{"a":{{{.aField}}}}`),
+			expectedOrderFields:      []string{"aField"},
+			expectedPrefixes:         []string{`{"a":{`},
+			expectedTrailingTemplate: `}}`,
+		},
+		{
+			template:                 []byte(`{{{.aField}}}`),
+			expectedOrderFields:      []string{"aField"},
+			expectedPrefixes:         []string{`{`},
+			expectedTrailingTemplate: `}`,
+		},
+		{
+			template:                 []byte(`{{.aField}}{{.anotherField}}`),
+			expectedOrderFields:      []string{"aField", "anotherField"},
+			expectedPrefixes:         []string{"", ""},
+			expectedTrailingTemplate: "",
+		},
+		{
+			template:                 []byte(`{{.aField}}}{{{.anotherField}}`),
+			expectedOrderFields:      []string{"aField", "anotherField"},
+			expectedPrefixes:         []string{"", "}{"},
+			expectedTrailingTemplate: "",
+		},
+		{
+			template:                 []byte(`{{.aField}} with prefix and trailing {{.anotherField}}`),
+			expectedOrderFields:      []string{"aField", "anotherField"},
+			expectedPrefixes:         []string{"", " with prefix and trailing "},
+			expectedTrailingTemplate: "",
+		},
+		{
+			template:                 []byte(`with prefix {{.aField}} {"k":{{.anotherField}}} and trailing`),
+			expectedOrderFields:      []string{"aField", "anotherField"},
+			expectedPrefixes:         []string{"with prefix ", ` {"k":`},
+			expectedTrailingTemplate: "} and trailing",
+		},
+		{
+			template:                 []byte(`[{{.aField}}-{{.aField}}]`),
+			expectedOrderFields:      []string{"aField", "aField"},
+			expectedPrefixes:         []string{"[", "-"},
+			expectedTrailingTemplate: "]",
+		},
+		{
+			template:                 []byte(`{{.aField}}`),
+			expectedOrderFields:      []string{"aField"},
+			expectedPrefixes:         []string{""},
+			expectedTrailingTemplate: "",
+		},
+		{
+			template:                 []byte(`{{.}} {{.a{{.aField}} {{.unterminated`),
+			expectedOrderFields:      []string{"aField"},
+			expectedPrefixes:         []string{"{{.}} {{.a"},
+			expectedTrailingTemplate: " {{.unterminated",
+		},
+	}
 
-			if len(templateFieldsMap) != len(testCase.expectedTemplateFieldsMap) {
-				t.Errorf("Expected equal templateFieldsMap")
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("with template: %s", string(testCase.template)), func(t *testing.T) {
+			orderedFields, prefixes, trailingTemplate := parseCustomTemplate(testCase.template)
+			if len(orderedFields) != len(testCase.expectedOrderFields) {
+				t.Fatalf("Expected %d ordered fields, given %d", len(testCase.expectedOrderFields), len(orderedFields))
 			}
 
-			for k := range templateFieldsMap {
-				if _, ok := testCase.expectedTemplateFieldsMap[k]; !ok {
-					t.Errorf("Missing expected field `%s` in templateFieldsMap", k)
+			for i := range orderedFields {
+				if orderedFields[i] != testCase.expectedOrderFields[i] {
+					t.Errorf("Expected ordered field at position %d is wrong (expected: `%s`, given: `%s`", i, testCase.expectedOrderFields[i], orderedFields[i])
+				}
+
+				if string(prefixes[i]) != testCase.expectedPrefixes[i] {
+					t.Errorf("Expected prefix for field `%s` is wrong (expected: `%s`, given: `%s`", orderedFields[i], testCase.expectedPrefixes[i], prefixes[i])
 				}
 			}
 
-			if string(trailingTemplate) != string(testCase.expectedTrailingTemplate) {
+			if string(trailingTemplate) != testCase.expectedTrailingTemplate {
 				t.Errorf("Expected trailing template is wrong (expected: `%s`, given: `%s`", testCase.expectedTrailingTemplate, trailingTemplate)
 			}
 		})
 	}
 }
 
+func Test_ParseTemplateWithoutPlaceholders(t *testing.T) {
+	template := []byte(`{"static":"event"}`)
+	if allocs := testing.AllocsPerRun(100, func() { parseCustomTemplate(template) }); allocs != 0 {
+		t.Fatalf("expected no allocations parsing a template without placeholders, got %v", allocs)
+	}
+}
+
+func Test_EmitTrailingTemplateWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeLong},
+		{Name: "beta", Type: FieldTypeKeyword},
+	}
+
+	// alpha is a static field, whose value is coalesced in the prefixes, while beta is emitted for each event
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  value: 1\n- name: beta\n  enum: [\"b\"]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		template string
+		expected string
+	}{
+		{template: `{{.beta}}`, expected: `b`},
+		{template: `{{.alpha}}`, expected: `1`},
+		{template: `beta={{.beta}}`, expected: `beta=b`},
+		{template: `alpha={{.alpha}}`, expected: `alpha=1`},
+		{template: `{{.beta}} end`, expected: `b end`},
+		{template: `[{{.beta}}-{{.beta}}]`, expected: `[b-b]`},
+		{template: `[{{.alpha}}-{{.beta}}-{{.alpha}}]`, expected: `[1-b-1]`},
+		{template: `{{.beta}}{{.alpha}}`, expected: `b1`},
+		{template: "{{.beta}}\n", expected: "b\n"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("with template: %s", testCase.template), func(t *testing.T) {
+			g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, []byte(testCase.template), 0)
+
+			var buf bytes.Buffer
+			for i := 0; i < 3; i++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				if buf.String() != testCase.expected {
+					t.Fatalf("expected %q, got %q", testCase.expected, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_FieldNotInFieldsYamlWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "missing":"{{.does.not.exist}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	_, err := NewGeneratorWithCustomTemplate(template, Config{}, flds, 10)
+	if !errors.Is(err, customTemplateFieldNotInFieldsYaml) {
+		t.Fatalf("expected error %v, got %v", customTemplateFieldNotInFieldsYaml, err)
+	}
+
+	if !strings.Contains(err.Error(), `"does.not.exist"`) {
+		t.Errorf("expected error to name the field, got %s", err)
+	}
+}
+
+func Test_ManyGeneratorsWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	for i := 0; i < 1000; i++ {
+		template := []byte(fmt.Sprintf(`{"alpha":"{{.alpha}}","i":%d}`, i))
+		if i%2 == 0 {
+			template = []byte(fmt.Sprintf(`{"i":%d}`, i))
+		}
+
+		g, state := makeGeneratorWithCustomTemplate(t, Config{}, flds, template, 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if m := unmarshalJSONT[any](t, buf.Bytes()); m["i"] != float64(i) {
+			t.Fatalf("expected event %d, got %s", i, buf.String())
+		}
+	}
+}
+
+func Test_CoalesceStaticEmittersWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword, Value: "a"},
+		{Name: "beta", Type: FieldTypeKeyword},
+		{Name: "gamma", Type: FieldTypeLong},
+		{Name: "delta", Type: FieldTypeLong},
+		{Name: "epsilon", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: beta\n  enum: [\"b\"]\n- name: gamma\n  value: 1\n- name: delta\n  null_probability: 1\n- name: epsilon\n  value: e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{.alpha}},"beta":"{{.beta}}","gamma":{{.gamma}},"delta":{{.delta}},"epsilon":{{.epsilon}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	// the static alpha, gamma and epsilon are joined with the text around them, except for the prefix of the nullable delta
+	if len(g.(*GeneratorWithCustomTemplate).emitters) != 3 {
+		t.Fatalf("expected 3 emitters, got %d", len(g.(*GeneratorWithCustomTemplate).emitters))
+	}
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `{"alpha":"a","beta":"b","gamma":1,"epsilon":"e"}`; buf.String() != expected {
+		t.Fatalf("expected %s, got %s", expected, buf.String())
+	}
+}
+
 func Test_EmptyCaseWithCustomTemplate(t *testing.T) {
 	template, _ := generateCustomTemplateFromField(Config{}, []Field{})
 	t.Logf("with template: %s", string(template))
@@ -202,6 +440,63 @@ func Test_CardinalityWithCustomTemplate(t *testing.T) {
 	test_CardinalityTWithCustomTemplate[string](t, FieldTypeDate)
 }
 
+func Test_CardinalityPoolWithCustomTemplate(t *testing.T) {
+	for _, reusePattern := range []string{"", config.DistributionUniform, config.DistributionZipf} {
+		flds := Fields{
+			{Name: "alpha", Type: FieldTypeKeyword},
+		}
+
+		cfg, err := config.LoadConfigFromYaml([]byte(fmt.Sprintf("- name: alpha\n  cardinality_pool: 500\n  cardinality_reuse_pattern: %q", reusePattern)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		template := []byte(`{{.alpha}}`)
+		t.Logf("with reuse pattern %q, with template: %s", reusePattern, string(template))
+
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+		occurrences := make(map[string]int)
+
+		var buf bytes.Buffer
+		for i := 0; i < 100000; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			occurrences[buf.String()]++
+		}
+
+		if len(occurrences) != 500 {
+			t.Fatalf("expected 500 distinct values, got %d", len(occurrences))
+		}
+
+		if reusePattern == config.DistributionZipf {
+			// the first values of the pool recur the most
+			values := state.cardinalityPools["alpha"].values
+			if first, last := occurrences[values[0].(string)], occurrences[values[len(values)-1].(string)]; first <= 10*last {
+				t.Fatalf("expected the first value to recur much more than the last one, got %d and %d occurrences", first, last)
+			}
+		}
+	}
+}
+
+func Test_CardinalityPoolNotValidReusePatternWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  cardinality_pool: 500\n  cardinality_reuse_pattern: normal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+		t.Fatal("expected error on not valid cardinality reuse pattern")
+	}
+}
+
 func test_CardinalityTWithCustomTemplate[T any](t *testing.T, ty string) {
 	template := []byte(`{"alpha":"{{.alpha}}", "beta":"{{.beta}}"}`)
 	if ty == FieldTypeInteger || ty == FieldTypeFloat {
@@ -336,6 +631,69 @@ func Test_FieldConstKeywordWithCustomTemplate(t *testing.T) {
 	}
 }
 
+func Test_FieldConstKeywordConfigValueWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "data_stream.type", Type: FieldTypeConstantKeyword},
+		{Name: "data_stream.dataset", Type: FieldTypeConstantKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: data_stream.type\n  value: logs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"data_stream.type":{{.data_stream.type}},"data_stream.dataset":"{{.data_stream.dataset}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var dataset string
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if m["data_stream.type"] != "logs" {
+			t.Fatalf("expected constant value logs, got %s", m["data_stream.type"])
+		}
+
+		if i == 0 {
+			dataset = m["data_stream.dataset"]
+		}
+
+		if m["data_stream.dataset"] != dataset {
+			t.Fatalf("expected constant value %s, got %s", dataset, m["data_stream.dataset"])
+		}
+	}
+
+	if state.source.draws != 0 {
+		t.Fatalf("expected no random draws, got %d", state.source.draws)
+	}
+}
+
+func Test_FieldConstKeywordNotValidWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeConstantKeyword},
+	}
+
+	for _, yaml := range []string{
+		"- name: alpha\n  cardinality:\n    numerator: 1\n    denominator: 10",
+		"- name: alpha\n  enum: [\"a\", \"b\"]",
+	} {
+		cfg, err := config.LoadConfigFromYaml([]byte(yaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+			t.Fatalf("expected error on varying constant_keyword with config %s", yaml)
+		}
+	}
+}
+
 func Test_FieldStaticOverrideStringWithCustomTemplate(t *testing.T) {
 	fld := Field{
 		Name: "alpha",
@@ -471,95 +829,2238 @@ func Test_FieldDateWithCustomTemplate(t *testing.T) {
 	}
 }
 
-func Test_FieldIPWithCustomTemplate(t *testing.T) {
+func Test_FieldDateTimezoneWithCustomTemplate(t *testing.T) {
 	fld := Field{
 		Name: "alpha",
-		Type: FieldTypeIP,
+		Type: FieldTypeDate,
 	}
 
 	template := []byte(`{"alpha":"{{.alpha}}"}`)
 	t.Logf("with template: %s", string(template))
-	nSpins := rand.Intn(1024) + 1
-	for i := 0; i < nSpins; i++ {
 
-		b := testSingleTWithCustomTemplate[string](t, fld, nil, template)
+	for timezone, offset := range map[string]string{"UTC": "Z", "Asia/Kolkata": "+05:30"} {
+		configYaml := []byte("- name: alpha\n  timezone: " + timezone)
 
-		if ip := net.ParseIP(b); ip == nil {
-			t.Errorf("Fail parse ip %s", b)
+		b := testSingleTWithCustomTemplate[string](t, fld, configYaml, template)
+		if !strings.HasSuffix(b, offset) {
+			t.Errorf("expected date in %s with offset %s, got %s", timezone, offset, b)
 		}
-	}
-}
-
-func Test_FieldFloatsWithCustomTemplate(t *testing.T) {
-	_testNumericWithCustomTemplate[float64](t, FieldTypeDouble)
-	_testNumericWithCustomTemplate[float32](t, FieldTypeFloat)
-	_testNumericWithCustomTemplate[float32](t, FieldTypeHalfFloat)
-	_testNumericWithCustomTemplate[float64](t, FieldTypeScaledFloat)
 
+		if _, err := time.Parse(FieldTypeTimeLayout, b); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		}
+	}
 }
 
-func Test_FieldIntegersWithCustomTemplate(t *testing.T) {
-	_testNumericWithCustomTemplate[int](t, FieldTypeInteger)
-	_testNumericWithCustomTemplate[int64](t, FieldTypeLong)
-	_testNumericWithCustomTemplate[uint64](t, FieldTypeUnsignedLong)
-}
-
-func _testNumericWithCustomTemplate[T any](t *testing.T, ty string) {
+func Test_FieldDateTimezoneNotValidWithCustomTemplate(t *testing.T) {
 	fld := Field{
 		Name: "alpha",
-		Type: ty,
+		Type: FieldTypeDate,
 	}
 
-	template := []byte(`{"alpha":{{.alpha}}}`)
-	t.Logf("with template: %s", string(template))
-	nSpins := rand.Intn(1024) + 1
-	for i := 0; i < nSpins; i++ {
-		testSingleTWithCustomTemplate[T](t, fld, nil, template)
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  timezone: Mars/Olympus_Mons"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, Fields{fld}, 0); err == nil {
+		t.Fatal("expected error on not valid timezone")
 	}
 }
 
-func testSingleTWithCustomTemplate[T any](t *testing.T, fld Field, yaml []byte, template []byte) T {
-	var err error
-	var cfg Config
+// dateFormatsPrecision are the date formats with the precision of the dates they represent
+var dateFormatsPrecision = map[string]time.Duration{
+	config.DateFormatEpochMillis:     time.Millisecond,
+	config.DateFormatEpochSecond:     time.Second,
+	config.DateFormatRFC3339:         time.Microsecond,
+	config.DateFormatRFC3339Nano:     time.Nanosecond,
+	"02 Jan 2006 15:04:05.000 -0700": time.Millisecond,
+}
 
-	if yaml != nil {
-		cfg, err = config.LoadConfigFromYaml(yaml)
-		if err != nil {
-			t.Fatal(err)
+// parseFormattedDate parses back a date emitted with format and decoded from JSON: epoch formats as numbers, the
+// others as strings
+func parseFormattedDate(t *testing.T, format string, v any) time.Time {
+	t.Helper()
+
+	switch format {
+	case config.DateFormatEpochMillis, config.DateFormatEpochSecond:
+		n, ok := v.(float64)
+		if !ok {
+			t.Fatalf("expected %s date as a number, got %#v", format, v)
 		}
-	}
 
-	g, state := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 0)
+		if format == config.DateFormatEpochSecond {
+			return time.Unix(int64(n), 0)
+		}
 
-	var buf bytes.Buffer
+		return time.UnixMilli(int64(n))
+	}
 
-	if err := g.Emit(state, &buf); err != nil {
-		t.Fatal(err)
+	s, ok := v.(string)
+	if !ok {
+		t.Fatalf("expected %s date as a string, got %#v", format, v)
 	}
 
-	// BufferWithMutex should now contain an event shaped like {"alpha": "constant_keyword"}
-	m := unmarshalJSONT[T](t, buf.Bytes())
+	layout := format
+	switch format {
+	case config.DateFormatRFC3339:
+		layout = FieldTypeTimeLayout
+	case config.DateFormatRFC3339Nano:
+		layout = time.RFC3339Nano
+	}
 
-	if len(m) != 1 {
-		t.Errorf("Expected map size 1, got %d", len(m))
+	ts, err := time.Parse(layout, s)
+	if err != nil {
+		t.Fatalf("Fail parse %s timestamp %v", format, err)
 	}
 
-	v, ok := m[fld.Name]
+	return ts
+}
 
-	if !ok {
-		t.Errorf("Missing key %v", fld.Name)
+// assertNearDate checks that the date is within the FieldTypeTimeRange preceding the time window of its generation
+func assertNearDate(t *testing.T, ts, before, after time.Time, precision time.Duration) {
+	t.Helper()
 
+	if ts.Before(before.Add(-FieldTypeTimeRange*time.Second).Truncate(precision)) || ts.After(after) {
+		t.Fatalf("Date generated out of span range %v", ts)
 	}
-
-	return v
 }
 
-func makeGeneratorWithCustomTemplate(t *testing.T, cfg Config, fields Fields, template []byte, totSize uint64) (Generator, *GenState) {
-	g, err := NewGeneratorWithCustomTemplate(template, cfg, fields, totSize)
+func Test_DateFormat(t *testing.T) {
+	instant := time.Date(2023, time.May, 17, 10, 20, 30, 123456789, time.FixedZone("", 5*60*60+30*60))
 
-	if err != nil {
-		t.Fatal(err)
-	}
+	for format, precision := range dateFormatsPrecision {
+		formatFunc, err := makeDateFormatFunc(format)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	return g, NewGenState()
+		b, err := json.Marshal(formatFunc(instant))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			t.Fatal(err)
+		}
+
+		if ts := parseFormattedDate(t, format, v); !ts.Equal(instant.Truncate(precision)) {
+			t.Errorf("expected %s date parsed back to %v, got %v from %s", format, instant.Truncate(precision), ts, b)
+		}
+	}
+}
+
+func Test_FieldDateFormatWithCustomTemplate(t *testing.T) {
+	for format, precision := range dateFormatsPrecision {
+		flds := Fields{{Name: "alpha", Type: FieldTypeDate}}
+		cfg, err := config.LoadConfigFromYaml([]byte(fmt.Sprintf("- name: alpha\n  format: %q", format)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		template, _ := generateCustomTemplateFromField(cfg, flds)
+		t.Logf("with template: %s", string(template))
+
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 100; i++ {
+			buf.Reset()
+			before := time.Now()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			ts := parseFormattedDate(t, format, unmarshalJSONT[any](t, buf.Bytes())["alpha"])
+			assertNearDate(t, ts, before, time.Now(), precision)
+		}
+	}
+}
+
+func Test_FieldDateFormatNotValidWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	for _, format := range []string{"epoch_nanos", "yyyy-MM-dd"} {
+		cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  format: " + format))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, Fields{fld}, 0); !errors.Is(err, notValidDateFormat) {
+			t.Fatalf("expected error on not valid format %s, got %v", format, err)
+		}
+	}
+}
+
+func Test_FieldIPWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeIP,
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+	nSpins := rand.Intn(1024) + 1
+	for i := 0; i < nSpins; i++ {
+
+		b := testSingleTWithCustomTemplate[string](t, fld, nil, template)
+
+		if ip := net.ParseIP(b); ip == nil {
+			t.Errorf("Fail parse ip %s", b)
+		}
+	}
+}
+
+func Test_FieldFloatsWithCustomTemplate(t *testing.T) {
+	_testNumericWithCustomTemplate[float64](t, FieldTypeDouble)
+	_testNumericWithCustomTemplate[float32](t, FieldTypeFloat)
+	_testNumericWithCustomTemplate[float32](t, FieldTypeHalfFloat)
+	_testNumericWithCustomTemplate[float64](t, FieldTypeScaledFloat)
+
+}
+
+func Test_FieldIntegersWithCustomTemplate(t *testing.T) {
+	_testNumericWithCustomTemplate[int](t, FieldTypeInteger)
+	_testNumericWithCustomTemplate[int64](t, FieldTypeLong)
+	_testNumericWithCustomTemplate[uint64](t, FieldTypeUnsignedLong)
+}
+
+func _testNumericWithCustomTemplate[T any](t *testing.T, ty string) {
+	fld := Field{
+		Name: "alpha",
+		Type: ty,
+	}
+
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+	nSpins := rand.Intn(1024) + 1
+	for i := 0; i < nSpins; i++ {
+		testSingleTWithCustomTemplate[T](t, fld, nil, template)
+	}
+}
+
+func testSingleTWithCustomTemplate[T any](t *testing.T, fld Field, yaml []byte, template []byte) T {
+	var err error
+	var cfg Config
+
+	if yaml != nil {
+		cfg, err = config.LoadConfigFromYaml(yaml)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 0)
+
+	var buf bytes.Buffer
+
+	if err := g.Emit(state, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// BufferWithMutex should now contain an event shaped like {"alpha": "constant_keyword"}
+	m := unmarshalJSONT[T](t, buf.Bytes())
+
+	if len(m) != 1 {
+		t.Errorf("Expected map size 1, got %d", len(m))
+	}
+
+	v, ok := m[fld.Name]
+
+	if !ok {
+		t.Errorf("Missing key %v", fld.Name)
+
+	}
+
+	return v
+}
+
+func makeGeneratorWithCustomTemplate(t *testing.T, cfg Config, fields Fields, template []byte, totSize uint64) (Generator, *GenState) {
+	g, err := NewGeneratorWithCustomTemplate(template, cfg, fields, totSize)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return g, NewGenState()
+}
+
+func Test_SeedWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeDouble},
+		{Name: "delta", Type: FieldTypeIP},
+		{Name: "epsilon", Type: FieldTypeBool},
+		{Name: "zeta", Type: FieldTypeGeoPoint},
+		{Name: "eta", Type: FieldTypeKeyword},
+	}
+
+	yaml := []byte("- name: beta\n  range:\n    min: 0\n    max: 1000\n- name: eta\n  cardinality:\n    numerator: 1\n    denominator: 10")
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}, "gamma":{{.gamma}}, "delta":"{{.delta}}", "epsilon":{{.epsilon}}, "zeta":"{{.zeta}}", "eta":"{{.eta}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Seed = 42
+
+	emitAll := func() []byte {
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 100*1024)
+
+		var buf bytes.Buffer
+		for {
+			err := g.Emit(state, &buf)
+			if err == io.EOF {
+				return buf.Bytes()
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	first := emitAll()
+	second := emitAll()
+	if len(first) == 0 {
+		t.Fatal("expected some output")
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("expected identical output with the same seed")
+	}
+}
+
+func Test_SeedWordsWithConcurrentGeneratorsWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeConstantKeyword},
+		{Name: "gamma", Type: FieldTypeText},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":"{{.beta}}", "gamma":"{{.gamma}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	emitAll := func(seed int64) []byte {
+		g, state := makeGeneratorWithCustomTemplate(t, Config{Seed: seed}, flds, template, 10*1024)
+
+		var buf bytes.Buffer
+		for {
+			err := g.Emit(state, &buf)
+			if err == io.EOF {
+				return buf.Bytes()
+			}
+
+			if err != nil {
+				t.Error(err)
+				return nil
+			}
+		}
+	}
+
+	expected := emitAll(42)
+
+	// the words are drawn from the state of each generator, not from a source shared with the others
+	outputs := make([][]byte, 4)
+	var wg sync.WaitGroup
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outputs[i] = emitAll(int64(42 + i%2))
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i, output := range outputs {
+		if i%2 == 0 && !bytes.Equal(output, expected) {
+			t.Errorf("expected identical output with the same seed, got a different one from generator %d", i)
+		}
+
+		if i%2 == 1 && bytes.Equal(output, expected) {
+			t.Errorf("expected different output with a different seed from generator %d", i)
+		}
+	}
+}
+
+func Test_IndependentStatesWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	yaml := []byte("- name: alpha\n  cardinality:\n    numerator: 1\n    denominator: 10")
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewGeneratorWithCustomTemplate(template, cfg, flds, 10*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states := []*GenState{NewGenState(), NewGenState()}
+	emitted := make([]uint64, len(states))
+
+	var wg sync.WaitGroup
+	for i := range states {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var buf bytes.Buffer
+			for {
+				buf.Reset()
+				err := g.Emit(states[i], &buf)
+				if err == io.EOF {
+					return
+				}
+
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				emitted[i] += 1
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if emitted[0] != g.totEvents || emitted[1] != g.totEvents {
+		t.Errorf("expected each state to emit %d events, got %v", g.totEvents, emitted)
+	}
+
+	for i, state := range states {
+		if state.counter != g.totEvents {
+			t.Errorf("expected counter of state %d to be %d, got %d", i, g.totEvents, state.counter)
+		}
+	}
+}
+
+func Test_TotEventsWithCustomTemplateN(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, err := NewGeneratorWithCustomTemplateN(template, Config{}, flds, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+
+	var emitted uint64
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		emitted += 1
+	}
+
+	if emitted != 1000 {
+		t.Errorf("expected 1000 events, got %d", emitted)
+	}
+}
+
+func Test_TotEventsEstimationWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	// alpha values length spans from 1 to 200 characters
+	enum := make([]string, 0, 200)
+	for i := 1; i <= 200; i++ {
+		enum = append(enum, fmt.Sprintf("%q", strings.Repeat("x", i)))
+	}
+
+	yaml := []byte(fmt.Sprintf("- name: alpha\n  enum: [%s]", strings.Join(enum, ", ")))
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sampling more than the default number of events keeps the estimation close regardless of the seed
+	cfg.Seed = 1
+	cfg.EstimationSamples = 256
+
+	totSize := uint64(1024 * 1024)
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, totSize)
+
+	var emittedSize uint64
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		emittedSize += uint64(buf.Len())
+	}
+
+	if emittedSize < totSize*9/10 || emittedSize > totSize*11/10 {
+		t.Errorf("expected emitted size to be within 10%% of %d, got %d", totSize, emittedSize)
+	}
+}
+
+func Test_PrettyJSONWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeIP},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}","nested":{"beta":{{.beta}},"list":["{{.gamma}}",1]}}`)
+	t.Logf("with template: %s", string(template))
+
+	emit := func(prettyJSON bool) []byte {
+		cfg := Config{Seed: 1, PrettyJSON: prettyJSON}
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.Bytes()
+	}
+
+	compact := emit(false)
+	pretty := emit(true)
+
+	if !bytes.Contains(pretty, []byte("\n  \"nested\": {\n    \"beta\": ")) {
+		t.Fatalf("expected indented event, got %s", pretty)
+	}
+
+	var compactEvent, prettyEvent any
+	if err := json.Unmarshal(compact, &compactEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(pretty, &prettyEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(compactEvent, prettyEvent) {
+		t.Fatalf("expected pretty event %s to be the same as compact event %s", pretty, compact)
+	}
+}
+
+func Test_PrettyJSONNotValidJSONWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`alpha={{.alpha}} {`)
+	t.Logf("with template: %s", string(template))
+
+	emit := func(prettyJSON bool) string {
+		cfg := Config{Seed: 1, PrettyJSON: prettyJSON}
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.String()
+	}
+
+	if compact, pretty := emit(false), emit(true); compact != pretty {
+		t.Fatalf("expected event not being JSON to be untouched, got %q instead of %q", pretty, compact)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate(template, Config{PrettyJSON: true, NDJSON: true}, flds, 0); err == nil {
+		t.Fatal("expected error on pretty printed JSON with NDJSON")
+	}
+}
+
+func Test_NDJSONWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeKeyword},
+	}
+
+	// beta values carry trailing whitespaces as well
+	yaml := []byte("- name: beta\n  enum: [\"\", \" \", \"\\t\\n\", \"\\n\\n\"]")
+	cfg, err := config.LoadConfigFromYaml(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.NDJSON = true
+
+	templates := [][]byte{
+		[]byte(`{"alpha":"{{.alpha}}"}{{.beta}}`),
+		[]byte("{\"alpha\":\"{{.alpha}}\"}{{.beta}} \n\n"),
+		[]byte("{\"alpha\":\"{{.alpha}}\"}\r\n{{.beta}}\t"),
+	}
+
+	for _, template := range templates {
+		t.Logf("with template: %q", string(template))
+
+		g, err := NewGeneratorWithCustomTemplateN(template, cfg, flds, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		state := NewGenState()
+		for {
+			err := g.Emit(state, &buf)
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !strings.HasSuffix(buf.String(), "}\n") {
+			t.Errorf("expected exactly one trailing new line, got %q", buf.String())
+		}
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 20 {
+			t.Fatalf("expected 20 lines, got %d", len(lines))
+		}
+
+		for _, line := range lines {
+			if !json.Valid([]byte(line)) {
+				t.Errorf("expected a valid json line, got %q", line)
+			}
+		}
+	}
+}
+
+func Test_NullProbabilityWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`prefix {{.alpha}}`)
+	t.Logf("with template: %s", string(template))
+
+	for _, nullProbability := range []float64{0.0, 0.5, 1.0} {
+		t.Run(fmt.Sprintf("with null probability %.1f", nullProbability), func(t *testing.T) {
+			yaml := []byte(fmt.Sprintf("- name: alpha\n  enum: [\"value\"]\n  null_probability: %f", nullProbability))
+			cfg, err := config.LoadConfigFromYaml(yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			totEvents := 10000
+			g, err := NewGeneratorWithCustomTemplateN(template, cfg, flds, uint64(totEvents))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var nulls int
+			var buf bytes.Buffer
+			state := NewGenState()
+			for i := 0; i < totEvents; i++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				switch buf.String() {
+				case "":
+					nulls += 1
+				case "prefix value":
+				default:
+					t.Fatalf("unexpected event: %q", buf.String())
+				}
+			}
+
+			omissionRate := float64(nulls) / float64(totEvents)
+			if math.Abs(omissionRate-nullProbability) > 0.02 {
+				t.Errorf("expected omission rate %.2f, got %.4f", nullProbability, omissionRate)
+			}
+		})
+	}
+}
+
+func Test_NullProbabilityJSONWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeLong},
+		{Name: "beta", Type: FieldTypeKeyword},
+		{Name: "gamma", Type: FieldTypeLong},
+		{Name: "delta", Type: FieldTypeKeyword},
+	}
+
+	testCases := []struct {
+		template string
+		allNulls string
+	}{
+		{
+			template: `{"alpha":{{.alpha}},"beta":"{{.beta}}"}`,
+			allNulls: `{}`,
+		},
+		{
+			template: `{"beta":"{{.beta}}","gamma":{{.gamma}},"alpha":{{.alpha}}}`,
+			allNulls: `{}`,
+		},
+		{
+			template: `{ "alpha": {{.alpha}}, "beta": "{{.beta}}", "nested": { "gamma": {{.gamma}}, "delta": "{{.delta}}" } }`,
+			allNulls: `{ "nested": {} }`,
+		},
+		{
+			template: `{"list":["{{.beta}}",{{.alpha}}],"nested":{"delta":"{{.delta}}"},"gamma":{{.gamma}},"text":"{not a bracket}"}`,
+			allNulls: `{"list":[],"nested":{},"text":"{not a bracket}"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.template, func(t *testing.T) {
+			t.Run("with the first field null", func(t *testing.T) {
+				cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  null_probability: 1.0\n- name: beta\n  null_probability: 1.0"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, []byte(testCase.template), 0)
+
+				var buf bytes.Buffer
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				event := unmarshalJSONT[any](t, buf.Bytes())
+				if _, ok := event["alpha"]; ok {
+					t.Errorf("expected alpha omitted, got %s", buf.String())
+				}
+			})
+
+			t.Run("with all fields null", func(t *testing.T) {
+				yaml := "- name: alpha\n  null_probability: 1.0\n- name: beta\n  null_probability: 1.0\n" +
+					"- name: gamma\n  null_probability: 1.0\n- name: delta\n  null_probability: 1.0"
+				cfg, err := config.LoadConfigFromYaml([]byte(yaml))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, []byte(testCase.template), 0)
+
+				var buf bytes.Buffer
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				if buf.String() != testCase.allNulls {
+					t.Errorf("expected %s, got %s", testCase.allNulls, buf.String())
+				}
+			})
+
+			t.Run("with any field null", func(t *testing.T) {
+				yaml := "- name: alpha\n  null_probability: 0.5\n- name: beta\n  null_probability: 0.5\n" +
+					"- name: gamma\n  null_probability: 0.5\n- name: delta\n  null_probability: 0.5"
+				cfg, err := config.LoadConfigFromYaml([]byte(yaml))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, []byte(testCase.template), 0)
+
+				var buf bytes.Buffer
+				for i := 0; i < 1000; i++ {
+					buf.Reset()
+					if err := g.Emit(state, &buf); err != nil {
+						t.Fatal(err)
+					}
+
+					if !json.Valid(buf.Bytes()) {
+						t.Fatalf("expected valid JSON, got %s", buf.String())
+					}
+				}
+			})
+		})
+	}
+}
+
+func Test_WeightedEnumWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeKeyword},
+	}
+
+	yaml := []byte(`- name: alpha
+  weighted_enum:
+    - value: info
+      weight: 70
+    - value: warn
+      weight: 20
+    - value: error
+      weight: 10
+    - value: never
+      weight: 0
+- name: beta
+  weighted_enum:
+    - value: a
+    - value: b
+`)
+	cfg, err := config.LoadConfigFromYaml(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{{.alpha}} {{.beta}}`)
+	t.Logf("with template: %s", string(template))
+
+	totEvents := 100000
+	g, err := NewGeneratorWithCustomTemplateN(template, cfg, flds, uint64(totEvents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	observed := make(map[string]int)
+	var buf bytes.Buffer
+	state := NewGenState()
+	for i := 0; i < totEvents; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		values := strings.Split(buf.String(), " ")
+		observed[values[0]] += 1
+		observed[values[1]] += 1
+	}
+
+	expected := map[string]float64{"info": 0.7, "warn": 0.2, "error": 0.1, "never": 0, "a": 0.5, "b": 0.5}
+	for value, expectedRate := range expected {
+		observedRate := float64(observed[value]) / float64(totEvents)
+		if math.Abs(observedRate-expectedRate) > 0.01 {
+			t.Errorf("expected rate %.2f for %s, got %.4f", expectedRate, value, observedRate)
+		}
+	}
+}
+
+func Test_DistributionWithCustomTemplate(t *testing.T) {
+	// expected mean and variance of the zipf distribution with s=2, v=1 and imax=100
+	var zipfNorm, zipfMean, zipfSquaresMean float64
+	for k := 0; k <= 100; k++ {
+		p := math.Pow(1+float64(k), -2)
+		zipfNorm += p
+		zipfMean += float64(k) * p
+		zipfSquaresMean += float64(k*k) * p
+	}
+
+	zipfMean /= zipfNorm
+	zipfVariance := zipfSquaresMean/zipfNorm - zipfMean*zipfMean
+
+	testCases := []struct {
+		scenario         string
+		fieldType        string
+		configYaml       string
+		expectedMean     float64
+		expectedVariance float64
+	}{
+		{
+			scenario:         "normal",
+			fieldType:        FieldTypeDouble,
+			configYaml:       "distribution:\n    type: normal\n    mean: 100\n    stddev: 15",
+			expectedMean:     100,
+			expectedVariance: 225,
+		},
+		{
+			scenario:         "exponential",
+			fieldType:        FieldTypeDouble,
+			configYaml:       "distribution:\n    type: exponential\n    lambda: 0.5",
+			expectedMean:     2,
+			expectedVariance: 4,
+		},
+		{
+			scenario:         "zipf",
+			fieldType:        FieldTypeLong,
+			configYaml:       "range:\n    min: 0\n    max: 100\n  distribution:\n    type: zipf\n    s: 2\n    v: 1",
+			expectedMean:     zipfMean,
+			expectedVariance: zipfVariance,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			flds := Fields{
+				{Name: "alpha", Type: testCase.fieldType},
+			}
+
+			cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  " + testCase.configYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template := []byte(`{{.alpha}}`)
+			t.Logf("with template: %s", string(template))
+
+			totEvents := 100000
+			g, err := NewGeneratorWithCustomTemplateN(template, cfg, flds, uint64(totEvents))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var sum, sumSquares float64
+			var buf bytes.Buffer
+			state := NewGenState()
+			for i := 0; i < totEvents; i++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				v, err := strconv.ParseFloat(buf.String(), 64)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				sum += v
+				sumSquares += v * v
+			}
+
+			mean := sum / float64(totEvents)
+			variance := sumSquares/float64(totEvents) - mean*mean
+
+			if math.Abs(mean-testCase.expectedMean) > testCase.expectedMean*0.05 {
+				t.Errorf("expected mean %f, got %f", testCase.expectedMean, mean)
+			}
+
+			if math.Abs(variance-testCase.expectedVariance) > testCase.expectedVariance*0.1 {
+				t.Errorf("expected variance %f, got %f", testCase.expectedVariance, variance)
+			}
+		})
+	}
+}
+
+func Test_DistributionClampedToRangeWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeDouble},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  range:\n    min: -5\n    max: 5\n  distribution:\n    type: normal\n    mean: 0\n    stddev: 10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{{.alpha}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, err := NewGeneratorWithCustomTemplateN(template, cfg, flds, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	state := NewGenState()
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := strconv.ParseFloat(buf.String(), 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v < -5 || v > 5 {
+			t.Fatalf("expected value in [-5, 5], got %f", v)
+		}
+	}
+}
+
+// distributionWithinRangeCases are the numeric fields with distributions, and fuzziness, generating values past tight range bounds
+var distributionWithinRangeCases = []struct {
+	fieldType  string
+	configYaml string
+	min, max   float64
+}{
+	{
+		fieldType:  FieldTypeLong,
+		configYaml: "range:\n    min: 9.5\n    max: 10.5\n  distribution:\n    type: normal\n    mean: 10\n    stddev: 1",
+		min:        9.5,
+		max:        10.5,
+	},
+	{
+		fieldType:  FieldTypeLong,
+		configYaml: "range:\n    min: -12\n    max: -8\n  fuzziness: 0.1\n  distribution:\n    type: normal\n    mean: -10\n    stddev: 3",
+		min:        -12,
+		max:        -8,
+	},
+	{
+		fieldType:  FieldTypeLong,
+		configYaml: "range:\n    min: 0\n    max: 2\n  fuzziness: 0.5\n  distribution:\n    type: exponential\n    lambda: 0.5",
+		min:        0,
+		max:        2,
+	},
+	{
+		fieldType:  FieldTypeDouble,
+		configYaml: "range:\n    min: -0.05\n    max: 0.05\n  fuzziness: 0.5\n  distribution:\n    type: normal\n    mean: 0\n    stddev: 0.1",
+		min:        -0.05,
+		max:        0.05,
+	},
+	{
+		fieldType:  FieldTypeScaledFloat,
+		configYaml: "scaling_factor: 10\n  range:\n    min: 0.95\n    max: 1.05\n  distribution:\n    type: normal\n    mean: 1\n    stddev: 0.2",
+		min:        0.95,
+		max:        1.05,
+	},
+	{
+		fieldType:  FieldTypeHalfFloat,
+		configYaml: "range:\n    min: 0.999\n    max: 1.001\n  distribution:\n    type: normal\n    mean: 1\n    stddev: 1",
+		min:        0.999,
+		max:        1.001,
+	},
+}
+
+func Test_DistributionWithinRangeWithCustomTemplate(t *testing.T) {
+	const samples = 100000
+
+	for _, testCase := range distributionWithinRangeCases {
+		flds := Fields{
+			{Name: "alpha", Type: testCase.fieldType},
+		}
+
+		cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  " + testCase.configYaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		template := []byte(`{{.alpha}}`)
+		t.Logf("with template: %s", string(template))
+
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+		var outOfRange int
+		var buf bytes.Buffer
+		for i := 0; i < samples; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := strconv.ParseFloat(buf.String(), 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v < testCase.min || v > testCase.max {
+				outOfRange++
+			}
+		}
+
+		if outOfRange > 0 {
+			t.Fatalf("expected %s values in [%v, %v], got %d out of range with config %q", testCase.fieldType, testCase.min, testCase.max, outOfRange, testCase.configYaml)
+		}
+	}
+}
+
+// greatCircleDistanceKm is the haversine distance between two points, in kilometers
+func greatCircleDistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+func Test_FieldGeoPointInRegionWithCustomTemplate(t *testing.T) {
+	testCases := []struct {
+		scenario   string
+		configYaml string
+		template   []byte
+		inRegion   func(lat, lon float64) bool
+	}{
+		{
+			scenario:   "bounding box",
+			configYaml: "geo_point:\n    bounding_box:\n      min_lat: 45.5\n      max_lat: 47\n      min_lon: 7\n      max_lon: 10.25",
+			template:   []byte(`{"alpha":"{{.alpha}}"}`),
+			inRegion: func(lat, lon float64) bool {
+				return lat >= 45.5 && lat <= 47 && lon >= 7 && lon <= 10.25
+			},
+		},
+		{
+			scenario:   "bounding box with object format",
+			configYaml: "geo_point:\n    format: object\n    bounding_box:\n      min_lat: -10\n      max_lat: 10\n      min_lon: -20\n      max_lon: 20",
+			template:   []byte(`{"alpha":{{.alpha}}}`),
+			inRegion: func(lat, lon float64) bool {
+				return lat >= -10 && lat <= 10 && lon >= -20 && lon <= 20
+			},
+		},
+		{
+			scenario:   "radius",
+			configYaml: "geo_point:\n    center:\n      lat: 52.52\n      lon: 13.405\n    radius: 50",
+			template:   []byte(`{"alpha":"{{.alpha}}"}`),
+			inRegion: func(lat, lon float64) bool {
+				return greatCircleDistanceKm(52.52, 13.405, lat, lon) <= 50+1e-3
+			},
+		},
+		{
+			scenario:   "radius across the antimeridian",
+			configYaml: "geo_point:\n    format: object\n    center:\n      lat: -17\n      lon: 179.9\n    radius: 500",
+			template:   []byte(`{"alpha":{{.alpha}}}`),
+			inRegion: func(lat, lon float64) bool {
+				return lon >= -180 && lon <= 180 && greatCircleDistanceKm(-17, 179.9, lat, lon) <= 500+1e-3
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			flds := Fields{
+				{Name: "alpha", Type: FieldTypeGeoPoint},
+			}
+
+			cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  " + testCase.configYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("with template: %s", string(testCase.template))
+
+			g, err := NewGeneratorWithCustomTemplateN(testCase.template, cfg, flds, 10000)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			state := NewGenState()
+			for i := 0; i < 10000; i++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				var lat, lon float64
+				if cfg, _ := cfg.GetField("alpha"); cfg.GeoPoint.Format == config.GeoPointFormatObject {
+					m := unmarshalJSONT[map[string]float64](t, buf.Bytes())
+					lat, lon = m["alpha"]["lat"], m["alpha"]["lon"]
+				} else {
+					m := unmarshalJSONT[string](t, buf.Bytes())
+					latLon := strings.Split(m["alpha"], ",")
+					lat, _ = strconv.ParseFloat(latLon[0], 64)
+					lon, _ = strconv.ParseFloat(latLon[1], 64)
+				}
+
+				if !testCase.inRegion(lat, lon) {
+					t.Fatalf("expected point in region, got %f,%f", lat, lon)
+				}
+			}
+		})
+	}
+}
+
+func Test_FieldIPInCIDRWithCustomTemplate(t *testing.T) {
+	for _, cidr := range []string{"10.0.0.0/8", "192.168.1.0/28", "2001:db8::/32", "fd00:1234::/120"} {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		flds := Fields{
+			{Name: "alpha", Type: FieldTypeIP},
+		}
+
+		cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  cidr: " + cidr))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		template := []byte(`{"alpha":"{{.alpha}}"}`)
+		t.Logf("with template: %s", string(template))
+
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 1000; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			m := unmarshalJSONT[string](t, buf.Bytes())
+			ip := net.ParseIP(m["alpha"])
+			if ip == nil {
+				t.Fatalf("Fail parse ip %s", m["alpha"])
+			}
+
+			if !ipNet.Contains(ip) {
+				t.Fatalf("expected ip in %s, got %s", cidr, ip)
+			}
+		}
+	}
+}
+
+func Test_FieldIPInvalidCIDRWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeIP},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  cidr: 10.0.0.0/33"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+		t.Fatal("expected error on invalid cidr")
+	}
+}
+
+func Test_FieldIPv6WithCustomTemplate(t *testing.T) {
+	for _, cidr := range []string{"", "2001:db8::/120"} {
+		flds := Fields{
+			{Name: "alpha", Type: FieldTypeIPv6},
+		}
+
+		ipNet := globalUnicastIPv6
+		cfg := Config{}
+		if len(cidr) > 0 {
+			_, ipNet, _ = net.ParseCIDR(cidr)
+
+			var err error
+			cfg, err = config.LoadConfigFromYaml([]byte("- name: alpha\n  cidr: " + cidr))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		template := []byte(`{"alpha":"{{.alpha}}"}`)
+		t.Logf("with template: %s", string(template))
+
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 1000; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			m := unmarshalJSONT[string](t, buf.Bytes())
+			ip := net.ParseIP(m["alpha"])
+			if ip == nil || len(ip) != net.IPv6len || ip.To4() != nil {
+				t.Fatalf("expected IPv6 address, got %s", m["alpha"])
+			}
+
+			// the zero runs are compressed, as in the canonical form
+			if ip.String() != m["alpha"] {
+				t.Fatalf("expected canonical form %s, got %s", ip, m["alpha"])
+			}
+
+			if !ipNet.Contains(ip) {
+				t.Fatalf("expected ip in %s, got %s", ipNet, ip)
+			}
+
+			if len(cidr) > 0 && !strings.HasPrefix(m["alpha"], "2001:db8::") {
+				t.Fatalf("expected compressed zero run, got %s", m["alpha"])
+			}
+		}
+	}
+}
+
+func Test_FieldIPv6InvalidCIDRWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeIPv6},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  cidr: 10.0.0.0/8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+		t.Fatal("expected error on IPv4 cidr")
+	}
+}
+
+func Test_FieldMACWithCustomTemplate(t *testing.T) {
+	macRegex := regexp.MustCompile(`^([0-9a-f]{2}:){5}[0-9a-f]{2}$`)
+
+	for _, configYaml := range []string{"", "- name: alpha\n  oui: 00:1A:2b", "- name: alpha\n  locally_administered: true"} {
+		flds := Fields{
+			{Name: "alpha", Type: FieldTypeMAC},
+		}
+
+		cfg, err := config.LoadConfigFromYaml([]byte(configYaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fieldCfg, _ := cfg.GetField("alpha")
+
+		template := []byte(`{"alpha":"{{.alpha}}"}`)
+		t.Logf("with template: %s", string(template))
+
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 1000; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			m := unmarshalJSONT[string](t, buf.Bytes())
+			if !macRegex.MatchString(m["alpha"]) {
+				t.Fatalf("expected mac address, got %s", m["alpha"])
+			}
+
+			mac, _ := net.ParseMAC(m["alpha"])
+			switch {
+			case len(fieldCfg.OUI) > 0:
+				if !strings.HasPrefix(m["alpha"], "00:1a:2b:") {
+					t.Fatalf("expected mac with oui 00:1a:2b, got %s", m["alpha"])
+				}
+			case fieldCfg.LocallyAdministered:
+				if mac[0]&0x03 != 0x02 {
+					t.Fatalf("expected locally administered unicast mac, got %s", m["alpha"])
+				}
+			default:
+				if mac[0]&0x03 != 0 {
+					t.Fatalf("expected universally administered unicast mac, got %s", m["alpha"])
+				}
+			}
+		}
+	}
+}
+
+func Test_FieldMACNotValidOUIWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeMAC},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  oui: 00:1a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+		t.Fatal("expected error on not valid oui")
+	}
+}
+
+func Test_FieldHTTPStatusWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeHTTPStatus},
+	}
+
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, Config{}, flds, template, 0)
+
+	const samples = 100000
+	classes := make(map[int]int)
+
+	var buf bytes.Buffer
+	for i := 0; i < samples; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[int](t, buf.Bytes())
+		if m["alpha"] < 100 || m["alpha"] > 599 {
+			t.Fatalf("expected http status code, got %d", m["alpha"])
+		}
+
+		classes[m["alpha"]/100]++
+	}
+
+	// the default distribution has 74.8% of 2xx and 1.2% of 5xx
+	if ratio := float64(classes[2]) / samples; ratio < 0.73 || ratio > 0.765 {
+		t.Fatalf("expected 2xx to dominate with about 74.8%%, got %.2f%%", ratio*100)
+	}
+
+	if ratio := float64(classes[5]) / samples; ratio < 0.008 || ratio > 0.016 {
+		t.Fatalf("expected rare 5xx with about 1.2%%, got %.2f%%", ratio*100)
+	}
+}
+
+func Test_FieldHTTPStatusWithWeightedEnumWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeHTTPStatus},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  weighted_enum:\n    - value: \"200\"\n      weight: 1\n    - value: \"503\"\n      weight: 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[int](t, buf.Bytes())
+		if m["alpha"] != 200 && m["alpha"] != 503 {
+			t.Fatalf("expected 200 or 503, got %d", m["alpha"])
+		}
+	}
+}
+
+func Test_FieldHTTPStatusNotValidWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeHTTPStatus},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  weighted_enum:\n    - value: ok"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+		t.Fatal("expected error on not valid http status code")
+	}
+}
+
+func Test_FieldScaledFloatWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeScaledFloat},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  scaling_factor: 1000\n  range:\n    min: 1\n    max: 100"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		raw := unmarshalJSONT[json.Number](t, buf.Bytes())["alpha"].String()
+		if _, decimals, found := strings.Cut(raw, "."); found && len(decimals) > 3 {
+			t.Fatalf("expected at most 3 decimals, got %s", raw)
+		}
+
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v < 1 || v > 100 {
+			t.Fatalf("expected value in range [1, 100], got %f", v)
+		}
+
+		if scaled := v * 1000; math.Abs(scaled-math.Round(scaled)) > 1e-6 {
+			t.Fatalf("expected value quantized by 1000, got %s", raw)
+		}
+	}
+}
+
+func Test_FieldNestedWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{
+			Name: "alpha",
+			Type: FieldTypeNested,
+			Fields: Fields{
+				{Name: "name", Type: FieldTypeKeyword},
+				{Name: "count", Type: FieldTypeLong},
+			},
+		},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  documents:\n    min: 2\n    max: 5\n- name: alpha.count\n  range:\n    min: 1\n    max: 10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		documents := unmarshalJSONT[[]map[string]any](t, buf.Bytes())["alpha"]
+		if len(documents) < 2 || len(documents) > 5 {
+			t.Fatalf("expected between 2 and 5 sub-documents, got %d: %s", len(documents), buf.String())
+		}
+
+		for _, document := range documents {
+			if len(document) != 2 {
+				t.Fatalf("expected 2 keys in sub-document, got %v", document)
+			}
+
+			if _, ok := document["name"].(string); !ok {
+				t.Fatalf("expected string name in sub-document, got %v", document)
+			}
+
+			if count, ok := document["count"].(float64); !ok || count < 1 || count > 10 {
+				t.Fatalf("expected count in range [1, 10] in sub-document, got %v", document)
+			}
+		}
+	}
+}
+
+func Test_ArrayLengthWithCustomTemplate(t *testing.T) {
+	testCases := []struct {
+		scenario   string
+		configYaml string
+		min        int
+		max        int
+	}{
+		{
+			scenario:   "fixed length",
+			configYaml: "- name: alpha\n  array_length:\n    min: 3\n    max: 3",
+			min:        3,
+			max:        3,
+		},
+		{
+			scenario:   "ranged length",
+			configYaml: "- name: alpha\n  array_length:\n    min: 1\n    max: 4",
+			min:        1,
+			max:        4,
+		},
+		{
+			scenario:   "ranged length with cardinality",
+			configYaml: "- name: alpha\n  array_length:\n    min: 1\n    max: 4\n  cardinality:\n    numerator: 1\n    denominator: 10",
+			min:        1,
+			max:        4,
+		},
+		{
+			scenario:   "empty array",
+			configYaml: "- name: alpha\n  array_length:\n    min: 0\n    max: 0",
+			min:        0,
+			max:        0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			flds := Fields{
+				{Name: "alpha", Type: FieldTypeIP},
+			}
+
+			cfg, err := config.LoadConfigFromYaml([]byte(testCase.configYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template := []byte(`{"alpha":{{.alpha}}}`)
+			t.Logf("with template: %s", string(template))
+
+			g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+			values := make(map[string]struct{})
+			lengths := make(map[int]struct{})
+			var buf bytes.Buffer
+			for i := 0; i < 1000; i++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				ips := unmarshalJSONT[[]string](t, buf.Bytes())["alpha"]
+				if ips == nil {
+					t.Fatalf("expected array, got %s", buf.String())
+				}
+
+				if len(ips) < testCase.min || len(ips) > testCase.max {
+					t.Fatalf("expected between %d and %d values, got %d", testCase.min, testCase.max, len(ips))
+				}
+
+				lengths[len(ips)] = struct{}{}
+				for _, ip := range ips {
+					if net.ParseIP(ip) == nil {
+						t.Fatalf("Fail parse ip %s", ip)
+					}
+
+					values[ip] = struct{}{}
+				}
+			}
+
+			if len(lengths) != testCase.max-testCase.min+1 {
+				t.Errorf("expected every length between %d and %d, got %v", testCase.min, testCase.max, lengths)
+			}
+
+			fieldCfg, _ := cfg.GetField("alpha")
+			if fieldCfg.Cardinality.Numerator > 0 && len(values) != 10 {
+				t.Errorf("expected 10 distinct values, got %d", len(values))
+			}
+		})
+	}
+}
+
+func Test_FieldRangeTypesWithCustomTemplate(t *testing.T) {
+	parseFloat := func(t *testing.T, raw json.RawMessage) float64 {
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	parseDate := func(t *testing.T, raw json.RawMessage) float64 {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			t.Fatal(err)
+		}
+
+		date, err := time.Parse(FieldTypeTimeLayout, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return float64(date.UnixMilli())
+	}
+
+	parseIP := func(t *testing.T, raw json.RawMessage) float64 {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			t.Fatal(err)
+		}
+
+		ip := net.ParseIP(v).To4()
+		if ip == nil {
+			t.Fatalf("Fail parse ip %s", v)
+		}
+
+		return float64(uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3]))
+	}
+
+	testCases := []struct {
+		fieldType  string
+		configYaml string
+		parse      func(t *testing.T, raw json.RawMessage) float64
+		min        float64
+		max        float64
+	}{
+		{
+			fieldType:  FieldTypeIntegerRange,
+			configYaml: "- name: alpha\n  range:\n    min: 10\n    max: 12",
+			parse:      parseFloat,
+			min:        10,
+			max:        12,
+		},
+		{
+			fieldType:  FieldTypeLongRange,
+			configYaml: "- name: alpha\n  range:\n    min: -100\n    max: 100",
+			parse:      parseFloat,
+			min:        -100,
+			max:        100,
+		},
+		{
+			fieldType:  FieldTypeLongRange,
+			configYaml: "- name: alpha\n  range:\n    min: -9223372036854775808\n    max: 9223372036854775807",
+			parse:      parseFloat,
+			min:        math.MinInt64,
+			max:        math.MaxInt64,
+		},
+		{
+			fieldType:  FieldTypeDoubleRange,
+			configYaml: "- name: alpha\n  range:\n    min: 0.5\n    max: 1.5",
+			parse:      parseFloat,
+			min:        0.5,
+			max:        1.5,
+		},
+		{
+			fieldType:  FieldTypeDateRange,
+			configYaml: "- name: alpha\n  range:\n    min: 1672531200000\n    max: 1672617600000",
+			parse:      parseDate,
+			min:        1672531200000,
+			max:        1672617600000,
+		},
+		{
+			fieldType:  FieldTypeDateRange,
+			configYaml: "- name: alpha\n  format: epoch_millis\n  range:\n    min: 1672531200000\n    max: 1672617600000",
+			parse:      parseFloat,
+			min:        1672531200000,
+			max:        1672617600000,
+		},
+		{
+			// only min set after now
+			fieldType:  FieldTypeDateRange,
+			configYaml: "- name: alpha\n  format: epoch_millis\n  range:\n    min: 4102444800000",
+			parse:      parseFloat,
+			min:        4102444800000,
+			max:        4102444800000 + FieldTypeTimeRange*1000,
+		},
+		{
+			// only max set before the time window
+			fieldType:  FieldTypeDateRange,
+			configYaml: "- name: alpha\n  format: epoch_millis\n  range:\n    max: 1672617600000",
+			parse:      parseFloat,
+			min:        1672617600000 - FieldTypeTimeRange*1000,
+			max:        1672617600000,
+		},
+		{
+			fieldType:  FieldTypeIPRange,
+			configYaml: "- name: alpha\n  cidr: 10.1.0.0/16",
+			parse:      parseIP,
+			min:        float64(10<<24 | 1<<16),
+			max:        float64(10<<24 | 1<<16 | 0xffff),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.fieldType, func(t *testing.T) {
+			flds := Fields{
+				{Name: "alpha", Type: testCase.fieldType},
+			}
+
+			cfg, err := config.LoadConfigFromYaml([]byte(testCase.configYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template := []byte(`{"alpha":{{.alpha}}}`)
+			t.Logf("with template: %s", string(template))
+
+			g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+			var buf bytes.Buffer
+			for i := 0; i < 1000; i++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				value := unmarshalJSONT[map[string]json.RawMessage](t, buf.Bytes())["alpha"]
+				if len(value) != 2 {
+					t.Fatalf("expected gte and lt only, got %s", buf.String())
+				}
+
+				gte, lt := testCase.parse(t, value["gte"]), testCase.parse(t, value["lt"])
+				if gte >= lt {
+					t.Fatalf("expected gte lower than lt, got %s", buf.String())
+				}
+
+				if gte < testCase.min || lt > testCase.max {
+					t.Fatalf("expected bounds between %f and %f, got %s", testCase.min, testCase.max, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_FieldRangeTypesNotValidWithCustomTemplate(t *testing.T) {
+	testCases := []struct {
+		fieldType  string
+		configYaml string
+	}{
+		{
+			fieldType:  FieldTypeLongRange,
+			configYaml: "- name: alpha\n  range:\n    min: 10\n    max: 10",
+		},
+		{
+			fieldType:  FieldTypeDateRange,
+			configYaml: "- name: alpha\n  format: unknown",
+		},
+		{
+			fieldType:  FieldTypeIPRange,
+			configYaml: "- name: alpha\n  cidr: 10.0.0.1/32",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.fieldType, func(t *testing.T) {
+			flds := Fields{
+				{Name: "alpha", Type: testCase.fieldType},
+			}
+
+			cfg, err := config.LoadConfigFromYaml([]byte(testCase.configYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+// objectKeys returns the keys of the JSON object in data as they are, including duplicates
+func objectKeys(t *testing.T, data []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		t.Fatalf("expected object, got %s", string(data))
+	}
+
+	var keys []string
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys = append(keys, token.(string))
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return keys
+}
+
+func Test_FieldObjectDynamicKeysWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeObject, ObjectType: FieldTypeLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  dynamic_keys:\n    keys:\n      min: 2\n      max: 6\n    alphabet: ab\n    key_length:\n      min: 3\n      max: 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		object := unmarshalJSONT[json.RawMessage](t, buf.Bytes())["alpha"]
+		keys := objectKeys(t, object)
+		if len(keys) < 2 || len(keys) > 6 {
+			t.Fatalf("expected between 2 and 6 keys, got %d: %s", len(keys), buf.String())
+		}
+
+		unique := make(map[string]struct{})
+		for _, key := range keys {
+			if len(key) != 3 || strings.Trim(key, "ab") != "" {
+				t.Fatalf("expected key of 3 characters from the alphabet, got %s", key)
+			}
+
+			if _, ok := unique[key]; ok {
+				t.Fatalf("expected unique keys, got %s", buf.String())
+			}
+
+			unique[key] = struct{}{}
+		}
+
+		values := make(map[string]int64)
+		if err := json.Unmarshal(object, &values); err != nil {
+			t.Fatalf("expected long values: %s", err)
+		}
+	}
+}
+
+func Test_FieldObjectDynamicKeysNotValidWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeObject},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  dynamic_keys:\n    keys:\n      min: 5\n      max: 5\n    alphabet: ab\n    key_length:\n      min: 2\n      max: 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+		t.Fatal("expected error on not enough distinct key names")
+	}
+}
+
+func Test_FieldKeywordCharsetWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  charset: hex\n  length:\n    min: 32\n    max: 32"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		value := unmarshalJSONT[string](t, buf.Bytes())["alpha"]
+		if len(value) != 32 || strings.Trim(value, "0123456789abcdef") != "" {
+			t.Fatalf("expected hex value of 32 characters, got %s", value)
+		}
+	}
+}
+
+func Test_FieldKeywordCharsetRangedLengthWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  charset: 'xyz-'\n  length:\n    min: 4\n    max: 12"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	lengths := make(map[int]struct{})
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		value := unmarshalJSONT[string](t, buf.Bytes())["alpha"]
+		if len(value) < 4 || len(value) > 12 || strings.Trim(value, "xyz-") != "" {
+			t.Fatalf("expected value between 4 and 12 characters from the charset, got %s", value)
+		}
+
+		lengths[len(value)] = struct{}{}
+	}
+
+	if len(lengths) != 9 {
+		t.Fatalf("expected values of each length between 4 and 12, got %d different lengths", len(lengths))
+	}
+}
+
+func Test_FieldKeywordCharsetNotValidWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  charset: 'ab\"'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+		t.Fatal("expected error on charset with quotes")
+	}
+}
+
+func Test_EventIndexWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}","index":{{.@eventIndex}}}`)
+	t.Logf("with template: %s", string(template))
+
+	// the events emitted for estimating the total number of events don't count
+	g, err := NewGeneratorWithCustomTemplate(template, Config{}, flds, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+
+	var buf bytes.Buffer
+	for i := 0; i < 10; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if index := unmarshalJSONT[any](t, buf.Bytes())["index"]; index != float64(i) {
+			t.Fatalf("expected event index %d, got %v", i, index)
+		}
+	}
+}
+
+func Test_FieldCounterWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  counter:\n    start: 10\n    step: 5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+
+	// generators are reconstructed with a total size, such that the estimation pass runs, and emit with their own state
+	for r := 0; r < 2; r++ {
+		g, _ := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 100000)
+
+		var buf bytes.Buffer
+		for i := 0; i < 100; i++ {
+			buf.Reset()
+			if err := g.Emit(nil, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			if value := unmarshalJSONT[int64](t, buf.Bytes())["alpha"]; value != int64(10+i*5) {
+				t.Fatalf("expected value %d, got %d", 10+i*5, value)
+			}
+		}
+	}
+}
+
+func Test_FieldSameAsWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "source.ip", Type: FieldTypeIP},
+		{Name: "client.ip", Type: FieldTypeIP},
+		{Name: "host.name", Type: FieldTypeKeyword},
+		{Name: "host.hostname", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: client.ip\n  same_as: source.ip\n- name: host.hostname\n  same_as: host.name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the fields referencing another one are emitted both before and after it
+	template := []byte(`{"client.ip":"{{.client.ip}}","source.ip":"{{.source.ip}}","host.name":"{{.host.name}}","host.hostname":"{{.host.hostname}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	values := make(map[string]struct{})
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if m["client.ip"] != m["source.ip"] {
+			t.Fatalf("expected client.ip same as source.ip, got %s", buf.String())
+		}
+
+		if m["host.hostname"] != m["host.name"] {
+			t.Fatalf("expected host.hostname same as host.name, got %s", buf.String())
+		}
+
+		values[m["source.ip"]] = struct{}{}
+	}
+
+	if len(values) < 2 {
+		t.Errorf("expected different values across events, got %d", len(values))
+	}
+}
+
+func Test_FieldSameAsCycleWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeKeyword},
+		{Name: "gamma", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  same_as: beta\n- name: beta\n  same_as: gamma\n- name: gamma\n  same_as: alpha"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); err == nil {
+		t.Fatal("expected error on same_as cycle")
+	}
+}
+
+func Test_FieldBoolTrueProbabilityWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeBool},
+	}
+
+	for _, trueProbability := range []float64{0.0, 0.2, 1.0} {
+		t.Run(fmt.Sprintf("with true probability %.1f", trueProbability), func(t *testing.T) {
+			cfg, err := config.LoadConfigFromYaml([]byte(fmt.Sprintf("- name: alpha\n  true_probability: %f", trueProbability)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template := []byte(`{"alpha":{{.alpha}}}`)
+			t.Logf("with template: %s", string(template))
+
+			g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+			totEvents := 50000
+			var trues int
+			var buf bytes.Buffer
+			for i := 0; i < totEvents; i++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				if unmarshalJSONT[bool](t, buf.Bytes())["alpha"] {
+					trues += 1
+				}
+			}
+
+			trueRate := float64(trues) / float64(totEvents)
+			if math.Abs(trueRate-trueProbability) > 0.01 {
+				t.Errorf("expected true rate %.2f, got %.4f", trueProbability, trueRate)
+			}
+		})
+	}
+}
+
+func Test_FieldBoolTrueProbabilityNotValid(t *testing.T) {
+	if _, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  true_probability: 1.5")); err == nil {
+		t.Fatal("expected error on true probability greater than 1")
+	}
+}
+
+func Test_ResetWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{{.beta}}, "gamma":"{{.gamma}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	for _, seed := range []int64{0, 1} {
+		cfg, err := config.LoadConfigFromYaml([]byte("- name: beta\n  range:\n    min: 0\n    max: 1000\n- name: gamma\n  cardinality:\n    numerator: 1\n    denominator: 10"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg.Seed = seed
+
+		g, err := NewGeneratorWithCustomTemplate(template, cfg, flds, 10000)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		run := func() []byte {
+			var events bytes.Buffer
+			var buf bytes.Buffer
+			for {
+				buf.Reset()
+				err := g.Emit(nil, &buf)
+				if err == io.EOF {
+					return events.Bytes()
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				events.Write(buf.Bytes())
+				events.WriteByte('\n')
+			}
+		}
+
+		first := run()
+		g.Reset()
+		second := run()
+
+		if firstCount, secondCount := bytes.Count(first, []byte{'\n'}), bytes.Count(second, []byte{'\n'}); firstCount != secondCount {
+			t.Fatalf("expected %d events after reset, got %d", firstCount, secondCount)
+		}
+
+		if seed != 0 && !bytes.Equal(first, second) {
+			t.Fatalf("expected the same events after reset with seed %d", seed)
+		}
+	}
+}
+
+func Test_ValidateJSONWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	for _, cfg := range []Config{{ValidateJSON: true}, {ValidateJSON: true, NDJSON: true}} {
+		valid := []byte(`{"alpha":"{{.alpha}}"}`)
+		t.Logf("with template: %s", string(valid))
+
+		g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, valid, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 100; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			unmarshalJSONT[string](t, buf.Bytes())
+		}
+
+		broken := []byte(`{"alpha":"{{.alpha}}",}`)
+		t.Logf("with template: %s", string(broken))
+
+		g, state = makeGeneratorWithCustomTemplate(t, cfg, flds, broken, 0)
+
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if !errors.Is(err, notValidJSON) {
+			t.Fatalf("expected error on not valid JSON event, got %v", err)
+		}
+
+		if !strings.Contains(err.Error(), "event 0 at offset ") || !strings.Contains(err.Error(), `",}`) {
+			t.Fatalf("expected error reporting the event and the offset, got %v", err)
+		}
+
+		if buf.Len() > 0 {
+			t.Fatalf("expected nothing emitted for a not valid JSON event, got %s", buf.String())
+		}
+	}
+
+	// the events are not validated by default
+	broken := []byte(`{"alpha":"{{.alpha}}",}`)
+	g, state := makeGeneratorWithCustomTemplate(t, Config{}, flds, broken, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); err != nil {
+		t.Fatalf("expected no validation by default, got %v", err)
+	}
 }
@@ -6,41 +6,81 @@ package genlib
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"math"
 	"math/rand"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
 )
 
 var generateOnFieldNotInFieldsYaml = errors.New("generate called on a field not present in fields yaml definition")
+var notValidUserAgentCategory = errors.New("not valid user agent category")
+var notValidChoice = errors.New("choice takes at least a value")
+var notValidWeightedChoice = errors.New("weightedChoice takes at least a pair of value and weight, with weights greater than or equal to 0")
+var notValidDurationRange = errors.New("randomDuration min and max must be durations, with min lower than or equal to max")
+var notValidDurationUnit = errors.New("randomDuration unit must be one of 'ns', 'us', 'ms' or 's'")
+var notValidBase64Length = errors.New("randomBase64 length must be greater than or equal to 0, and lower than or equal to max length")
+var notValidPathOS = errors.New("randomPath os must be one of 'windows', 'linux' or 'darwin'")
+var notValidPathDepth = errors.New("randomPath depth must be greater than or equal to 0")
+var notValidProcessOS = errors.New("randomProcessName and randomCommandLine os must be one of 'windows', 'linux' or 'darwin'")
+var notValidFQDNLabels = errors.New("randomFQDN max labels must be greater than or equal to 2")
+var notValidEmailDomain = errors.New("randomEmail domains must not be empty, contain '@' nor start or end with a dot")
+var notValidPortMode = errors.New("randomPort mode must be one of 'well-known', 'ephemeral' or 'any'")
+var notValidHumanBytes = errors.New("humanBytes takes a number of bytes greater than or equal to 0, and units either 'binary' or 'decimal'")
+var notValidTLSVersion = errors.New("randomTLSCipher version must be one of '1.0', '1.1', '1.2' or '1.3'")
+var notValidLogLevelWeights = errors.New("randomLogLevel weights must be a dictionary of levels with weights greater than or equal to 0")
+var notConfiguredECSVersion = errors.New("ecsVersion requires the ECS version to be configured")
 
 // GeneratorWithTextTemplate
 type GeneratorWithTextTemplate struct {
-	tpl       *template.Template
-	state     *GenState
-	errChan   chan error
-	totEvents uint64
+	tpl          *template.Template
+	fieldMap     map[string]any
+	seed         int64
+	ndjson       bool
+	prettyJSON   bool
+	validateJSON bool
+	state        *GenState
+	totEvents    uint64
+	outputs      *outputs
 }
 
-// awsAZs list all possible AZs for a specific AWS region
-// NOTE: this list is not comprehensive
-// missing regions: af-south-1, ap-south-2, ap-southeast-3, ap-southeast-4, eu-central-2, eu-south-1, eu-south-2, me-central-1
+// awsAZs list all possible AZs for a specific AWS commercial region
 var awsAZs map[string][]string = map[string][]string{
+	"af-south-1":     {"af-south-1a", "af-south-1b", "af-south-1c"},
 	"ap-east-1":      {"ap-east-1a", "ap-east-1b", "ap-east-1c"},
 	"ap-northeast-1": {"ap-northeast-1a", "ap-northeast-1c", "ap-northeast-1d"},
 	"ap-northeast-2": {"ap-northeast-2a", "ap-northeast-2b", "ap-northeast-2c", "ap-northeast-2d"},
 	"ap-northeast-3": {"ap-northeast-3a", "ap-northeast-3b", "ap-northeast-3c"},
 	"ap-south-1":     {"ap-south-1a", "ap-south-1b", "ap-south-1c"},
+	"ap-south-2":     {"ap-south-2a", "ap-south-2b", "ap-south-2c"},
 	"ap-southeast-1": {"ap-southeast-1a", "ap-southeast-1b", "ap-southeast-1c"},
 	"ap-southeast-2": {"ap-southeast-2a", "ap-southeast-2b", "ap-southeast-2c"},
+	"ap-southeast-3": {"ap-southeast-3a", "ap-southeast-3b", "ap-southeast-3c"},
+	"ap-southeast-4": {"ap-southeast-4a", "ap-southeast-4b", "ap-southeast-4c"},
 	"ca-central-1":   {"ca-central-1a", "ca-central-1b", "ca-central-1d"},
+	"ca-west-1":      {"ca-west-1a", "ca-west-1b", "ca-west-1c"},
 	"eu-central-1":   {"eu-central-1a", "eu-central-1b", "eu-central-1c"},
+	"eu-central-2":   {"eu-central-2a", "eu-central-2b", "eu-central-2c"},
 	"eu-north-1":     {"eu-north-1a", "eu-north-1b", "eu-north-1c"},
+	"eu-south-1":     {"eu-south-1a", "eu-south-1b", "eu-south-1c"},
+	"eu-south-2":     {"eu-south-2a", "eu-south-2b", "eu-south-2c"},
 	"eu-west-1":      {"eu-west-1a", "eu-west-1b", "eu-west-1c"},
 	"eu-west-2":      {"eu-west-2a", "eu-west-2b", "eu-west-2c"},
 	"eu-west-3":      {"eu-west-3a", "eu-west-3b", "eu-west-3c"},
+	"il-central-1":   {"il-central-1a", "il-central-1b", "il-central-1c"},
+	"me-central-1":   {"me-central-1a", "me-central-1b", "me-central-1c"},
 	"me-south-1":     {"me-south-1a", "me-south-1b", "me-south-1c"},
 	"sa-east-1":      {"sa-east-1a", "sa-east-1b", "sa-east-1c"},
 	"us-east-1":      {"us-east-1a", "us-east-1b", "us-east-1c", "us-east-1d", "us-east-1e", "us-east-1f"},
@@ -49,143 +89,1054 @@ var awsAZs map[string][]string = map[string][]string{
 	"us-west-2":      {"us-west-2a", "us-west-2b", "us-west-2c", "us-west-2d"},
 }
 
-func calculateTotEventsWithTextTemplate(totSize uint64, fieldMap map[string]any, errChan chan error, tpl []byte, templateFns template.FuncMap) (uint64, error) {
+// gcpZones list all possible zones for a specific GCP region
+// NOTE: this list is not comprehensive
+var gcpZones map[string][]string = map[string][]string{
+	"asia-east1":              {"asia-east1-a", "asia-east1-b", "asia-east1-c"},
+	"asia-east2":              {"asia-east2-a", "asia-east2-b", "asia-east2-c"},
+	"asia-northeast1":         {"asia-northeast1-a", "asia-northeast1-b", "asia-northeast1-c"},
+	"asia-northeast2":         {"asia-northeast2-a", "asia-northeast2-b", "asia-northeast2-c"},
+	"asia-northeast3":         {"asia-northeast3-a", "asia-northeast3-b", "asia-northeast3-c"},
+	"asia-south1":             {"asia-south1-a", "asia-south1-b", "asia-south1-c"},
+	"asia-south2":             {"asia-south2-a", "asia-south2-b", "asia-south2-c"},
+	"asia-southeast1":         {"asia-southeast1-a", "asia-southeast1-b", "asia-southeast1-c"},
+	"asia-southeast2":         {"asia-southeast2-a", "asia-southeast2-b", "asia-southeast2-c"},
+	"australia-southeast1":    {"australia-southeast1-a", "australia-southeast1-b", "australia-southeast1-c"},
+	"australia-southeast2":    {"australia-southeast2-a", "australia-southeast2-b", "australia-southeast2-c"},
+	"europe-central2":         {"europe-central2-a", "europe-central2-b", "europe-central2-c"},
+	"europe-north1":           {"europe-north1-a", "europe-north1-b", "europe-north1-c"},
+	"europe-southwest1":       {"europe-southwest1-a", "europe-southwest1-b", "europe-southwest1-c"},
+	"europe-west1":            {"europe-west1-b", "europe-west1-c", "europe-west1-d"},
+	"europe-west2":            {"europe-west2-a", "europe-west2-b", "europe-west2-c"},
+	"europe-west3":            {"europe-west3-a", "europe-west3-b", "europe-west3-c"},
+	"europe-west4":            {"europe-west4-a", "europe-west4-b", "europe-west4-c"},
+	"europe-west6":            {"europe-west6-a", "europe-west6-b", "europe-west6-c"},
+	"europe-west8":            {"europe-west8-a", "europe-west8-b", "europe-west8-c"},
+	"europe-west9":            {"europe-west9-a", "europe-west9-b", "europe-west9-c"},
+	"me-west1":                {"me-west1-a", "me-west1-b", "me-west1-c"},
+	"northamerica-northeast1": {"northamerica-northeast1-a", "northamerica-northeast1-b", "northamerica-northeast1-c"},
+	"northamerica-northeast2": {"northamerica-northeast2-a", "northamerica-northeast2-b", "northamerica-northeast2-c"},
+	"southamerica-east1":      {"southamerica-east1-a", "southamerica-east1-b", "southamerica-east1-c"},
+	"southamerica-west1":      {"southamerica-west1-a", "southamerica-west1-b", "southamerica-west1-c"},
+	"us-central1":             {"us-central1-a", "us-central1-b", "us-central1-c", "us-central1-f"},
+	"us-east1":                {"us-east1-b", "us-east1-c", "us-east1-d"},
+	"us-east4":                {"us-east4-a", "us-east4-b", "us-east4-c"},
+	"us-east5":                {"us-east5-a", "us-east5-b", "us-east5-c"},
+	"us-south1":               {"us-south1-a", "us-south1-b", "us-south1-c"},
+	"us-west1":                {"us-west1-a", "us-west1-b", "us-west1-c"},
+	"us-west2":                {"us-west2-a", "us-west2-b", "us-west2-c"},
+	"us-west3":                {"us-west3-a", "us-west3-b", "us-west3-c"},
+	"us-west4":                {"us-west4-a", "us-west4-b", "us-west4-c"},
+}
+
+// azureRegions list all possible availability zones for a specific Azure region
+// NOTE: this list is not comprehensive, only regions supporting availability zones are listed
+var azureRegions map[string][]string = map[string][]string{
+	"australiaeast":      {"australiaeast-1", "australiaeast-2", "australiaeast-3"},
+	"brazilsouth":        {"brazilsouth-1", "brazilsouth-2", "brazilsouth-3"},
+	"canadacentral":      {"canadacentral-1", "canadacentral-2", "canadacentral-3"},
+	"centralindia":       {"centralindia-1", "centralindia-2", "centralindia-3"},
+	"centralus":          {"centralus-1", "centralus-2", "centralus-3"},
+	"eastasia":           {"eastasia-1", "eastasia-2", "eastasia-3"},
+	"eastus":             {"eastus-1", "eastus-2", "eastus-3"},
+	"eastus2":            {"eastus2-1", "eastus2-2", "eastus2-3"},
+	"francecentral":      {"francecentral-1", "francecentral-2", "francecentral-3"},
+	"germanywestcentral": {"germanywestcentral-1", "germanywestcentral-2", "germanywestcentral-3"},
+	"israelcentral":      {"israelcentral-1", "israelcentral-2", "israelcentral-3"},
+	"italynorth":         {"italynorth-1", "italynorth-2", "italynorth-3"},
+	"japaneast":          {"japaneast-1", "japaneast-2", "japaneast-3"},
+	"koreacentral":       {"koreacentral-1", "koreacentral-2", "koreacentral-3"},
+	"northeurope":        {"northeurope-1", "northeurope-2", "northeurope-3"},
+	"norwayeast":         {"norwayeast-1", "norwayeast-2", "norwayeast-3"},
+	"polandcentral":      {"polandcentral-1", "polandcentral-2", "polandcentral-3"},
+	"qatarcentral":       {"qatarcentral-1", "qatarcentral-2", "qatarcentral-3"},
+	"southafricanorth":   {"southafricanorth-1", "southafricanorth-2", "southafricanorth-3"},
+	"southcentralus":     {"southcentralus-1", "southcentralus-2", "southcentralus-3"},
+	"southeastasia":      {"southeastasia-1", "southeastasia-2", "southeastasia-3"},
+	"swedencentral":      {"swedencentral-1", "swedencentral-2", "swedencentral-3"},
+	"switzerlandnorth":   {"switzerlandnorth-1", "switzerlandnorth-2", "switzerlandnorth-3"},
+	"uaenorth":           {"uaenorth-1", "uaenorth-2", "uaenorth-3"},
+	"uksouth":            {"uksouth-1", "uksouth-2", "uksouth-3"},
+	"westeurope":         {"westeurope-1", "westeurope-2", "westeurope-3"},
+	"westus2":            {"westus2-1", "westus2-2", "westus2-3"},
+	"westus3":            {"westus3-1", "westus3-2", "westus3-3"},
+}
+
+// userAgents list plausible user agent strings for each category
+// NOTE: this list is not comprehensive
+var userAgents map[string][]string = map[string][]string{
+	"browser": {
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.67",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Safari/605.1.15",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0",
+		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 OPR/109.0.0.0",
+	},
+	"mobile": {
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Mobile/15E148 Safari/604.1",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/124.0.6367.88 Mobile/15E148 Safari/604.1",
+		"Mozilla/5.0 (iPad; CPU OS 17_4_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Mobile/15E148 Safari/604.1",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.113 Mobile Safari/537.36",
+		"Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.113 Mobile Safari/537.36",
+		"Mozilla/5.0 (Linux; Android 13; SM-A536B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Mobile Safari/537.36",
+		"Mozilla/5.0 (Android 14; Mobile; rv:125.0) Gecko/125.0 Firefox/125.0",
+	},
+	"bot": {
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		"Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)",
+		"Mozilla/5.0 (compatible; YandexBot/3.0; +http://yandex.com/bots)",
+		"DuckDuckBot/1.1; (+http://duckduckgo.com/duckduckbot.html)",
+		"Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)",
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+		"curl/8.4.0",
+		"python-requests/2.31.0",
+	},
+}
+
+// allUserAgents is the user agent strings of every category, sorted by category
+var allUserAgents []string = func() []string {
+	categories := make([]string, 0, len(userAgents))
+	for category := range userAgents {
+		categories = append(categories, category)
+	}
+
+	sort.Strings(categories)
+
+	var all []string
+	for _, category := range categories {
+		all = append(all, userAgents[category]...)
+	}
+
+	return all
+}()
+
+// k8sDeployments and k8sNamespaces list plausible names of Kubernetes deployments and namespaces
+// NOTE: these lists are not comprehensive
+var k8sDeployments = []string{
+	"nginx", "frontend", "backend", "api-gateway", "checkout", "cart", "payment", "auth", "redis", "postgres",
+	"coredns", "metrics-server", "elastic-agent", "kube-state-metrics", "ingress-nginx-controller", "cert-manager", "prometheus", "grafana",
+}
+
+var k8sNamespaces = []string{
+	"default", "kube-system", "kube-public", "kube-node-lease", "monitoring", "logging", "ingress-nginx", "cert-manager", "production", "staging",
+}
+
+// k8sSafeAlphabet is the alphabet of the random suffixes of the Kubernetes generated names, without vowels and easily confused characters
+const k8sSafeAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+
+// randK8sSuffix returns a random suffix of n characters of the Kubernetes generated names
+func randK8sSuffix(r *rand.Rand, n int) string {
+	suffix := make([]byte, n)
+	for i := range suffix {
+		suffix[i] = k8sSafeAlphabet[r.Intn(len(k8sSafeAlphabet))]
+	}
+
+	return string(suffix)
+}
+
+// pathConvention is how the paths returned by randomPath look like on an OS: the roots they start from, the separator
+// of their directories, among the given ones, and the extensions of their file names
+type pathConvention struct {
+	roots      []string
+	separator  string
+	dirs       []string
+	extensions []string
+}
+
+// pathConventions are the conventions of the paths returned by randomPath for each OS
+// NOTE: these lists are not comprehensive
+var pathConventions = map[string]pathConvention{
+	"windows": {
+		roots:      []string{"C:", "C:", "C:", "D:", "E:"},
+		separator:  `\`,
+		dirs:       []string{"Windows", "System32", "Program Files", "Program Files (x86)", "ProgramData", "Users", "AppData", "Local", "Roaming", "Temp", "Microsoft", "Logs"},
+		extensions: []string{".exe", ".dll", ".sys", ".log", ".txt", ".ini", ".ps1", ".bat"},
+	},
+	"linux": {
+		roots:      []string{""},
+		separator:  "/",
+		dirs:       []string{"usr", "bin", "sbin", "lib", "local", "share", "var", "log", "etc", "opt", "home", "tmp", "run", "srv"},
+		extensions: []string{".so", ".log", ".conf", ".sh", ".py", ".txt", ".json", ".yml"},
+	},
+	"darwin": {
+		roots:      []string{""},
+		separator:  "/",
+		dirs:       []string{"Applications", "Library", "System", "Users", "Caches", "Logs", "Preferences", "usr", "bin", "local", "private", "var", "tmp"},
+		extensions: []string{".app", ".dylib", ".plist", ".log", ".sh", ".txt", ".json"},
+	},
+}
+
+// processNames and processArgs list plausible names of processes, and arguments of their command lines, for each OS
+// NOTE: these lists are not comprehensive
+var processNames = map[string][]string{
+	"windows": {
+		"svchost.exe", "explorer.exe", "powershell.exe", "cmd.exe", "lsass.exe", "services.exe", "winlogon.exe", "rundll32.exe",
+		"msedge.exe", "chrome.exe", "taskhostw.exe", "conhost.exe", "wmiprvse.exe", "regsvr32.exe", "notepad.exe", "spoolsv.exe",
+	},
+	"linux": {
+		"systemd", "sshd", "bash", "sh", "cron", "nginx", "python3", "java", "node", "dockerd",
+		"containerd", "kubelet", "curl", "sudo", "rsyslogd", "postgres",
+	},
+	"darwin": {
+		"launchd", "kernel_task", "WindowServer", "Finder", "Dock", "mds", "mdworker", "zsh",
+		"bash", "sshd", "cfprefsd", "trustd", "softwareupdated", "Safari", "python3", "curl",
+	},
+}
+
+var processArgs = map[string][]string{
+	"windows": {
+		"-k netsvcs", "-p", "/c", "-NoProfile", "-ExecutionPolicy Bypass", "-EncodedCommand", "/s", "/quiet",
+		"/norestart", "--type=renderer", "-Embedding", "/f", "-s", "/v",
+	},
+	"linux": {
+		"-D", "-e", "-f", "-v", "--verbose", "--no-daemon", "-c /etc/config.conf", "--user=root",
+		"-jar app.jar", "-m http.server", "--port=8080", "-o /tmp/out", "-l", "--config /etc/service.yml",
+	},
+	"darwin": {
+		"-s", "-v", "-l", "--verbose", "-f", "-psn_0_12345", "-NSDocumentRevisionsDebugMode YES",
+		"--config ~/Library/Preferences/config.plist", "-c", "-L", "-o /tmp/out",
+	},
+}
+
+// fqdnSubdomains, fqdnDomainWords and fqdnTLDs list plausible labels of the names returned by randomFQDN
+// NOTE: these lists are not comprehensive
+var fqdnSubdomains = []string{
+	"www", "api", "app", "mail", "smtp", "cdn", "static", "img", "auth", "login", "vpn", "dev", "staging", "prod", "internal",
+	"eu-west-1", "us-east-1", "ap-south-1", "edge", "ns1", "ns2", "git", "docs", "status", "metrics",
+}
+
+var fqdnDomainWords = []string{
+	"acme", "example", "contoso", "globex", "initech", "umbrella", "hooli", "wayne", "stark", "wonka",
+	"cloud", "data", "net", "web", "shop", "media", "tech", "secure", "online", "digital",
+}
+
+var fqdnTLDs = []string{"com", "com", "com", "net", "org", "io", "co", "dev", "app", "info", "co.uk", "de", "fr", "it", "jp"}
+
+const (
+	// defaultFQDNMaxLabels is the max number of labels of the names returned by randomFQDN when not given
+	defaultFQDNMaxLabels = 4
+	// fqdnMaxLength is the max length of a DNS name, without the trailing dot: the curated labels are way shorter than the max of 63
+	fqdnMaxLength = 253
+)
+
+// emailFirstNames and emailLastNames are the names the local part of the addresses returned by randomEmail are made of,
+// while defaultEmailDomains are the domains of common email providers they use when no domain is given
+// NOTE: these lists are not comprehensive
+var emailFirstNames = []string{
+	"james", "mary", "john", "patricia", "robert", "jennifer", "michael", "linda", "david", "elizabeth", "william", "barbara",
+	"maria", "luca", "sofia", "mohammed", "fatima", "wei", "yuki", "olga", "ahmed", "priya", "carlos", "ana",
+}
+
+var emailLastNames = []string{
+	"smith", "johnson", "williams", "brown", "jones", "garcia", "miller", "davis", "rodriguez", "martinez", "wilson", "anderson",
+	"rossi", "muller", "dubois", "kowalski", "nguyen", "kim", "tanaka", "ivanova", "singh", "silva", "cohen", "hansen",
+}
+
+var defaultEmailDomains = []string{"gmail.com", "yahoo.com", "outlook.com", "hotmail.com", "icloud.com", "proton.me", "aol.com"}
+
+// wellKnownPorts is the distribution of the ports returned by randomPort in the well-known mode: mostly HTTPS and HTTP,
+// then SSH, DNS and other common services
+var wellKnownPorts = []config.WeightedValue{
+	{Value: "443", Weight: 40},
+	{Value: "80", Weight: 20},
+	{Value: "22", Weight: 8},
+	{Value: "53", Weight: 8},
+	{Value: "123", Weight: 3},
+	{Value: "25", Weight: 2},
+	{Value: "587", Weight: 2},
+	{Value: "993", Weight: 2},
+	{Value: "3389", Weight: 2},
+	{Value: "445", Weight: 2},
+	{Value: "3306", Weight: 2},
+	{Value: "5432", Weight: 2},
+	{Value: "8080", Weight: 2},
+	{Value: "6379", Weight: 1},
+	{Value: "9200", Weight: 1},
+	{Value: "389", Weight: 1},
+	{Value: "21", Weight: 1},
+	{Value: "161", Weight: 1},
+}
+
+const (
+	// ephemeralPortMin and portMax are the bounds of the dynamic ports, as of the IANA ranges, and the last port
+	ephemeralPortMin = 49152
+	portMax          = 65535
+)
+
+// byteUnits are the bases and the names of the units of the sizes returned by humanBytes
+var byteUnits = map[string]struct {
+	base  float64
+	names []string
+}{
+	"binary":  {base: 1024, names: []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}},
+	"decimal": {base: 1000, names: []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}},
+}
+
+// formatHumanBytes formats the number of bytes with the greatest unit it's at least one of: bytes are formatted as integers,
+// other units with one decimal
+func formatHumanBytes(n float64, base float64, names []string) string {
+	if n < base {
+		return strconv.FormatFloat(n, 'f', 0, 64) + " " + names[0]
+	}
+
+	i := 0
+	for n >= base && i < len(names)-1 {
+		n /= base
+		i++
+	}
+
+	// the rounding to one decimal may make a whole greater unit
+	if math.Round(n*10)/10 >= base && i < len(names)-1 {
+		n /= base
+		i++
+	}
+
+	return strconv.FormatFloat(n, 'f', 1, 64) + " " + names[i]
+}
+
+// tlsVersions is the distribution of the versions returned by randomTLSVersion: mostly 1.3 and 1.2, with rare legacy ones
+var tlsVersions = []config.WeightedValue{
+	{Value: "1.3", Weight: 60},
+	{Value: "1.2", Weight: 37},
+	{Value: "1.1", Weight: 2},
+	{Value: "1.0", Weight: 1},
+}
+
+// tlsCiphers are the cipher suites, by their IANA names, that can be negotiated with each TLS version:
+// TLS 1.3 has its own suites, TLS 1.2 adds the AEAD ones to the CBC ones of the previous versions
+// NOTE: these lists are not comprehensive
+var tlsCiphers = map[string][]string{
+	"1.3": {
+		"TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384", "TLS_CHACHA20_POLY1305_SHA256",
+	},
+	"1.2": {
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256", "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256",
+		"TLS_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256",
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", "TLS_RSA_WITH_AES_128_CBC_SHA",
+	},
+	"1.1": {
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA", "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+		"TLS_RSA_WITH_AES_128_CBC_SHA", "TLS_RSA_WITH_AES_256_CBC_SHA", "TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+	},
+	"1.0": {
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA", "TLS_RSA_WITH_AES_128_CBC_SHA",
+		"TLS_RSA_WITH_AES_256_CBC_SHA", "TLS_RSA_WITH_3DES_EDE_CBC_SHA", "TLS_RSA_WITH_RC4_128_SHA",
+	},
+}
+
+// defaultLogLevels is the distribution of the levels of a typical application log: mostly info and debug, with rare errors
+var defaultLogLevels = []config.WeightedValue{
+	{Value: "debug", Weight: 20},
+	{Value: "info", Weight: 65},
+	{Value: "warn", Weight: 10},
+	{Value: "error", Weight: 4.5},
+	{Value: "fatal", Weight: 0.5},
+}
+
+// durationUnits are the units of the durations returned by randomDuration
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+// choiceWeight returns the weight of a weightedChoice pair, of any numeric type
+func choiceWeight(weight any) (float64, bool) {
+	v := reflect.ValueOf(weight)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), v.Int() >= 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), v.Float() >= 0
+	default:
+		return 0, false
+	}
+}
+
+func calculateTotEventsWithTextTemplate(totSize uint64, fieldMap map[string]any, tpl []byte, seed int64, samples int) (uint64, uint64, error) {
 	if totSize == 0 {
-		return 0, nil
+		return 0, 0, nil
 	}
 
-	// Generate a single event to calculate the total number of events based on its size
+	// Generate a few sample events to calculate the total number of events based on their average size.
+	// The estimation has its own state, so that no error is left pending for the emission.
+	state := NewGenStateWithSeed(seed)
 	t := template.New("estimate_tot_events")
 	t = t.Option("missingkey=error")
-	tempTemplateFns := template.FuncMap{}
-	for k, v := range templateFns {
-		tempTemplateFns[k] = v
+
+	parsedTpl, err := t.Funcs(templateFuncs(fieldMap, state)).Parse(string(tpl))
+	if err != nil {
+		return 0, 0, err
 	}
 
-	tempTemplateFns["generate"] = func(field string) any {
-		state := NewGenState()
-		state.prevCacheForDup[field] = make(map[any]struct{})
-		state.prevCacheCardinality[field] = make([]any, 0)
-		bindF, ok := fieldMap[field].(EmitF)
+	buf := bytes.NewBufferString("")
+	for i := 0; i < samples; i++ {
+		err = parsedTpl.Execute(buf, nil)
+
+		select {
+		case generateErr := <-state.errChan:
+			return 0, 0, generateErr
+		default:
+		}
+
+		if err != nil {
+			return 0, 0, err
+		}
+
+		state.counter += 1
+	}
+
+	return totEventsFromSamples(totSize, uint64(buf.Len()), samples), avgEventSizeFromSamples(uint64(buf.Len()), samples), nil
+}
+
+// templateFuncs returns the functions available in the template, bound to the given state
+func templateFuncs(fieldMap map[string]any, state *GenState) template.FuncMap {
+	if state.errChan == nil {
+		state.errChan = make(chan error, 1)
+	}
+
+	templateFns := sprig.TxtFuncMap()
+
+	templateFns["awsAZFromRegion"] = func(region string) string {
+		azs, ok := awsAZs[region]
 		if !ok {
-			close(errChan)
-			return nil
+			return "NoAZ"
 		}
 
-		return bindF(state)
+		return azs[state.rand.Intn(len(azs))]
 	}
 
-generateErr:
-	for {
-		select {
-		case <-errChan:
-			return 0, generateOnFieldNotInFieldsYaml
+	templateFns["gcpZoneFromRegion"] = func(region string) string {
+		zones, ok := gcpZones[region]
+		if !ok {
+			return "NoZone"
+		}
+
+		return zones[state.rand.Intn(len(zones))]
+	}
+
+	templateFns["azureZoneFromRegion"] = func(region string) string {
+		zones, ok := azureRegions[region]
+		if !ok {
+			return "NoZone"
+		}
+
+		return zones[state.rand.Intn(len(zones))]
+	}
+
+	// the networks of the prefixes passed to randomIPv6, parsed once
+	ipv6Nets := make(map[string]*net.IPNet)
+	templateFns["randomIPv6"] = func(prefix ...string) (string, error) {
+		if len(prefix) == 0 {
+			return randIPInNet(state.rand, globalUnicastIPv6).String(), nil
+		}
+
+		ipNet, ok := ipv6Nets[prefix[0]]
+		if !ok {
+			_, parsedIPNet, err := net.ParseCIDR(prefix[0])
+			if err != nil {
+				return "", err
+			}
+
+			if parsedIPNet.IP.To4() != nil {
+				return "", fmt.Errorf("%w: %q", notValidIPv6CIDR, prefix[0])
+			}
+
+			ipNet = parsedIPNet
+			ipv6Nets[prefix[0]] = ipNet
+		}
+
+		return randIPInNet(state.rand, ipNet).String(), nil
+	}
+
+	// the functions of the OUIs passed to randomMAC, made once
+	macFuncs := make(map[string]func(r *rand.Rand) net.HardwareAddr)
+	templateFns["randomMAC"] = func(oui ...string) (string, error) {
+		var prefix string
+		if len(oui) > 0 {
+			prefix = oui[0]
+		}
+
+		macFunc, ok := macFuncs[prefix]
+		if !ok {
+			var err error
+			if macFunc, err = makeMACFunc(prefix, false); err != nil {
+				return "", err
+			}
+
+			macFuncs[prefix] = macFunc
+		}
+
+		return macFunc(state.rand).String(), nil
+	}
+
+	templateFns["randomUserAgent"] = func(category ...string) (string, error) {
+		pool := allUserAgents
+		if len(category) > 0 {
+			var ok bool
+			if pool, ok = userAgents[category[0]]; !ok {
+				return "", fmt.Errorf("%w: %q", notValidUserAgentCategory, category[0])
+			}
+		}
+
+		return pool[state.rand.Intn(len(pool))], nil
+	}
+
+	templateFns["k8sPodName"] = func(deployment ...string) string {
+		name := k8sDeployments[state.rand.Intn(len(k8sDeployments))]
+		if len(deployment) > 0 {
+			name = deployment[0]
+		}
+
+		// the pod template hash of the replica set, then the random suffix of the pod
+		return name + "-" + randK8sSuffix(state.rand, 8+state.rand.Intn(3)) + "-" + randK8sSuffix(state.rand, 5)
+	}
+
+	templateFns["k8sNamespace"] = func() string {
+		return k8sNamespaces[state.rand.Intn(len(k8sNamespaces))]
+	}
+
+	templateFns["containerID"] = func() string {
+		id := make([]byte, 32)
+		readRand(state.rand, id)
+
+		return hex.EncodeToString(id)
+	}
+
+	// the default distribution is valid
+	httpStatusFunc, _ := makeHTTPStatusFunc(nil)
+	templateFns["randomHTTPStatus"] = func() int64 {
+		return httpStatusFunc(state.rand)
+	}
+
+	templateFns["randomFQDN"] = func(maxLabels ...int) (string, error) {
+		labelsMax := defaultFQDNMaxLabels
+		if len(maxLabels) > 0 {
+			labelsMax = maxLabels[0]
+		}
+
+		if labelsMax < 2 {
+			return "", fmt.Errorf("%w: %d", notValidFQDNLabels, labelsMax)
+		}
+
+		// the domain name is one or two words, with the TLD making the last one or two labels
+		domain := fqdnDomainWords[state.rand.Intn(len(fqdnDomainWords))]
+		if state.rand.Intn(3) == 0 {
+			domain += "-" + fqdnDomainWords[state.rand.Intn(len(fqdnDomainWords))]
+		}
+
+		tld := fqdnTLDs[state.rand.Intn(len(fqdnTLDs))]
+		labels := 2 + strings.Count(tld, ".")
+		if labels > labelsMax {
+			// the second level domain of the country code alone would make too many labels
+			tld = tld[strings.LastIndexByte(tld, '.')+1:]
+			labels = 2
+		}
+
+		fqdn := domain + "." + tld
+
+		for subdomains := state.rand.Intn(labelsMax - 1); subdomains > 0 && labels < labelsMax; subdomains-- {
+			subdomain := fqdnSubdomains[state.rand.Intn(len(fqdnSubdomains))]
+			if len(subdomain)+1+len(fqdn) > fqdnMaxLength {
+				break
+			}
+
+			fqdn = subdomain + "." + fqdn
+			labels++
+		}
+
+		return fqdn, nil
+	}
+
+	templateFns["randomEmail"] = func(domains ...string) (string, error) {
+		if len(domains) == 0 {
+			domains = defaultEmailDomains
+		}
+
+		for _, domain := range domains {
+			if len(domain) == 0 || strings.Contains(domain, "@") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+				return "", fmt.Errorf("%w: %q", notValidEmailDomain, domain)
+			}
+		}
+
+		first := emailFirstNames[state.rand.Intn(len(emailFirstNames))]
+		last := emailLastNames[state.rand.Intn(len(emailLastNames))]
+
+		// the local part is made of names only, joined by at most a dot or an underscore, with no leading or trailing dot
+		var localPart string
+		switch state.rand.Intn(5) {
+		case 0:
+			localPart = first + "." + last
+		case 1:
+			localPart = first + last
+		case 2:
+			localPart = first[:1] + "." + last
+		case 3:
+			localPart = first + "_" + last
 		default:
-			break generateErr
+			localPart = first + strconv.Itoa(state.rand.Intn(100))
 		}
+
+		return localPart + "@" + domains[state.rand.Intn(len(domains))], nil
 	}
 
-	parsedTpl, err := t.Funcs(templateFns).Parse(string(tpl))
-	if err != nil {
-		return 0, err
+	wellKnownPortIndex := makeWeightedIndexFunc(wellKnownPorts)
+	templateFns["randomPort"] = func(mode string) (int, error) {
+		switch mode {
+		case "well-known":
+			return strconv.Atoi(wellKnownPorts[wellKnownPortIndex(state.rand)].Value)
+		case "ephemeral":
+			return ephemeralPortMin + state.rand.Intn(portMax-ephemeralPortMin+1), nil
+		case "any":
+			return 1 + state.rand.Intn(portMax), nil
+		default:
+			return 0, fmt.Errorf("%w: %q", notValidPortMode, mode)
+		}
 	}
 
-	buf := bytes.NewBufferString("")
-	err = parsedTpl.Execute(buf, nil)
-	if err != nil {
-		return 0, err
+	templateFns["humanBytes"] = func(n any, units ...string) (string, error) {
+		size, ok := choiceWeight(n)
+		if !ok {
+			return "", fmt.Errorf("%w: %v", notValidHumanBytes, n)
+		}
+
+		unit := "binary"
+		if len(units) > 0 {
+			unit = units[0]
+		}
+
+		byteUnit, ok := byteUnits[unit]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", notValidHumanBytes, unit)
+		}
+
+		return formatHumanBytes(size, byteUnit.base, byteUnit.names), nil
 	}
 
-	singleEventSize := uint64(buf.Len())
-	if singleEventSize == 0 {
-		return 1, nil
+	templateFns["randomStackTrace"] = func(lang string, depth int) (string, error) {
+		return randomStackTrace(state.rand, lang, depth)
 	}
 
-	totEvents := totSize / singleEventSize
-	if totEvents < 1 {
-		totEvents = 1
+	tlsVersionIndex := makeWeightedIndexFunc(tlsVersions)
+	templateFns["randomTLSVersion"] = func() string {
+		return tlsVersions[tlsVersionIndex(state.rand)].Value
 	}
 
-	return totEvents, nil
-}
+	templateFns["randomTLSCipher"] = func(version string) (string, error) {
+		ciphers, ok := tlsCiphers[version]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", notValidTLSVersion, version)
+		}
 
-func NewGeneratorWithTextTemplate(tpl []byte, cfg Config, fields Fields, totSize uint64) (*GeneratorWithTextTemplate, error) {
-	// Preprocess the fields, generating appropriate bound function
-	state := NewGenState()
-	fieldMap := make(map[string]any)
-	for _, field := range fields {
-		if err := bindField(cfg, field, fieldMap, true); err != nil {
-			return nil, err
+		return ciphers[state.rand.Intn(len(ciphers))], nil
+	}
+
+	defaultLogLevelIndex := makeWeightedIndexFunc(defaultLogLevels)
+	templateFns["randomLogLevel"] = func(weights ...map[string]any) (string, error) {
+		if len(weights) == 0 {
+			return defaultLogLevels[defaultLogLevelIndex(state.rand)].Value, nil
+		}
+
+		if len(weights) > 1 || len(weights[0]) == 0 {
+			return "", fmt.Errorf("%w: %v", notValidLogLevelWeights, weights)
+		}
+
+		weightedValues := make([]config.WeightedValue, 0, len(weights[0]))
+		for level, weight := range weights[0] {
+			levelWeight, ok := choiceWeight(weight)
+			if !ok {
+				return "", fmt.Errorf("%w: %q weight %v", notValidLogLevelWeights, level, weight)
+			}
+
+			weightedValues = append(weightedValues, config.WeightedValue{Value: level, Weight: levelWeight})
 		}
 
-		state.prevCacheForDup[field.Name] = make(map[any]struct{})
-		state.prevCacheCardinality[field.Name] = make([]any, 0)
+		// the map iteration order is random: sorting the levels keeps the values the same for the same seed
+		sort.Slice(weightedValues, func(i, j int) bool {
+			return weightedValues[i].Value < weightedValues[j].Value
+		})
+
+		return weightedValues[makeWeightedIndexFunc(weightedValues)(state.rand)].Value, nil
 	}
 
-	errChan := make(chan error)
+	semverFunc := makeSemverFunc(config.Semver{})
+	templateFns["randomSemver"] = func() string {
+		return semverFunc(state.rand)
+	}
 
-	templateFns := sprig.TxtFuncMap()
+	templateFns["randomBase64"] = func(length int, maxLength ...int) (string, error) {
+		bounds := config.Length{Min: length, Max: length}
+		if len(maxLength) > 0 {
+			bounds.Max = maxLength[0]
+		}
 
-	templateFns["awsAZFromRegion"] = func(region string) string {
-		azs, ok := awsAZs[region]
+		if err := bounds.Validate(); err != nil {
+			return "", fmt.Errorf("%w: %d, %d", notValidBase64Length, bounds.Min, bounds.Max)
+		}
+
+		return makeBinaryFunc(bounds)(state.rand), nil
+	}
+
+	templateFns["choice"] = func(values ...any) (any, error) {
+		if len(values) == 0 {
+			return nil, notValidChoice
+		}
+
+		return values[state.rand.Intn(len(values))], nil
+	}
+
+	templateFns["weightedChoice"] = func(pairs ...any) (any, error) {
+		if len(pairs) == 0 || len(pairs)%2 != 0 {
+			return nil, fmt.Errorf("%w: %d arguments", notValidWeightedChoice, len(pairs))
+		}
+
+		weightedValues := make([]config.WeightedValue, 0, len(pairs)/2)
+		for i := 1; i < len(pairs); i += 2 {
+			weight, ok := choiceWeight(pairs[i])
+			if !ok {
+				return nil, fmt.Errorf("%w: weight %v", notValidWeightedChoice, pairs[i])
+			}
+
+			weightedValues = append(weightedValues, config.WeightedValue{Weight: weight})
+		}
+
+		return pairs[2*makeWeightedIndexFunc(weightedValues)(state.rand)], nil
+	}
+
+	templateFns["randomDuration"] = func(min, max, unit string) (int64, error) {
+		unitDuration, ok := durationUnits[unit]
 		if !ok {
-			return "NoAZ"
+			return 0, fmt.Errorf("%w: %q", notValidDurationUnit, unit)
 		}
 
-		return azs[rand.Intn(len(azs))]
+		minDuration, minErr := time.ParseDuration(min)
+		maxDuration, maxErr := time.ParseDuration(max)
+		// the span must not overflow, with the max duration included
+		span := int64(maxDuration - minDuration)
+		if minErr != nil || maxErr != nil || minDuration > maxDuration || span < 0 || span == math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %q, %q", notValidDurationRange, min, max)
+		}
+
+		duration := minDuration + time.Duration(state.rand.Int63n(span+1))
+
+		return int64(duration / unitDuration), nil
 	}
 
-	templateFns["generate"] = func(field string) any {
+	templateFns["randomPath"] = func(os string, depth int) (string, error) {
+		convention, ok := pathConventions[os]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", notValidPathOS, os)
+		}
+
+		if depth < 0 {
+			return "", fmt.Errorf("%w: %d", notValidPathDepth, depth)
+		}
+
+		var sb strings.Builder
+		sb.WriteString(convention.roots[state.rand.Intn(len(convention.roots))])
+		for i := 0; i < depth; i++ {
+			sb.WriteString(convention.separator)
+			sb.WriteString(convention.dirs[state.rand.Intn(len(convention.dirs))])
+		}
+
+		sb.WriteString(convention.separator)
+		sb.WriteString(loremWords[state.rand.Intn(len(loremWords))])
+		sb.WriteString(convention.extensions[state.rand.Intn(len(convention.extensions))])
+
+		return sb.String(), nil
+	}
+
+	templateFns["randomProcessName"] = func(os string) (string, error) {
+		names, ok := processNames[os]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", notValidProcessOS, os)
+		}
+
+		return names[state.rand.Intn(len(names))], nil
+	}
+
+	templateFns["randomCommandLine"] = func(os string, name ...string) (string, error) {
+		names, ok := processNames[os]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", notValidProcessOS, os)
+		}
+
+		var sb strings.Builder
+		if len(name) > 0 {
+			sb.WriteString(name[0])
+		} else {
+			sb.WriteString(names[state.rand.Intn(len(names))])
+		}
+
+		args := processArgs[os]
+		for i := 1 + state.rand.Intn(3); i > 0; i-- {
+			sb.WriteByte(' ')
+			sb.WriteString(args[state.rand.Intn(len(args))])
+		}
+
+		return sb.String(), nil
+	}
+
+	templateFns["eventIndex"] = func() uint64 {
+		return state.counter
+	}
+
+	templateFns["ecsVersion"] = func() (string, error) {
+		if bindF, ok := fieldMap[ECSVersionFieldName].(EmitF); ok {
+			if ecsVersion, ok := bindF(state).(string); ok {
+				return ecsVersion, nil
+			}
+		}
+
+		return "", notConfiguredECSVersion
+	}
+
+	templateFns["timestamp"] = func() time.Time {
+		if bindF, ok := fieldMap[TimestampFieldName].(EmitF); ok {
+			if timestamp, ok := bindF(state).(time.Time); ok {
+				return timestamp
+			}
+		}
+
+		return time.Now()
+	}
+
+	generate := func(field string) (any, bool) {
 		bindF, ok := fieldMap[field].(EmitF)
 		if !ok {
-			close(errChan)
-			return nil
+			// errChan is buffered: when an error is already pending there's no need to report another one
+			select {
+			case state.errChan <- fmt.Errorf("%w: %q", generateOnFieldNotInFieldsYaml, field):
+			default:
+			}
+
+			return nil, false
 		}
 
-		return bindF(state)
+		return bindF(state), true
 	}
 
-	totEvents, err := calculateTotEventsWithTextTemplate(totSize, fieldMap, errChan, tpl, templateFns)
+	templateFns["generate"] = func(field string) any {
+		value, _ := generate(field)
+		return value
+	}
+
+	// generateOnce returns the same value of the field for every reference within the event, as same_as does
+	templateFns["generateOnce"] = func(field string) any {
+		if cached, ok := state.sameAsCache[field]; ok && cached.event == state.counter {
+			return cached.value
+		}
+
+		value, ok := generate(field)
+		if ok {
+			state.sameAsCache[field] = sameAsValue{event: state.counter, value: value}
+		}
+
+		return value
+	}
+
+	return templateFns
+}
+
+// TemplateFuncNames returns the sorted names of the functions available in a text template, the sprig ones included
+func TemplateFuncNames() []string {
+	templateFns := templateFuncs(nil, NewGenState())
+
+	names := make([]string, 0, len(templateFns))
+	for name := range templateFns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// NewGeneratorWithTextTemplate returns a generator emitting events up to an estimated totSize in bytes
+func NewGeneratorWithTextTemplate(tpl []byte, cfg Config, fields Fields, totSize uint64) (*GeneratorWithTextTemplate, error) {
+	tpl, err := ExpandTemplateIncludes(tpl, cfg.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	gen, err := newGeneratorWithTextTemplate(tpl, cfg, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	totEvents, _, err := calculateTotEventsWithTextTemplate(totSize, gen.fieldMap, tpl, cfg.Seed, estimationSamples(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	gen.totEvents = totEvents
+
+	return gen, nil
+}
+
+// NewGeneratorWithTextTemplateN returns a generator emitting exactly totEvents events, with no limit when totEvents is zero
+func NewGeneratorWithTextTemplateN(tpl []byte, cfg Config, fields Fields, totEvents uint64) (*GeneratorWithTextTemplate, error) {
+	tpl, err := ExpandTemplateIncludes(tpl, cfg.TemplateDir)
 	if err != nil {
 		return nil, err
 	}
 
+	gen, err := newGeneratorWithTextTemplate(tpl, cfg, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	gen.totEvents = totEvents
+
+	return gen, nil
+}
+
+func newGeneratorWithTextTemplate(tpl []byte, cfg Config, fields Fields) (*GeneratorWithTextTemplate, error) {
+	if cfg.NDJSON && cfg.PrettyJSON {
+		return nil, notValidPrettyJSON
+	}
+
+	// Preprocess the fields, generating appropriate bound function
+	fieldMap := make(map[string]any)
+	for _, field := range fields {
+		if err := bindField(cfg, field, fieldMap, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := bindTimestamp(cfg, fieldMap, true); err != nil {
+		return nil, err
+	}
+
+	if err := bindECSVersion(cfg, fieldMap, true); err != nil {
+		return nil, err
+	}
+
+	if err := bindSameAs(cfg, fields, fieldMap, true); err != nil {
+		return nil, err
+	}
+
+	state := NewGenStateWithSeed(cfg.Seed)
+
 	t := template.New("generator")
 	t = t.Option("missingkey=error")
 
-	parsedTpl, err := t.Funcs(templateFns).Parse(string(tpl))
+	parsedTpl, err := t.Funcs(templateFuncs(fieldMap, state)).Parse(string(tpl))
 	if err != nil {
 		return nil, err
 	}
 
-	return &GeneratorWithTextTemplate{tpl: parsedTpl, totEvents: totEvents, state: state, errChan: errChan}, nil
+	// The parsed template is already bound to the internal state
+	state.tpl = parsedTpl
+	state.tplSource = parsedTpl
+
+	return &GeneratorWithTextTemplate{tpl: parsedTpl, fieldMap: fieldMap, seed: cfg.Seed, ndjson: cfg.NDJSON, prettyJSON: cfg.PrettyJSON, validateJSON: cfg.ValidateJSON, state: state, outputs: newOutputs()}, nil
+}
+
+// Reset returns the generator to its state after construction, with the same semantic of GeneratorWithCustomTemplate.Reset
+func (gen GeneratorWithTextTemplate) Reset() {
+	gen.state.reset(gen.seed)
+}
+
+// generatorSeed returns the seed of the generator, zero when not set
+func (gen GeneratorWithTextTemplate) generatorSeed() int64 {
+	return gen.seed
 }
 
+// Close finalizes the writers the events were streamed to with EmitTo, with the same semantic of
+// GeneratorWithCustomTemplate.Close
 func (gen GeneratorWithTextTemplate) Close() error {
-	return nil
+	return gen.outputs.close()
 }
 
+// Emit generates an event in buf, using state for keeping track of what was generated.
+// Independent states can be used for independent emission streams with the same generator;
+// when state is nil the generator internal state is used.
 func (gen GeneratorWithTextTemplate) Emit(state *GenState, buf *bytes.Buffer) error {
-	state = gen.state
-	if err := gen.emit(state, buf); err != nil {
+	return gen.EmitTo(state, buf)
+}
+
+// EmitTo generates an event streaming it to w, with the same semantic of Emit.
+// Writers to be flushed or closed are finalized by Close.
+func (gen GeneratorWithTextTemplate) EmitTo(state *GenState, w io.Writer) error {
+	if state == nil {
+		state = gen.state
+	}
+
+	state.lazyInit(gen.seed)
+
+	emit := gen.emit
+	if gen.validateJSON {
+		emit = gen.emitValidJSON
+	}
+
+	var err error
+	switch {
+	case gen.ndjson:
+		err = emitNDJSON(state, w, emit)
+	case gen.prettyJSON:
+		err = emitPrettyJSON(state, w, emit)
+	default:
+		err = emit(state, w)
+	}
+
+	if err != nil {
 		return err
 	}
 
+	gen.outputs.add(w)
 	state.counter += 1
 
 	return nil
 }
 
-func (gen GeneratorWithTextTemplate) emit(state *GenState, buf *bytes.Buffer) error {
+// stateTemplate returns the template bound to state, cloning it from the parsed one at first use
+func (gen GeneratorWithTextTemplate) stateTemplate(state *GenState) (*template.Template, error) {
+	if state.tpl != nil && state.tplSource == gen.tpl {
+		return state.tpl, nil
+	}
+
+	t, err := gen.tpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	state.tpl = t.Funcs(templateFuncs(gen.fieldMap, state))
+	state.tplSource = gen.tpl
+
+	return state.tpl, nil
+}
+
+// emitValidJSON emits an event failing when it is not valid JSON, as configured by ValidateJSON
+func (gen GeneratorWithTextTemplate) emitValidJSON(state *GenState, w io.Writer) error {
+	return emitValidJSON(state, w, gen.emit)
+}
+
+func (gen GeneratorWithTextTemplate) emit(state *GenState, w io.Writer) error {
 	if gen.totEvents == 0 || state.counter < gen.totEvents {
+		tpl, err := gen.stateTemplate(state)
+		if err != nil {
+			return err
+		}
+
+		err = tpl.Execute(w, nil)
+
 		select {
-		case <-gen.errChan:
-			return generateOnFieldNotInFieldsYaml
+		case generateErr := <-state.errChan:
+			return generateErr
 		default:
-			err := gen.tpl.Execute(buf, nil)
-			if err != nil {
-				return err
-			}
+		}
+
+		if err != nil {
+			return err
 		}
 	} else {
 		return io.EOF
@@ -2,11 +2,18 @@ package genlib
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"math/rand"
 	"net"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -175,6 +182,26 @@ func Test_FieldConstKeywordWithTextTemplate(t *testing.T) {
 	}
 }
 
+func Test_FieldConstKeywordNotValidWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeConstantKeyword},
+	}
+
+	for _, yaml := range []string{
+		"- name: alpha\n  cardinality:\n    numerator: 1\n    denominator: 10",
+		"- name: alpha\n  enum: [\"a\", \"b\"]",
+	} {
+		cfg, err := config.LoadConfigFromYaml([]byte(yaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewGeneratorWithTextTemplate([]byte(`{{generate "alpha"}}`), cfg, flds, 0); !errors.Is(err, notValidConstantKeyword) {
+			t.Fatalf("expected error %v with config %s, got %v", notValidConstantKeyword, yaml, err)
+		}
+	}
+}
+
 func Test_FieldStaticOverrideStringWithTextTemplate(t *testing.T) {
 	fld := Field{
 		Name: "alpha",
@@ -310,6 +337,52 @@ func Test_FieldDateWithTextTemplate(t *testing.T) {
 	}
 }
 
+func Test_FieldDateTimezoneWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999Z07:00"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	for timezone, offset := range map[string]string{"UTC": "Z", "Asia/Kolkata": "+05:30"} {
+		configYaml := []byte("- name: alpha\n  timezone: " + timezone)
+
+		b := testSingleTWithTextTemplate[string](t, fld, configYaml, template)
+		if !strings.HasSuffix(b, offset) {
+			t.Errorf("expected date in %s with offset %s, got %s", timezone, offset, b)
+		}
+	}
+}
+
+func Test_FieldDateFormatWithTextTemplate(t *testing.T) {
+	for format, precision := range dateFormatsPrecision {
+		flds := Fields{{Name: "alpha", Type: FieldTypeDate}}
+		cfg, err := config.LoadConfigFromYaml([]byte(fmt.Sprintf("- name: alpha\n  format: %q", format)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		template, _ := generateTextTemplateFromField(cfg, flds)
+		t.Logf("with template: %s", string(template))
+
+		g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 100; i++ {
+			buf.Reset()
+			before := time.Now()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			ts := parseFormattedDate(t, format, unmarshalJSONT[any](t, buf.Bytes())["alpha"])
+			assertNearDate(t, ts, before, time.Now(), precision)
+		}
+	}
+}
+
 func Test_FieldIPWithTextTemplate(t *testing.T) {
 	fld := Field{
 		Name: "alpha",
@@ -402,3 +475,1791 @@ func makeGeneratorWithTextTemplate(t *testing.T, cfg Config, fields Fields, temp
 
 	return g, NewGenState()
 }
+
+func Test_GcpZoneFromRegionWithTextTemplate(t *testing.T) {
+	template := []byte(`{{gcpZoneFromRegion "us-central1"}} {{gcpZoneFromRegion "not-a-region"}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, []Field{}, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	s := strings.Split(buf.String(), " ")
+	if len(s) != 2 {
+		t.Fatalf("expected two space separated zones, got %s", buf.String())
+	}
+
+	if !strings.HasPrefix(s[0], "us-central1-") {
+		t.Errorf("zone %s does not belong to region us-central1", s[0])
+	}
+
+	if s[1] != "NoZone" {
+		t.Errorf("expected NoZone for unknown region, got %s", s[1])
+	}
+}
+
+func Test_AzureZoneFromRegionWithTextTemplate(t *testing.T) {
+	template := []byte(`{{azureZoneFromRegion "eastus"}} {{azureZoneFromRegion "not-a-region"}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, []Field{}, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	s := strings.Split(buf.String(), " ")
+	if len(s) != 2 {
+		t.Fatalf("expected two space separated zones, got %s", buf.String())
+	}
+
+	if !strings.HasPrefix(s[0], "eastus-") {
+		t.Errorf("zone %s does not belong to region eastus", s[0])
+	}
+
+	if s[1] != "NoZone" {
+		t.Errorf("expected NoZone for unknown region, got %s", s[1])
+	}
+}
+
+func Test_AwsAZFromRegionWithTextTemplate(t *testing.T) {
+	for region := range awsAZs {
+		template := []byte(fmt.Sprintf(`{{awsAZFromRegion "%s"}}`, region))
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, []Field{}, template, 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(buf.String(), region) {
+			t.Errorf("AZ %s does not belong to region %s", buf.String(), region)
+		}
+	}
+}
+
+func Test_SeedWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeDouble},
+		{Name: "delta", Type: FieldTypeIP},
+		{Name: "epsilon", Type: FieldTypeBool},
+		{Name: "zeta", Type: FieldTypeGeoPoint},
+		{Name: "eta", Type: FieldTypeKeyword},
+	}
+
+	yaml := []byte("- name: beta\n  range:\n    min: 0\n    max: 1000\n- name: eta\n  cardinality:\n    numerator: 1\n    denominator: 10")
+	template := []byte(`{"alpha":"{{generate "alpha"}}", "beta":{{generate "beta"}}, "gamma":{{generate "gamma"}}, "delta":"{{generate "delta"}}", "epsilon":{{generate "epsilon"}}, "zeta":"{{generate "zeta"}}", "eta":"{{generate "eta"}}", "az":"{{awsAZFromRegion "us-east-1"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Seed = 42
+
+	emitAll := func() []byte {
+		g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 100*1024)
+
+		var buf bytes.Buffer
+		for {
+			err := g.Emit(state, &buf)
+			if err == io.EOF {
+				return buf.Bytes()
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	first := emitAll()
+	second := emitAll()
+	if len(first) == 0 {
+		t.Fatal("expected some output")
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("expected identical output with the same seed")
+	}
+}
+
+func Test_IndependentStatesWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	yaml := []byte("- name: alpha\n  cardinality:\n    numerator: 1\n    denominator: 10")
+	template := []byte(`{"alpha":"{{generate "alpha"}}", "beta":{{generate "beta"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewGeneratorWithTextTemplate(template, cfg, flds, 10*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states := []*GenState{NewGenState(), NewGenState()}
+	emitted := make([]uint64, len(states))
+
+	var wg sync.WaitGroup
+	for i := range states {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var buf bytes.Buffer
+			for {
+				buf.Reset()
+				err := g.Emit(states[i], &buf)
+				if err == io.EOF {
+					return
+				}
+
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				emitted[i] += 1
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if emitted[0] != g.totEvents || emitted[1] != g.totEvents {
+		t.Errorf("expected each state to emit %d events, got %v", g.totEvents, emitted)
+	}
+
+	for i, state := range states {
+		if state.counter != g.totEvents {
+			t.Errorf("expected counter of state %d to be %d, got %d", i, g.totEvents, state.counter)
+		}
+	}
+}
+
+func Test_TotEventsWithTextTemplateN(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}", "beta":{{generate "beta"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, err := NewGeneratorWithTextTemplateN(template, Config{}, flds, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+
+	var emitted uint64
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		emitted += 1
+	}
+
+	if emitted != 1000 {
+		t.Errorf("expected 1000 events, got %d", emitted)
+	}
+}
+
+func Test_TotEventsEstimationWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	// alpha values length spans from 1 to 200 characters
+	enum := make([]string, 0, 200)
+	for i := 1; i <= 200; i++ {
+		enum = append(enum, fmt.Sprintf("%q", strings.Repeat("x", i)))
+	}
+
+	yaml := []byte(fmt.Sprintf("- name: alpha\n  enum: [%s]", strings.Join(enum, ", ")))
+	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sampling more than the default number of events keeps the estimation close regardless of the seed
+	cfg.Seed = 1
+	cfg.EstimationSamples = 256
+
+	totSize := uint64(1024 * 1024)
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, totSize)
+
+	var emittedSize uint64
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		emittedSize += uint64(buf.Len())
+	}
+
+	if emittedSize < totSize*9/10 || emittedSize > totSize*11/10 {
+		t.Errorf("expected emitted size to be within 10%% of %d, got %d", totSize, emittedSize)
+	}
+}
+
+func Test_GenerateOnFieldNotInFieldsYamlWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}", "missing":"{{generate "does.not.exist"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, err := NewGeneratorWithTextTemplateN(template, Config{}, flds, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = g.Emit(NewGenState(), &buf)
+	if !errors.Is(err, generateOnFieldNotInFieldsYaml) {
+		t.Fatalf("expected error %v, got %v", generateOnFieldNotInFieldsYaml, err)
+	}
+
+	if !strings.Contains(err.Error(), `"does.not.exist"`) {
+		t.Errorf("expected error to name the field, got %s", err)
+	}
+}
+
+func Test_GenerateOnceWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"first":"{{generateOnce "alpha"}}","second":"{{generateOnce "alpha"}}","beta":{{generateOnce "beta"}},"message":"{{generateOnce "alpha"}} is {{generateOnce "beta"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, flds, template, 0)
+
+	values := make(map[string]struct{})
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+		if m["first"] != m["second"] {
+			t.Fatalf("expected the same value within the event, got %v and %v", m["first"], m["second"])
+		}
+
+		if expected := fmt.Sprintf("%v is %v", m["first"], m["beta"]); m["message"] != expected {
+			t.Fatalf("expected message %q, got %q", expected, m["message"])
+		}
+
+		values[m["first"].(string)] = struct{}{}
+	}
+
+	if len(values) < 900 {
+		t.Fatalf("expected different values across events, got %d distinct values", len(values))
+	}
+}
+
+func Test_GenerateOnceOnFieldNotInFieldsYamlWithTextTemplate(t *testing.T) {
+	template := []byte(`{"missing":"{{generateOnce "does.not.exist"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); !errors.Is(err, generateOnFieldNotInFieldsYaml) {
+		t.Fatalf("expected error %v, got %v", generateOnFieldNotInFieldsYaml, err)
+	}
+}
+
+func Test_GenerateOnFieldNotInFieldsYamlDuringEstimationAndEmissionWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}", "missing":"{{generate "does.not.exist"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	// during estimation
+	_, err := NewGeneratorWithTextTemplate(template, Config{}, flds, 10*1024)
+	if !errors.Is(err, generateOnFieldNotInFieldsYaml) {
+		t.Fatalf("expected error %v, got %v", generateOnFieldNotInFieldsYaml, err)
+	}
+
+	// during emission, repeatedly and from independent states
+	g, err := NewGeneratorWithTextTemplateN(template, Config{}, flds, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			state := NewGenState()
+			var buf bytes.Buffer
+			for j := 0; j < 10; j++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); !errors.Is(err, generateOnFieldNotInFieldsYaml) {
+					t.Errorf("expected error %v, got %v", generateOnFieldNotInFieldsYaml, err)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func Test_NullProbabilityWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{{ $alpha := generate "alpha" }}{{ if eq $alpha nil }}null{{ else }}{{ $alpha }}{{ end }}`)
+	t.Logf("with template: %s", string(template))
+
+	for _, nullProbability := range []float64{0.0, 0.5, 1.0} {
+		t.Run(fmt.Sprintf("with null probability %.1f", nullProbability), func(t *testing.T) {
+			yaml := []byte(fmt.Sprintf("- name: alpha\n  enum: [\"value\"]\n  null_probability: %f", nullProbability))
+			cfg, err := config.LoadConfigFromYaml(yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			totEvents := 10000
+			g, err := NewGeneratorWithTextTemplateN(template, cfg, flds, uint64(totEvents))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var nulls int
+			var buf bytes.Buffer
+			state := NewGenState()
+			for i := 0; i < totEvents; i++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				switch buf.String() {
+				case "null":
+					nulls += 1
+				case "value":
+				default:
+					t.Fatalf("unexpected event: %q", buf.String())
+				}
+			}
+
+			omissionRate := float64(nulls) / float64(totEvents)
+			if math.Abs(omissionRate-nullProbability) > 0.02 {
+				t.Errorf("expected omission rate %.2f, got %.4f", nullProbability, omissionRate)
+			}
+		})
+	}
+}
+
+func Test_WeightedEnumWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeKeyword},
+	}
+
+	yaml := []byte(`- name: alpha
+  weighted_enum:
+    - value: info
+      weight: 70
+    - value: warn
+      weight: 20
+    - value: error
+      weight: 10
+    - value: never
+      weight: 0
+- name: beta
+  weighted_enum:
+    - value: a
+    - value: b
+`)
+	cfg, err := config.LoadConfigFromYaml(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{{generate "alpha"}} {{generate "beta"}}`)
+	t.Logf("with template: %s", string(template))
+
+	totEvents := 100000
+	g, err := NewGeneratorWithTextTemplateN(template, cfg, flds, uint64(totEvents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	observed := make(map[string]int)
+	var buf bytes.Buffer
+	state := NewGenState()
+	for i := 0; i < totEvents; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		values := strings.Split(buf.String(), " ")
+		observed[values[0]] += 1
+		observed[values[1]] += 1
+	}
+
+	expected := map[string]float64{"info": 0.7, "warn": 0.2, "error": 0.1, "never": 0, "a": 0.5, "b": 0.5}
+	for value, expectedRate := range expected {
+		observedRate := float64(observed[value]) / float64(totEvents)
+		if math.Abs(observedRate-expectedRate) > 0.01 {
+			t.Errorf("expected rate %.2f for %s, got %.4f", expectedRate, value, observedRate)
+		}
+	}
+}
+
+func Test_FieldGeoPointInRadiusWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeGeoPoint},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  geo_point:\n    format: object\n    center:\n      lat: 40.7128\n      lon: -74.006\n    radius: 10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, err := NewGeneratorWithTextTemplateN(template, cfg, flds, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	state := NewGenState()
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[map[string]float64](t, buf.Bytes())
+		if distance := greatCircleDistanceKm(40.7128, -74.006, m["alpha"]["lat"], m["alpha"]["lon"]); distance > 10+1e-3 {
+			t.Fatalf("expected point within 10km, got %fkm", distance)
+		}
+	}
+}
+
+func Test_FieldIPInCIDRWithTextTemplate(t *testing.T) {
+	for _, cidr := range []string{"172.16.0.0/12", "2001:db8:abcd::/48"} {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		flds := Fields{
+			{Name: "alpha", Type: FieldTypeIP},
+		}
+
+		cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  cidr: " + cidr))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+		t.Logf("with template: %s", string(template))
+
+		g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 1000; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			m := unmarshalJSONT[string](t, buf.Bytes())
+			ip := net.ParseIP(m["alpha"])
+			if ip == nil {
+				t.Fatalf("Fail parse ip %s", m["alpha"])
+			}
+
+			if !ipNet.Contains(ip) {
+				t.Fatalf("expected ip in %s, got %s", cidr, ip)
+			}
+		}
+	}
+}
+
+func Test_FieldIPv6WithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeIPv6},
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}","beta":"{{randomIPv6}}","gamma":"{{randomIPv6 "fd00::/112"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, flds, template, 0)
+
+	_, prefixNet, _ := net.ParseCIDR("fd00::/112")
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		for _, k := range []string{"alpha", "beta", "gamma"} {
+			ip := net.ParseIP(m[k])
+			if ip == nil || len(ip) != net.IPv6len || ip.To4() != nil {
+				t.Fatalf("expected IPv6 address, got %s", m[k])
+			}
+
+			if ip.String() != m[k] {
+				t.Fatalf("expected canonical form %s, got %s", ip, m[k])
+			}
+		}
+
+		if !prefixNet.Contains(net.ParseIP(m["gamma"])) || !strings.HasPrefix(m["gamma"], "fd00::") {
+			t.Fatalf("expected ip in fd00::/112, got %s", m["gamma"])
+		}
+	}
+}
+
+func Test_RandomIPv6NotValidPrefixWithTextTemplate(t *testing.T) {
+	template := []byte(`{{randomIPv6 "10.0.0.0/8"}}`)
+	t.Logf("with template: %s", string(template))
+
+	if _, err := NewGeneratorWithTextTemplate(template, Config{}, Fields{}, 1024); !errors.Is(err, notValidIPv6CIDR) {
+		t.Fatalf("expected error %v, got %v", notValidIPv6CIDR, err)
+	}
+}
+
+func Test_FieldMACWithTextTemplate(t *testing.T) {
+	macRegex := regexp.MustCompile(`^([0-9a-f]{2}:){5}[0-9a-f]{2}$`)
+
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeMAC},
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}","beta":"{{randomMAC}}","gamma":"{{randomMAC "f4:5c:89"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		for _, k := range []string{"alpha", "beta", "gamma"} {
+			if !macRegex.MatchString(m[k]) {
+				t.Fatalf("expected mac address, got %s", m[k])
+			}
+		}
+
+		if !strings.HasPrefix(m["gamma"], "f4:5c:89:") {
+			t.Fatalf("expected mac with oui f4:5c:89, got %s", m["gamma"])
+		}
+	}
+}
+
+func Test_RandomUserAgentWithTextTemplate(t *testing.T) {
+	template := []byte(`{"alpha":"{{randomUserAgent}}","beta":"{{randomUserAgent "bot"}}","gamma":"{{randomUserAgent "mobile"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if len(m["alpha"]) == 0 {
+			t.Fatal("expected a user agent, got an empty one")
+		}
+
+		if !inPool(userAgents["bot"], m["beta"]) {
+			t.Fatalf("expected a bot user agent, got %s", m["beta"])
+		}
+
+		if !inPool(userAgents["mobile"], m["gamma"]) {
+			t.Fatalf("expected a mobile user agent, got %s", m["gamma"])
+		}
+	}
+}
+
+// inPool tells whether value is one of the pool
+func inPool(pool []string, value string) bool {
+	for _, candidate := range pool {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func Test_RandomUserAgentNotValidCategoryWithTextTemplate(t *testing.T) {
+	template := []byte(`{{randomUserAgent "fridge"}}`)
+	t.Logf("with template: %s", string(template))
+
+	if _, err := NewGeneratorWithTextTemplate(template, Config{}, Fields{}, 1024); !errors.Is(err, notValidUserAgentCategory) {
+		t.Fatalf("expected error %v, got %v", notValidUserAgentCategory, err)
+	}
+}
+
+func Test_K8sNamesWithTextTemplate(t *testing.T) {
+	template := []byte(`{"kubernetes.pod.name":"{{k8sPodName}}","kubernetes.deployment.name":"{{k8sPodName "web"}}","kubernetes.namespace":"{{k8sNamespace}}","container.id":"{{containerID}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	podNameRegex := regexp.MustCompile(`^[a-z][a-z-]*-[a-z0-9]+-[a-z0-9]{5}$`)
+	containerIDRegex := regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if podName := m["kubernetes.pod.name"]; !podNameRegex.MatchString(podName) {
+			t.Fatalf("expected pod name matching %s, got %s", podNameRegex, podName)
+		}
+
+		if podName := m["kubernetes.deployment.name"]; !podNameRegex.MatchString(podName) || !strings.HasPrefix(podName, "web-") {
+			t.Fatalf("expected pod name of the web deployment, got %s", podName)
+		}
+
+		if namespace := m["kubernetes.namespace"]; !inPool(k8sNamespaces, namespace) {
+			t.Fatalf("expected a known namespace, got %s", namespace)
+		}
+
+		if containerID := m["container.id"]; !containerIDRegex.MatchString(containerID) {
+			t.Fatalf("expected container id of 64 hex characters, got %s", containerID)
+		}
+	}
+}
+
+func Test_ChoiceWithTextTemplate(t *testing.T) {
+	template := []byte(`{"alpha":"{{choice "a" "b" "c"}}","beta":"{{weightedChoice "x" 1 "y" 3 "z" 6.0}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	const samples = 30000
+	alphas := make(map[string]int)
+	betas := make(map[string]int)
+
+	var buf bytes.Buffer
+	for i := 0; i < samples; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		alphas[m["alpha"]]++
+		betas[m["beta"]]++
+	}
+
+	for value, expected := range map[string]float64{"a": 1. / 3, "b": 1. / 3, "c": 1. / 3} {
+		if rate := float64(alphas[value]) / samples; math.Abs(rate-expected) > 0.02 {
+			t.Errorf("expected choice rate of %s %.2f, got %.4f", value, expected, rate)
+		}
+	}
+
+	for value, expected := range map[string]float64{"x": 0.1, "y": 0.3, "z": 0.6} {
+		if rate := float64(betas[value]) / samples; math.Abs(rate-expected) > 0.02 {
+			t.Errorf("expected weighted choice rate of %s %.2f, got %.4f", value, expected, rate)
+		}
+	}
+
+	if len(alphas) != 3 || len(betas) != 3 {
+		t.Errorf("expected only the given values to be chosen, got %v and %v", alphas, betas)
+	}
+}
+
+func Test_ChoiceSeedWithTextTemplate(t *testing.T) {
+	template := []byte(`{{choice 1 2 3 4 5 6 7 8 9}} {{weightedChoice "x" 1 "y" 2 "z" 3}}`)
+	t.Logf("with template: %s", string(template))
+
+	emit := func() string {
+		g, state := makeGeneratorWithTextTemplate(t, Config{Seed: 42}, Fields{}, template, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 100; i++ {
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return buf.String()
+	}
+
+	if first, second := emit(), emit(); first != second {
+		t.Fatalf("expected the same choices with the same seed, got %s and %s", first, second)
+	}
+}
+
+func Test_ChoiceNotValidWithTextTemplate(t *testing.T) {
+	for template, expected := range map[string]error{
+		`{{choice}}`:                     notValidChoice,
+		`{{weightedChoice "a" 1 "b"}}`:   notValidWeightedChoice,
+		`{{weightedChoice "a" -1}}`:      notValidWeightedChoice,
+		`{{weightedChoice "a" "b"}}`:     notValidWeightedChoice,
+		`{{weightedChoice "a" 1 "b" 2}}`: nil,
+	} {
+		t.Logf("with template: %s", template)
+
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, []byte(template), 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); !errors.Is(err, expected) {
+			t.Fatalf("expected error %v, got %v", expected, err)
+		}
+	}
+}
+
+func Test_RandomDurationWithTextTemplate(t *testing.T) {
+	template := []byte(`{"ns":{{randomDuration "1ms" "2ms" "ns"}},"us":{{randomDuration "1ms" "2ms" "us"}},"ms":{{randomDuration "1s" "1s" "ms"}},"s":{{randomDuration "90s" "2m" "s"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[int64](t, buf.Bytes())
+		for unit, bounds := range map[string][2]int64{"ns": {1000000, 2000000}, "us": {1000, 2000}, "ms": {1000, 1000}, "s": {90, 120}} {
+			if m[unit] < bounds[0] || m[unit] > bounds[1] {
+				t.Fatalf("expected duration in %s between %d and %d, got %d", unit, bounds[0], bounds[1], m[unit])
+			}
+		}
+	}
+}
+
+func Test_RandomDurationNotValidWithTextTemplate(t *testing.T) {
+	for template, expected := range map[string]error{
+		`{{randomDuration "2s" "1s" "ms"}}`:  notValidDurationRange,
+		`{{randomDuration "1x" "2s" "ms"}}`:  notValidDurationRange,
+		`{{randomDuration "1s" "2s" "min"}}`: notValidDurationUnit,
+	} {
+		t.Logf("with template: %s", template)
+
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, []byte(template), 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); !errors.Is(err, expected) {
+			t.Fatalf("expected error %v, got %v", expected, err)
+		}
+	}
+}
+
+func Test_RandomPathWithTextTemplate(t *testing.T) {
+	template := []byte(`{"windows":{{randomPath "windows" 3 | toJson}},"linux":{{randomPath "linux" 2 | toJson}},"darwin":{{randomPath "darwin" 0 | toJson}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	windowsPath := regexp.MustCompile(`^[CDE]:(\\[^\\/]+){3}\\[a-z]+\.[a-z0-9]+$`)
+	linuxPath := regexp.MustCompile(`^(/[^\\/]+){2}/[a-z]+\.[a-z0-9]+$`)
+	darwinPath := regexp.MustCompile(`^/[a-z]+\.[a-z0-9]+$`)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if !windowsPath.MatchString(m["windows"]) {
+			t.Fatalf("expected windows path with a drive, 3 directories and a file name separated by backslashes, got %s", m["windows"])
+		}
+
+		if !linuxPath.MatchString(m["linux"]) {
+			t.Fatalf("expected linux path from the root, with 2 directories and a file name separated by slashes, got %s", m["linux"])
+		}
+
+		if !darwinPath.MatchString(m["darwin"]) {
+			t.Fatalf("expected darwin path with a file name in the root, got %s", m["darwin"])
+		}
+	}
+}
+
+func Test_RandomPathNotValidWithTextTemplate(t *testing.T) {
+	for template, expected := range map[string]error{
+		`{{randomPath "plan9" 2}}`:  notValidPathOS,
+		`{{randomPath "linux" -1}}`: notValidPathDepth,
+	} {
+		t.Logf("with template: %s", template)
+
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, []byte(template), 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); !errors.Is(err, expected) {
+			t.Fatalf("expected error %v, got %v", expected, err)
+		}
+	}
+}
+
+func Test_RandomProcessWithTextTemplate(t *testing.T) {
+	for _, os := range []string{"windows", "linux", "darwin"} {
+		template := []byte(fmt.Sprintf(`{{$name := randomProcessName %[1]q}}{"process.name":"{{$name}}","process.command_line":"{{randomCommandLine %[1]q $name}}","other.command_line":"{{randomCommandLine %[1]q}}"}`, os))
+		t.Logf("with template: %s", string(template))
+
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 1000; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			m := unmarshalJSONT[string](t, buf.Bytes())
+			if !inPool(processNames[os], m["process.name"]) {
+				t.Fatalf("expected %s process name, got %s", os, m["process.name"])
+			}
+
+			if !strings.HasPrefix(m["process.command_line"], m["process.name"]+" ") {
+				t.Fatalf("expected command line starting with the process name %s and followed by arguments, got %s", m["process.name"], m["process.command_line"])
+			}
+
+			if name, _, found := strings.Cut(m["other.command_line"], " "); !found || !inPool(processNames[os], name) {
+				t.Fatalf("expected command line starting with a %s process name and followed by arguments, got %s", os, m["other.command_line"])
+			}
+		}
+	}
+}
+
+func Test_RandomProcessNotValidWithTextTemplate(t *testing.T) {
+	for _, template := range []string{`{{randomProcessName "plan9"}}`, `{{randomCommandLine "plan9"}}`} {
+		t.Logf("with template: %s", template)
+
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, []byte(template), 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); !errors.Is(err, notValidProcessOS) {
+			t.Fatalf("expected error %v, got %v", notValidProcessOS, err)
+		}
+	}
+}
+
+func Test_RandomFQDNWithTextTemplate(t *testing.T) {
+	template := []byte(`{"default":"{{randomFQDN}}","two":"{{randomFQDN 2}}","many":"{{randomFQDN 200}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	label := regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		for key, maxLabels := range map[string]int{"default": 4, "two": 2, "many": 200} {
+			fqdn := unmarshalJSONT[string](t, buf.Bytes())[key]
+			if len(fqdn) > 253 {
+				t.Fatalf("expected FQDN of at most 253 characters, got %d: %s", len(fqdn), fqdn)
+			}
+
+			labels := strings.Split(fqdn, ".")
+			if len(labels) < 2 || len(labels) > maxLabels {
+				t.Fatalf("expected FQDN with between 2 and %d labels, got %s", maxLabels, fqdn)
+			}
+
+			for _, l := range labels {
+				if len(l) > 63 || !label.MatchString(l) {
+					t.Fatalf("expected labels of at most 63 letters, digits and hyphens, got %q in %s", l, fqdn)
+				}
+			}
+		}
+	}
+}
+
+func Test_RandomFQDNNotValidWithTextTemplate(t *testing.T) {
+	template := []byte(`{{randomFQDN 1}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); !errors.Is(err, notValidFQDNLabels) {
+		t.Fatalf("expected error %v, got %v", notValidFQDNLabels, err)
+	}
+}
+
+func Test_RandomEmailWithTextTemplate(t *testing.T) {
+	template := []byte(`{"user.email":"{{randomEmail}}","client.user.email":"{{randomEmail "example.com" "corp.example.org"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	email := regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)*@([a-z0-9-]+\.)+[a-z]+$`)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		for key, pool := range map[string][]string{"user.email": defaultEmailDomains, "client.user.email": {"example.com", "corp.example.org"}} {
+			value := unmarshalJSONT[string](t, buf.Bytes())[key]
+			if !email.MatchString(value) {
+				t.Fatalf("expected valid email address, got %s", value)
+			}
+
+			if _, domain, _ := strings.Cut(value, "@"); !inPool(pool, domain) {
+				t.Fatalf("expected email address with a domain in %v, got %s", pool, value)
+			}
+		}
+	}
+}
+
+func Test_RandomEmailNotValidWithTextTemplate(t *testing.T) {
+	for _, template := range []string{`{{randomEmail ""}}`, `{{randomEmail "a@example.com"}}`, `{{randomEmail ".example.com"}}`, `{{randomEmail "example.com."}}`} {
+		t.Logf("with template: %s", template)
+
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, []byte(template), 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); !errors.Is(err, notValidEmailDomain) {
+			t.Fatalf("expected error %v, got %v", notValidEmailDomain, err)
+		}
+	}
+}
+
+func Test_RandomPortWithTextTemplate(t *testing.T) {
+	template := []byte(`{"destination.port":{{randomPort "well-known"}},"source.port":{{randomPort "ephemeral"}},"any":{{randomPort "any"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	const samples = 10000
+	var common int
+
+	var buf bytes.Buffer
+	for i := 0; i < samples; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[int](t, buf.Bytes())
+		switch m["destination.port"] {
+		case 80, 443, 22, 53:
+			common++
+		}
+
+		if m["source.port"] < 49152 || m["source.port"] > 65535 {
+			t.Fatalf("expected ephemeral port between 49152 and 65535, got %d", m["source.port"])
+		}
+
+		if m["any"] < 1 || m["any"] > 65535 {
+			t.Fatalf("expected port between 1 and 65535, got %d", m["any"])
+		}
+	}
+
+	// 80, 443, 22 and 53 have 76% of the weight
+	if ratio := float64(common) / samples; ratio < 0.74 || ratio > 0.78 {
+		t.Fatalf("expected common ports with about 76%%, got %.2f%%", ratio*100)
+	}
+}
+
+func Test_RandomPortNotValidWithTextTemplate(t *testing.T) {
+	template := []byte(`{{randomPort "registered"}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); !errors.Is(err, notValidPortMode) {
+		t.Fatalf("expected error %v, got %v", notValidPortMode, err)
+	}
+}
+
+func Test_HumanBytesWithTextTemplate(t *testing.T) {
+	testCases := []struct {
+		template string
+		expected string
+	}{
+		{template: `{{humanBytes 0}}`, expected: "0 B"},
+		{template: `{{humanBytes 1023}}`, expected: "1023 B"},
+		{template: `{{humanBytes 1024}}`, expected: "1.0 KiB"},
+		{template: `{{humanBytes 1536}}`, expected: "1.5 KiB"},
+		{template: `{{humanBytes 1048575}}`, expected: "1.0 MiB"},
+		{template: `{{humanBytes 1073741824}}`, expected: "1.0 GiB"},
+		{template: `{{humanBytes 1073741824 "binary"}}`, expected: "1.0 GiB"},
+		{template: `{{humanBytes 999 "decimal"}}`, expected: "999 B"},
+		{template: `{{humanBytes 1000 "decimal"}}`, expected: "1.0 kB"},
+		{template: `{{humanBytes 1073741824 "decimal"}}`, expected: "1.1 GB"},
+		{template: `{{humanBytes 1500000000.0 "decimal"}}`, expected: "1.5 GB"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("with template: %s", testCase.template), func(t *testing.T) {
+			g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, []byte(testCase.template), 0)
+
+			var buf bytes.Buffer
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != testCase.expected {
+				t.Fatalf("expected %q, got %q", testCase.expected, buf.String())
+			}
+		})
+	}
+}
+
+func Test_HumanBytesNotValidWithTextTemplate(t *testing.T) {
+	for _, template := range []string{`{{humanBytes -1}}`, `{{humanBytes "1024"}}`, `{{humanBytes 1024 "metric"}}`} {
+		t.Logf("with template: %s", template)
+
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, []byte(template), 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); !errors.Is(err, notValidHumanBytes) {
+			t.Fatalf("expected error %v, got %v", notValidHumanBytes, err)
+		}
+	}
+}
+
+func Test_RandomTLSWithTextTemplate(t *testing.T) {
+	template := []byte(`{{$version := randomTLSVersion}}{"tls.version":"{{$version}}","tls.cipher":"{{randomTLSCipher $version}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	versions := make(map[string]int)
+
+	var buf bytes.Buffer
+	for i := 0; i < 10000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		version, cipher := m["tls.version"], m["tls.cipher"]
+		versions[version]++
+
+		// TLS 1.3 suites don't name the key exchange, and AEAD suites need TLS 1.2 at least
+		isTLS13Cipher := !strings.Contains(cipher, "_WITH_")
+		isAEADCipher := strings.Contains(cipher, "_GCM_") || strings.Contains(cipher, "_CHACHA20_")
+		switch {
+		case !inPool(tlsCiphers[version], cipher):
+			t.Fatalf("expected cipher of TLS %s, got %s", version, cipher)
+		case version == "1.3" && !isTLS13Cipher, version != "1.3" && isTLS13Cipher:
+			t.Fatalf("expected TLS 1.3 ciphers with TLS 1.3 only, got %s with TLS %s", cipher, version)
+		case (version == "1.0" || version == "1.1") && isAEADCipher:
+			t.Fatalf("expected no AEAD cipher with TLS %s, got %s", version, cipher)
+		}
+	}
+
+	if len(versions) != 4 || versions["1.3"] < versions["1.2"] || versions["1.2"] < versions["1.1"] {
+		t.Fatalf("expected mostly TLS 1.3 and 1.2, got %v", versions)
+	}
+}
+
+func Test_RandomTLSCipherNotValidWithTextTemplate(t *testing.T) {
+	template := []byte(`{{randomTLSCipher "3.0"}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); !errors.Is(err, notValidTLSVersion) {
+		t.Fatalf("expected error %v, got %v", notValidTLSVersion, err)
+	}
+}
+
+func Test_RandomLogLevelWithTextTemplate(t *testing.T) {
+	template := []byte(`{"log.level":"{{randomLogLevel}}","custom.level":"{{randomLogLevel (dict "info" 1 "error" 3)}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	const samples = 100000
+	levels := make(map[string]int)
+	customLevels := make(map[string]int)
+
+	var buf bytes.Buffer
+	for i := 0; i < samples; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		levels[m["log.level"]]++
+		customLevels[m["custom.level"]]++
+	}
+
+	if len(levels) != 5 {
+		t.Fatalf("expected the 5 default levels, got %v", levels)
+	}
+
+	for level, count := range levels {
+		if level != "info" && count >= levels["info"] {
+			t.Fatalf("expected info to be the most common level, got %v", levels)
+		}
+	}
+
+	// the default distribution has 0.5% of fatal
+	if ratio := float64(levels["fatal"]) / samples; ratio < 0.003 || ratio > 0.007 {
+		t.Fatalf("expected fatal to be rare with about 0.5%%, got %.2f%%", ratio*100)
+	}
+
+	if len(customLevels) != 2 {
+		t.Fatalf("expected the 2 weighted levels only, got %v", customLevels)
+	}
+
+	if ratio := float64(customLevels["error"]) / samples; ratio < 0.74 || ratio > 0.76 {
+		t.Fatalf("expected error with about 75%%, got %.2f%%", ratio*100)
+	}
+}
+
+func Test_RandomLogLevelNotValidWithTextTemplate(t *testing.T) {
+	for _, template := range []string{`{{randomLogLevel (dict "info" -1)}}`, `{{randomLogLevel (dict "info" "a lot")}}`, `{{randomLogLevel dict}}`} {
+		t.Logf("with template: %s", template)
+
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, []byte(template), 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); !errors.Is(err, notValidLogLevelWeights) {
+			t.Fatalf("expected error %v, got %v", notValidLogLevelWeights, err)
+		}
+	}
+}
+
+func Test_RandomHTTPStatusWithTextTemplate(t *testing.T) {
+	template := []byte(`{"alpha":{{randomHTTPStatus}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	const samples = 100000
+	classes := make(map[int]int)
+
+	var buf bytes.Buffer
+	for i := 0; i < samples; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[int](t, buf.Bytes())
+		classes[m["alpha"]/100]++
+	}
+
+	// the default distribution has 74.8% of 2xx and 1.2% of 5xx
+	if ratio := float64(classes[2]) / samples; ratio < 0.73 || ratio > 0.765 {
+		t.Fatalf("expected 2xx to dominate with about 74.8%%, got %.2f%%", ratio*100)
+	}
+
+	if ratio := float64(classes[5]) / samples; ratio < 0.008 || ratio > 0.016 {
+		t.Fatalf("expected rare 5xx with about 1.2%%, got %.2f%%", ratio*100)
+	}
+}
+
+func Test_CardinalityPoolWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  cardinality_pool: 500\n  range:\n    min: 0\n    max: 1000000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{{generate "alpha"}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	distinct := make(map[string]struct{})
+
+	var buf bytes.Buffer
+	for i := 0; i < 100000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		distinct[buf.String()] = struct{}{}
+	}
+
+	if len(distinct) != 500 {
+		t.Fatalf("expected 500 distinct values, got %d", len(distinct))
+	}
+}
+
+func Test_FieldScaledFloatWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeScaledFloat},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  scaling_factor: 1000\n  range:\n    min: 1\n    max: 100"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		raw := unmarshalJSONT[json.Number](t, buf.Bytes())["alpha"].String()
+		if _, decimals, found := strings.Cut(raw, "."); found && len(decimals) > 3 {
+			t.Fatalf("expected at most 3 decimals, got %s", raw)
+		}
+
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if scaled := v * 1000; math.Abs(scaled-math.Round(scaled)) > 1e-6 {
+			t.Fatalf("expected value quantized by 1000, got %s", raw)
+		}
+	}
+}
+
+func Test_FieldNestedWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{
+			Name: "alpha",
+			Type: FieldTypeNested,
+			Fields: Fields{
+				{Name: "name", Type: FieldTypeKeyword},
+				{Name: "count", Type: FieldTypeLong},
+			},
+		},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  documents:\n    min: 2\n    max: 5\n- name: alpha.count\n  range:\n    min: 1\n    max: 10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha" | toJson}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		documents := unmarshalJSONT[[]map[string]any](t, buf.Bytes())["alpha"]
+		if len(documents) < 2 || len(documents) > 5 {
+			t.Fatalf("expected between 2 and 5 sub-documents, got %d: %s", len(documents), buf.String())
+		}
+
+		for _, document := range documents {
+			if len(document) != 2 {
+				t.Fatalf("expected 2 keys in sub-document, got %v", document)
+			}
+
+			if _, ok := document["name"].(string); !ok {
+				t.Fatalf("expected string name in sub-document, got %v", document)
+			}
+
+			if count, ok := document["count"].(float64); !ok || count < 1 || count > 10 {
+				t.Fatalf("expected count in range [1, 10] in sub-document, got %v", document)
+			}
+		}
+	}
+}
+
+func Test_ArrayLengthWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  array_length:\n    min: 1\n    max: 4\n  enum: [\"a\", \"b\", \"c\"]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha" | toJson}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		values := unmarshalJSONT[[]string](t, buf.Bytes())["alpha"]
+		if len(values) < 1 || len(values) > 4 {
+			t.Fatalf("expected between 1 and 4 values, got %d", len(values))
+		}
+
+		for _, value := range values {
+			if value != "a" && value != "b" && value != "c" {
+				t.Fatalf("expected value in enum, got %s", value)
+			}
+		}
+	}
+}
+
+func Test_FieldRangeTypesWithTextTemplate(t *testing.T) {
+	for _, fieldType := range []string{FieldTypeLongRange, FieldTypeDoubleRange} {
+		t.Run(fieldType, func(t *testing.T) {
+			flds := Fields{
+				{Name: "alpha", Type: fieldType},
+			}
+
+			cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  range:\n    min: 1\n    max: 5"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template := []byte(`{"alpha":{{generate "alpha" | toJson}}}`)
+			t.Logf("with template: %s", string(template))
+
+			g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+			var buf bytes.Buffer
+			for i := 0; i < 1000; i++ {
+				buf.Reset()
+				if err := g.Emit(state, &buf); err != nil {
+					t.Fatal(err)
+				}
+
+				value := unmarshalJSONT[map[string]float64](t, buf.Bytes())["alpha"]
+				if value["gte"] >= value["lt"] {
+					t.Fatalf("expected gte lower than lt, got %s", buf.String())
+				}
+
+				if value["gte"] < 1 || value["lt"] > 5 {
+					t.Fatalf("expected bounds between 1 and 5, got %s", buf.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_FieldObjectDynamicKeysWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeObject},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  dynamic_keys:\n    keys:\n      min: 1\n      max: 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha" | toJson}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		object := unmarshalJSONT[map[string]string](t, buf.Bytes())["alpha"]
+		if len(object) < 1 || len(object) > 3 {
+			t.Fatalf("expected between 1 and 3 keys, got %d: %s", len(object), buf.String())
+		}
+	}
+}
+
+func Test_FieldKeywordCharsetWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  charset: hex\n  length:\n    min: 32\n    max: 32\n- name: beta\n  charset: lower\n  length:\n    min: 1\n    max: 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}","beta":"{{generate "beta"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if alpha := m["alpha"]; len(alpha) != 32 || strings.Trim(alpha, "0123456789abcdef") != "" {
+			t.Fatalf("expected hex value of 32 characters, got %s", alpha)
+		}
+
+		if beta := m["beta"]; len(beta) < 1 || len(beta) > 3 || strings.Trim(beta, "abcdefghijklmnopqrstuvwxyz") != "" {
+			t.Fatalf("expected lowercase value between 1 and 3 characters, got %s", beta)
+		}
+	}
+}
+
+func Test_EventIndexWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}","index":{{eventIndex}}}`)
+	t.Logf("with template: %s", string(template))
+
+	// the events emitted for estimating the total number of events don't count
+	g, err := NewGeneratorWithTextTemplate(template, Config{}, flds, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+
+	var buf bytes.Buffer
+	for i := 0; i < 10; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if index := unmarshalJSONT[any](t, buf.Bytes())["index"]; index != float64(i) {
+			t.Fatalf("expected event index %d, got %v", i, index)
+		}
+	}
+}
+
+func Test_FieldCounterWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  counter:\n    step: 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 100000)
+
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if value := unmarshalJSONT[int64](t, buf.Bytes())["alpha"]; value != int64(i*3) {
+			t.Fatalf("expected value %d, got %d", i*3, value)
+		}
+	}
+}
+
+func Test_FieldSameAsWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "source.ip", Type: FieldTypeIP},
+		{Name: "client.ip", Type: FieldTypeIP},
+		{Name: "destination.ip", Type: FieldTypeIP},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: client.ip\n  same_as: source.ip\n- name: destination.ip\n  same_as: client.ip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"client.ip":"{{generate "client.ip"}}","source.ip":"{{generate "source.ip"}}","destination.ip":"{{generate "destination.ip"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if m["client.ip"] != m["source.ip"] || m["destination.ip"] != m["source.ip"] {
+			t.Fatalf("expected client.ip and destination.ip same as source.ip, got %s", buf.String())
+		}
+	}
+}
+
+func Test_FieldBoolTrueProbabilityWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeBool},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  true_probability: 0.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	totEvents := 50000
+	var trues int
+	var buf bytes.Buffer
+	for i := 0; i < totEvents; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if unmarshalJSONT[bool](t, buf.Bytes())["alpha"] {
+			trues += 1
+		}
+	}
+
+	trueRate := float64(trues) / float64(totEvents)
+	if math.Abs(trueRate-0.2) > 0.01 {
+		t.Errorf("expected true rate 0.20, got %.4f", trueRate)
+	}
+}
+
+func Test_TemplateFuncNames(t *testing.T) {
+	names := TemplateFuncNames()
+
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("expected sorted names, got %v", names)
+	}
+
+	for _, expected := range []string{"generate", "awsAZFromRegion", "timestamp", "date"} {
+		i := sort.SearchStrings(names, expected)
+		if i == len(names) || names[i] != expected {
+			t.Errorf("expected %s in %v", expected, names)
+		}
+	}
+}
+
+func Test_ResetWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}", "beta":{{generate "beta"}}, "gamma":"{{generate "gamma"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	for _, seed := range []int64{0, 1} {
+		cfg, err := config.LoadConfigFromYaml([]byte("- name: beta\n  range:\n    min: 0\n    max: 1000\n- name: gamma\n  cardinality:\n    numerator: 1\n    denominator: 10"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg.Seed = seed
+
+		g, err := NewGeneratorWithTextTemplate(template, cfg, flds, 10000)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		run := func() []byte {
+			var events bytes.Buffer
+			var buf bytes.Buffer
+			for {
+				buf.Reset()
+				err := g.Emit(nil, &buf)
+				if err == io.EOF {
+					return events.Bytes()
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				events.Write(buf.Bytes())
+				events.WriteByte('\n')
+			}
+		}
+
+		first := run()
+		g.Reset()
+		second := run()
+
+		if firstCount, secondCount := bytes.Count(first, []byte{'\n'}), bytes.Count(second, []byte{'\n'}); firstCount != secondCount {
+			t.Fatalf("expected %d events after reset, got %d", firstCount, secondCount)
+		}
+
+		if seed != 0 && !bytes.Equal(first, second) {
+			t.Fatalf("expected the same events after reset with seed %d", seed)
+		}
+	}
+}
+
+func Test_DistributionWithinRangeWithTextTemplate(t *testing.T) {
+	const samples = 100000
+
+	for _, testCase := range distributionWithinRangeCases {
+		flds := Fields{
+			{Name: "alpha", Type: testCase.fieldType},
+		}
+
+		cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  " + testCase.configYaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		template := []byte(`{{generate "alpha"}}`)
+		t.Logf("with template: %s", string(template))
+
+		g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+		var outOfRange int
+		var buf bytes.Buffer
+		for i := 0; i < samples; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := strconv.ParseFloat(buf.String(), 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v < testCase.min || v > testCase.max {
+				outOfRange++
+			}
+		}
+
+		if outOfRange > 0 {
+			t.Fatalf("expected %s values in [%v, %v], got %d out of range with config %q", testCase.fieldType, testCase.min, testCase.max, outOfRange, testCase.configYaml)
+		}
+	}
+}
+
+func Test_ValidateJSONWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	for _, cfg := range []Config{{ValidateJSON: true}, {ValidateJSON: true, NDJSON: true}} {
+		valid := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+		t.Logf("with template: %s", string(valid))
+
+		g, state := makeGeneratorWithTextTemplate(t, cfg, flds, valid, 0)
+
+		var buf bytes.Buffer
+		for i := 0; i < 100; i++ {
+			buf.Reset()
+			if err := g.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			unmarshalJSONT[string](t, buf.Bytes())
+		}
+
+		broken := []byte(`{"alpha":"{{generate "alpha"}}",}`)
+		t.Logf("with template: %s", string(broken))
+
+		g, state = makeGeneratorWithTextTemplate(t, cfg, flds, broken, 0)
+
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if !errors.Is(err, notValidJSON) {
+			t.Fatalf("expected error on not valid JSON event, got %v", err)
+		}
+
+		if !strings.Contains(err.Error(), "event 0 at offset ") || !strings.Contains(err.Error(), `",}`) {
+			t.Fatalf("expected error reporting the event and the offset, got %v", err)
+		}
+
+		if buf.Len() > 0 {
+			t.Fatalf("expected nothing emitted for a not valid JSON event, got %s", buf.String())
+		}
+	}
+
+	// the events are not validated by default
+	broken := []byte(`{"alpha":"{{generate "alpha"}}",}`)
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, flds, broken, 0)
+
+	var buf bytes.Buffer
+	if err := g.Emit(state, &buf); err != nil {
+		t.Fatalf("expected no validation by default, got %v", err)
+	}
+}
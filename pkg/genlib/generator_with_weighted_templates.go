@@ -0,0 +1,140 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+var notValidWeightedTemplates = errors.New("weighted templates must be at least one, with non negative weights")
+
+// WeightedTemplate is a custom template chosen for an event proportionally to its weight
+type WeightedTemplate struct {
+	Template []byte
+	Weight   float64
+}
+
+// GeneratorWithWeightedTemplates emits each event with one of its custom templates, chosen by weight, or uniformly when no weight is set.
+// The templates share the fields, the config and the state, so that counters, cardinalities and timestamps span all the events.
+type GeneratorWithWeightedTemplates struct {
+	gens          []*GeneratorWithCustomTemplate
+	weightedIndex func(r *rand.Rand) int
+	totEvents     uint64
+	seed          int64
+	state         *GenState
+	// outputs are shared with the generators of the templates
+	outputs *outputs
+}
+
+// NewGeneratorWithWeightedTemplates returns a generator emitting events up to an estimated totSize in bytes
+func NewGeneratorWithWeightedTemplates(templates []WeightedTemplate, cfg Config, fields Fields, totSize uint64) (*GeneratorWithWeightedTemplates, error) {
+	gen, err := newGeneratorWithWeightedTemplates(templates, cfg, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	totEvents, _, err := calculateTotEventsWithWeightedTemplates(totSize, gen, estimationSamples(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	gen.totEvents = totEvents
+
+	return gen, nil
+}
+
+// NewGeneratorWithWeightedTemplatesN returns a generator emitting exactly totEvents events, with no limit when totEvents is zero
+func NewGeneratorWithWeightedTemplatesN(templates []WeightedTemplate, cfg Config, fields Fields, totEvents uint64) (*GeneratorWithWeightedTemplates, error) {
+	gen, err := newGeneratorWithWeightedTemplates(templates, cfg, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	gen.totEvents = totEvents
+
+	return gen, nil
+}
+
+func newGeneratorWithWeightedTemplates(templates []WeightedTemplate, cfg Config, fields Fields) (*GeneratorWithWeightedTemplates, error) {
+	if len(templates) == 0 {
+		return nil, notValidWeightedTemplates
+	}
+
+	outputs := newOutputs()
+	gens := make([]*GeneratorWithCustomTemplate, 0, len(templates))
+	weightedValues := make([]config.WeightedValue, 0, len(templates))
+	for _, template := range templates {
+		if template.Weight < 0 {
+			return nil, notValidWeightedTemplates
+		}
+
+		// the generators have no limit of their own, the total number of events being the one of all of them together
+		gen, err := NewGeneratorWithCustomTemplateN(template.Template, cfg, fields, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		gen.outputs = outputs
+		gens = append(gens, gen)
+		weightedValues = append(weightedValues, config.WeightedValue{Weight: template.Weight})
+	}
+
+	return &GeneratorWithWeightedTemplates{
+		gens:          gens,
+		weightedIndex: makeWeightedIndexFunc(weightedValues),
+		seed:          cfg.Seed,
+		state:         NewGenStateWithSeed(cfg.Seed),
+		outputs:       outputs,
+	}, nil
+}
+
+func calculateTotEventsWithWeightedTemplates(totSize uint64, gen *GeneratorWithWeightedTemplates, samples int) (uint64, uint64, error) {
+	if totSize == 0 {
+		return 0, 0, nil
+	}
+
+	// Generate a few sample events, with the templates chosen by weight, to calculate the total number of events based on their average size
+	state := NewGenStateWithSeed(gen.seed)
+	buf := bytes.NewBufferString("")
+	for i := 0; i < samples; i++ {
+		if err := gen.EmitTo(state, buf); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return totEventsFromSamples(totSize, uint64(buf.Len()), samples), avgEventSizeFromSamples(uint64(buf.Len()), samples), nil
+}
+
+// Close finalizes the writers the events were streamed to with EmitTo, with the same semantic of
+// GeneratorWithCustomTemplate.Close
+func (gen GeneratorWithWeightedTemplates) Close() error {
+	return gen.outputs.close()
+}
+
+// Emit generates an event in buf, with the same semantic of GeneratorWithCustomTemplate.Emit
+func (gen GeneratorWithWeightedTemplates) Emit(state *GenState, buf *bytes.Buffer) error {
+	return gen.EmitTo(state, buf)
+}
+
+// EmitTo generates an event streaming it to w, with the same semantic of GeneratorWithCustomTemplate.EmitTo
+func (gen GeneratorWithWeightedTemplates) EmitTo(state *GenState, w io.Writer) error {
+	if state == nil {
+		state = gen.state
+	}
+
+	state.lazyInit(gen.seed)
+
+	if gen.totEvents > 0 && state.counter >= gen.totEvents {
+		return io.EOF
+	}
+
+	// the chosen generator increments the counter of the shared state
+	return gen.gens[gen.weightedIndex(state.rand)].EmitTo(state, w)
+}
@@ -0,0 +1,103 @@
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func Test_GeneratorWithWeightedTemplates(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	templates := []WeightedTemplate{
+		{Template: []byte(`{"kind":"access","alpha":"{{.alpha}}"}`), Weight: 70},
+		{Template: []byte(`{"kind":"error","beta":{{.beta}}}`), Weight: 30},
+	}
+
+	const totEvents = 10000
+
+	g, err := NewGeneratorWithWeightedTemplatesN(templates, Config{}, flds, totEvents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+	var buf bytes.Buffer
+	var events, access, errorEvents int
+	for {
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		events++
+		switch {
+		case strings.HasPrefix(buf.String(), `{"kind":"access"`):
+			access++
+		case strings.HasPrefix(buf.String(), `{"kind":"error"`):
+			errorEvents++
+		default:
+			t.Fatalf("unexpected event %s", buf.String())
+		}
+	}
+
+	if events != totEvents {
+		t.Fatalf("expected %d events, got %d", totEvents, events)
+	}
+
+	if rate := float64(access) / totEvents; math.Abs(rate-0.7) > 0.03 {
+		t.Errorf("expected about 70%% of access events, got %.2f%%", rate*100)
+	}
+
+	if rate := float64(errorEvents) / totEvents; math.Abs(rate-0.3) > 0.03 {
+		t.Errorf("expected about 30%% of error events, got %.2f%%", rate*100)
+	}
+}
+
+func Test_GeneratorWithWeightedTemplatesTotSize(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	templates := []WeightedTemplate{
+		{Template: []byte(`{"alpha":"{{.alpha}}"}`), Weight: 1},
+		{Template: []byte(`{"alpha":"{{.alpha}}","padding":"0123456789"}`), Weight: 1},
+	}
+
+	// the estimation is on a few samples, with templates of different sizes: the seed makes it reproducible
+	g, err := NewGeneratorWithWeightedTemplates(templates, Config{Seed: 1}, flds, 10*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	for {
+		err := g.Emit(nil, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if size := buf.Len(); size < 7*1024 || size > 13*1024 {
+		t.Fatalf("expected about 10KB, got %d bytes", size)
+	}
+
+	if _, err := NewGeneratorWithWeightedTemplatesN(nil, Config{}, flds, 1); !errors.Is(err, notValidWeightedTemplates) {
+		t.Fatalf("expected error %v, got %v", notValidWeightedTemplates, err)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+var notValidGeoPointCountry = errors.New("geo_point country must be one of the bundled ISO 3166-1 alpha-2 codes")
+
+// lonLat is a vertex of a country polygon
+type lonLat struct {
+	lon, lat float64
+}
+
+// countryPolygons are low resolution outlines of the mainland of some countries, keyed by their ISO 3166-1 alpha-2 code.
+// They are rough by design: a few dozens of vertices keep points off the sea most of the times, not at the borders.
+var countryPolygons = map[string][]lonLat{
+	"AT": {{9.5, 47.5}, {10.2, 47.3}, {12.2, 47.6}, {13.0, 47.5}, {13.0, 48.3}, {13.8, 48.7}, {15.0, 49.0}, {16.9, 48.6}, {17.1, 48.0}, {16.5, 47.5}, {16.1, 46.8}, {14.5, 46.4}, {12.2, 46.8}, {10.5, 46.9}, {9.6, 47.1}},
+	"AU": {{113.5, -22.0}, {114.0, -26.5}, {115.0, -33.5}, {117.9, -35.1}, {123.5, -33.9}, {131.0, -31.5}, {137.5, -33.0}, {138.0, -34.7}, {140.0, -37.9}, {146.3, -39.1}, {150.0, -37.5}, {153.6, -28.5}, {153.0, -25.0}, {146.0, -18.5}, {145.3, -14.9}, {142.5, -10.7}, {141.4, -16.0}, {139.0, -17.3}, {135.6, -14.6}, {136.9, -12.2}, {132.6, -11.5}, {129.5, -14.9}, {126.0, -14.0}, {122.2, -17.9}, {119.0, -20.0}},
+	"BE": {{2.6, 51.1}, {3.4, 51.4}, {4.4, 51.4}, {5.8, 51.1}, {5.7, 50.8}, {6.4, 50.3}, {5.8, 49.5}, {4.8, 49.9}, {4.1, 50.0}, {2.9, 50.7}},
+	"BR": {{-60.0, 5.2}, {-51.6, 4.2}, {-50.0, 0.0}, {-35.0, -5.2}, {-34.8, -7.5}, {-39.0, -13.5}, {-39.7, -19.6}, {-41.0, -22.0}, {-48.5, -26.0}, {-53.4, -33.7}, {-57.6, -30.2}, {-53.8, -27.1}, {-54.6, -25.6}, {-58.1, -20.2}, {-57.5, -16.3}, {-60.3, -15.1}, {-65.3, -10.9}, {-70.5, -11.0}, {-73.8, -7.3}, {-69.9, -4.2}, {-69.4, -1.1}, {-70.0, 1.5}, {-66.8, 1.2}, {-64.0, 4.0}},
+	"CH": {{6.0, 46.2}, {6.1, 46.6}, {7.0, 47.5}, {8.6, 47.8}, {9.5, 47.5}, {9.5, 47.0}, {10.5, 46.9}, {10.2, 46.3}, {9.0, 45.9}, {8.4, 46.3}, {7.0, 45.9}},
+	"DE": {{6.1, 50.8}, {6.0, 51.9}, {7.0, 52.3}, {7.0, 53.3}, {8.7, 53.9}, {9.9, 54.8}, {11.0, 54.0}, {13.8, 54.1}, {14.2, 53.3}, {14.6, 52.6}, {15.0, 51.1}, {12.1, 50.3}, {13.8, 48.8}, {13.0, 47.5}, {10.2, 47.3}, {7.6, 47.6}, {8.2, 48.9}, {6.4, 49.5}},
+	"EG": {{25.0, 31.6}, {29.0, 30.9}, {32.3, 31.3}, {34.2, 31.3}, {34.9, 29.5}, {32.6, 29.9}, {33.6, 28.0}, {35.0, 24.5}, {36.9, 22.0}, {31.4, 22.0}, {25.0, 22.0}},
+	"ES": {{-9.0, 43.0}, {-7.8, 43.7}, {-1.8, 43.4}, {3.2, 42.4}, {3.0, 41.8}, {0.9, 41.0}, {-0.3, 39.5}, {0.2, 38.7}, {-0.8, 37.6}, {-2.1, 36.7}, {-5.4, 36.1}, {-6.4, 36.8}, {-7.4, 37.2}, {-7.0, 38.2}, {-7.3, 39.5}, {-6.8, 41.0}, {-8.2, 41.8}, {-8.9, 42.0}},
+	"FR": {{2.5, 51.1}, {4.2, 49.9}, {8.2, 49.0}, {7.6, 47.6}, {6.1, 46.3}, {7.0, 45.9}, {7.0, 44.2}, {7.5, 43.8}, {3.2, 43.0}, {3.0, 42.5}, {-1.7, 43.3}, {-1.2, 46.0}, {-2.5, 47.3}, {-4.5, 48.4}, {-1.6, 48.7}, {-1.3, 49.6}, {0.2, 49.7}, {1.6, 50.9}},
+	"GB": {{-5.7, 50.0}, {1.4, 51.2}, {1.7, 52.7}, {0.2, 53.5}, {-1.5, 55.0}, {-2.0, 55.9}, {-1.8, 57.6}, {-3.0, 58.6}, {-5.0, 58.6}, {-6.2, 56.8}, {-5.5, 55.3}, {-4.8, 54.8}, {-3.2, 54.2}, {-3.0, 53.4}, {-4.6, 53.3}, {-4.0, 52.0}, {-5.2, 51.7}, {-3.0, 51.5}, {-4.2, 51.2}},
+	"IN": {{68.2, 23.7}, {70.0, 20.8}, {72.8, 19.0}, {76.5, 9.0}, {77.5, 8.1}, {79.9, 10.3}, {80.3, 15.9}, {84.8, 19.3}, {87.0, 21.5}, {89.0, 21.8}, {88.2, 24.3}, {88.9, 26.3}, {92.0, 26.8}, {97.0, 27.8}, {95.0, 29.0}, {91.7, 27.7}, {88.8, 28.0}, {88.2, 27.0}, {84.0, 28.5}, {80.3, 30.0}, {81.0, 30.3}, {78.8, 31.5}, {78.0, 35.5}, {74.5, 37.0}, {73.8, 34.3}, {74.6, 32.8}, {74.6, 31.1}, {71.0, 28.0}, {70.0, 27.0}, {69.5, 24.3}},
+	"IT": {{7.0, 45.9}, {8.4, 46.4}, {10.5, 46.6}, {12.4, 47.0}, {13.7, 46.5}, {13.7, 45.7}, {12.3, 45.3}, {12.3, 44.3}, {13.6, 43.5}, {14.7, 42.1}, {16.0, 41.4}, {18.5, 40.1}, {18.3, 39.8}, {17.2, 40.4}, {16.5, 39.7}, {17.1, 39.0}, {16.0, 37.9}, {15.6, 38.3}, {15.7, 40.0}, {14.5, 40.6}, {12.3, 41.7}, {11.1, 42.4}, {10.5, 43.0}, {10.2, 43.9}, {8.8, 44.4}, {7.5, 43.8}, {7.0, 44.3}, {6.8, 45.1}},
+	"LU": {{6.03, 50.18}, {6.13, 50.13}, {6.40, 49.82}, {6.51, 49.70}, {6.37, 49.47}, {6.10, 49.46}, {5.82, 49.55}, {5.90, 49.70}, {5.75, 49.85}, {5.97, 50.13}},
+	"MX": {{-117.1, 32.5}, {-114.8, 32.5}, {-111.0, 31.3}, {-106.5, 31.8}, {-104.5, 29.6}, {-101.4, 29.8}, {-99.5, 27.5}, {-97.2, 26.0}, {-97.7, 21.8}, {-96.0, 19.0}, {-94.5, 18.2}, {-91.5, 18.5}, {-90.4, 21.0}, {-87.0, 21.5}, {-87.5, 18.5}, {-88.3, 17.9}, {-90.0, 17.8}, {-91.4, 17.2}, {-90.5, 16.1}, {-92.2, 14.6}, {-94.0, 16.0}, {-96.5, 15.7}, {-101.0, 17.3}, {-105.5, 20.5}, {-105.3, 22.3}, {-109.4, 25.6}, {-112.2, 29.0}, {-114.6, 31.7}},
+	"NL": {{3.4, 51.4}, {4.4, 51.4}, {5.9, 51.0}, {6.0, 51.8}, {7.0, 52.2}, {7.2, 53.2}, {6.9, 53.4}, {5.0, 53.3}, {4.6, 52.5}, {3.9, 51.8}},
+	"PL": {{14.2, 53.9}, {16.0, 54.3}, {18.6, 54.8}, {19.6, 54.4}, {23.5, 54.2}, {23.9, 53.1}, {23.2, 52.2}, {24.1, 50.8}, {22.6, 49.1}, {19.8, 49.2}, {18.8, 49.5}, {16.9, 50.4}, {15.0, 51.0}, {14.6, 52.6}, {14.3, 53.3}},
+	"PT": {{-8.8, 42.1}, {-8.1, 41.8}, {-6.6, 41.9}, {-6.9, 41.0}, {-7.0, 39.7}, {-7.3, 39.4}, {-7.0, 38.2}, {-7.5, 37.2}, {-8.9, 37.0}, {-8.8, 38.7}, {-9.5, 38.8}, {-8.7, 40.6}},
+	"US": {{-124.7, 48.4}, {-95.2, 49.0}, {-89.6, 48.0}, {-82.5, 45.3}, {-82.7, 41.7}, {-79.0, 43.3}, {-76.0, 44.2}, {-71.5, 45.0}, {-67.8, 47.1}, {-67.0, 44.8}, {-70.0, 41.8}, {-74.0, 40.5}, {-75.5, 35.2}, {-81.0, 31.5}, {-80.1, 26.5}, {-81.8, 25.2}, {-82.8, 27.9}, {-84.0, 30.0}, {-89.5, 30.2}, {-94.0, 29.6}, {-97.2, 26.0}, {-99.5, 27.5}, {-101.4, 29.8}, {-104.5, 29.6}, {-106.5, 31.8}, {-111.0, 31.3}, {-114.8, 32.5}, {-117.1, 32.5}, {-120.6, 34.6}, {-123.8, 39.5}, {-124.2, 42.0}},
+	"ZA": {{16.5, -28.6}, {20.0, -28.4}, {20.0, -24.8}, {25.0, -25.7}, {27.0, -23.6}, {29.4, -22.1}, {31.3, -22.4}, {32.0, -26.8}, {32.9, -26.9}, {32.4, -28.6}, {30.9, -30.5}, {28.0, -33.0}, {25.6, -34.0}, {22.0, -34.1}, {20.0, -34.8}, {18.4, -34.2}, {17.8, -31.0}},
+}
+
+// countryCodes returns the sorted codes of the bundled country polygons
+func countryCodes() []string {
+	codes := make([]string, 0, len(countryPolygons))
+	for code := range countryPolygons {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+	return codes
+}
+
+// polygonBoundingBox returns the minimum and maximum longitude and latitude of the vertices of polygon
+func polygonBoundingBox(polygon []lonLat) (min, max lonLat) {
+	min, max = polygon[0], polygon[0]
+	for _, vertex := range polygon[1:] {
+		if vertex.lon < min.lon {
+			min.lon = vertex.lon
+		}
+		if vertex.lon > max.lon {
+			max.lon = vertex.lon
+		}
+		if vertex.lat < min.lat {
+			min.lat = vertex.lat
+		}
+		if vertex.lat > max.lat {
+			max.lat = vertex.lat
+		}
+	}
+
+	return min, max
+}
+
+// inPolygon tells if the point is inside polygon, by counting the edges a ray from the point crosses
+func inPolygon(polygon []lonLat, lon, lat float64) bool {
+	var inside bool
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.lat > lat) != (b.lat > lat) && lon < (b.lon-a.lon)*(lat-a.lat)/(b.lat-a.lat)+a.lon {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// makeCountryGeoPointFunc returns a function generating latitude and longitude inside the polygon of the country,
+// sampling its bounding box until a point falls inside the polygon
+func makeCountryGeoPointFunc(country string) (func(r *rand.Rand) (float64, float64), error) {
+	polygon, ok := countryPolygons[strings.ToUpper(country)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q not in %s", notValidGeoPointCountry, country, strings.Join(countryCodes(), ", "))
+	}
+
+	min, max := polygonBoundingBox(polygon)
+	return func(r *rand.Rand) (float64, float64) {
+		for {
+			lat := min.lat + r.Float64()*(max.lat-min.lat)
+			lon := min.lon + r.Float64()*(max.lon-min.lon)
+			if inPolygon(polygon, lon, lat) {
+				return lat, lon
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,92 @@
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func Test_CountryPolygons(t *testing.T) {
+	for _, code := range countryCodes() {
+		polygon := countryPolygons[code]
+		if len(polygon) < 3 {
+			t.Fatalf("expected polygon of %s with at least 3 vertices, got %d", code, len(polygon))
+		}
+
+		for _, vertex := range polygon {
+			if vertex.lat < -90 || vertex.lat > 90 || vertex.lon < -180 || vertex.lon > 180 {
+				t.Fatalf("expected valid vertices of %s, got %v", code, vertex)
+			}
+		}
+
+		geoPointFunc, err := makeCountryGeoPointFunc(strings.ToLower(code))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := newRand(1)
+		for i := 0; i < 100; i++ {
+			if lat, lon := geoPointFunc(r); !inPolygon(polygon, lon, lat) {
+				t.Fatalf("expected point inside %s, got %f,%f", code, lat, lon)
+			}
+		}
+	}
+}
+
+func Test_FieldGeoPointInCountryWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeGeoPoint},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  geo_point:\n    country: LU"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		latLon := strings.Split(unmarshalJSONT[string](t, buf.Bytes())["alpha"], ",")
+		lat, _ := strconv.ParseFloat(latLon[0], 64)
+		lon, _ := strconv.ParseFloat(latLon[1], 64)
+		if lat < 49.44 || lat > 50.19 || lon < 5.73 || lon > 6.53 {
+			t.Fatalf("expected point inside the bounding box of Luxembourg, got %f,%f", lat, lon)
+		}
+	}
+}
+
+func Test_FieldGeoPointInCountryNotValid(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeGeoPoint},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  geo_point:\n    country: XX"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{"alpha":"{{.alpha}}"}`), cfg, flds, 0); !errors.Is(err, notValidGeoPointCountry) {
+		t.Fatalf("expected not valid country error, got %v", err)
+	}
+
+	if _, err := NewGeneratorWithTextTemplate([]byte(`{"alpha":"{{generate "alpha"}}"}`), cfg, flds, 0); !errors.Is(err, notValidGeoPointCountry) {
+		t.Fatalf("expected not valid country error, got %v", err)
+	}
+
+	if _, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  geo_point:\n    country: LU\n    center:\n      lat: 49.6\n      lon: 6.1\n    radius: 10")); err == nil {
+		t.Fatal("expected error with both a country and a center")
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math"
+)
+
+const (
+	// halfFloatMax is the greatest finite half precision value
+	halfFloatMax = 65504
+	// halfFloatSignificandBits are the bits of the significand, the implicit leading one included
+	halfFloatSignificandBits = 11
+	// halfFloatMinExp is the exponent, as returned by math.Frexp, of the smallest normal half precision value
+	halfFloatMinExp = -13
+)
+
+// quantizeHalfFloat returns v with the precision of a half precision float, rounded with round to a multiple of the
+// half precision quantum in the binade of v. Values out of the half precision range saturate to the greatest finite one.
+func quantizeHalfFloat(v float64, round func(float64) float64) float64 {
+	if v == 0 || math.IsNaN(v) {
+		return v
+	}
+
+	_, exp := math.Frexp(v)
+	if exp < halfFloatMinExp {
+		// subnormal values share the quantum of the smallest normal ones
+		exp = halfFloatMinExp
+	}
+
+	quantum := math.Ldexp(1, exp-halfFloatSignificandBits)
+	return math.Max(-halfFloatMax, math.Min(halfFloatMax, round(v/quantum)*quantum))
+}
+
+// makeHalfFloatFunc returns a function generating float values as they are stored by a half_float field, so that they are
+// the same once indexed: values are rounded to the nearest half precision one, staying within the range bounds when set
+func makeHalfFloatFunc(fieldCfg ConfigField, field Field) func(state *GenState) float64 {
+	dummyFunc := makeFloatFunc(fieldCfg, field)
+	min, minErr := fieldCfg.Range.MinAsFloat64()
+	max, maxErr := fieldCfg.Range.MaxAsFloat64()
+
+	return func(state *GenState) float64 {
+		dummyFloat := dummyFunc(state)
+		if fieldCfg.Fuzziness > 0 {
+			if previousDummyFloat, ok := state.prevCache[field.Name].(float64); ok {
+				dummyFloat = fuzzyFloat(state.rand, previousDummyFloat, fieldCfg.Fuzziness, min, max)
+			}
+			state.prevCache[field.Name] = dummyFloat
+		}
+
+		halfFloat := quantizeHalfFloat(dummyFloat, math.RoundToEven)
+		switch {
+		case minErr == nil && halfFloat < min:
+			halfFloat = quantizeHalfFloat(dummyFloat, math.Ceil)
+		case maxErr == nil && halfFloat > max:
+			halfFloat = quantizeHalfFloat(dummyFloat, math.Floor)
+		}
+
+		return halfFloat
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+const halfFloatYaml = `- name: alpha
+  range:
+    min: 0.1
+    max: 1000.5
+`
+
+// float16RoundTrip converts v to the nearest half precision value, ties to even, and back, through the float32 bits
+func float16RoundTrip(v float64) float64 {
+	f := float32(v)
+	bits := math.Float32bits(f)
+	if exp := int(bits>>23&0xff) - 127; exp < -14 {
+		// subnormal half precision values are multiples of 2^-24
+		quantum := math.Ldexp(1, -24)
+		return math.RoundToEven(float64(f)/quantum) * quantum
+	}
+
+	// normal half precision values keep 10 of the 23 significand bits of a float32
+	const dropped = 13
+	bits += 1<<(dropped-1) - 1 + bits>>dropped&1
+	return float64(math.Float32frombits(bits &^ (1<<dropped - 1)))
+}
+
+// assertHalfFloat checks that value survives the half precision round trip and is within the halfFloatYaml range
+func assertHalfFloat(t *testing.T, value float64) {
+	t.Helper()
+
+	if roundTripped := float16RoundTrip(value); roundTripped != value {
+		t.Fatalf("expected value equal to its half precision round trip %v, got %v", roundTripped, value)
+	}
+
+	if value < 0.1 || value > 1000.5 {
+		t.Fatalf("expected value between 0.1 and 1000.5, got %v", value)
+	}
+}
+
+func Test_QuantizeHalfFloat(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		// values spanning from the subnormal half precision ones to the greatest finite one
+		v := math.Ldexp(r.Float64(), r.Intn(42)-26)
+		if r.Intn(2) == 0 {
+			v = -v
+		}
+
+		if quantized, expected := quantizeHalfFloat(v, math.RoundToEven), float16RoundTrip(v); quantized != expected {
+			t.Fatalf("expected %v quantized to %v, got %v", v, expected, quantized)
+		}
+	}
+
+	if quantized := quantizeHalfFloat(1e6, math.RoundToEven); quantized != halfFloatMax {
+		t.Fatalf("expected out of range value saturated to %v, got %v", halfFloatMax, quantized)
+	}
+}
+
+func Test_FieldHalfFloatWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeHalfFloat},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(halfFloatYaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		assertHalfFloat(t, unmarshalJSONT[float64](t, buf.Bytes())["alpha"])
+	}
+}
+
+func Test_FieldHalfFloatWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeHalfFloat},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(halfFloatYaml + "  fuzziness: 0.1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		assertHalfFloat(t, unmarshalJSONT[float64](t, buf.Bytes())["alpha"])
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var notValidJSONEvent = errors.New("events must be JSON objects")
+var notValidJSON = errors.New("generated event is not valid JSON")
+
+// decodeJSONEvent decodes a generated event, keeping its numbers as json.Number so that integers don't lose precision
+func decodeJSONEvent(event []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(event))
+	decoder.UseNumber()
+
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil || doc == nil {
+		return nil, fmt.Errorf("%w: %s", notValidJSONEvent, event)
+	}
+
+	return doc, nil
+}
+
+// lookupEventValue returns the value of the dotted name in doc, either as a key or as a path of nested objects
+func lookupEventValue(doc map[string]any, name string) (any, bool) {
+	if value, ok := doc[name]; ok {
+		return value, true
+	}
+
+	for i := 0; i < len(name); i++ {
+		if name[i] != '.' {
+			continue
+		}
+
+		if nested, ok := doc[name[:i]].(map[string]any); ok {
+			if value, ok := lookupEventValue(nested, name[i+1:]); ok {
+				return value, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// eventValueString formats a value of a decoded event: nil as empty, strings as they are, and anything else JSON encoded
+func eventValueString(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		return string(encoded), nil
+	}
+}
+
+// emitValidJSON emits an event through emit to w, failing instead when it is not valid JSON: the error reports the
+// index of the event, the byte offset of the syntax error and the event itself
+func emitValidJSON(state *GenState, w io.Writer, emit func(state *GenState, w io.Writer) error) error {
+	buf := state.pool.Get().(*bytes.Buffer)
+	defer state.pool.Put(buf)
+
+	buf.Reset()
+	if err := emit(state, buf); err != nil {
+		return err
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		var v any
+		err := json.Unmarshal(buf.Bytes(), &v)
+
+		var offset int64
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			offset = syntaxErr.Offset
+		}
+
+		return fmt.Errorf("%w: event %d at offset %d: %v: %s", notValidJSON, state.counter, offset, err, buf.Bytes())
+	}
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
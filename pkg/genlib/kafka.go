@@ -0,0 +1,199 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// defaultKafkaBatchSize is the number of messages of each produced batch when not set
+const defaultKafkaBatchSize = 100
+
+var notValidKafkaOptions = errors.New("not valid kafka options")
+
+// KafkaMessage is a message produced by a KafkaWriter, with a nil Key when no key field is set
+type KafkaMessage struct {
+	Key   []byte
+	Value []byte
+}
+
+// KafkaProducer produces batches of messages to a Kafka topic
+type KafkaProducer interface {
+	// Produce returns once all the messages are produced, or at the first failure: it must not retain messages, being reused for the next batch
+	Produce(ctx context.Context, messages []KafkaMessage) error
+	Close() error
+}
+
+// KafkaOptions are the options of a KafkaWriter
+type KafkaOptions struct {
+	// Brokers are the addresses of the Kafka brokers
+	Brokers []string
+	// Topic the messages are produced to
+	Topic string
+	// KeyField is the name of the field whose value is the key of each message, either by its dotted name or by its path in nested objects,
+	// the messages having no key when not set
+	KeyField string
+	// BatchSize is the number of messages of each produced batch, defaults to 100 when not set
+	BatchSize int
+	// Linger is the maximum time a message waits for its batch to fill before being produced, a batch waiting to be full when not set
+	Linger time.Duration
+	// Producer overrides the Kafka client producing the messages to Brokers
+	Producer KafkaProducer
+}
+
+// KafkaWriter writes events as the messages of a Kafka topic, batching them.
+// A batch is produced when full, or when writing an event after its first message has been waiting for more than the linger time:
+// the last batch is produced by Close.
+type KafkaWriter struct {
+	ctx       context.Context
+	producer  KafkaProducer
+	keyField  string
+	batchSize int
+	linger    time.Duration
+	// now allows overriding the time in tests
+	now func() time.Time
+
+	batch      []KafkaMessage
+	batchStart time.Time
+}
+
+// kafkaGoProducer is the KafkaProducer of the Kafka client
+type kafkaGoProducer struct {
+	writer   *kafka.Writer
+	messages []kafka.Message
+}
+
+func (kp *kafkaGoProducer) Produce(ctx context.Context, messages []KafkaMessage) error {
+	kp.messages = kp.messages[:0]
+	for _, message := range messages {
+		kp.messages = append(kp.messages, kafka.Message{Key: message.Key, Value: message.Value})
+	}
+
+	return kp.writer.WriteMessages(ctx, kp.messages...)
+}
+
+func (kp *kafkaGoProducer) Close() error {
+	return kp.writer.Close()
+}
+
+// NewKafkaWriter returns a KafkaWriter producing its messages with ctx
+func NewKafkaWriter(ctx context.Context, options KafkaOptions) (*KafkaWriter, error) {
+	if options.BatchSize < 0 {
+		return nil, fmt.Errorf("%w: negative batch size %d", notValidKafkaOptions, options.BatchSize)
+	}
+
+	if options.Linger < 0 {
+		return nil, fmt.Errorf("%w: negative linger %s", notValidKafkaOptions, options.Linger)
+	}
+
+	batchSize := options.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultKafkaBatchSize
+	}
+
+	producer := options.Producer
+	if producer == nil {
+		if len(options.Brokers) == 0 {
+			return nil, fmt.Errorf("%w: missing brokers", notValidKafkaOptions)
+		}
+
+		if options.Topic == "" {
+			return nil, fmt.Errorf("%w: missing topic", notValidKafkaOptions)
+		}
+
+		producer = &kafkaGoProducer{
+			writer: &kafka.Writer{
+				Addr:  kafka.TCP(options.Brokers...),
+				Topic: options.Topic,
+				// the messages with the same key go to the same partition
+				Balancer:  &kafka.Hash{},
+				BatchSize: batchSize,
+				// the batches are already full when written, but the last one
+				BatchTimeout: time.Millisecond,
+			},
+		}
+	}
+
+	return &KafkaWriter{
+		ctx:       ctx,
+		producer:  producer,
+		keyField:  options.KeyField,
+		batchSize: batchSize,
+		linger:    options.Linger,
+		now:       time.Now,
+		batch:     make([]KafkaMessage, 0, batchSize),
+	}, nil
+}
+
+// WriteEvent adds the event to the current batch as a message, producing the batch when full or lingering for too long.
+// The event must be a JSON object when a key field is set.
+func (kw *KafkaWriter) WriteEvent(event []byte) error {
+	message := KafkaMessage{
+		// the event buffer is reused by the generation
+		Value: append([]byte(nil), event...),
+	}
+
+	if kw.keyField != "" {
+		key, err := kw.messageKey(event)
+		if err != nil {
+			return err
+		}
+
+		message.Key = key
+	}
+
+	if len(kw.batch) == 0 {
+		kw.batchStart = kw.now()
+	}
+
+	kw.batch = append(kw.batch, message)
+
+	if len(kw.batch) < kw.batchSize && (kw.linger == 0 || kw.now().Sub(kw.batchStart) < kw.linger) {
+		return nil
+	}
+
+	return kw.flush()
+}
+
+// messageKey is the value of the key field of event, with missing and null values being an empty key
+func (kw *KafkaWriter) messageKey(event []byte) ([]byte, error) {
+	doc, err := decodeJSONEvent(event)
+	if err != nil {
+		return nil, err
+	}
+
+	value, _ := lookupEventValue(doc, kw.keyField)
+
+	key, err := eventValueString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(key), nil
+}
+
+func (kw *KafkaWriter) flush() error {
+	err := kw.producer.Produce(kw.ctx, kw.batch)
+	kw.batch = kw.batch[:0]
+
+	return err
+}
+
+// Close produces the last batch, if any, and closes the producer
+func (kw *KafkaWriter) Close() error {
+	if len(kw.batch) > 0 {
+		if err := kw.flush(); err != nil {
+			_ = kw.producer.Close()
+			return err
+		}
+	}
+
+	return kw.producer.Close()
+}
@@ -0,0 +1,153 @@
+package genlib
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+type memKafkaProducer struct {
+	batches  []int
+	messages []KafkaMessage
+	closed   bool
+}
+
+func (p *memKafkaProducer) Produce(_ context.Context, messages []KafkaMessage) error {
+	p.batches = append(p.batches, len(messages))
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+func (p *memKafkaProducer) Close() error {
+	p.closed = true
+	return nil
+}
+
+func Test_KafkaWriter(t *testing.T) {
+	const totEvents = 250
+
+	flds := Fields{
+		{Name: "host.name", Type: FieldTypeKeyword},
+		{Name: "event.sequence", Type: FieldTypeLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: host.name\n  enum: [\"alpha\", \"beta\", \"gamma\"]\n- name: event.sequence\n  counter:\n    start: 0\n    step: 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"host":{"name":"{{.host.name}}"},"event":{"sequence":{{.event.sequence}}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, err := NewGeneratorWithCustomTemplateN(template, cfg, flds, totEvents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer := &memKafkaProducer{}
+	kw, err := NewKafkaWriter(context.Background(), KafkaOptions{KeyField: "host.name", BatchSize: 100, Producer: producer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := kw.WriteEvent(buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := kw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !producer.closed {
+		t.Fatal("expected producer closed")
+	}
+
+	if len(producer.batches) != 3 || producer.batches[0] != 100 || producer.batches[1] != 100 || producer.batches[2] != 50 {
+		t.Fatalf("expected batches of 100, 100 and 50 messages, got %v", producer.batches)
+	}
+
+	seen := make(map[float64]struct{}, totEvents)
+	for _, message := range producer.messages {
+		event := unmarshalJSONT[any](t, message.Value)
+		sequence := event["event"].(map[string]any)["sequence"].(float64)
+		if _, ok := seen[sequence]; ok {
+			t.Fatalf("event %v produced more than once", sequence)
+		}
+
+		seen[sequence] = struct{}{}
+
+		if hostName := event["host"].(map[string]any)["name"]; string(message.Key) != hostName {
+			t.Errorf("expected key %s, got %s", hostName, message.Key)
+		}
+	}
+
+	if len(seen) != totEvents {
+		t.Fatalf("expected %d events, got %d", totEvents, len(seen))
+	}
+}
+
+func Test_KafkaWriterLinger(t *testing.T) {
+	producer := &memKafkaProducer{}
+	kw, err := NewKafkaWriter(context.Background(), KafkaOptions{BatchSize: 100, Linger: time.Second, Producer: producer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	kw.now = func() time.Time { return now }
+
+	// the third message has been waiting for more than a second since the first one
+	for i := 0; i < 4; i++ {
+		if err := kw.WriteEvent([]byte(`not json, since there is no key field`)); err != nil {
+			t.Fatal(err)
+		}
+
+		now = now.Add(600 * time.Millisecond)
+	}
+
+	if err := kw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(producer.batches) != 2 || producer.batches[0] != 3 || producer.batches[1] != 1 {
+		t.Fatalf("expected batches of 3 and 1 messages, got %v", producer.batches)
+	}
+
+	if producer.messages[0].Key != nil {
+		t.Fatalf("expected no key, got %s", producer.messages[0].Key)
+	}
+}
+
+func Test_KafkaWriterNotValid(t *testing.T) {
+	if _, err := NewKafkaWriter(context.Background(), KafkaOptions{Topic: "events"}); !errors.Is(err, notValidKafkaOptions) {
+		t.Fatalf("expected options error, got %v", err)
+	}
+
+	kw, err := NewKafkaWriter(context.Background(), KafkaOptions{KeyField: "host.name", Producer: &memKafkaProducer{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := kw.WriteEvent([]byte(`not json`)); !errors.Is(err, notValidJSONEvent) {
+		t.Fatalf("expected event error, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math/rand"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// loremWords are the words text values are made of
+var loremWords = []string{
+	"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do",
+	"eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua", "enim",
+	"ad", "minim", "veniam", "quis", "nostrud", "exercitation", "ullamco", "laboris", "nisi", "aliquip",
+	"ex", "ea", "commodo", "consequat", "duis", "aute", "irure", "in", "reprehenderit", "voluptate",
+	"velit", "esse", "cillum", "eu", "fugiat", "nulla", "pariatur", "excepteur", "sint", "occaecat",
+	"cupidatat", "non", "proident", "sunt", "culpa", "qui", "officia", "deserunt", "mollit", "anim",
+	"id", "est", "laborum", "perspiciatis", "unde", "omnis", "iste", "natus", "error", "voluptatem",
+	"accusantium", "doloremque", "laudantium", "totam", "rem", "aperiam", "eaque", "ipsa", "quae", "ab",
+	"illo", "inventore", "veritatis", "quasi", "architecto", "beatae", "vitae", "dicta", "explicabo", "nemo",
+	"ipsam", "quia", "voluptas", "aspernatur", "aut", "odit", "fugit", "consequuntur", "magni", "dolores",
+	"eos", "ratione", "sequi", "nesciunt", "neque", "porro", "quisquam", "dolorem", "adipisci", "numquam",
+	"eius", "modi", "tempora", "incidunt", "magnam", "quaerat", "minima", "nostrum", "exercitationem", "ullam",
+	"corporis", "suscipit", "laboriosam", "aliquid", "commodi", "consequatur", "autem", "vel", "eum", "iure",
+	"quam", "nihil", "molestiae", "illum", "quo", "at", "vero", "accusamus", "iusto", "odio",
+	"dignissimos", "ducimus", "blanditiis", "praesentium", "deleniti", "atque", "corrupti", "quos", "quas", "molestias",
+	"excepturi", "occaecati", "cupiditate", "provident", "similique", "mollitia", "animi", "recusandae", "fuga", "harum",
+}
+
+// defaultTextWordCount is the number of words of text values when word_count is not configured
+var defaultTextWordCount = config.Length{Min: 5, Max: 25}
+
+// loremCommaMinWords is the number of words from which a sentence may have a comma
+const loremCommaMinWords = 6
+
+// makeTextFunc returns a function generating text values, made of the configured number of words split in the configured
+// number of sentences: each sentence starts with a capital letter and ends with a period, the longer ones having a comma too
+func makeTextFunc(fieldCfg ConfigField) func(r *rand.Rand) string {
+	wordCount := defaultTextWordCount
+	if fieldCfg.WordCount != nil {
+		wordCount = *fieldCfg.WordCount
+	}
+
+	sentenceCount := config.Length{Min: 1, Max: 1}
+	if fieldCfg.SentenceCount != nil {
+		sentenceCount = *fieldCfg.SentenceCount
+	}
+
+	wordCountFunc := makeLengthFunc(wordCount)
+	sentenceCountFunc := makeLengthFunc(sentenceCount)
+
+	return func(r *rand.Rand) string {
+		words := wordCountFunc(r)
+		sentences := sentenceCountFunc(r)
+		// each sentence has at least a word
+		if sentences > words {
+			sentences = words
+		}
+
+		var sb strings.Builder
+		for i := 0; i < sentences; i++ {
+			// the words are split evenly, the first sentences taking the remainder
+			n := words / sentences
+			if i < words%sentences {
+				n++
+			}
+
+			comma := -1
+			if n >= loremCommaMinWords {
+				comma = 1 + r.Intn(n-2)
+			}
+
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+
+			for j := 0; j < n; j++ {
+				word := loremWords[r.Intn(len(loremWords))]
+				if j == 0 {
+					first, size := utf8.DecodeRuneInString(word)
+					sb.WriteRune(unicode.ToUpper(first))
+					sb.WriteString(word[size:])
+				} else {
+					sb.WriteByte(' ')
+					sb.WriteString(word)
+				}
+
+				if j == comma {
+					sb.WriteByte(',')
+				}
+			}
+
+			sb.WriteByte('.')
+		}
+
+		return sb.String()
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// assertText checks that value is made of sentences starting with a capital letter and ending with a period,
+// with a number of words and sentences within the given lengths
+func assertText(t *testing.T, value string, wordCount, sentenceCount config.Length) {
+	t.Helper()
+
+	words := strings.Fields(value)
+	if len(words) < wordCount.Min || len(words) > wordCount.Max {
+		t.Fatalf("expected between %d and %d words, got %d: %s", wordCount.Min, wordCount.Max, len(words), value)
+	}
+
+	sentences := strings.SplitAfter(value, ". ")
+	if len(sentences) < sentenceCount.Min || len(sentences) > sentenceCount.Max {
+		t.Fatalf("expected between %d and %d sentences, got %d: %s", sentenceCount.Min, sentenceCount.Max, len(sentences), value)
+	}
+
+	for _, sentence := range sentences {
+		sentence = strings.TrimSpace(sentence)
+		if !unicode.IsUpper([]rune(sentence)[0]) || !strings.HasSuffix(sentence, ".") {
+			t.Fatalf("expected capitalized sentence ending with a period, got %s", sentence)
+		}
+	}
+}
+
+func Test_FieldTextWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "message", Type: FieldTypeText},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: message\n  word_count:\n    min: 10\n    max: 40\n  sentence_count:\n    min: 2\n    max: 4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"message":"{{.message}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		assertText(t, unmarshalJSONT[string](t, buf.Bytes())["message"], config.Length{Min: 10, Max: 40}, config.Length{Min: 2, Max: 4})
+	}
+}
+
+func Test_FieldTextWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "message", Type: FieldTypeText},
+		{Name: "error.message", Type: FieldTypeText},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: message\n  word_count:\n    min: 3\n    max: 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"message":"{{generate "message"}}","error.message":"{{generate "error.message"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		assertText(t, m["message"], config.Length{Min: 3, Max: 3}, config.Length{Min: 1, Max: 1})
+		assertText(t, m["error.message"], defaultTextWordCount, config.Length{Min: 1, Max: 1})
+	}
+}
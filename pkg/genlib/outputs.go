@@ -0,0 +1,102 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"io"
+	"reflect"
+	"sync"
+)
+
+// flusher is implemented by the writers buffering what is written to them, as *bufio.Writer and *gzip.Writer
+type flusher interface {
+	Flush() error
+}
+
+// generatorCloser is implemented by the writers genlib returns for EmitTo, as the compression ones, whose Close terminates
+// what they write without closing the writer they write to
+type generatorCloser interface {
+	io.Closer
+	closedByGenerators()
+}
+
+// outputs are the writers a generator emitted events to with EmitTo, that need to be finalized when the generator is
+// closed: the ones buffering their content are flushed and the ones genlib returns are closed, each one once, in the
+// order of their first event. Any other io.Closer, as os.Stdout or a file, belongs to the caller and is not closed.
+// Writers neither flushing nor closed, as *bytes.Buffer, are not kept track of.
+type outputs struct {
+	mu      sync.Mutex
+	writers []io.Writer
+	last    io.Writer
+}
+
+func newOutputs() *outputs {
+	return &outputs{}
+}
+
+// add keeps track of w, unless it is already
+func (o *outputs) add(w io.Writer) {
+	if o == nil {
+		return
+	}
+
+	_, isFlusher := w.(flusher)
+	_, isCloser := w.(generatorCloser)
+	if !isFlusher && !isCloser {
+		return
+	}
+
+	// writers of a non comparable type cannot be told apart from the tracked ones
+	if !reflect.TypeOf(w).Comparable() {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// the same writer is usually passed for all the events
+	if o.last == w {
+		return
+	}
+
+	o.last = w
+	for _, tracked := range o.writers {
+		if tracked == w {
+			return
+		}
+	}
+
+	o.writers = append(o.writers, w)
+}
+
+// close flushes the tracked writers and closes the ones genlib returns, returning the first error while still finalizing all of them.
+// The writers are not tracked anymore afterwards, so that closing twice does not close them twice.
+func (o *outputs) close() error {
+	if o == nil {
+		return nil
+	}
+
+	o.mu.Lock()
+	writers := o.writers
+	o.writers, o.last = nil, nil
+	o.mu.Unlock()
+
+	var firstErr error
+	for _, w := range writers {
+		if f, ok := w.(flusher); ok {
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+
+		if c, ok := w.(generatorCloser); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
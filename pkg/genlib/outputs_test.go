@@ -0,0 +1,179 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+var errMockClose = errors.New("mock close error")
+
+// mockOutput is a writer buffering its content until flushed, counting the calls to Flush and Close
+type mockOutput struct {
+	pending bytes.Buffer
+	flushed bytes.Buffer
+	flushes int
+	closes  int
+}
+
+func (m *mockOutput) Write(p []byte) (int, error) {
+	return m.pending.Write(p)
+}
+
+func (m *mockOutput) Flush() error {
+	m.flushes++
+	_, err := m.pending.WriteTo(&m.flushed)
+	return err
+}
+
+func (m *mockOutput) Close() error {
+	m.closes++
+	return errMockClose
+}
+
+func Test_CloseFinalizesOutputs(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	templates := map[GeneratorKind][]byte{
+		GeneratorKindCustomTemplate: []byte(`{"alpha":"{{.alpha}}"}`),
+		GeneratorKindTextTemplate:   []byte(`{"alpha":"{{generate "alpha"}}"}`),
+	}
+
+	for kind, template := range templates {
+		g, err := NewGenerator(kind, template, Config{}, flds, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bulkGen, err := NewGeneratorWithBulkFormat(g, "logs")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, gen := range []Generator{g, bulkGen} {
+			state := NewGenState()
+			var w mockOutput
+			for i := 0; i < 10; i++ {
+				if err := gen.EmitTo(state, &w); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// events emitted to a writer not to be finalized are not kept track of
+			var buf bytes.Buffer
+			if err := gen.Emit(state, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := gen.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// the writer belongs to the caller, that is the one closing it
+			if w.flushes != 1 || w.closes != 0 {
+				t.Fatalf("expected writer to be flushed once and not closed, got %d flushes and %d closes", w.flushes, w.closes)
+			}
+
+			if w.pending.Len() > 0 || w.flushed.Len() == 0 {
+				t.Fatalf("expected writer content to be flushed, got %d bytes pending", w.pending.Len())
+			}
+
+			if err := gen.Close(); err != nil {
+				t.Fatalf("expected second close to be a no-op, got %v", err)
+			}
+
+			if w.flushes != 1 {
+				t.Fatalf("expected writer to be flushed once, got %d flushes", w.flushes)
+			}
+		}
+	}
+}
+
+func Test_CloseFinalizesOutputsWithWeightedTemplates(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	templates := []WeightedTemplate{
+		{Template: []byte(`{"alpha":"{{.alpha}}"}`)},
+		{Template: []byte(`{"beta":"{{.alpha}}"}`)},
+	}
+
+	g, err := NewGeneratorWithWeightedTemplatesN(templates, Config{}, flds, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+	var w mockOutput
+	for i := 0; i < 100; i++ {
+		if err := g.EmitTo(state, &w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.flushes != 1 || w.closes != 0 {
+		t.Fatalf("expected writer shared by the templates to be flushed once and not closed, got %d flushes and %d closes", w.flushes, w.closes)
+	}
+}
+
+func Test_CloseTerminatesCompressionOutputs(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	g, err := NewGeneratorWithCustomTemplate([]byte(`{"alpha":"{{.alpha}}"}`), Config{}, flds, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var w mockOutput
+	cw, err := NewCompressionWriter(config.CompressionGzip, &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+	for i := 0; i < 10; i++ {
+		if err := g.EmitTo(state, cw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the compressed stream is terminated, while the writer it's written to is left open
+	if w.closes != 0 {
+		t.Fatalf("expected writer compressed to not be closed, got %d closes", w.closes)
+	}
+
+	r, err := gzip.NewReader(&w.pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decompressed, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("expected terminated compressed stream, got %v", err)
+	}
+
+	if events := bytes.Count(decompressed, []byte(`{"alpha":`)); events != 10 {
+		t.Fatalf("expected 10 events decompressed, got %d in %s", events, decompressed)
+	}
+}
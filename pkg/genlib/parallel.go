@@ -0,0 +1,120 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+var notValidWorkers = errors.New("workers must be greater than 0")
+
+// GenerateParallel generates the events of gen with workers goroutines, each one with its own GenState, passing each event to out.
+// The events are split across the workers by their index, worker i generating the events i, i+workers, i+2*workers and so on, so that
+// their total number is the same as when generated by a single goroutine; since they are generated concurrently, the events are passed
+// to out in no particular order. The states of the workers are seeded from the seed of gen, hence with a seed and the same number of
+// workers the same events are generated in every run. out is never called concurrently and must not retain buf after returning.
+// The first error returned by either a worker or out stops the generation and is returned.
+// NOTE: gen must have a limit on the number of events, otherwise the generation never ends.
+func GenerateParallel(gen Generator, workers int, out func(buf *bytes.Buffer) error) error {
+	if workers < 1 {
+		return notValidWorkers
+	}
+
+	done := make(chan struct{})
+	bufs := make(chan *bytes.Buffer, workers)
+
+	var firstErr error
+	var stopOnce sync.Once
+	stop := func(err error) {
+		stopOnce.Do(func() {
+			firstErr = err
+			close(done)
+		})
+	}
+
+	// the states are seeded independently, otherwise the workers would generate the same values
+	var seed int64
+	if seeded, ok := gen.(seededGenerator); ok {
+		seed = seeded.generatorSeed()
+	}
+
+	seeds := newRand(seed)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		state := NewGenStateWithSeed(seeds.Int63())
+
+		wg.Add(1)
+		go func(first uint64) {
+			defer wg.Done()
+
+			if err := generateWorker(gen, state, first, uint64(workers), bufs, done); err != nil {
+				stop(err)
+			}
+		}(uint64(i))
+	}
+
+	go func() {
+		wg.Wait()
+		close(bufs)
+	}()
+
+	for buf := range bufs {
+		select {
+		case <-done:
+			// the remaining events are discarded
+		default:
+			if err := out(buf); err != nil {
+				stop(err)
+			}
+		}
+
+		PutBuffer(buf)
+	}
+
+	return firstErr
+}
+
+// seededGenerator is implemented by the generators whose seed derives the seeds of the states of GenerateParallel
+type seededGenerator interface {
+	generatorSeed() int64
+}
+
+// generateWorker generates the events from first, every step events
+func generateWorker(gen Generator, state *GenState, first, step uint64, bufs chan<- *bytes.Buffer, done <-chan struct{}) error {
+	for index := first; ; index += step {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+
+		// the event counter of the state is the index of the event: the generator stops at the same total number of events
+		state.counter = index
+
+		buf := GetBuffer()
+
+		err := gen.Emit(state, buf)
+		if err == io.EOF {
+			PutBuffer(buf)
+			return nil
+		}
+
+		if err != nil {
+			PutBuffer(buf)
+			return err
+		}
+
+		select {
+		case bufs <- buf:
+		case <-done:
+			PutBuffer(buf)
+			return nil
+		}
+	}
+}
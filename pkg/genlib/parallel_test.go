@@ -0,0 +1,147 @@
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func Test_GenerateParallel(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	// with a rate each event has its own timestamp, derived from its index
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := Config{Timestamp: &config.Timestamp{Start: start, End: start.Add(time.Hour), Rate: 1000, Timezone: "UTC"}}
+
+	const totEvents = 1001
+
+	customTemplate := []byte(`{"@timestamp":"{{.@timestamp}}","alpha":"{{.alpha}}","beta":{{.beta}}}`)
+	textTemplate := []byte(`{"@timestamp":"{{timestamp | date "2006-01-02T15:04:05.999999Z07:00"}}","alpha":"{{generate "alpha"}}","beta":{{generate "beta"}}}`)
+
+	for _, workers := range []int{1, 4} {
+		customGen, err := NewGeneratorWithCustomTemplateN(customTemplate, cfg, flds, totEvents)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		textGen, err := NewGeneratorWithTextTemplateN(textTemplate, cfg, flds, totEvents)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, gen := range []Generator{customGen, textGen} {
+			timestamps := make(map[time.Time]struct{})
+			err := GenerateParallel(gen, workers, func(buf *bytes.Buffer) error {
+				timestamp, err := time.Parse(FieldTypeTimeLayout, unmarshalJSONT[any](t, buf.Bytes())["@timestamp"].(string))
+				if err != nil {
+					return err
+				}
+
+				timestamps[timestamp] = struct{}{}
+
+				return nil
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(timestamps) != totEvents {
+				t.Fatalf("expected %d distinct events with %d workers, got %d", totEvents, workers, len(timestamps))
+			}
+
+			for i := 0; i < totEvents; i++ {
+				if _, ok := timestamps[start.Add(time.Duration(i)*time.Millisecond)]; !ok {
+					t.Fatalf("missing event %d with %d workers", i, workers)
+				}
+			}
+		}
+	}
+}
+
+func Test_GenerateParallelSeed(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}","beta":{{.beta}}}`)
+	t.Logf("with template: %s", string(template))
+
+	// generateSorted returns the events generated in parallel with seed, sorted since they come in no particular order
+	generateSorted := func(seed int64) []string {
+		gen, err := NewGeneratorWithCustomTemplateN(template, Config{Seed: seed}, flds, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var events []string
+		err = GenerateParallel(gen, 4, func(buf *bytes.Buffer) error {
+			events = append(events, buf.String())
+			return nil
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sort.Strings(events)
+
+		return events
+	}
+
+	first, again, other := generateSorted(42), generateSorted(42), generateSorted(43)
+	if !reflect.DeepEqual(first, again) {
+		t.Error("expected the same events with the same seed")
+	}
+
+	if reflect.DeepEqual(first, other) {
+		t.Error("expected different events with a different seed")
+	}
+}
+
+func Test_GenerateParallelError(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, err := NewGeneratorWithCustomTemplateN(template, Config{}, flds, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outErr := errors.New("out error")
+
+	var events int
+	err = GenerateParallel(g, 4, func(buf *bytes.Buffer) error {
+		events += 1
+		if events == 10 {
+			return outErr
+		}
+
+		return nil
+	})
+
+	if !errors.Is(err, outErr) {
+		t.Fatalf("expected out error, got %v", err)
+	}
+
+	if events != 10 {
+		t.Fatalf("expected out not to be called after the error, got %d events", events)
+	}
+
+	if err := GenerateParallel(g, 0, func(buf *bytes.Buffer) error { return nil }); !errors.Is(err, notValidWorkers) {
+		t.Fatalf("expected workers error, got %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+	parquetwriter "github.com/xitongsys/parquet-go/writer"
+)
+
+// ParquetExtension is the extension of the corpus files written by a ParquetWriter
+const ParquetExtension = ".parquet"
+
+// parquetWriterParallelism is the number of goroutines marshalling the rows of a Parquet file
+const parquetWriterParallelism = 4
+
+var notValidParquetValue = errors.New("not valid value for parquet column")
+
+// parquetColumnKind is how the values of a field are stored in a Parquet column
+type parquetColumnKind int
+
+const (
+	parquetColumnString parquetColumnKind = iota
+	parquetColumnInt32
+	parquetColumnInt64
+	parquetColumnUint64
+	parquetColumnFloat
+	parquetColumnDouble
+	parquetColumnBoolean
+	parquetColumnTimestamp
+)
+
+type parquetColumn struct {
+	name string
+	kind parquetColumnKind
+	// dateFormat is the format of the values of a timestamp column
+	dateFormat string
+}
+
+// ParquetWriter writes JSON events as the rows of a Parquet file, with a column for each field
+type ParquetWriter struct {
+	columns []parquetColumn
+	writer  *parquetwriter.JSONWriter
+}
+
+// parquetColumnKindByType maps a field type to the Parquet column storing its values:
+// any type without a Parquet equivalent, as objects or ranges, is stored as a string with the JSON encoding of the value.
+// The dates formatted by a Go time layout are stored as strings too, as they are generated, since the layout can leave out
+// any part of the date.
+func parquetColumnKindByType(fieldType string, dateFormat string) parquetColumnKind {
+	switch fieldType {
+	case FieldTypeInteger:
+		return parquetColumnInt32
+	case FieldTypeLong:
+		return parquetColumnInt64
+	case FieldTypeUnsignedLong:
+		return parquetColumnUint64
+	case FieldTypeFloat, FieldTypeHalfFloat:
+		return parquetColumnFloat
+	case FieldTypeDouble, FieldTypeScaledFloat:
+		return parquetColumnDouble
+	case FieldTypeBool:
+		return parquetColumnBoolean
+	case FieldTypeDate:
+		switch dateFormat {
+		case "", config.DateFormatRFC3339, config.DateFormatRFC3339Nano, config.DateFormatEpochMillis, config.DateFormatEpochSecond:
+			return parquetColumnTimestamp
+		default:
+			return parquetColumnString
+		}
+	default:
+		return parquetColumnString
+	}
+}
+
+func parquetColumnTag(column parquetColumn) string {
+	var columnType string
+	switch column.kind {
+	case parquetColumnInt32:
+		columnType = "type=INT32"
+	case parquetColumnInt64:
+		columnType = "type=INT64"
+	case parquetColumnUint64:
+		columnType = "type=INT64, convertedtype=UINT_64"
+	case parquetColumnFloat:
+		columnType = "type=FLOAT"
+	case parquetColumnDouble:
+		columnType = "type=DOUBLE"
+	case parquetColumnBoolean:
+		columnType = "type=BOOLEAN"
+	case parquetColumnTimestamp:
+		columnType = "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+	default:
+		columnType = "type=BYTE_ARRAY, convertedtype=UTF8"
+	}
+
+	// every column is optional, since fields can be missing from the events
+	return fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", column.name, columnType)
+}
+
+// NewParquetWriter returns a ParquetWriter writing to w a Parquet file whose schema derives from the types of fields,
+// and from the format of the date fields as configured in cfg
+func NewParquetWriter(cfg Config, fields Fields, w io.Writer) (*ParquetWriter, error) {
+	type schemaField struct {
+		Tag    string
+		Fields []schemaField `json:",omitempty"`
+	}
+
+	schema := schemaField{Tag: "name=parquet_go_root, repetitiontype=REQUIRED"}
+	columns := make([]parquetColumn, 0, len(fields))
+	for _, field := range fields {
+		fieldCfg, _ := cfg.GetField(field.Name)
+		column := parquetColumn{name: field.Name, kind: parquetColumnKindByType(field.Type, fieldCfg.Format), dateFormat: fieldCfg.Format}
+		columns = append(columns, column)
+		schema.Fields = append(schema.Fields, schemaField{Tag: parquetColumnTag(column)})
+	}
+
+	jsonSchema, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonWriter, err := parquetwriter.NewJSONWriterFromWriter(string(jsonSchema), w, parquetWriterParallelism)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParquetWriter{columns: columns, writer: jsonWriter}, nil
+}
+
+// WriteEvent writes the JSON event as a row, with the value of each field looked up either by its dotted name or by its path in nested objects
+func (pw *ParquetWriter) WriteEvent(event []byte) error {
+	doc, err := decodeJSONEvent(event)
+	if err != nil {
+		return err
+	}
+
+	row := make(map[string]any, len(pw.columns))
+	for _, column := range pw.columns {
+		value, ok := lookupEventValue(doc, column.name)
+		if !ok || value == nil {
+			continue
+		}
+
+		rowValue, err := parquetRowValue(column, value)
+		if err != nil {
+			return fmt.Errorf("%w %q: %v", notValidParquetValue, column.name, value)
+		}
+
+		row[column.name] = rowValue
+	}
+
+	jsonRow, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	return pw.writer.Write(string(jsonRow))
+}
+
+// Close writes the footer of the Parquet file, it doesn't close the underlying writer
+func (pw *ParquetWriter) Close() error {
+	return pw.writer.WriteStop()
+}
+
+func parquetRowValue(column parquetColumn, value any) (any, error) {
+	switch column.kind {
+	case parquetColumnInt32, parquetColumnInt64:
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil, notValidParquetValue
+		}
+
+		return number.Int64()
+	case parquetColumnUint64:
+		// unsigned longs above math.MaxInt64 don't fit in an int64
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil, notValidParquetValue
+		}
+
+		return strconv.ParseUint(number.String(), 10, 64)
+	case parquetColumnFloat, parquetColumnDouble:
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil, notValidParquetValue
+		}
+
+		return number.Float64()
+	case parquetColumnBoolean:
+		if _, ok := value.(bool); !ok {
+			return nil, notValidParquetValue
+		}
+
+		return value, nil
+	case parquetColumnTimestamp:
+		// dates are either RFC3339 strings or epoch numbers, in seconds or milliseconds according to the format
+		switch v := value.(type) {
+		case string:
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, err
+			}
+
+			return t.UnixMilli(), nil
+		case json.Number:
+			epoch, err := v.Int64()
+			if err != nil {
+				return nil, err
+			}
+
+			if column.dateFormat == config.DateFormatEpochSecond {
+				return time.Unix(epoch, 0).UnixMilli(), nil
+			}
+
+			return epoch, nil
+		default:
+			return nil, notValidParquetValue
+		}
+	default:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(encoded), nil
+	}
+}
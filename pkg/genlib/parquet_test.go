@@ -0,0 +1,393 @@
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func Test_ParquetWriter(t *testing.T) {
+	flds := Fields{
+		{Name: "host.name", Type: FieldTypeKeyword},
+		{Name: "event.sequence", Type: FieldTypeLong},
+		{Name: "http.response.status_code", Type: FieldTypeInteger},
+		{Name: "system.cpu.pct", Type: FieldTypeDouble},
+		{Name: "event.ingested", Type: FieldTypeDate},
+		{Name: "labels", Type: FieldTypeObject, ObjectType: FieldTypeKeyword},
+		{Name: "tls.established", Type: FieldTypeBool},
+	}
+
+	template, objectKeysField := generateCustomTemplateFromField(Config{}, flds)
+	flds = append(flds, objectKeysField...)
+	t.Logf("with template: %s", string(template))
+
+	const totEvents = 100
+
+	g, err := NewGeneratorWithCustomTemplateN(template, Config{}, flds, totEvents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "corpus"+ParquetExtension)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pw, err := NewParquetWriter(Config{}, flds, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewGenState()
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		err := g.Emit(state, &buf)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := pw.WriteEvent(buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := local.NewLocalFileReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer pf.Close()
+
+	pr, err := reader.NewParquetColumnReader(pf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer pr.ReadStop()
+
+	if rows := pr.GetNumRows(); rows != totEvents {
+		t.Fatalf("expected %d rows, got %d", totEvents, rows)
+	}
+
+	expectedTypes := map[string]parquet.Type{
+		"host.name":                 parquet.Type_BYTE_ARRAY,
+		"event.sequence":            parquet.Type_INT64,
+		"http.response.status_code": parquet.Type_INT32,
+		"system.cpu.pct":            parquet.Type_DOUBLE,
+		"event.ingested":            parquet.Type_INT64,
+		"tls.established":           parquet.Type_BOOLEAN,
+		"labels":                    parquet.Type_BYTE_ARRAY,
+	}
+
+	columns := make(map[string]*parquet.SchemaElement)
+	for i, element := range pr.SchemaHandler.SchemaElements {
+		columns[pr.SchemaHandler.Infos[i].ExName] = element
+	}
+
+	for name, expectedType := range expectedTypes {
+		column, ok := columns[name]
+		if !ok {
+			t.Fatalf("missing column %s", name)
+		}
+
+		if column.GetType() != expectedType {
+			t.Errorf("expected column %s of type %s, got %s", name, expectedType, column.GetType())
+		}
+	}
+
+	if columns["event.ingested"].GetConvertedType() != parquet.ConvertedType_TIMESTAMP_MILLIS {
+		t.Errorf("expected date column as timestamp, got %s", columns["event.ingested"].GetConvertedType())
+	}
+
+	values, _, _, err := pr.ReadColumnByIndex(0, totEvents)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range values {
+		if s, ok := value.(string); !ok || len(s) == 0 {
+			t.Fatalf("expected host.name values, got %v", value)
+		}
+	}
+}
+
+func Test_ParquetWriterNotValid(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeLong},
+	}
+
+	pw, err := NewParquetWriter(Config{}, flds, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pw.WriteEvent([]byte(`not json`)); !errors.Is(err, notValidJSONEvent) {
+		t.Fatalf("expected event error, got %v", err)
+	}
+
+	if err := pw.WriteEvent([]byte(`{"alpha":"a"}`)); !errors.Is(err, notValidParquetValue) {
+		t.Fatalf("expected value error, got %v", err)
+	}
+}
+
+func Test_ParquetWriterUnsignedLong(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeUnsignedLong},
+	}
+
+	path := filepath.Join(t.TempDir(), "corpus"+ParquetExtension)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pw, err := NewParquetWriter(Config{}, flds, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []uint64{0, math.MaxInt64 + 1, math.MaxUint64}
+	for _, value := range expected {
+		if err := pw.WriteEvent([]byte(fmt.Sprintf(`{"alpha":%d}`, value))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := local.NewLocalFileReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer pf.Close()
+
+	pr, err := reader.NewParquetColumnReader(pf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer pr.ReadStop()
+
+	if convertedType := pr.SchemaHandler.SchemaElements[1].GetConvertedType(); convertedType != parquet.ConvertedType_UINT_64 {
+		t.Errorf("expected unsigned long column as UINT_64, got %s", convertedType)
+	}
+
+	values, _, _, err := pr.ReadColumnByIndex(0, int64(len(expected)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != len(expected) {
+		t.Fatalf("expected %d values, got %d", len(expected), len(values))
+	}
+
+	for i, value := range values {
+		// UINT_64 values are stored with the bits of an INT64
+		v, ok := value.(int64)
+		if !ok || uint64(v) != expected[i] {
+			t.Errorf("expected %d, got %v", expected[i], value)
+		}
+	}
+}
+
+func Test_ParquetWriterDateFormats(t *testing.T) {
+	testCases := []struct {
+		format        string
+		convertedType parquet.ConvertedType
+		expected      func(t *testing.T, value any) any
+	}{
+		{
+			format:        "",
+			convertedType: parquet.ConvertedType_TIMESTAMP_MILLIS,
+			expected:      parquetExpectedTimestamp(time.RFC3339Nano),
+		},
+		{
+			format:        config.DateFormatRFC3339,
+			convertedType: parquet.ConvertedType_TIMESTAMP_MILLIS,
+			expected:      parquetExpectedTimestamp(time.RFC3339),
+		},
+		{
+			format:        config.DateFormatRFC3339Nano,
+			convertedType: parquet.ConvertedType_TIMESTAMP_MILLIS,
+			expected:      parquetExpectedTimestamp(time.RFC3339Nano),
+		},
+		{
+			format:        config.DateFormatEpochMillis,
+			convertedType: parquet.ConvertedType_TIMESTAMP_MILLIS,
+			expected: func(t *testing.T, value any) any {
+				millis, err := value.(json.Number).Int64()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return millis
+			},
+		},
+		{
+			format:        config.DateFormatEpochSecond,
+			convertedType: parquet.ConvertedType_TIMESTAMP_MILLIS,
+			expected: func(t *testing.T, value any) any {
+				seconds, err := value.(json.Number).Int64()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return seconds * 1000
+			},
+		},
+		{
+			format:        "2006-01-02",
+			convertedType: parquet.ConvertedType_UTF8,
+			expected: func(t *testing.T, value any) any {
+				return value
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.format, func(t *testing.T) {
+			flds := Fields{
+				{Name: "alpha", Type: FieldTypeDate},
+			}
+
+			cfg, err := config.LoadConfigFromYaml([]byte(fmt.Sprintf("- name: alpha\n  format: %q", testCase.format)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template, _ := generateCustomTemplateFromField(cfg, flds)
+
+			const totEvents = 10
+
+			g, err := NewGeneratorWithCustomTemplateN(template, cfg, flds, totEvents)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "corpus"+ParquetExtension)
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pw, err := NewParquetWriter(cfg, flds, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := make([]any, 0, totEvents)
+			state := NewGenState()
+			var buf bytes.Buffer
+			for {
+				buf.Reset()
+				err := g.Emit(state, &buf)
+				if err == io.EOF {
+					break
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				decoder := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+				decoder.UseNumber()
+				var event map[string]any
+				if err := decoder.Decode(&event); err != nil {
+					t.Fatal(err)
+				}
+
+				expected = append(expected, testCase.expected(t, event["alpha"]))
+
+				if err := pw.WriteEvent(buf.Bytes()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := pw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			pf, err := local.NewLocalFileReader(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer pf.Close()
+
+			pr, err := reader.NewParquetColumnReader(pf, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer pr.ReadStop()
+
+			if convertedType := pr.SchemaHandler.SchemaElements[1].GetConvertedType(); convertedType != testCase.convertedType {
+				t.Errorf("expected date column as %s, got %s", testCase.convertedType, convertedType)
+			}
+
+			values, _, _, err := pr.ReadColumnByIndex(0, totEvents)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(values) != len(expected) {
+				t.Fatalf("expected %d values, got %d", len(expected), len(values))
+			}
+
+			for i, value := range values {
+				if value != expected[i] {
+					t.Errorf("expected %v, got %v", expected[i], value)
+				}
+			}
+		})
+	}
+}
+
+// parquetExpectedTimestamp returns the epoch milliseconds a date formatted with layout is stored as
+func parquetExpectedTimestamp(layout string) func(t *testing.T, value any) any {
+	return func(t *testing.T, value any) any {
+		ts, err := time.Parse(layout, value.(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return ts.UnixMilli()
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+var notValidPattern = errors.New("pattern is not a valid regular expression")
+var notValidUnboundedPattern = errors.New("pattern must not contain unbounded repetitions, as `*`, `+` or `{n,}`")
+var notValidPatternCharClass = errors.New("pattern character classes must match characters other than the double quote and the backslash")
+
+// patternAnyChars are the ranges of characters generated for `.`: printable ASCII,
+// except the double quote and the backslash that must be escaped in JSON strings
+var patternAnyChars = []rune{' ', '!', '#', '[', ']', '~'}
+
+// patternFunc appends to dst a random string matching the pattern it was compiled from
+type patternFunc func(r *rand.Rand, dst []byte) []byte
+
+// compilePattern compiles a regular expression, in the syntax of the regexp package, to a function generating strings matching it.
+// Assertions as `^` and `$` are ignored, and unbounded repetitions are rejected since the generated strings must have a limited length.
+func compilePattern(pattern string) (patternFunc, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %s", notValidPattern, pattern, err)
+	}
+
+	f, err := compilePatternRegexp(re)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", err, pattern)
+	}
+
+	return f, nil
+}
+
+func compilePatternRegexp(re *syntax.Regexp) (patternFunc, error) {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return func(_ *rand.Rand, dst []byte) []byte { return dst }, nil
+	case syntax.OpLiteral:
+		literal := string(re.Rune)
+		return func(_ *rand.Rand, dst []byte) []byte { return append(dst, literal...) }, nil
+	case syntax.OpCharClass:
+		return compilePatternCharClass(re.Rune)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return compilePatternRanges(patternAnyChars), nil
+	case syntax.OpCapture:
+		return compilePatternRegexp(re.Sub[0])
+	case syntax.OpQuest:
+		return compilePatternRepeat(re.Sub[0], 0, 1)
+	case syntax.OpRepeat:
+		if re.Max < 0 {
+			return nil, notValidUnboundedPattern
+		}
+
+		return compilePatternRepeat(re.Sub[0], re.Min, re.Max)
+	case syntax.OpStar, syntax.OpPlus:
+		return nil, notValidUnboundedPattern
+	case syntax.OpConcat, syntax.OpAlternate:
+		subs := make([]patternFunc, 0, len(re.Sub))
+		for _, sub := range re.Sub {
+			f, err := compilePatternRegexp(sub)
+			if err != nil {
+				return nil, err
+			}
+
+			subs = append(subs, f)
+		}
+
+		if re.Op == syntax.OpAlternate {
+			return func(r *rand.Rand, dst []byte) []byte {
+				return subs[r.Intn(len(subs))](r, dst)
+			}, nil
+		}
+
+		return func(r *rand.Rand, dst []byte) []byte {
+			for _, f := range subs {
+				dst = f(r, dst)
+			}
+
+			return dst
+		}, nil
+	default:
+		return nil, notValidPattern
+	}
+}
+
+func compilePatternRepeat(sub *syntax.Regexp, min, max int) (patternFunc, error) {
+	f, err := compilePatternRegexp(sub)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r *rand.Rand, dst []byte) []byte {
+		n := min + r.Intn(max-min+1)
+		for i := 0; i < n; i++ {
+			dst = f(r, dst)
+		}
+
+		return dst
+	}, nil
+}
+
+// compilePatternCharClass generates a character among the ranges, pairs of lower and upper bounds, preferring the printable ASCII ones.
+// As for `.`, the double quote and the backslash are never generated, since they must be escaped in JSON strings.
+func compilePatternCharClass(ranges []rune) (patternFunc, error) {
+	printable := make([]rune, 0, len(ranges))
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo < ' ' {
+			lo = ' '
+		}
+
+		if hi > '~' {
+			hi = '~'
+		}
+
+		if lo <= hi {
+			printable = append(printable, lo, hi)
+		}
+	}
+
+	if len(printable) == 0 {
+		return compilePatternRanges(ranges), nil
+	}
+
+	printable = excludePatternRune(excludePatternRune(printable, '"'), '\\')
+	if len(printable) == 0 {
+		return nil, notValidPatternCharClass
+	}
+
+	return compilePatternRanges(printable), nil
+}
+
+// excludePatternRune returns the ranges, pairs of lower and upper bounds, without c
+func excludePatternRune(ranges []rune, c rune) []rune {
+	excluded := make([]rune, 0, len(ranges)+2)
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if c < lo || c > hi {
+			excluded = append(excluded, lo, hi)
+			continue
+		}
+
+		if lo < c {
+			excluded = append(excluded, lo, c-1)
+		}
+
+		if c < hi {
+			excluded = append(excluded, c+1, hi)
+		}
+	}
+
+	return excluded
+}
+
+func compilePatternRanges(ranges []rune) patternFunc {
+	var total int
+	for i := 0; i < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+
+	return func(r *rand.Rand, dst []byte) []byte {
+		n := r.Intn(total)
+		for i := 0; i < len(ranges); i += 2 {
+			size := int(ranges[i+1]-ranges[i]) + 1
+			if n < size {
+				return utf8.AppendRune(dst, ranges[i]+rune(n))
+			}
+
+			n -= size
+		}
+
+		return dst
+	}
+}
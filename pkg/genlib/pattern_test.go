@@ -0,0 +1,161 @@
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func Test_CompilePattern(t *testing.T) {
+	patterns := []string{
+		`[A-Z]{3}-[0-9]{6}`,
+		`^[0-9a-f]{32}$`,
+		`(GET|POST|PUT)\s/api/v[12]/[a-z]{1,8}`,
+		`user-\d{2,4}(\.admin)?`,
+		`[^a-z]{5}`,
+		`.{10}`,
+		`(?i)host[_-]\w{4}`,
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			patternFunc, err := compilePattern(pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			re := regexp.MustCompile(`^(?:` + pattern + `)$`)
+			for i := 0; i < 1000; i++ {
+				if value := patternFunc(r, nil); !re.Match(value) {
+					t.Fatalf("expected value matching %s, got %q", pattern, value)
+				}
+			}
+		})
+	}
+}
+
+func Test_CompilePatternCharClassJSONString(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, pattern := range []string{`["\\a]{10}`, `[^a-z]{10}`, `[[:punct:]]{10}`, `\W{10}`} {
+		t.Run(pattern, func(t *testing.T) {
+			patternFunc, err := compilePattern(pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 1000; i++ {
+				// the value is a valid JSON string once quoted, as in a custom template
+				value := patternFunc(r, []byte{'"'})
+				value = append(value, '"')
+				if !json.Valid(value) {
+					t.Fatalf("expected value valid in a JSON string, got %s", value)
+				}
+			}
+		})
+	}
+}
+
+func Test_CompilePatternNotValid(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		err     error
+	}{
+		{pattern: `.*`, err: notValidUnboundedPattern},
+		{pattern: `[a-z]+`, err: notValidUnboundedPattern},
+		{pattern: `id-[0-9]{3,}`, err: notValidUnboundedPattern},
+		{pattern: `(a|b*)c`, err: notValidUnboundedPattern},
+		{pattern: `[a-z`, err: notValidPattern},
+		{pattern: `["\\]{3}`, err: notValidPatternCharClass},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			if _, err := compilePattern(testCase.pattern); !errors.Is(err, testCase.err) {
+				t.Fatalf("expected error %q, got %v", testCase.err, err)
+			}
+		})
+	}
+}
+
+func Test_FieldPatternWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  pattern: '[A-Z]{3}-[0-9]{6}'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	re := regexp.MustCompile(`^[A-Z]{3}-[0-9]{6}$`)
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if value := unmarshalJSONT[string](t, buf.Bytes())["alpha"]; !re.MatchString(value) {
+			t.Fatalf("expected value matching pattern, got %s", value)
+		}
+	}
+}
+
+func Test_FieldPatternWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  pattern: 'sku-[0-9a-f]{8}'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	re := regexp.MustCompile(`^sku-[0-9a-f]{8}$`)
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if value := unmarshalJSONT[string](t, buf.Bytes())["alpha"]; !re.MatchString(value) {
+			t.Fatalf("expected value matching pattern, got %s", value)
+		}
+	}
+}
+
+func Test_FieldUnboundedPatternNotValid(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  pattern: '.*'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); !errors.Is(err, notValidUnboundedPattern) {
+		t.Fatalf("expected unbounded pattern error, got %v", err)
+	}
+
+	if _, err := NewGeneratorWithTextTemplate([]byte(`{{generate "alpha"}}`), cfg, flds, 0); !errors.Is(err, notValidUnboundedPattern) {
+		t.Fatalf("expected unbounded pattern error, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+var notValidProgress = errors.New("progress interval must be greater than 0, with a progress function")
+
+// estimatedEventsGenerator is implemented by the generators knowing the total number of events they emit
+type estimatedEventsGenerator interface {
+	estimatedEvents() uint64
+}
+
+func (gen GeneratorWithCustomTemplate) estimatedEvents() uint64 {
+	return gen.totEvents
+}
+
+func (gen GeneratorWithTextTemplate) estimatedEvents() uint64 {
+	return gen.totEvents
+}
+
+func (gen GeneratorWithWeightedTemplates) estimatedEvents() uint64 {
+	return gen.totEvents
+}
+
+func (gen GeneratorWithBulkFormat) estimatedEvents() uint64 {
+	if estimated, ok := gen.gen.(estimatedEventsGenerator); ok {
+		return estimated.estimatedEvents()
+	}
+
+	return 0
+}
+
+// progressCounters are the events and bytes emitted by a GeneratorWithProgress, across all the states
+type progressCounters struct {
+	events uint64
+	bytes  uint64
+}
+
+// GeneratorWithProgress wraps a Generator calling a function with the progress of the generation every interval events.
+// Generators without progress to report are just not wrapped, so that reporting costs nothing when not configured.
+type GeneratorWithProgress struct {
+	gen             Generator
+	interval        uint64
+	progressFunc    func(progress Progress)
+	estimatedEvents uint64
+	counters        *progressCounters
+	outputs         *outputs
+}
+
+// NewGeneratorWithProgress returns a GeneratorWithProgress wrapping gen, calling progressFunc every interval events.
+// The events and bytes of the progress are the ones emitted by the generator with any state.
+func NewGeneratorWithProgress(gen Generator, interval uint64, progressFunc func(progress Progress)) (*GeneratorWithProgress, error) {
+	if interval == 0 || progressFunc == nil {
+		return nil, notValidProgress
+	}
+
+	progressGen := &GeneratorWithProgress{
+		gen:          gen,
+		interval:     interval,
+		progressFunc: progressFunc,
+		counters:     &progressCounters{},
+		outputs:      newOutputs(),
+	}
+
+	if estimated, ok := gen.(estimatedEventsGenerator); ok {
+		progressGen.estimatedEvents = estimated.estimatedEvents()
+	}
+
+	return progressGen, nil
+}
+
+// Close closes the wrapped generator and finalizes the writers the events were streamed to with EmitTo, returning the first error
+func (gen GeneratorWithProgress) Close() error {
+	err := gen.gen.Close()
+	if outputsErr := gen.outputs.close(); err == nil {
+		err = outputsErr
+	}
+
+	return err
+}
+
+// Emit generates an event in buf through the wrapped generator, reporting the progress every interval events
+func (gen GeneratorWithProgress) Emit(state *GenState, buf *bytes.Buffer) error {
+	before := buf.Len()
+	if err := gen.gen.Emit(state, buf); err != nil {
+		return err
+	}
+
+	gen.add(uint64(buf.Len() - before))
+
+	return nil
+}
+
+// EmitTo generates an event streaming it to w, with the same semantic of Emit
+func (gen GeneratorWithProgress) EmitTo(state *GenState, w io.Writer) error {
+	// The event is generated first so that its size is known
+	eventBuf := GetBuffer()
+	defer PutBuffer(eventBuf)
+
+	if err := gen.gen.EmitTo(state, eventBuf); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(eventBuf.Bytes()); err != nil {
+		return err
+	}
+
+	gen.outputs.add(w)
+	gen.add(uint64(eventBuf.Len()))
+
+	return nil
+}
+
+// add counts an event of size bytes, reporting the progress when a multiple of the interval
+func (gen GeneratorWithProgress) add(size uint64) {
+	totBytes := atomic.AddUint64(&gen.counters.bytes, size)
+	events := atomic.AddUint64(&gen.counters.events, 1)
+	if events%gen.interval != 0 {
+		return
+	}
+
+	gen.progressFunc(Progress{Events: events, Bytes: totBytes, EstimatedEvents: gen.estimatedEvents})
+}
+
+// withProgress wraps gen in a GeneratorWithProgress when the progress is configured, returning it as it is otherwise
+func withProgress(cfg Config, gen Generator) (Generator, error) {
+	if cfg.ProgressInterval == 0 {
+		return gen, nil
+	}
+
+	return NewGeneratorWithProgress(gen, cfg.ProgressInterval, cfg.ProgressFunc)
+}
@@ -0,0 +1,130 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func Test_ProgressWithNewGenerator(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	templates := map[GeneratorKind][]byte{
+		GeneratorKindCustomTemplate: []byte(`{"alpha":"{{.alpha}}","beta":{{.beta}}}`),
+		GeneratorKindTextTemplate:   []byte(`{"alpha":"{{generate "alpha"}}","beta":{{generate "beta"}}}`),
+	}
+
+	for kind, template := range templates {
+		var reports []Progress
+		cfg := Config{
+			ProgressInterval: 7,
+			ProgressFunc: func(progress Progress) {
+				reports = append(reports, progress)
+			},
+		}
+
+		g, err := NewGenerator(kind, template, cfg, flds, 10000)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		state := NewGenState()
+
+		var events, size uint64
+		var buf bytes.Buffer
+		for {
+			buf.Reset()
+			err := g.Emit(state, &buf)
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			events++
+			size += uint64(buf.Len())
+
+			// the progress is reported right after every interval events
+			if events%cfg.ProgressInterval == 0 {
+				last := reports[len(reports)-1]
+				if last.Events != events || last.Bytes != size {
+					t.Fatalf("expected progress of %d events and %d bytes, got %+v", events, size, last)
+				}
+			}
+		}
+
+		if expected := int(events / cfg.ProgressInterval); len(reports) != expected {
+			t.Fatalf("expected %d progress reports for %d events, got %d", expected, events, len(reports))
+		}
+
+		for _, progress := range reports {
+			if progress.EstimatedEvents != events {
+				t.Fatalf("expected %d estimated events, got %d", events, progress.EstimatedEvents)
+			}
+		}
+	}
+}
+
+func Test_ProgressWithEmitTo(t *testing.T) {
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	gen, err := NewGeneratorWithCustomTemplateN(template, Config{}, Fields{{Name: "alpha", Type: FieldTypeKeyword}}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reports []Progress
+	g, err := NewGeneratorWithProgress(gen, 10, func(progress Progress) {
+		reports = append(reports, progress)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var w bytes.Buffer
+	state := NewGenState()
+	for {
+		err := g.EmitTo(state, &w)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(reports) != 10 {
+		t.Fatalf("expected 10 progress reports, got %d", len(reports))
+	}
+
+	if last := reports[len(reports)-1]; last.Events != 100 || last.Bytes != uint64(w.Len()) || last.EstimatedEvents != 100 {
+		t.Fatalf("expected progress of 100 events and %d bytes, got %+v", w.Len(), last)
+	}
+}
+
+func Test_ProgressNotValid(t *testing.T) {
+	gen, err := NewGeneratorWithCustomTemplateN([]byte(`{}`), Config{}, Fields{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGeneratorWithProgress(gen, 0, func(progress Progress) {}); !errors.Is(err, notValidProgress) {
+		t.Fatalf("expected error on zero progress interval, got %v", err)
+	}
+
+	if _, err := NewGenerator(GeneratorKindCustomTemplate, []byte(`{}`), Config{ProgressInterval: 10}, Fields{}, 0); !errors.Is(err, notValidProgress) {
+		t.Fatalf("expected error on progress interval without progress function, got %v", err)
+	}
+}
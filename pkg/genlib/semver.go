@@ -0,0 +1,71 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math/rand"
+	"strconv"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// defaultSemverMajor, defaultSemverMinor and defaultSemverPatch are the bounds of the generated semantic versions when not configured
+var (
+	defaultSemverMajor = config.Length{Min: 0, Max: 9}
+	defaultSemverMinor = config.Length{Min: 0, Max: 20}
+	defaultSemverPatch = config.Length{Min: 0, Max: 30}
+)
+
+// semverPrereleases are the identifiers of the generated prerelease versions, each followed by a number
+var semverPrereleases = []string{"alpha", "beta", "rc", "SNAPSHOT"}
+
+const semverHexDigits = "0123456789abcdef"
+
+// makeSemverFunc returns a function generating semantic versions as `MAJOR.MINOR.PATCH`, within the configured bounds,
+// with a prerelease (e.g. `-rc.2`) and a build metadata (e.g. `+3f2a9c1`) according to their configured probability
+func makeSemverFunc(semver config.Semver) func(r *rand.Rand) string {
+	major, minor, patch := defaultSemverMajor, defaultSemverMinor, defaultSemverPatch
+	if semver.Major != nil {
+		major = *semver.Major
+	}
+
+	if semver.Minor != nil {
+		minor = *semver.Minor
+	}
+
+	if semver.Patch != nil {
+		patch = *semver.Patch
+	}
+
+	majorFunc := makeLengthFunc(major)
+	minorFunc := makeLengthFunc(minor)
+	patchFunc := makeLengthFunc(patch)
+
+	return func(r *rand.Rand) string {
+		v := make([]byte, 0, 32)
+		v = strconv.AppendInt(v, int64(majorFunc(r)), 10)
+		v = append(v, '.')
+		v = strconv.AppendInt(v, int64(minorFunc(r)), 10)
+		v = append(v, '.')
+		v = strconv.AppendInt(v, int64(patchFunc(r)), 10)
+
+		if semver.PrereleaseProbability > 0 && r.Float64() < semver.PrereleaseProbability {
+			v = append(v, '-')
+			v = append(v, semverPrereleases[r.Intn(len(semverPrereleases))]...)
+			v = append(v, '.')
+			v = strconv.AppendInt(v, int64(1+r.Intn(9)), 10)
+		}
+
+		if semver.BuildProbability > 0 && r.Float64() < semver.BuildProbability {
+			// an abbreviated commit hash
+			v = append(v, '+')
+			for i := 0; i < 7; i++ {
+				v = append(v, semverHexDigits[r.Intn(len(semverHexDigits))])
+			}
+		}
+
+		return string(v)
+	}
+}
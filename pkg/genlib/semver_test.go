@@ -0,0 +1,128 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// semverRegex is the regular expression suggested by the semantic versioning specification
+var semverRegex = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// assertSemver checks that value is a valid semantic version within the given bounds,
+// and returns its prerelease and build metadata
+func assertSemver(t *testing.T, value string, major, minor, patch config.Length) (string, string) {
+	t.Helper()
+
+	matches := semverRegex.FindStringSubmatch(value)
+	if matches == nil {
+		t.Fatalf("expected valid semantic version, got %s", value)
+	}
+
+	for i, bounds := range []config.Length{major, minor, patch} {
+		n, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n < bounds.Min || n > bounds.Max {
+			t.Fatalf("expected version number between %d and %d, got %s", bounds.Min, bounds.Max, value)
+		}
+	}
+
+	return matches[4], matches[5]
+}
+
+func Test_FieldSemverWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "package.version", Type: FieldTypeVersion},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(`- name: package.version
+  semver:
+    major:
+      min: 1
+      max: 2
+    minor:
+      min: 0
+      max: 0
+    patch:
+      min: 5
+      max: 10
+    prerelease_probability: 0.5
+    build_probability: 0.5
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"package.version":"{{.package.version}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var prereleases, builds int
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		value := unmarshalJSONT[string](t, buf.Bytes())["package.version"]
+		prerelease, build := assertSemver(t, value, config.Length{Min: 1, Max: 2}, config.Length{Min: 0, Max: 0}, config.Length{Min: 5, Max: 10})
+		if len(prerelease) > 0 {
+			prereleases++
+		}
+
+		if len(build) > 0 {
+			builds++
+		}
+	}
+
+	if prereleases < 400 || prereleases > 600 {
+		t.Fatalf("expected about half of the versions with a prerelease, got %d", prereleases)
+	}
+
+	if builds < 400 || builds > 600 {
+		t.Fatalf("expected about half of the versions with a build metadata, got %d", builds)
+	}
+}
+
+func Test_FieldSemverWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "agent.version", Type: FieldTypeSemver},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: agent.version\n  semver:\n    major:\n      min: 8\n      max: 8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"agent.version":"{{generate "agent.version"}}","package.version":"{{randomSemver}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if prerelease, build := assertSemver(t, m["agent.version"], config.Length{Min: 8, Max: 8}, defaultSemverMinor, defaultSemverPatch); len(prerelease) > 0 || len(build) > 0 {
+			t.Fatalf("expected version without prerelease and build metadata, got %s", m["agent.version"])
+		}
+
+		assertSemver(t, m["package.version"], defaultSemverMajor, defaultSemverMinor, defaultSemverPatch)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+var notValidStackTraceLanguage = errors.New("randomStackTrace language must be one of 'java', 'go' or 'python'")
+var notValidStackTraceDepth = errors.New("randomStackTrace depth must be greater than 0")
+
+// stackTraceModules, stackTraceTypes and stackTraceFunctions are the names the frames of the stack traces are made of,
+// while stackTraceErrors are the exceptions, panics and errors for each language with their messages
+// NOTE: these lists are not comprehensive
+var (
+	stackTraceModules   = []string{"order", "payment", "user", "inventory", "auth", "cart", "search", "report", "billing", "notification"}
+	stackTraceTypes     = []string{"Service", "Controller", "Repository", "Handler", "Client", "Processor", "Validator", "Mapper"}
+	stackTraceFunctions = []string{"process", "handle", "execute", "validate", "load", "save", "fetch", "parse", "apply", "dispatch"}
+	stackTraceErrors    = map[string][]string{
+		"java": {
+			"java.lang.NullPointerException: Cannot invoke method on null object",
+			"java.lang.IllegalStateException: Connection pool exhausted",
+			"java.lang.IllegalArgumentException: Invalid identifier",
+			"java.io.IOException: Connection reset by peer",
+			"java.util.concurrent.TimeoutException: Request timed out after 30000 ms",
+		},
+		"go": {
+			"panic: runtime error: invalid memory address or nil pointer dereference",
+			"panic: runtime error: index out of range [3] with length 3",
+			"panic: assignment to entry in nil map",
+			"panic: context deadline exceeded",
+		},
+		"python": {
+			"KeyError: 'id'",
+			"ValueError: invalid literal for int() with base 10: 'abc'",
+			"AttributeError: 'NoneType' object has no attribute 'get'",
+			"TimeoutError: timed out",
+			"ConnectionError: Connection refused",
+		},
+	}
+)
+
+// randomStackTrace returns a multi-line stack trace in the style of the language, with an exception, a panic or an error
+// header and depth frames, the innermost first as printed by the language, except for Python printing it last
+func randomStackTrace(r *rand.Rand, lang string, depth int) (string, error) {
+	errorHeaders, ok := stackTraceErrors[lang]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", notValidStackTraceLanguage, lang)
+	}
+
+	if depth <= 0 {
+		return "", fmt.Errorf("%w: %d", notValidStackTraceDepth, depth)
+	}
+
+	pick := func(values []string) string {
+		return values[r.Intn(len(values))]
+	}
+
+	line := func() string {
+		return strconv.Itoa(1 + r.Intn(500))
+	}
+
+	errorHeader := pick(errorHeaders)
+
+	var sb strings.Builder
+	switch lang {
+	case "java":
+		sb.WriteString(errorHeader)
+		for i := 0; i < depth; i++ {
+			module, typ := pick(stackTraceModules), pick(stackTraceModules)
+			className := strings.ToUpper(typ[:1]) + typ[1:] + pick(stackTraceTypes)
+			fmt.Fprintf(&sb, "\n\tat com.example.%s.%s.%s(%s.java:%s)", module, className, pick(stackTraceFunctions), className, line())
+		}
+	case "go":
+		sb.WriteString(errorHeader)
+		sb.WriteString("\n\ngoroutine ")
+		sb.WriteString(strconv.Itoa(1 + r.Intn(1000)))
+		sb.WriteString(" [running]:")
+		for i := 0; i < depth; i++ {
+			module := pick(stackTraceModules)
+			fmt.Fprintf(&sb, "\ngithub.com/example/app/%s.%s(...)\n\t/app/%s/%s.go:%s +0x%x", module, pick(stackTraceFunctions), module, module, line(), 16+r.Intn(4080))
+		}
+	case "python":
+		sb.WriteString("Traceback (most recent call last):")
+		for i := 0; i < depth; i++ {
+			module := pick(stackTraceModules)
+			fmt.Fprintf(&sb, "\n  File \"/app/%s/%s.py\", line %s, in %s\n    %s = %s(request)", module, pick(stackTraceModules), line(), pick(stackTraceFunctions), module, pick(stackTraceFunctions))
+		}
+		sb.WriteByte('\n')
+		sb.WriteString(errorHeader)
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,69 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_RandomStackTraceWithTextTemplate(t *testing.T) {
+	template := []byte(`{"java":{{randomStackTrace "java" 5 | toJson}},"go":{{randomStackTrace "go" 3 | toJson}},"python":{{randomStackTrace "python" 1 | toJson}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, template, 0)
+
+	testCases := map[string]struct {
+		header *regexp.Regexp
+		frame  *regexp.Regexp
+		depth  int
+	}{
+		"java":   {header: regexp.MustCompile(`^java\.[a-z.]+\.[A-Za-z]+Exception: `), frame: regexp.MustCompile(`(?m)^\tat com\.example\.[a-z]+\.[A-Za-z]+\.[a-z]+\([A-Za-z]+\.java:\d+\)$`), depth: 5},
+		"go":     {header: regexp.MustCompile(`^panic: .+\n\ngoroutine \d+ \[running\]:\n`), frame: regexp.MustCompile(`(?m)^github\.com/example/app/[a-z]+\.[a-z]+\(\.\.\.\)\n\t/app/[a-z]+/[a-z]+\.go:\d+ \+0x[0-9a-f]+$`), depth: 3},
+		"python": {header: regexp.MustCompile(`^Traceback \(most recent call last\):\n`), frame: regexp.MustCompile(`(?m)^  File "/app/[a-z]+/[a-z]+\.py", line \d+, in [a-z]+\n    .+$`), depth: 1},
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		for lang, testCase := range testCases {
+			if !testCase.header.MatchString(m[lang]) {
+				t.Fatalf("expected %s stack trace header, got %s", lang, m[lang])
+			}
+
+			if frames := len(testCase.frame.FindAllString(m[lang], -1)); frames != testCase.depth {
+				t.Fatalf("expected %d %s frames, got %d in %s", testCase.depth, lang, frames, m[lang])
+			}
+		}
+
+		if lines := strings.Split(m["python"], "\n"); !strings.Contains(lines[len(lines)-1], "Error: ") {
+			t.Fatalf("expected python error last, got %s", m["python"])
+		}
+	}
+}
+
+func Test_RandomStackTraceNotValidWithTextTemplate(t *testing.T) {
+	for template, expected := range map[string]error{
+		`{{randomStackTrace "cobol" 3}}`: notValidStackTraceLanguage,
+		`{{randomStackTrace "java" 0}}`:  notValidStackTraceDepth,
+	} {
+		t.Logf("with template: %s", template)
+
+		g, state := makeGeneratorWithTextTemplate(t, Config{}, Fields{}, []byte(template), 0)
+
+		var buf bytes.Buffer
+		if err := g.Emit(state, &buf); !errors.Is(err, expected) {
+			t.Fatalf("expected error %v, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var notValidTemplateInclude = errors.New("template includes must not form a cycle")
+
+// includeDirective is an @include directive on its own line of a template, with the whitespace around it and the path of the included file,
+// that must be quoted when containing whitespace
+var includeDirective = regexp.MustCompile(`(?m)^([ \t]*)@include[ \t]+("[^"\n]+"|[^\s"]+)([ \t\r]*)$`)
+
+// includeMarker is what a template must contain for having any include, checked before the regular expression
+var includeMarker = []byte("@include")
+
+// ExpandTemplateIncludes replaces the @include directives of the template, each on its own line, with the content of the included files,
+// their trailing new line stripped, keeping the whitespace around the directives.
+// The paths are relative to dir, the working directory when empty, and the ones of the included files relative to the file including them,
+// that can include other files in turn as long as they do not form a cycle.
+// The generators expand the includes of their templates relative to Config.TemplateDir on their own.
+func ExpandTemplateIncludes(template []byte, dir string) ([]byte, error) {
+	if !bytes.Contains(template, includeMarker) {
+		return template, nil
+	}
+
+	return expandIncludes(template, dir, nil)
+}
+
+func expandIncludes(template []byte, dir string, including []string) ([]byte, error) {
+	if !bytes.Contains(template, includeMarker) {
+		return template, nil
+	}
+
+	var expanded bytes.Buffer
+	var last int
+	for _, loc := range includeDirective.FindAllSubmatchIndex(template, -1) {
+		expanded.Write(template[last:loc[3]])
+		last = loc[6]
+
+		path := strings.Trim(string(template[loc[4]:loc[5]]), `"`)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, includingPath := range including {
+			if includingPath == absPath {
+				return nil, fmt.Errorf("%w: %s", notValidTemplateInclude, strings.Join(append(including, absPath), " -> "))
+			}
+		}
+
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err = expandIncludes(bytes.TrimSuffix(bytes.TrimSuffix(content, []byte("\n")), []byte("\r")), filepath.Dir(absPath), append(including, absPath))
+		if err != nil {
+			return nil, err
+		}
+
+		expanded.Write(content)
+	}
+
+	expanded.Write(template[last:])
+
+	return expanded.Bytes(), nil
+}
@@ -0,0 +1,110 @@
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func writeTemplateFile(t *testing.T, dir, name, content string) {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ExpandTemplateIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "snippets/host.tpl", `"host":{"name":"{{.host.name}}"},`+"\n"+`@include "ecs.tpl"`+"\n")
+	writeTemplateFile(t, dir, "snippets/ecs.tpl", `"ecs":{"version":"8.11.0"}`+"\n")
+
+	expanded, err := ExpandTemplateIncludes([]byte("{\"message\":\"{{.message}}\",\n\t@include snippets/host.tpl \n}"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\"message\":\"{{.message}}\",\n\t\"host\":{\"name\":\"{{.host.name}}\"},\n\"ecs\":{\"version\":\"8.11.0\"} \n}"
+	if string(expanded) != expected {
+		t.Fatalf("expected %s, got %s", expected, expanded)
+	}
+
+	template := []byte(`{"message":"{{.message}}"}`)
+	if expanded, err := ExpandTemplateIncludes(template, dir); err != nil || &expanded[0] != &template[0] {
+		t.Fatalf("expected template without includes as it is, got %s, %v", expanded, err)
+	}
+}
+
+func Test_ExpandTemplateIncludesNotOnOwnLine(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "snippet.tpl", `"alpha":"{{.alpha}}"`)
+
+	template := []byte(`{"message":"use @include snippet.tpl for sharing blocks", "help": "@include snippet.tpl"}` + "\n" + `x @include snippet.tpl`)
+	expanded, err := ExpandTemplateIncludes(template, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(expanded) != string(template) {
+		t.Fatalf("expected directives not on their own line left alone, got %s", expanded)
+	}
+}
+
+func Test_ExpandTemplateIncludesNotValid(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "a.tpl", "@include b.tpl")
+	writeTemplateFile(t, dir, "b.tpl", "@include a.tpl")
+
+	if _, err := ExpandTemplateIncludes([]byte("@include a.tpl"), dir); !errors.Is(err, notValidTemplateInclude) {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+
+	if _, err := ExpandTemplateIncludes([]byte("@include missing.tpl"), dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+}
+
+func Test_TemplateIncludesWithGenerators(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "custom.tpl", `"alpha":"{{.alpha}}"`)
+	writeTemplateFile(t, dir, "text.tpl", `"alpha":"{{generate "alpha"}}"`)
+
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte("- name: alpha\n  enum: [shared]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.TemplateDir = dir
+
+	customGen, err := NewGeneratorWithCustomTemplate([]byte("{\n@include \"custom.tpl\"\n}"), cfg, flds, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	textGen, err := NewGeneratorWithTextTemplate([]byte("{\n@include \"text.tpl\"\n}"), cfg, flds, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, g := range []Generator{customGen, textGen} {
+		var buf bytes.Buffer
+		if err := g.Emit(nil, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != "{\n\"alpha\":\"shared\"\n}" {
+			t.Fatalf("expected the included snippet expanded, got %s", buf.String())
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// TimestampFieldName is the field the timestamp of the events is emitted for
+const TimestampFieldName = "@timestamp"
+
+var notValidTimestamp = errors.New("timestamp start must be before end, and rate greater than or equal to 0")
+var notValidTimezone = errors.New("timezone must be a location name of the IANA Time Zone database")
+var notValidBusinessHours = errors.New("business hours must be within 0 and 24 with start before end, off hours weight between 0 and 1, without a timestamp rate")
+
+// maxBusinessHoursTries is the number of random timestamps drawn, at most, for choosing one according to the business hours weighting
+const maxBusinessHoursTries = 1000
+
+// loadTimezone returns the location dates are formatted in, the local one when timezone is not set
+func loadTimezone(timezone string) (*time.Location, error) {
+	if len(timezone) == 0 {
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", notValidTimezone, timezone)
+	}
+
+	return location, nil
+}
+
+type timestampValue struct {
+	event uint64
+	set   bool
+	value time.Time
+}
+
+// makeTimestampFunc returns a function generating the timestamp of the current event of the state, the same for all its calls within the event
+func makeTimestampFunc(timestamp config.Timestamp, now time.Time) (func(state *GenState) time.Time, error) {
+	end := timestamp.End
+	if end.IsZero() {
+		end = now
+	}
+
+	start := timestamp.Start
+	if start.IsZero() {
+		start = end.Add(-FieldTypeTimeRange * time.Second)
+	}
+
+	if !start.Before(end) || timestamp.Rate < 0 {
+		return nil, notValidTimestamp
+	}
+
+	location, err := loadTimezone(timestamp.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	var timestampFunc func(state *GenState) time.Time
+	if timestamp.Rate > 0 {
+		interval := float64(time.Second) / timestamp.Rate
+		// the timestamps wrap around to start once past end, so that they stay within the time window however many the events
+		perWindow := uint64(float64(end.Sub(start))/interval) + 1
+		timestampFunc = func(state *GenState) time.Time {
+			return start.Add(time.Duration(float64(state.counter%perWindow) * interval))
+		}
+	} else {
+		window := end.Sub(start)
+		timestampFunc = func(state *GenState) time.Time {
+			return start.Add(time.Duration(state.rand.Int63n(int64(window) + 1)))
+		}
+	}
+
+	if timestamp.BusinessHours != nil {
+		if timestamp.Rate > 0 {
+			return nil, notValidBusinessHours
+		}
+
+		businessHours := *timestamp.BusinessHours
+		if businessHours.Location == nil && len(timestamp.Timezone) > 0 {
+			businessHours.Location = location
+		}
+
+		weightFunc, err := makeBusinessHoursWeightFunc(businessHours)
+		if err != nil {
+			return nil, err
+		}
+
+		uniformFunc := timestampFunc
+		timestampFunc = func(state *GenState) time.Time {
+			// rejection sampling: a random timestamp is kept with a probability equal to its weight
+			var t time.Time
+			for i := 0; i < maxBusinessHoursTries; i++ {
+				t = uniformFunc(state)
+				if state.rand.Float64() < weightFunc(t) {
+					break
+				}
+			}
+
+			return t
+		}
+	}
+
+	return func(state *GenState) time.Time {
+		if state.timestamp.set && state.timestamp.event == state.counter {
+			return state.timestamp.value
+		}
+
+		state.timestamp = timestampValue{event: state.counter, set: true, value: timestampFunc(state).In(location)}
+
+		return state.timestamp.value
+	}, nil
+}
+
+// makeBusinessHoursWeightFunc returns a function weighting a timestamp 1 within the business hours and the off hours weight otherwise
+func makeBusinessHoursWeightFunc(businessHours config.BusinessHours) (func(t time.Time) float64, error) {
+	location := businessHours.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	startHour, endHour := businessHours.StartHour, businessHours.EndHour
+	if startHour == 0 && endHour == 0 {
+		startHour, endHour = 9, 17
+	}
+
+	weekdays := businessHours.Weekdays
+	if len(weekdays) == 0 {
+		weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	}
+
+	offHoursWeight := businessHours.OffHoursWeight
+	if offHoursWeight == 0 {
+		offHoursWeight = 0.1
+	}
+
+	if startHour < 0 || endHour > 24 || startHour >= endHour || offHoursWeight < 0 || offHoursWeight > 1 {
+		return nil, notValidBusinessHours
+	}
+
+	var workingDays [7]bool
+	for _, weekday := range weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return nil, notValidBusinessHours
+		}
+
+		workingDays[weekday] = true
+	}
+
+	return func(t time.Time) float64 {
+		t = t.In(location)
+		if workingDays[t.Weekday()] && t.Hour() >= startHour && t.Hour() < endHour {
+			return 1
+		}
+
+		return offHoursWeight
+	}, nil
+}
+
+// bindTimestamp binds the @timestamp field to the timestamp of the events, when configured, overriding any field definition
+func bindTimestamp(cfg Config, fieldMap map[string]any, withReturn bool) error {
+	if cfg.Timestamp == nil {
+		return nil
+	}
+
+	timestampFunc, err := makeTimestampFunc(*cfg.Timestamp, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if withReturn {
+		var emitF EmitF
+		emitF = func(state *GenState) any {
+			return timestampFunc(state)
+		}
+
+		fieldMap[TimestampFieldName] = emitF
+
+		return nil
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *GenState, buf writer) error {
+		v := make([]byte, 0, 32)
+		_, err := buf.Write(timestampFunc(state).AppendFormat(v, FieldTypeTimeLayout))
+		return err
+	}
+
+	fieldMap[TimestampFieldName] = emitFNotReturn
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func Test_TimestampFixedRateWithCustomTemplate(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := Config{Timestamp: &config.Timestamp{Start: start, End: start.Add(time.Hour), Rate: 10}}
+
+	template := []byte(`{"@timestamp":"{{.@timestamp}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		timestamp, err := time.Parse(FieldTypeTimeLayout, unmarshalJSONT[string](t, buf.Bytes())["@timestamp"])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := start.Add(time.Duration(i) * 100 * time.Millisecond); !timestamp.Equal(expected) {
+			t.Fatalf("expected timestamp %s, got %s", expected, timestamp)
+		}
+	}
+}
+
+func Test_TimestampFixedRatePastEndWithCustomTemplate(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Second)
+	cfg := Config{Timestamp: &config.Timestamp{Start: start, End: end, Rate: 10}}
+
+	template := []byte(`{"@timestamp":"{{.@timestamp}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, Fields{}, template, 0)
+
+	// the 11 timestamps from start to end, both included, over and over
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		timestamp, err := time.Parse(FieldTypeTimeLayout, unmarshalJSONT[string](t, buf.Bytes())["@timestamp"])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if timestamp.After(end) {
+			t.Fatalf("expected timestamp not after %s, got %s", end, timestamp)
+		}
+
+		if expected := start.Add(time.Duration(i%11) * 100 * time.Millisecond); !timestamp.Equal(expected) {
+			t.Fatalf("expected timestamp %s, got %s", expected, timestamp)
+		}
+	}
+}
+
+func Test_TimestampWindowWithTextTemplate(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	cfg := Config{Timestamp: &config.Timestamp{Start: start, End: end}}
+
+	template := []byte(`{"@timestamp":"{{timestamp | date "2006-01-02T15:04:05.999999999Z07:00"}}","same":{{eq timestamp (generate "@timestamp")}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, Fields{}, template, 0)
+
+	var buf bytes.Buffer
+	timestamps := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+		if m["same"] != true {
+			t.Fatalf("expected the same timestamp within the event, got %s", buf.String())
+		}
+
+		timestamp, err := time.Parse(time.RFC3339Nano, m["@timestamp"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if timestamp.Before(start) || timestamp.After(end) {
+			t.Fatalf("expected timestamp within %s and %s, got %s", start, end, timestamp)
+		}
+
+		timestamps[m["@timestamp"].(string)] = struct{}{}
+	}
+
+	if len(timestamps) < 2 {
+		t.Errorf("expected different timestamps across events, got %d", len(timestamps))
+	}
+}
+
+func Test_TimestampNotValid(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, timestamp := range []config.Timestamp{
+		{Start: start, End: start.Add(-time.Hour)},
+		{Start: start, End: start.Add(time.Hour), Rate: -1},
+	} {
+		cfg := Config{Timestamp: &timestamp}
+		if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.@timestamp}}`), cfg, Fields{}, 0); !errors.Is(err, notValidTimestamp) {
+			t.Fatalf("expected timestamp error, got %v", err)
+		}
+	}
+}
+
+func Test_TimestampTimezoneWithCustomTemplate(t *testing.T) {
+	// Europe/Rome switches from CET (+01:00) to CEST (+02:00) at 01:00 UTC of the 26th of March 2023
+	start := time.Date(2023, 3, 26, 0, 0, 0, 0, time.UTC)
+	cfg := Config{Timestamp: &config.Timestamp{Start: start, End: start.Add(3 * time.Hour), Rate: 1.0 / 1800, Timezone: "Europe/Rome"}}
+
+	template := []byte(`{"@timestamp":"{{.@timestamp}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, Fields{}, template, 0)
+
+	expected := []string{
+		"2023-03-26T01:00:00+01:00",
+		"2023-03-26T01:30:00+01:00",
+		"2023-03-26T03:00:00+02:00",
+		"2023-03-26T03:30:00+02:00",
+	}
+
+	var buf bytes.Buffer
+	for _, e := range expected {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if timestamp := unmarshalJSONT[string](t, buf.Bytes())["@timestamp"]; timestamp != e {
+			t.Fatalf("expected timestamp %s, got %s", e, timestamp)
+		}
+	}
+}
+
+func Test_TimestampTimezoneNotValid(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := Config{Timestamp: &config.Timestamp{Start: start, End: start.Add(time.Hour), Timezone: "Mars/Olympus_Mons"}}
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.@timestamp}}`), cfg, Fields{}, 0); !errors.Is(err, notValidTimezone) {
+		t.Fatalf("expected timezone error, got %v", err)
+	}
+}
+
+func Test_TimestampBusinessHoursWithCustomTemplate(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skip("timezone database not available:", err)
+	}
+
+	// four weeks, from Monday to Sunday
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, location)
+	end := start.Add(4 * 7 * 24 * time.Hour)
+	cfg := Config{Timestamp: &config.Timestamp{
+		Start: start,
+		End:   end,
+		BusinessHours: &config.BusinessHours{
+			Location:       location,
+			StartHour:      9,
+			EndHour:        18,
+			OffHoursWeight: 0.2,
+		},
+	}}
+
+	template := []byte(`{"@timestamp":"{{.@timestamp}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, Fields{}, template, 0)
+
+	var hours [24]int
+	var weekendBusinessHours, weekdayBusinessHours int
+	var buf bytes.Buffer
+	for i := 0; i < 100000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		timestamp, err := time.Parse(FieldTypeTimeLayout, unmarshalJSONT[string](t, buf.Bytes())["@timestamp"])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if timestamp.Before(start) || timestamp.After(end) {
+			t.Fatalf("expected timestamp within %s and %s, got %s", start, end, timestamp)
+		}
+
+		timestamp = timestamp.In(location)
+		hours[timestamp.Hour()] += 1
+
+		if timestamp.Hour() >= 9 && timestamp.Hour() < 18 {
+			if timestamp.Weekday() == time.Saturday || timestamp.Weekday() == time.Sunday {
+				weekendBusinessHours += 1
+			} else {
+				weekdayBusinessHours += 1
+			}
+		}
+	}
+
+	var businessHours, overnight int
+	for hour := 9; hour < 18; hour++ {
+		businessHours += hours[hour]
+	}
+
+	for hour := 0; hour < 6; hour++ {
+		overnight += hours[hour]
+	}
+
+	// per hour, business hours bins get (5 + 2*0.2) / 7 of the weekday rate, overnight bins 0.2
+	businessHoursRate, overnightRate := float64(businessHours)/9, float64(overnight)/6
+	if businessHoursRate < 3*overnightRate {
+		t.Errorf("expected business hours bins significantly above overnight bins, got %.0f and %.0f per hour", businessHoursRate, overnightRate)
+	}
+
+	if overnight == 0 || weekendBusinessHours == 0 {
+		t.Errorf("expected events overnight and on weekends, got %d and %d", overnight, weekendBusinessHours)
+	}
+
+	// per day, weekend business hours get 0.2 of the weekday ones
+	if ratio := (float64(weekendBusinessHours) / 2) / (float64(weekdayBusinessHours) / 5); math.Abs(ratio-0.2) > 0.05 {
+		t.Errorf("expected weekend to weekday business hours ratio 0.2, got %.2f", ratio)
+	}
+}
+
+func Test_TimestampBusinessHoursNotValid(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, businessHours := range []config.BusinessHours{
+		{StartHour: 18, EndHour: 9},
+		{StartHour: 9, EndHour: 25},
+		{OffHoursWeight: 2},
+		{Weekdays: []time.Weekday{7}},
+	} {
+		cfg := Config{Timestamp: &config.Timestamp{Start: start, End: start.Add(time.Hour), BusinessHours: &businessHours}}
+		if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.@timestamp}}`), cfg, Fields{}, 0); !errors.Is(err, notValidBusinessHours) {
+			t.Fatalf("expected business hours error, got %v", err)
+		}
+	}
+
+	cfg := Config{Timestamp: &config.Timestamp{Start: start, End: start.Add(time.Hour), Rate: 1, BusinessHours: &config.BusinessHours{}}}
+	if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.@timestamp}}`), cfg, Fields{}, 0); !errors.Is(err, notValidBusinessHours) {
+		t.Fatalf("expected business hours error with rate, got %v", err)
+	}
+}
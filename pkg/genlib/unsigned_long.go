@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+var notValidUnsignedLongRange = errors.New("unsigned_long field range min must be greater than or equal to 0 and lower than or equal to max")
+
+// randUint64n returns a random value in [0, n), without the bias of the modulo: n must be greater than zero
+func randUint64n(r *rand.Rand, n uint64) uint64 {
+	if n&(n-1) == 0 {
+		return r.Uint64() & (n - 1)
+	}
+
+	// the values lower than 2^64 % n are rejected, so that each remainder is equally likely
+	threshold := -n % n
+	for {
+		if v := r.Uint64(); v >= threshold {
+			return v % n
+		}
+	}
+}
+
+// randUint64Between returns a random value in [min, max], both included
+func randUint64Between(r *rand.Rand, min, max uint64) uint64 {
+	if min == 0 && max == math.MaxUint64 {
+		return r.Uint64()
+	}
+
+	return min + randUint64n(r, max-min+1)
+}
+
+// makeUnsignedLongFunc returns a function generating unsigned_long values between the range bounds, both included, that can go up to math.MaxUint64.
+// Without a range the values are the same as the ones of a long field.
+func makeUnsignedLongFunc(fieldCfg ConfigField, field Field) (func(state *GenState) uint64, error) {
+	if distributionFunc := makeDistributionFunc(fieldCfg); distributionFunc != nil {
+		return func(state *GenState) uint64 {
+			return float64ToUint64(math.Round(distributionFunc(state)))
+		}, nil
+	}
+
+	if fieldCfg.Range.Min == nil && fieldCfg.Range.Max == nil {
+		intFunc := makeIntFunc(fieldCfg, field)
+		return func(state *GenState) uint64 {
+			return uint64(intFunc(state))
+		}, nil
+	}
+
+	minValue, _ := fieldCfg.Range.MinAsUint64()
+	maxValue, _ := fieldCfg.Range.MaxAsUint64()
+	if fieldCfg.Range.Min != nil && *fieldCfg.Range.Min < 0 || minValue > maxValue {
+		return nil, fmt.Errorf("%w: %q", notValidUnsignedLongRange, field.Name)
+	}
+
+	return func(state *GenState) uint64 {
+		return randUint64Between(state.rand, minValue, maxValue)
+	}, nil
+}
+
+// fuzzyUint64 returns a random value within the fuzziness of the previous one, clamped to [min, max]
+func fuzzyUint64(r *rand.Rand, previous uint64, fuzziness float64, min, max uint64) uint64 {
+	lowerBound := float64ToUint64(float64(previous) * (1 - fuzziness))
+	higherBound := float64ToUint64(float64(previous) * (1 + fuzziness))
+	if lowerBound < min {
+		lowerBound = min
+	}
+
+	if higherBound > max {
+		higherBound = max
+	}
+
+	if lowerBound > higherBound {
+		return previous
+	}
+
+	return randUint64Between(r, lowerBound, higherBound)
+}
+
+// float64ToUint64 converts v to an unsigned integer, saturating instead of overflowing
+func float64ToUint64(v float64) uint64 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= math.MaxUint64:
+		return math.MaxUint64
+	default:
+		return uint64(v)
+	}
+}
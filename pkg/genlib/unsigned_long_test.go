@@ -0,0 +1,138 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// unsignedLongNearMaxYaml configures values in the 2^20 values below math.MaxUint64 included
+const unsignedLongNearMaxYaml = `- name: alpha
+  range:
+    min: 18446744073708503040
+    max: 18446744073709551615
+`
+
+const unsignedLongNearMaxMin = uint64(18446744073708503040)
+
+func Test_FieldUnsignedLongNearMaxWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeUnsignedLong},
+		{Name: "beta", Type: FieldTypeUnsignedLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(unsignedLongNearMaxYaml + "- name: beta\n  as_string: true\n  range:\n    min: 0\n    max: 18446744073709551615"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{.alpha}},"beta":{{.beta}}}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	var aboveMaxInt64 int
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[json.RawMessage](t, buf.Bytes())
+		alpha, err := strconv.ParseUint(string(m["alpha"]), 10, 64)
+		if err != nil {
+			t.Fatalf("expected unquoted unsigned long, got %s: %s", m["alpha"], err)
+		}
+
+		if alpha < unsignedLongNearMaxMin {
+			t.Fatalf("expected value greater than or equal to %d, got %d", unsignedLongNearMaxMin, alpha)
+		}
+
+		var betaString string
+		if err := json.Unmarshal(m["beta"], &betaString); err != nil {
+			t.Fatalf("expected unsigned long as string, got %s", m["beta"])
+		}
+
+		beta, err := strconv.ParseUint(betaString, 10, 64)
+		if err != nil {
+			t.Fatalf("expected unsigned long, got %s: %s", betaString, err)
+		}
+
+		if beta > math.MaxInt64 {
+			aboveMaxInt64++
+		}
+	}
+
+	// half of the values over the whole range don't fit in an int64
+	if aboveMaxInt64 < 400 || aboveMaxInt64 > 600 {
+		t.Fatalf("expected about half of the values above math.MaxInt64, got %d", aboveMaxInt64)
+	}
+}
+
+func Test_FieldUnsignedLongNearMaxWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeUnsignedLong},
+		{Name: "beta", Type: FieldTypeUnsignedLong},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(unsignedLongNearMaxYaml + "- name: beta\n  as_string: true\n  range:\n    min: 18446744073709551615"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha"}},"beta":"{{generate "beta"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[json.RawMessage](t, buf.Bytes())
+		if alpha, err := strconv.ParseUint(string(m["alpha"]), 10, 64); err != nil || alpha < unsignedLongNearMaxMin {
+			t.Fatalf("expected unquoted unsigned long greater than or equal to %d, got %s", unsignedLongNearMaxMin, m["alpha"])
+		}
+
+		if string(m["beta"]) != `"18446744073709551615"` {
+			t.Fatalf("expected math.MaxUint64 as string, got %s", m["beta"])
+		}
+	}
+}
+
+func Test_FieldUnsignedLongNotValidRange(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeUnsignedLong},
+	}
+
+	for _, yaml := range []string{
+		"- name: alpha\n  range:\n    min: -1\n    max: 10",
+		"- name: alpha\n  range:\n    min: 10\n    max: 5",
+	} {
+		cfg, err := config.LoadConfigFromYaml([]byte(yaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewGeneratorWithCustomTemplate([]byte(`{{.alpha}}`), cfg, flds, 0); !errors.Is(err, notValidUnsignedLongRange) {
+			t.Fatalf("expected error %v with config %s, got %v", notValidUnsignedLongRange, yaml, err)
+		}
+
+		if _, err := NewGeneratorWithTextTemplate([]byte(`{{generate "alpha"}}`), cfg, flds, 0); !errors.Is(err, notValidUnsignedLongRange) {
+			t.Fatalf("expected error %v with config %s, got %v", notValidUnsignedLongRange, yaml, err)
+		}
+	}
+}
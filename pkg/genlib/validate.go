@@ -0,0 +1,47 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"errors"
+	"fmt"
+)
+
+var customTemplateFieldNotInFieldsYaml = errors.New("custom template references a field not present in fields yaml definition")
+
+// builtinPlaceholders are the placeholders bound by every custom template generator, whatever the fields
+var builtinPlaceholders = []string{EventIndexFieldName}
+
+// ValidateCustomTemplate checks that every field referenced by the placeholders of template is either defined in fields
+// or a built-in placeholder, returning an error for each undefined one, in order of first appearance, or no errors when the template is valid
+func ValidateCustomTemplate(template []byte, fields Fields) []error {
+	orderedFields, _, _ := parseCustomTemplate(template)
+
+	definedFields := make(map[string]struct{}, len(fields)+len(builtinPlaceholders))
+	for _, placeholder := range builtinPlaceholders {
+		definedFields[placeholder] = struct{}{}
+	}
+
+	for _, field := range fields {
+		definedFields[field.Name] = struct{}{}
+	}
+
+	var errs []error
+	reported := make(map[string]struct{})
+	for _, fieldName := range orderedFields {
+		if _, ok := definedFields[fieldName]; ok {
+			continue
+		}
+
+		if _, ok := reported[fieldName]; ok {
+			continue
+		}
+
+		reported[fieldName] = struct{}{}
+		errs = append(errs, fmt.Errorf("%w: %q", customTemplateFieldNotInFieldsYaml, fieldName))
+	}
+
+	return errs
+}
@@ -0,0 +1,57 @@
+package genlib
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_ValidateCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta.gamma", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "beta":{"gamma":{{.beta.gamma}}}}`)
+	t.Logf("with template: %s", string(template))
+
+	if errs := ValidateCustomTemplate(template, flds); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func Test_ValidateCustomTemplateEventIndex(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "index":{{.@eventIndex}}}`)
+	t.Logf("with template: %s", string(template))
+
+	if errs := ValidateCustomTemplate(template, flds); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func Test_ValidateCustomTemplateMissingFields(t *testing.T) {
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}", "delta":"{{.delta}}", "epsilon":{"zeta":{{.epsilon.zeta}}}, "again":"{{.delta}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	errs := ValidateCustomTemplate(template, flds)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+
+	for i, expected := range []string{`"delta"`, `"epsilon.zeta"`} {
+		if !errors.Is(errs[i], customTemplateFieldNotInFieldsYaml) {
+			t.Errorf("expected error %v, got %v", customTemplateFieldNotInFieldsYaml, errs[i])
+		}
+
+		if expectedErr := customTemplateFieldNotInFieldsYaml.Error() + ": " + expected; errs[i].Error() != expectedErr {
+			t.Errorf("expected error %s, got %s", expectedErr, errs[i])
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var notValidValuesFile = errors.New("values_file must contain at least one value")
+
+// maxValuesFileLineSize is the maximum size of a line in a values file
+const maxValuesFileLineSize = 1024 * 1024
+
+var valuesFilesCache = struct {
+	sync.Mutex
+	values map[string][]string
+}{values: make(map[string][]string)}
+
+// loadValuesFile returns the distinct non blank lines of the values file at path, in the order they first appear.
+// Files are read once, and their values shared by all the fields referencing them.
+func loadValuesFile(path string) ([]string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	valuesFilesCache.Lock()
+	defer valuesFilesCache.Unlock()
+
+	if values, ok := valuesFilesCache.values[absPath]; ok {
+		return values, nil
+	}
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	values := make([]string, 0)
+	seen := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxValuesFileLineSize)
+	for scanner.Scan() {
+		value := strings.TrimSpace(scanner.Text())
+		if len(value) == 0 {
+			continue
+		}
+
+		if _, ok := seen[value]; ok {
+			continue
+		}
+
+		seen[value] = struct{}{}
+		values = append(values, value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%w: %q", notValidValuesFile, path)
+	}
+
+	valuesFilesCache.values[absPath] = values
+
+	return values, nil
+}
@@ -0,0 +1,140 @@
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func writeValuesFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "values.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func Test_LoadValuesFile(t *testing.T) {
+	path := writeValuesFile(t, "alpha\n\nbeta\n  gamma  \nalpha\nbeta\n")
+
+	values, err := loadValuesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(values, ",") != "alpha,beta,gamma" {
+		t.Fatalf("expected distinct non blank values, got %v", values)
+	}
+
+	cached, err := loadValuesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if &cached[0] != &values[0] {
+		t.Fatal("expected values loaded once")
+	}
+}
+
+func Test_LoadValuesFileNotValid(t *testing.T) {
+	if _, err := loadValuesFile(writeValuesFile(t, "\n  \n")); !errors.Is(err, notValidValuesFile) {
+		t.Fatalf("expected empty values file error, got %v", err)
+	}
+
+	if _, err := loadValuesFile(filepath.Join(t.TempDir(), "missing.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+}
+
+func Test_FieldValuesFileWithCustomTemplate(t *testing.T) {
+	const n = 100
+	var content strings.Builder
+	expected := make(map[string]struct{}, n)
+	for i := 0; i < n; i++ {
+		value := fmt.Sprintf("host-%03d.example.com", i)
+		expected[value] = struct{}{}
+		content.WriteString(value + "\n")
+	}
+
+	path := writeValuesFile(t, content.String())
+
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(fmt.Sprintf("- name: alpha\n  values_file: %s\n- name: beta\n  values_file: %s\n  cardinality:\n    numerator: 1\n    denominator: 10", path, path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}","beta":"{{.beta}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, cfg, flds, template, 0)
+
+	alphaValues := make(map[string]struct{})
+	betaValues := make(map[string]struct{})
+	var buf bytes.Buffer
+	for i := 0; i < 10000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		for _, value := range m {
+			if _, ok := expected[value]; !ok {
+				t.Fatalf("expected value from the values file, got %s", value)
+			}
+		}
+
+		alphaValues[m["alpha"]] = struct{}{}
+		betaValues[m["beta"]] = struct{}{}
+	}
+
+	if len(alphaValues) != n {
+		t.Errorf("expected all %d values, got %d", n, len(alphaValues))
+	}
+
+	if len(betaValues) != 10 {
+		t.Errorf("expected 10 values with cardinality, got %d", len(betaValues))
+	}
+}
+
+func Test_FieldValuesFileWithTextTemplate(t *testing.T) {
+	path := writeValuesFile(t, "alice\nbob\ncarol\n")
+
+	flds := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(fmt.Sprintf("- name: alpha\n  values_file: %s", path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if value := unmarshalJSONT[string](t, buf.Bytes())["alpha"]; value != "alice" && value != "bob" && value != "carol" {
+			t.Fatalf("expected value from the values file, got %s", value)
+		}
+	}
+}
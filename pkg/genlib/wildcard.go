@@ -0,0 +1,82 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// defaultWildcardComplexity is the number of arguments of wildcard values when complexity is not configured
+var defaultWildcardComplexity = config.Length{Min: 2, Max: 7}
+
+var (
+	wildcardBinDirs    = []string{"/usr/bin", "/usr/local/bin", "/usr/sbin", "/bin", "/opt/bin"}
+	wildcardDirs       = []string{"/var/log", "/var/lib", "/etc", "/tmp", "/home", "/opt", "/srv", "/usr/share"}
+	wildcardExtensions = []string{"", ".log", ".conf", ".json", ".yml", ".sh", ".txt"}
+)
+
+// hasKeywordValuesConfig tells whether the field configures the values of a keyword field, which take precedence
+// over the values specific to the field type
+func hasKeywordValuesConfig(fieldCfg ConfigField) bool {
+	return len(fieldCfg.Pattern) > 0 || len(fieldCfg.ValuesFile) > 0 || len(fieldCfg.WeightedEnum) > 0 || len(fieldCfg.Enum) > 0 ||
+		fieldCfg.Length != nil || len(fieldCfg.Charset) > 0
+}
+
+// makeWildcardFunc returns a function generating command line like wildcard values: an executable path followed by
+// the configured number of arguments, each one either a file path, a long or short flag or a key=value pair
+func makeWildcardFunc(fieldCfg ConfigField) func(r *rand.Rand) string {
+	complexity := defaultWildcardComplexity
+	if fieldCfg.Complexity != nil {
+		complexity = *fieldCfg.Complexity
+	}
+
+	complexityFunc := makeLengthFunc(complexity)
+
+	word := func(r *rand.Rand) string {
+		return loremWords[r.Intn(len(loremWords))]
+	}
+
+	return func(r *rand.Rand) string {
+		var sb strings.Builder
+		sb.WriteString(wildcardBinDirs[r.Intn(len(wildcardBinDirs))])
+		sb.WriteByte('/')
+		sb.WriteString(word(r))
+
+		for i := complexityFunc(r); i > 0; i-- {
+			sb.WriteByte(' ')
+			switch r.Intn(4) {
+			case 0:
+				sb.WriteString(wildcardDirs[r.Intn(len(wildcardDirs))])
+				sb.WriteByte('/')
+				sb.WriteString(word(r))
+				sb.WriteString(wildcardExtensions[r.Intn(len(wildcardExtensions))])
+			case 1:
+				sb.WriteString("--")
+				sb.WriteString(word(r))
+				if r.Intn(2) == 0 {
+					sb.WriteByte('=')
+					sb.WriteString(word(r))
+				}
+			case 2:
+				sb.WriteByte('-')
+				sb.WriteByte(byte('a' + r.Intn(26)))
+			default:
+				sb.WriteString(word(r))
+				sb.WriteByte('=')
+				if r.Intn(2) == 0 {
+					sb.WriteString(word(r))
+				} else {
+					sb.WriteString(strconv.Itoa(r.Intn(10000)))
+				}
+			}
+		}
+
+		return sb.String()
+	}
+}
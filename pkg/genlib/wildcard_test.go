@@ -0,0 +1,87 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+func Test_FieldWildcardWithCustomTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "process.command_line", Type: FieldTypeWildcard},
+		{Name: "process.name", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"process.command_line":"{{.process.command_line}}","process.name":"{{.process.name}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithCustomTemplate(t, Config{}, flds, template, 0)
+
+	var wildcardLength, keywordLength int
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		commandLine := m["process.command_line"]
+		if !strings.HasPrefix(commandLine, "/") || !strings.Contains(commandLine, " ") {
+			t.Fatalf("expected executable path followed by arguments separated by spaces, got %s", commandLine)
+		}
+
+		wildcardLength += len(commandLine)
+		keywordLength += len(m["process.name"])
+	}
+
+	if wildcardLength <= 2*keywordLength {
+		t.Fatalf("expected wildcard values longer than keyword ones, got an average length of %d and %d", wildcardLength/1000, keywordLength/1000)
+	}
+}
+
+func Test_FieldWildcardWithTextTemplate(t *testing.T) {
+	flds := Fields{
+		{Name: "process.command_line", Type: FieldTypeWildcard},
+		{Name: "url.original", Type: FieldTypeWildcard},
+	}
+
+	cfg, err := config.LoadConfigFromYaml([]byte(`- name: process.command_line
+  complexity:
+    min: 4
+    max: 4
+- name: url.original
+  enum: ["/index.html"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"process.command_line":"{{generate "process.command_line"}}","url.original":"{{generate "url.original"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	g, state := makeGeneratorWithTextTemplate(t, cfg, flds, template, 0)
+
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.Reset()
+		if err := g.Emit(state, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if arguments := strings.Count(m["process.command_line"], " "); arguments != 4 {
+			t.Fatalf("expected 4 arguments, got %d in %s", arguments, m["process.command_line"])
+		}
+
+		if m["url.original"] != "/index.html" {
+			t.Fatalf("expected enum value to take precedence, got %s", m["url.original"])
+		}
+	}
+}